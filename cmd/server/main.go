@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,10 +19,24 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 
+	"github.com/zeroLR/swagger-mcp-go/internal/apidoc"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/binder"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
 	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/health"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/janitor"
 	"github.com/zeroLR/swagger-mcp-go/internal/mcp"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"github.com/zeroLR/swagger-mcp-go/internal/plugins"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+	"github.com/zeroLR/swagger-mcp-go/internal/ratelimit"
+	"github.com/zeroLR/swagger-mcp-go/internal/redact"
 	"github.com/zeroLR/swagger-mcp-go/internal/registry"
 	"github.com/zeroLR/swagger-mcp-go/internal/specs"
+	"github.com/zeroLR/swagger-mcp-go/internal/tracing"
 )
 
 var (
@@ -27,6 +45,7 @@ var (
 	configFile  = flag.String("config", "", "Path to configuration file")
 	mode        = flag.String("mode", "stdio", "Server mode: stdio, http, or sse")
 	baseURL     = flag.String("base-url", "", "Base URL for upstream API (overrides spec servers)")
+	mock        = flag.Bool("mock", false, "Answer from the spec's examples and schemas instead of calling the upstream")
 	showVersion = flag.Bool("version", false, "Show version information")
 	showHelp    = flag.Bool("help", false, "Show help information")
 )
@@ -40,6 +59,9 @@ func main() {
 
 	cfg := mustLoadConfig()
 	normalizeMode(cfg)
+	if *mock {
+		cfg.Upstream.Mock = true
+	}
 
 	logger := mustInitLogger(cfg)
 	defer logger.Sync()
@@ -52,16 +74,24 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing := initTracing(ctx, cfg, logger)
+	defer shutdownTracing()
+
 	reg, fetcher := initCoreComponents(ctx, cfg, logger)
-	mcpServer := initMCPServer(ctx, cfg, reg, fetcher, logger)
+	hookManager, responseCache := initHooks(cfg, logger)
+	pluginManager := initPlugins(ctx, cfg, hookManager, logger)
+	drainer := proxy.NewDrainer()
+	mcpServer := initMCPServer(ctx, cfg, reg, fetcher, hookManager, drainer, logger)
+	startJanitor(ctx, cfg, logger, reg, mcpServer, responseCache)
+	healthChecker := newHealthChecker(cfg, reg, pluginManager, mcpServer)
 
-	httpServer := maybeStartHTTPServer(cfg, logger, reg)
+	httpServer := maybeStartHTTPServer(cfg, logger, reg, fetcher, hookManager, mcpServer.AuthManager(), mcpServer.Breakers(), drainer, healthChecker)
 
 	waitForShutdownSignal(logger)
-	performShutdown(cancel, httpServer, mcpServer, logger)
+	performShutdown(cfg, cancel, httpServer, mcpServer, drainer, pluginManager, logger)
 }
 
-// handleBasicFlags processes help, version and required flags
+// handleBasicFlags processes help and version flags
 func handleBasicFlags() {
 	if *showHelp {
 		printHelp()
@@ -71,19 +101,29 @@ func handleBasicFlags() {
 		fmt.Printf("swagger-mcp-go version %s\n", version)
 		os.Exit(0)
 	}
-	if *swaggerFile == "" {
-		fmt.Fprintf(os.Stderr, "Error: --swagger-file is required\n")
-		printHelp()
-		os.Exit(1)
-	}
 }
 
-// mustLoadConfig loads configuration or exits on failure
+// mustLoadConfig loads configuration or exits on failure. A spec is required, from
+// --swagger-file or specs.sources.
 func mustLoadConfig() *config.Config {
 	cfg, err := config.Load(*configFile)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if *swaggerFile == "" && len(cfg.Specs.Sources) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: --swagger-file or specs.sources in the config is required\n")
+		printHelp()
+		os.Exit(1)
+	}
+	if _, err := proxy.NewTLSConfig(cfg.Upstream.TLS.InsecureSkipVerify, cfg.Upstream.TLS.CAFile); err != nil {
+		log.Fatalf("Invalid upstream TLS configuration: %v", err)
+	}
+	if _, err := proxy.NewIdentityHeaders(cfg.Upstream.IdentityHeaders); err != nil {
+		log.Fatalf("Invalid upstream identity headers: %v", err)
+	}
+	if _, err := proxy.NewTrustedProxies(cfg.Upstream.TrustedProxies); err != nil {
+		log.Fatalf("Invalid upstream trusted proxies: %v", err)
+	}
 	return cfg
 }
 
@@ -110,22 +150,188 @@ func mustInitLogger(cfg *config.Config) *zap.Logger {
 	return logger
 }
 
-// initCoreComponents creates registry and spec fetcher and starts cleanup
+// initTracing installs the OpenTelemetry tracer provider when tracing is enabled and
+// returns a function that flushes pending spans. A failure to set it up is logged and
+// the server runs without tracing.
+func initTracing(ctx context.Context, cfg *config.Config, logger *zap.Logger) func() {
+	shutdown, err := tracing.Setup(ctx, cfg)
+	if err != nil {
+		logger.Error("Failed to initialize tracing", zap.Error(err))
+		return func() {}
+	}
+	if cfg.Tracing.Enabled {
+		logger.Info("Tracing enabled", zap.String("endpoint", cfg.Tracing.Endpoint))
+	}
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Error("Tracing shutdown error", zap.Error(err))
+		}
+	}
+}
+
+// initCoreComponents creates registry and spec fetcher
 func initCoreComponents(ctx context.Context, cfg *config.Config, logger *zap.Logger) (*registry.Registry, *specs.Fetcher) {
 	reg := registry.New(logger.Named("registry"))
 	maxSize := int64(10 * 1024 * 1024)
 	fetcher := specs.New(logger.Named("specs"), cfg.Upstream.Timeout, maxSize)
-	reg.StartCleanup(ctx, 5*time.Minute)
+	fetcher.SetAllowInvalid(cfg.Specs.AllowInvalid)
+	fetcher.SetRetry(cfg.Upstream.RetryCount+1, cfg.Upstream.RetryDelay)
 	return reg, fetcher
 }
 
-// initMCPServer loads spec and starts MCP server
-func initMCPServer(ctx context.Context, cfg *config.Config, reg *registry.Registry, fetcher *specs.Fetcher, logger *zap.Logger) *mcp.Server {
+// initHooks creates the hook manager run around upstream calls, and the response
+// cache it uses when cache.enabled is set
+func initHooks(cfg *config.Config, logger *zap.Logger) (*hooks.Manager, *hooks.ResponseCache) {
+	hookManager := hooks.NewManager(logger.Named("hooks"))
+
+	validationHook, err := newRequestValidationHook(cfg, logger)
+	if err != nil {
+		logger.Fatal("Invalid validation configuration", zap.Error(err))
+	}
+	if validationHook != nil {
+		hookManager.RegisterHook(validationHook)
+	}
+	bodyDefaultsHook, err := newBodyDefaultsHook(cfg)
+	if err != nil {
+		logger.Fatal("Invalid body defaults configuration", zap.Error(err))
+	}
+	if bodyDefaultsHook != nil {
+		hookManager.RegisterHook(bodyDefaultsHook)
+	}
+	if services := cfg.Validation.Response.Services; len(services) > 0 {
+		hookManager.RegisterHook(hooks.NewResponseValidationHook(logger.Named("validation"), hooks.PriorityHigh, services))
+	}
+	var responseCache *hooks.ResponseCache
+	if cfg.Cache.Enabled {
+		responseCache = hooks.NewResponseCache(logger.Named("cache"), cfg.Cache.DefaultTTL)
+		responseCache.SetMaxEntries(cfg.Cache.MaxEntries)
+		for _, hook := range responseCache.Hooks() {
+			hookManager.RegisterHook(hook)
+		}
+	}
+	return hookManager, responseCache
+}
+
+// initPlugins loads the built-in plugins when configured and the plugins listed in
+// plugins.load, then initializes and starts them
+func initPlugins(ctx context.Context, cfg *config.Config, hookManager *hooks.Manager, logger *zap.Logger) *plugins.Manager {
+	pluginManager := plugins.NewManager(logger.Named("plugins"), hookManager)
+	if cfg.Plugins.Builtin {
+		if err := pluginManager.LoadBuiltinPlugins(); err != nil {
+			logger.Fatal("Failed to load built-in plugins", zap.Error(err))
+		}
+	}
+
+	configs := make(map[string]map[string]interface{})
+	for _, pluginConfig := range cfg.Plugins.Load {
+		plugin, err := pluginManager.Load(pluginConfig.Name)
+		if err != nil {
+			logger.Fatal("Failed to load plugin", zap.String("name", pluginConfig.Name), zap.Error(err))
+		}
+		configs[plugin.Name()] = pluginConfig.Config
+	}
+
+	registry := pluginManager.Registry()
+	if err := registry.Initialize(configs); err != nil {
+		logger.Fatal("Failed to initialize plugins", zap.Error(err))
+	}
+	if err := registry.Start(ctx); err != nil {
+		logger.Fatal("Failed to start plugins", zap.Error(err))
+	}
+	return pluginManager
+}
+
+// newHealthChecker aggregates the health of the plugins, the registry, the circuit
+// breakers shared by MCP tools and /apis, and the upstreams under health.upstreams
+func newHealthChecker(cfg *config.Config, reg *registry.Registry, pluginManager *plugins.Manager, mcpServer *mcp.Server) *health.Checker {
+	checker := health.NewChecker(cfg.Health.Critical)
+	checker.Register(health.SubsystemPlugins, health.PluginCheck(pluginManager.Registry()))
+	checker.Register(health.SubsystemRegistry, health.RegistryCheck(reg))
+	checker.AddBreakers(mcpServer.Breakers())
+	if len(cfg.Health.Upstreams) > 0 {
+		checker.Register(health.SubsystemUpstreams, health.UpstreamCheck(&http.Client{}, cfg.Health.Upstreams, cfg.Health.Timeout))
+	}
+	return checker
+}
+
+// newRequestValidationHook builds the hook checking proxied calls against their spec,
+// or returns nil when validation is off for every service
+func newRequestValidationHook(cfg *config.Config, logger *zap.Logger) (*hooks.RequestValidationHook, error) {
+	mode, err := hooks.ParseValidationMode(cfg.Validation.Request.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := hooks.NewRequestValidationHook(logger.Named("validation"), hooks.PriorityHigh)
+	hook.SetMode(mode)
+	enabled := mode != hooks.ValidationOff
+	for serviceName, value := range cfg.Validation.Request.Services {
+		serviceMode, err := hooks.ParseValidationMode(value)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", serviceName, err)
+		}
+		hook.SetServiceMode(serviceName, serviceMode)
+		enabled = enabled || serviceMode != hooks.ValidationOff
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+	return hook, nil
+}
+
+// newBodyDefaultsHook builds the hook adding upstream.bodyDefaults to JSON request
+// bodies, or returns nil when none are configured. It runs ahead of request
+// validation so added fields count as supplied.
+func newBodyDefaultsHook(cfg *config.Config) (*hooks.BodyDefaultsHook, error) {
+	if len(cfg.Upstream.BodyDefaults) == 0 {
+		return nil, nil
+	}
+
+	hook := hooks.NewBodyDefaultsHook(hooks.PriorityHigh + 1)
+	for _, defaults := range cfg.Upstream.BodyDefaults {
+		if defaults.Service == "" {
+			return nil, fmt.Errorf("body defaults need a service")
+		}
+		fields, err := hooks.ParseBodyDefaults(defaults.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", defaults.Service, err)
+		}
+		hook.SetDefaults(defaults.Service, defaults.Operation, fields)
+	}
+	return hook, nil
+}
+
+// startJanitor prunes expired specs, cached responses and idle session state until
+// ctx is cancelled
+func startJanitor(ctx context.Context, cfg *config.Config, logger *zap.Logger, reg *registry.Registry, mcpServer *mcp.Server, responseCache *hooks.ResponseCache) {
+	j := janitor.New(logger.Named("janitor"), cfg.Janitor.Interval, cfg.Janitor.Jitter)
+	j.Register("registry_specs", reg.PruneExpired)
+	if responseCache != nil {
+		j.Register("response_cache", responseCache.Prune)
+	}
+	mcpServer.RegisterJanitorTasks(j, cfg.Janitor.SessionIdleTimeout)
+	j.Start(ctx)
+}
+
+// initMCPServer loads the specs and starts MCP server
+func initMCPServer(ctx context.Context, cfg *config.Config, reg *registry.Registry, fetcher *specs.Fetcher, hookManager *hooks.Manager, drainer *proxy.Drainer, logger *zap.Logger) *mcp.Server {
 	mcpServer := mcp.NewServer(logger.Named("mcp"), cfg, reg, fetcher)
 	mcpServer.SetMode(mcp.ServerMode(*mode))
-	headers := make(map[string]string)
-	if err := mcpServer.LoadSpecFromFile(*swaggerFile, *baseURL, headers); err != nil {
-		logger.Fatal("Failed to load OpenAPI spec", zap.Error(err))
+	mcpServer.SetHookManager(hookManager)
+	mcpServer.SetDrainer(drainer)
+	mcpServer.BridgeSpecEvents(ctx, reg.Events())
+	if *swaggerFile != "" {
+		headers := make(map[string]string)
+		if err := mcpServer.LoadSpecFromFile(*swaggerFile, *baseURL, headers); err != nil {
+			logger.Fatal("Failed to load OpenAPI spec", zap.Error(err))
+		}
+	}
+	if err := loadConfiguredSpecs(ctx, cfg, mcpServer, logger); err != nil {
+		logger.Fatal("Failed to load configured specs", zap.Error(err))
 	}
 	go func() {
 		if err := mcpServer.Start(ctx); err != nil {
@@ -135,12 +341,32 @@ func initMCPServer(ctx context.Context, cfg *config.Config, reg *registry.Regist
 	return mcpServer
 }
 
+// loadConfiguredSpecs registers every spec in specs.sources. Failures are gathered into
+// one error, or, with specs.continueOnError, logged so the other specs are still served.
+func loadConfiguredSpecs(ctx context.Context, cfg *config.Config, mcpServer *mcp.Server, logger *zap.Logger) error {
+	var errs []error
+	for _, source := range cfg.Specs.Sources {
+		if err := mcpServer.LoadSpecSource(ctx, source); err != nil {
+			if cfg.Specs.ContinueOnError {
+				logger.Warn("Skipping spec that failed to load",
+					zap.String("serviceName", source.ServiceName),
+					zap.Error(err))
+				continue
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", source.ServiceName, err))
+			continue
+		}
+		logger.Info("Loaded spec", zap.String("serviceName", source.ServiceName))
+	}
+	return errors.Join(errs...)
+}
+
 // maybeStartHTTPServer starts HTTP server if mode requires it
-func maybeStartHTTPServer(cfg *config.Config, logger *zap.Logger, reg *registry.Registry) *http.Server {
+func maybeStartHTTPServer(cfg *config.Config, logger *zap.Logger, reg *registry.Registry, fetcher *specs.Fetcher, hookManager *hooks.Manager, authManager *auth.Manager, breakers *circuitbreaker.Manager, drainer *proxy.Drainer, healthChecker *health.Checker) *http.Server {
 	if *mode == "stdio" {
 		return nil
 	}
-	router := setupRouter(cfg, logger.Named("http"), reg)
+	router := setupRouter(cfg, logger.Named("http"), reg, fetcher, hookManager, authManager, breakers, drainer, healthChecker)
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
 		Handler:      router,
@@ -164,19 +390,31 @@ func waitForShutdownSignal(logger *zap.Logger) {
 	logger.Info("Shutting down server...")
 }
 
-// performShutdown gracefully stops servers and background processes
-func performShutdown(cancel context.CancelFunc, httpServer *http.Server, mcpServer *mcp.Server, logger *zap.Logger) {
+// performShutdown gracefully stops servers and background processes. Proxied requests
+// in flight get until server.shutdownTimeout to finish, while new ones are answered
+// with 503; connections still open at the deadline are closed.
+func performShutdown(cfg *config.Config, cancel context.CancelFunc, httpServer *http.Server, mcpServer *mcp.Server, drainer *proxy.Drainer, pluginManager *plugins.Manager, logger *zap.Logger) {
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer shutdownCancel()
+
+	logger.Info("Draining in-flight requests", zap.Int("inFlight", drainer.InFlight()))
+	if err := drainer.Drain(shutdownCtx); err != nil {
+		logger.Warn("Shutdown deadline passed before in-flight requests finished", zap.Error(err))
+	}
+
 	cancel()
 	if httpServer != nil {
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer shutdownCancel()
 		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			logger.Error("HTTP server shutdown error", zap.Error(err))
+			httpServer.Close()
 		}
 	}
 	if err := mcpServer.Stop(); err != nil {
 		logger.Error("MCP server stop error", zap.Error(err))
 	}
+	if err := pluginManager.Registry().Stop(); err != nil {
+		logger.Error("Plugin stop error", zap.Error(err))
+	}
 	logger.Info("Server stopped")
 }
 
@@ -186,10 +424,13 @@ func printHelp() {
 Usage: swagger-mcp-go [OPTIONS]
 
 OPTIONS:
-  --swagger-file=FILE    Path to OpenAPI/Swagger specification file (required)
+  --swagger-file=FILE    Path to OpenAPI/Swagger specification file (required
+                         unless the config lists specs.sources)
   --config=FILE          Path to configuration file (optional)
   --mode=MODE            Server mode: stdio, http, or sse (default: stdio)
   --base-url=URL         Base URL for upstream API (overrides spec servers)
+  --mock                 Answer from the spec's examples and schemas instead of
+                         calling the upstream
   --version              Show version information
   --help                 Show this help message
 
@@ -239,7 +480,7 @@ func initLogger(cfg *config.Config) (*zap.Logger, error) {
 	return zapConfig.Build()
 }
 
-func setupRouter(cfg *config.Config, logger *zap.Logger, reg *registry.Registry) *gin.Engine {
+func setupRouter(cfg *config.Config, logger *zap.Logger, reg *registry.Registry, fetcher *specs.Fetcher, hookManager *hooks.Manager, authManager *auth.Manager, breakers *circuitbreaker.Manager, drainer *proxy.Drainer, healthChecker *health.Checker) *gin.Engine {
 	// Set Gin mode
 	gin.SetMode(gin.ReleaseMode)
 
@@ -247,51 +488,121 @@ func setupRouter(cfg *config.Config, logger *zap.Logger, reg *registry.Registry)
 
 	// Middleware
 	router.Use(gin.Recovery())
-	router.Use(ginLogger(logger))
+	router.Use(ginLogger(logger, newRedactor(cfg)))
+	if cfg.Tracing.Enabled {
+		router.Use(tracing.Middleware())
+	}
 
 	// CORS middleware
 	if cfg.Policies.CORS.Enabled {
 		router.Use(corsMiddleware(cfg))
 	}
 
+	// Routes registered through docs are described at /admin/openapi.json
+	docs := apidoc.New("swagger-mcp-go management API", version)
+
 	// Health check
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "healthy",
-			"timestamp": time.Now(),
+	docs.Handle(&router.RouterGroup, http.MethodGet, "/health", apidoc.Route{Summary: "Report server health", Tag: "system"},
+		func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"status":    "healthy",
+				"timestamp": time.Now(),
+			})
 		})
-	})
 
 	// Metrics endpoint
 	if cfg.Metrics.Enabled {
-		router.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
+		docs.Handle(&router.RouterGroup, http.MethodGet, cfg.Metrics.Path,
+			apidoc.Route{Summary: "Prometheus metrics", Tag: "system", ContentType: "text/plain"},
+			gin.WrapH(promhttp.Handler()))
+	}
+
+	// Proxy routes resolve against the specs in the registry
+	routeBinder := binder.New(logger.Named("binder"), cfg, reg)
+	routeBinder.SetHookManager(hookManager)
+	routeBinder.SetDrainer(drainer)
+	if rateLimiter := newRateLimiter(cfg, logger.Named("ratelimit")); rateLimiter != nil {
+		routeBinder.SetRateLimiter(rateLimiter)
+	}
+	if (cfg.Policies.RateLimit.KeyBy == "user" || len(cfg.Upstream.IdentityHeaders) > 0) && authManager != nil {
+		// Authenticate before limiting so requests are counted per user, and so the
+		// upstream can be told who the caller is
+		routeBinder.SetAuthManager(authManager)
+	}
+	if breakers != nil {
+		// The health checker already reports the shared breakers
+		routeBinder.SetBreakers(breakers)
+	} else {
+		healthChecker.AddBreakers(routeBinder.Breakers())
 	}
 
 	// Admin API
 	admin := router.Group("/admin")
 	{
-		admin.GET("/specs", listSpecsHandler(reg))
-		admin.POST("/specs", addSpecHandler(reg, logger))
-		admin.PUT("/specs/:service/refresh", refreshSpecHandler(reg, logger))
-		admin.DELETE("/specs/:service", removeSpecHandler(reg))
-		admin.GET("/stats", statsHandler(reg))
+		docs.Handle(admin, http.MethodGet, "/specs", apidoc.Route{Summary: "List registered specs", Tag: "specs",
+			Description: "Ordered by service name. Query parameters limit and offset select a page; filter keeps specs whose service name or title contains it."},
+			listSpecsHandler(reg))
+		docs.Handle(admin, http.MethodPost, "/specs", apidoc.Route{Summary: "Register a spec from a URL", Tag: "specs", RequestBody: true},
+			addSpecHandler(cfg, reg, fetcher, routeBinder, logger))
+		docs.Handle(admin, http.MethodGet, "/specs/:service", apidoc.Route{Summary: "Show a registered spec and its routes", Tag: "specs"},
+			specDetailHandler(cfg, reg, logger))
+		docs.Handle(admin, http.MethodPut, "/specs/:service/refresh", apidoc.Route{Summary: "Refetch a registered spec", Tag: "specs"},
+			refreshSpecHandler(reg, fetcher, logger))
+		docs.Handle(admin, http.MethodDelete, "/specs/:service", apidoc.Route{Summary: "Remove a registered spec", Tag: "specs"},
+			removeSpecHandler(reg))
+		docs.Handle(admin, http.MethodGet, "/stats", apidoc.Route{Summary: "Registry statistics", Tag: "system"},
+			statsHandler(reg))
+		docs.Handle(admin, http.MethodGet, "/health", apidoc.Route{Summary: "Report the health of each subsystem", Tag: "system"},
+			healthHandler(healthChecker))
+		docs.Handle(admin, http.MethodGet, "/openapi.json", apidoc.Route{Summary: "This OpenAPI document", Tag: "system"},
+			docs.Handler())
 	}
 
-	// Proxy routes will be dynamically registered here
 	apis := router.Group("/apis")
 	{
-		// Dynamic routes will be added by the route binder
-		apis.Any("/*path", func(c *gin.Context) {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error": "No service registered for this path",
-			})
-		})
+		apis.Any("/*path", routeBinder.Handler())
 	}
 
 	return router
 }
 
-func ginLogger(logger *zap.Logger) gin.HandlerFunc {
+// newRateLimiter builds the rate and concurrency limits for proxied requests, or returns
+// nil when none are configured
+func newRateLimiter(cfg *config.Config, logger *zap.Logger) *ratelimit.Manager {
+	policies := cfg.Policies
+	if !policies.RateLimit.Enabled && policies.Concurrency.MaxInFlight <= 0 && len(policies.Concurrency.Services) == 0 {
+		return nil
+	}
+
+	manager := ratelimit.NewManager(logger, true)
+	if policies.RateLimit.Enabled {
+		limit := ratelimit.Config{RequestsPerMinute: policies.RateLimit.RequestsPerMinute}
+		if policies.RateLimit.KeyBy == "user" {
+			limit.KeyGenerator = ratelimit.UserBasedKeyGenerator
+		}
+		manager.SetGlobalLimiter(ratelimit.NewTokenBucketLimiter(limit, logger))
+	}
+	if policies.Concurrency.MaxInFlight > 0 {
+		manager.SetConcurrencyLimiter("*", ratelimit.NewConcurrencyLimiter(policies.Concurrency.MaxInFlight))
+	}
+	for serviceName, maxInFlight := range policies.Concurrency.Services {
+		manager.SetConcurrencyLimiter(serviceName, ratelimit.NewConcurrencyLimiter(maxInFlight))
+	}
+	return manager
+}
+
+// newRedactor builds the redactor masking sensitive headers and body fields in logs
+func newRedactor(cfg *config.Config) *redact.Redactor {
+	rules := cfg.Logging.Redact
+	redactor := redact.New(rules.Headers, rules.BodyFields)
+	for serviceName, service := range rules.Services {
+		redactor.SetServiceRules(serviceName, service.Headers, service.BodyFields)
+	}
+	return redactor
+}
+
+// ginLogger logs every HTTP request, with its headers masked by redactor at debug level
+func ginLogger(logger *zap.Logger, redactor *redact.Redactor) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
@@ -308,24 +619,86 @@ func ginLogger(logger *zap.Logger) gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
-		logger.Info("HTTP request",
+		fields := []zap.Field{
 			zap.String("method", method),
 			zap.String("path", path),
 			zap.Int("status", statusCode),
 			zap.Duration("latency", latency),
 			zap.String("clientIP", clientIP),
-		)
+		}
+		if logger.Core().Enabled(zap.DebugLevel) {
+			serviceName := proxiedService(c.Request.URL.Path)
+			fields = append(fields,
+				zap.Any("headers", redactor.HTTPHeaders(serviceName, c.Request.Header)),
+				zap.Any("responseHeaders", redactor.HTTPHeaders(serviceName, c.Writer.Header())))
+		}
+		logger.Info("HTTP request", fields...)
+	}
+}
+
+// proxiedService returns the service a /apis/{serviceName}/... path is proxied to, or ""
+func proxiedService(path string) string {
+	rest, ok := strings.CutPrefix(path, "/apis/")
+	if !ok {
+		return ""
 	}
+	serviceName, _, _ := strings.Cut(rest, "/")
+	return serviceName
 }
 
+// corsMiddleware applies the configured CORS policy. A matching Origin is echoed back,
+// or "*" is sent when any origin is allowed and credentials are not; requests from other
+// origins get no CORS headers and their preflights are rejected with 403.
 func corsMiddleware(cfg *config.Config) gin.HandlerFunc {
+	cors := cfg.Policies.CORS
+	allowMethods := strings.Join(cors.AllowMethods, ", ")
+	allowHeaders := strings.Join(cors.AllowHeaders, ", ")
+
+	allowAny := false
+	allowed := make(map[string]bool, len(cors.AllowOrigins))
+	for _, origin := range cors.AllowOrigins {
+		if origin == "*" {
+			allowAny = true
+		}
+		allowed[strings.ToLower(origin)] = true
+	}
+
 	return func(c *gin.Context) {
-		// Simple CORS implementation
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+		if !allowAny && !allowed[strings.ToLower(origin)] {
+			if preflight {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
+				return
+			}
+			c.Next()
+			return
+		}
 
-		if c.Request.Method == "OPTIONS" {
+		if allowAny && !cors.AllowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			// Credentialed responses may not use the wildcard
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+		if cors.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				c.Header("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if cors.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+			}
 			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -336,16 +709,34 @@ func corsMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 // Admin API handlers
 
+// listSpecsHandler lists the registered specs by service name, a page at a time when
+// limit and offset are given, keeping those whose service name or title contains filter
 func listSpecsHandler(reg *registry.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		specs := reg.List()
+		var query struct {
+			Limit  int    `form:"limit" binding:"min=0"`
+			Offset int    `form:"offset" binding:"min=0"`
+			Filter string `form:"filter"`
+		}
+		if err := c.ShouldBindQuery(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		specs, total := reg.ListPage(query.Filter, query.Offset, query.Limit)
+		for i, specInfo := range specs {
+			specs[i] = redactedSpec(specInfo)
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"specs": specs,
+			"specs":  specs,
+			"total":  total,
+			"limit":  query.Limit,
+			"offset": query.Offset,
 		})
 	}
 }
 
-func addSpecHandler(reg *registry.Registry, logger *zap.Logger) gin.HandlerFunc {
+func addSpecHandler(cfg *config.Config, reg *registry.Registry, fetcher *specs.Fetcher, routeBinder *binder.Binder, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			URL         string            `json:"url" binding:"required"`
@@ -359,20 +750,176 @@ func addSpecHandler(reg *registry.Registry, logger *zap.Logger) gin.HandlerFunc
 			return
 		}
 
-		// TODO: Implement spec fetching and registration
-		c.JSON(http.StatusNotImplemented, gin.H{
-			"error": "Spec registration not yet implemented in HTTP API",
+		if err := validateSpecURL(req.URL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		ttl, err := parseTTL(req.TTL, cfg.Specs.DefaultTTL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		specInfo, err := fetcher.FetchSpec(c.Request.Context(), req.URL, req.ServiceName, req.Headers, ttl)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Failed to fetch spec: %v", err)})
+			return
+		}
+		specInfo.AuthPolicy = auth.PolicyFromSpec(specInfo.Spec)
+
+		if err := reg.Add(specInfo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to register spec: %v", err)})
+			return
+		}
+
+		// Binding now surfaces route errors here instead of on the first proxied request
+		if err := routeBinder.Bind(specInfo); err != nil {
+			logger.Warn("Failed to bind routes for service",
+				zap.String("serviceName", specInfo.ServiceName),
+				zap.Error(err))
+		}
+
+		c.JSON(http.StatusCreated, redactedSpec(specInfo))
+	}
+}
+
+// validateSpecURL checks that a spec URL is an absolute http or https URL
+func validateSpecURL(specURL string) error {
+	u, err := url.Parse(specURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid URL %q: must be an absolute http or https URL", specURL)
+	}
+	return nil
+}
+
+// redactedSpec returns a copy of specInfo with its header values masked, so admin
+// responses show which headers are sent upstream without the credentials they hold
+func redactedSpec(specInfo *models.SpecInfo) *models.SpecInfo {
+	if len(specInfo.Headers) == 0 {
+		return specInfo
+	}
+
+	redacted := *specInfo
+	redacted.Headers = make(map[string]string, len(specInfo.Headers))
+	for name := range specInfo.Headers {
+		redacted.Headers[name] = redact.Mask
+	}
+	return &redacted
+}
+
+// parseTTL parses a spec TTL, falling back to defaultTTL and then to one hour when empty
+func parseTTL(value, defaultTTL string) (time.Duration, error) {
+	if value == "" {
+		value = defaultTTL
+	}
+	if value == "" {
+		return time.Hour, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q: %w", value, err)
+	}
+	return ttl, nil
+}
+
+func specDetailHandler(cfg *config.Config, reg *registry.Registry, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceName := c.Param("service")
+
+		specInfo, valid := reg.Get(serviceName)
+		if specInfo == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+			return
+		}
+
+		routes := make([]models.RouteInfo, 0)
+		if specInfo.Spec != nil {
+			p := parser.New(logger.Named("parser"), "")
+			p.SetMaxSchemaDepth(cfg.MCP.MaxSchemaDepth)
+			if err := p.ParseSpec(specInfo.Spec); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to parse spec: %v", err)})
+				return
+			}
+			for _, route := range p.GetRoutes() {
+				routes = append(routes, models.RouteInfo{
+					Path:            route.Path,
+					Method:          route.Method,
+					ServiceName:     serviceName,
+					OperationID:     route.OperationID,
+					Summary:         route.Summary,
+					Tags:            route.Tags,
+					Deprecated:      route.Deprecated,
+					Sunset:          route.Sunset,
+					DeprecationNote: route.DeprecationNote,
+				})
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"spec":       redactedSpec(specInfo),
+			"routes":     routes,
+			"authPolicy": specInfo.AuthPolicy,
+			"expired":    !valid,
+			"expiresAt":  specInfo.FetchedAt.Add(specInfo.TTL),
 		})
 	}
 }
 
-func refreshSpecHandler(reg *registry.Registry, logger *zap.Logger) gin.HandlerFunc {
+func refreshSpecHandler(reg *registry.Registry, fetcher *specs.Fetcher, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		_ = c.Param("service")
+		serviceName := c.Param("service")
 
-		// TODO: Implement spec refresh
-		c.JSON(http.StatusNotImplemented, gin.H{
-			"error": "Spec refresh not yet implemented in HTTP API",
+		// Get returns expired specs too, and those are the ones most worth refreshing
+		existing, _ := reg.Get(serviceName)
+		if existing == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Service not found",
+			})
+			return
+		}
+
+		previousFetchedAt := existing.FetchedAt
+		specInfo, changed, err := fetcher.RefreshSpec(c.Request.Context(), existing)
+		if err != nil {
+			logger.Warn("Failed to refresh spec",
+				zap.String("serviceName", serviceName),
+				zap.String("url", existing.URL),
+				zap.Error(err))
+			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to fetch spec: %v", err)})
+			return
+		}
+
+		if changed {
+			// Operator settings belong to the service, not the document
+			specInfo.AuthPolicy = existing.AuthPolicy
+			specInfo.Metadata = existing.Metadata
+			specInfo.ApplyParameterDefaults = existing.ApplyParameterDefaults
+
+			if err := reg.Add(specInfo); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to register spec: %v", err)})
+				return
+			}
+		} else {
+			// The registered entry stays in place so bound routes are not rebuilt
+			touched, ok := reg.Touch(serviceName, specInfo.FetchedAt, specInfo.ETag, specInfo.LastModified)
+			if !ok {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+				return
+			}
+			specInfo = touched
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"spec":              redactedSpec(specInfo),
+			"changed":           changed,
+			"previousFetchedAt": previousFetchedAt,
 		})
 	}
 }
@@ -395,6 +942,15 @@ func removeSpecHandler(reg *registry.Registry) gin.HandlerFunc {
 	}
 }
 
+// healthHandler reports the aggregated health of the server's subsystems, answering
+// 503 when a critical subsystem is unhealthy
+func healthHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Check(c.Request.Context())
+		c.JSON(report.HTTPStatus(), report)
+	}
+}
+
 func statsHandler(reg *registry.Registry) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		stats := reg.Stats()
@@ -0,0 +1,686 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/health"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/mcp"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/redact"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+	"github.com/zeroLR/swagger-mcp-go/internal/specs"
+)
+
+// newTestRouter builds the HTTP router with a fresh registry
+func newTestRouter(t *testing.T) (http.Handler, *registry.Registry) {
+	t.Helper()
+
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	reg := registry.New(logger)
+	fetcher := specs.New(logger, 5*time.Second, 1024*1024)
+	return setupRouter(cfg, logger, reg, fetcher, hooks.NewManager(logger), nil, nil, nil, health.NewChecker(nil)), reg
+}
+
+// newPetstoreUpstream serves a spec at /openapi.json whose server is the upstream itself
+func newPetstoreUpstream(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/openapi.json":
+			fmt.Fprintf(w, `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "servers": [{"url": %q}],
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "responses": {"200": {"description": "ok"}}}
+    }
+  }
+}`, upstream.URL)
+		case "/pets":
+			w.Write([]byte(`[{"name": "Rex"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+// doJSON sends a request with an optional JSON body to the router
+func doJSON(t *testing.T, router http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatalf("Failed to encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestListSpecsHandler_Pagination(t *testing.T) {
+	router, reg := newTestRouter(t)
+	for _, serviceName := range []string{"users", "billing", "orders", "inventory", "payments"} {
+		reg.Add(&models.SpecInfo{ServiceName: serviceName, FetchedAt: time.Now(), TTL: time.Hour})
+	}
+
+	tests := []struct {
+		query         string
+		expectedCode  int
+		expected      []string
+		expectedTotal int
+	}{
+		{"", http.StatusOK, []string{"billing", "inventory", "orders", "payments", "users"}, 5},
+		{"?limit=2&offset=2", http.StatusOK, []string{"orders", "payments"}, 5},
+		{"?filter=ers&limit=1", http.StatusOK, []string{"orders"}, 2},
+		{"?limit=-1", http.StatusBadRequest, nil, 0},
+		{"?offset=abc", http.StatusBadRequest, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			recorder := doJSON(t, router, http.MethodGet, "/admin/specs"+tt.query, nil)
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedCode, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Specs []models.SpecInfo `json:"specs"`
+				Total int               `json:"total"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			names := make([]string, len(body.Specs))
+			for i, spec := range body.Specs {
+				names[i] = spec.ServiceName
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") || body.Total != tt.expectedTotal {
+				t.Errorf("Expected %v of %d, got %v of %d", tt.expected, tt.expectedTotal, names, body.Total)
+			}
+		})
+	}
+}
+
+func TestAddSpecHandler(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, reg := newTestRouter(t)
+
+	recorder := doJSON(t, router, http.MethodPost, "/admin/specs", map[string]interface{}{
+		"url":         upstream.URL + "/openapi.json",
+		"serviceName": "petstore",
+		"ttl":         "30m",
+	})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var specInfo models.SpecInfo
+	if err := json.Unmarshal(recorder.Body.Bytes(), &specInfo); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if specInfo.ServiceName != "petstore" {
+		t.Errorf("Expected service petstore, got %s", specInfo.ServiceName)
+	}
+	if specInfo.TTL != 30*time.Minute {
+		t.Errorf("Expected TTL 30m, got %v", specInfo.TTL)
+	}
+
+	if stored, _ := reg.Get("petstore"); stored == nil {
+		t.Fatalf("Expected spec to be registered")
+	}
+
+	// The new service's routes are live
+	recorder = doJSON(t, router, http.MethodGet, "/apis/petstore/pets", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected proxied status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Body.String() != `[{"name": "Rex"}]` {
+		t.Errorf("Expected upstream body, got %s", recorder.Body.String())
+	}
+}
+
+func TestAddSpecHandler_DefaultTTL(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, reg := newTestRouter(t)
+
+	recorder := doJSON(t, router, http.MethodPost, "/admin/specs", map[string]interface{}{
+		"url":         upstream.URL + "/openapi.json",
+		"serviceName": "petstore",
+	})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if stored, _ := reg.Get("petstore"); stored == nil || stored.TTL != time.Hour {
+		t.Errorf("Expected default TTL of 1h, got %+v", stored)
+	}
+}
+
+func TestAddSpecHandler_BadRequests(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, _ := newTestRouter(t)
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"missing service name", map[string]interface{}{"url": upstream.URL + "/openapi.json"}},
+		{"relative URL", map[string]interface{}{"url": "/openapi.json", "serviceName": "petstore"}},
+		{"unsupported scheme", map[string]interface{}{"url": "ftp://example.com/openapi.json", "serviceName": "petstore"}},
+		{"invalid TTL", map[string]interface{}{"url": upstream.URL + "/openapi.json", "serviceName": "petstore", "ttl": "soon"}},
+		{"fetch failure", map[string]interface{}{"url": upstream.URL + "/missing.json", "serviceName": "petstore"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := doJSON(t, router, http.MethodPost, "/admin/specs", tt.body)
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", recorder.Code)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil || body["error"] == "" {
+				t.Errorf("Expected an error message, got %s", recorder.Body.String())
+			}
+		})
+	}
+}
+
+// addPetstore registers the upstream's spec through the admin API
+func addPetstore(t *testing.T, router http.Handler, upstream *httptest.Server) {
+	t.Helper()
+
+	recorder := doJSON(t, router, http.MethodPost, "/admin/specs", map[string]interface{}{
+		"url":         upstream.URL + "/openapi.json",
+		"serviceName": "petstore",
+		"headers":     map[string]string{"X-Spec-Token": "secret"},
+	})
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("Failed to add spec: %d %s", recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestRefreshSpecHandler(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, reg := newTestRouter(t)
+	addPetstore(t, router, upstream)
+
+	previous, _ := reg.Get("petstore")
+	previous.AuthPolicy = &models.AuthPolicy{Type: "bearer", Required: true}
+	previous.Metadata = map[string]string{"team": "pets"}
+
+	recorder := doJSON(t, router, http.MethodPut, "/admin/specs/petstore/refresh", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var body struct {
+		Spec              models.SpecInfo `json:"spec"`
+		PreviousFetchedAt time.Time       `json:"previousFetchedAt"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !body.PreviousFetchedAt.Equal(previous.FetchedAt) {
+		t.Errorf("Expected previous fetch time %v, got %v", previous.FetchedAt, body.PreviousFetchedAt)
+	}
+
+	refreshed, _ := reg.Get("petstore")
+	if refreshed == previous {
+		t.Fatalf("Expected the registry to hold the refreshed spec")
+	}
+	if refreshed.AuthPolicy == nil || refreshed.AuthPolicy.Type != "bearer" {
+		t.Errorf("Expected auth policy to be kept, got %+v", refreshed.AuthPolicy)
+	}
+	if refreshed.Metadata["team"] != "pets" {
+		t.Errorf("Expected metadata to be kept, got %v", refreshed.Metadata)
+	}
+	if refreshed.Headers["X-Spec-Token"] != "secret" || refreshed.TTL != previous.TTL {
+		t.Errorf("Expected stored headers and TTL to be reused, got %v and %v", refreshed.Headers, refreshed.TTL)
+	}
+}
+
+func TestRefreshSpecHandler_NotModified(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	cached := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		upstream.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer cached.Close()
+
+	router, reg := newTestRouter(t)
+	addPetstore(t, router, cached)
+	previous, _ := reg.Get("petstore")
+	previousFetchedAt := previous.FetchedAt
+
+	recorder := doJSON(t, router, http.MethodPut, "/admin/specs/petstore/refresh", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var body struct {
+		Changed           bool      `json:"changed"`
+		PreviousFetchedAt time.Time `json:"previousFetchedAt"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Changed || !body.PreviousFetchedAt.Equal(previousFetchedAt) {
+		t.Errorf("Expected an unchanged spec fetched before at %v, got %+v", previousFetchedAt, body)
+	}
+
+	// The entry is renewed rather than replaced, so bound routes are kept
+	refreshed, fresh := reg.Get("petstore")
+	if refreshed != previous || !fresh || !refreshed.FetchedAt.After(previousFetchedAt) {
+		t.Errorf("Expected the registered entry to be renewed in place, got %+v", refreshed)
+	}
+}
+
+func TestRefreshSpecHandler_NotFound(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	recorder := doJSON(t, router, http.MethodPut, "/admin/specs/missing/refresh", nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", recorder.Code)
+	}
+}
+
+func TestRefreshSpecHandler_UpstreamFailure(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, reg := newTestRouter(t)
+	addPetstore(t, router, upstream)
+	previous, _ := reg.Get("petstore")
+
+	upstream.Close()
+
+	recorder := doJSON(t, router, http.MethodPut, "/admin/specs/petstore/refresh", nil)
+	if recorder.Code != http.StatusBadGateway {
+		t.Errorf("Expected status 502, got %d", recorder.Code)
+	}
+	if current, _ := reg.Get("petstore"); current != previous {
+		t.Errorf("Expected the previous spec to stay registered")
+	}
+}
+
+func TestSpecDetailHandler(t *testing.T) {
+	upstream := newPetstoreUpstream(t)
+	router, _ := newTestRouter(t)
+	addPetstore(t, router, upstream)
+
+	recorder := doJSON(t, router, http.MethodGet, "/admin/specs/petstore", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var body struct {
+		Spec    models.SpecInfo    `json:"spec"`
+		Routes  []models.RouteInfo `json:"routes"`
+		Expired bool               `json:"expired"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.Spec.ServiceName != "petstore" {
+		t.Errorf("Expected service petstore, got %s", body.Spec.ServiceName)
+	}
+	if body.Spec.Headers["X-Spec-Token"] != redact.Mask {
+		t.Errorf("Expected header values to be redacted, got %v", body.Spec.Headers)
+	}
+	if body.Expired {
+		t.Errorf("Expected a freshly added spec to be valid")
+	}
+	if len(body.Routes) != 1 {
+		t.Fatalf("Expected 1 route, got %d", len(body.Routes))
+	}
+	route := body.Routes[0]
+	if route.OperationID != "listPets" || route.Method != http.MethodGet || route.Path != "/pets" {
+		t.Errorf("Expected GET /pets listPets, got %s %s %s", route.Method, route.Path, route.OperationID)
+	}
+}
+
+func TestSpecDetailHandler_NotFound(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	recorder := doJSON(t, router, http.MethodGet, "/admin/specs/missing", nil)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", recorder.Code)
+	}
+}
+
+// newCORSRouter serves GET /ping behind the CORS middleware
+func newCORSRouter(origins []string, credentials bool) http.Handler {
+	cfg := &config.Config{}
+	cfg.Policies.CORS.AllowOrigins = origins
+	cfg.Policies.CORS.AllowMethods = []string{"GET", "POST"}
+	cfg.Policies.CORS.AllowHeaders = []string{"Content-Type", "Authorization"}
+	cfg.Policies.CORS.AllowCredentials = credentials
+	cfg.Policies.CORS.MaxAge = 10 * time.Minute
+
+	router := gin.New()
+	router.Use(corsMiddleware(cfg))
+	router.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+// corsRequest sends a request with an Origin header, as a preflight when preflight is set
+func corsRequest(router http.Handler, origin string, preflight bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	if preflight {
+		req = httptest.NewRequest(http.MethodOptions, "/ping", nil)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	req.Header.Set("Origin", origin)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     []string
+		credentials bool
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+	}{
+		{"allowed origin", []string{"https://app.example.com"}, false, "https://app.example.com", false, http.StatusOK, "https://app.example.com"},
+		{"allowed origin preflight", []string{"https://app.example.com"}, false, "https://app.example.com", true, http.StatusNoContent, "https://app.example.com"},
+		{"disallowed origin", []string{"https://app.example.com"}, false, "https://evil.example.com", false, http.StatusOK, ""},
+		{"disallowed origin preflight", []string{"https://app.example.com"}, false, "https://evil.example.com", true, http.StatusForbidden, ""},
+		{"wildcard", []string{"*"}, false, "https://any.example.com", false, http.StatusOK, "*"},
+		{"wildcard with credentials", []string{"*"}, true, "https://any.example.com", false, http.StatusOK, "https://any.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := corsRequest(newCORSRouter(tt.origins, tt.credentials), tt.origin, tt.preflight)
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, recorder.Code)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Expected allowed origin %q, got %q", tt.wantOrigin, got)
+			}
+			if got := recorder.Header().Get("Access-Control-Allow-Credentials"); (got == "true") != (tt.credentials && tt.wantOrigin != "") {
+				t.Errorf("Unexpected Access-Control-Allow-Credentials %q", got)
+			}
+		})
+	}
+}
+
+func TestGinLogger_Redaction(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Logging.Redact.Headers = []string{"X-Api-Key"}
+	cfg.Logging.Redact.Services = map[string]config.RedactRules{"petstore": {Headers: []string{"X-Pet-Token"}}}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	router := gin.New()
+	router.Use(ginLogger(zap.New(core), newRedactor(cfg)))
+	router.Any("/*path", func(c *gin.Context) {
+		c.Header("Set-Cookie", "session=abc")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/apis/petstore/pets", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("X-Pet-Token", "token")
+	req.Header.Set("Accept", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("HTTP request").All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected one request log, got %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	headers, _ := fields["headers"].(map[string]string)
+	for _, name := range []string{"Authorization", "X-Api-Key", "X-Pet-Token"} {
+		if headers[name] != "***" {
+			t.Errorf("Expected %s to be redacted, got %q", name, headers[name])
+		}
+	}
+	if headers["Accept"] != "application/json" {
+		t.Errorf("Expected other headers to be logged as sent, got %v", headers)
+	}
+	if responseHeaders, _ := fields["responseHeaders"].(map[string]string); responseHeaders["Set-Cookie"] != "***" {
+		t.Errorf("Expected Set-Cookie to be redacted, got %v", responseHeaders)
+	}
+}
+
+func TestCORSMiddleware_PreflightHeaders(t *testing.T) {
+	recorder := corsRequest(newCORSRouter([]string{"https://app.example.com"}, false), "https://app.example.com", true)
+
+	if got := recorder.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Errorf("Expected configured methods, got %q", got)
+	}
+	if got := recorder.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type, Authorization" {
+		t.Errorf("Expected configured headers, got %q", got)
+	}
+	if got := recorder.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Expected max age 600, got %q", got)
+	}
+}
+
+func TestAdminHealth(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	reg := registry.New(logger)
+	fetcher := specs.New(logger, 5*time.Second, 1024*1024)
+	checker := health.NewChecker([]string{health.SubsystemUpstreams})
+	checker.Register(health.SubsystemRegistry, health.RegistryCheck(reg))
+	checker.Register(health.SubsystemUpstreams, health.UpstreamCheck(http.DefaultClient, map[string]string{"petstore": down.URL}, time.Second))
+	router := setupRouter(cfg, logger, reg, fetcher, hooks.NewManager(logger), nil, nil, nil, checker)
+
+	recorder := doJSON(t, router, http.MethodGet, "/admin/health", nil)
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var report health.Report
+	if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Status != health.StatusUnhealthy {
+		t.Errorf("Expected status unhealthy, got %s", report.Status)
+	}
+	for _, name := range []string{health.SubsystemRegistry, health.SubsystemUpstreams, health.SubsystemCircuitBreakers} {
+		if _, ok := report.Subsystems[name]; !ok {
+			t.Errorf("Expected the %s subsystem to be reported, got %v", name, report.Subsystems)
+		}
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Upstream.CircuitBreaker.Threshold = 5
+	cfg.Policies.RateLimit.Enabled = true
+	cfg.Policies.RateLimit.RequestsPerMinute = 1
+	cfg.Metrics.Enabled = true
+	cfg.Metrics.Path = "/metrics"
+
+	hookManager := hooks.NewManager(logger)
+	hookManager.RegisterHook(hooks.NewMetricsHook(logger, hooks.PriorityLow))
+	reg := registry.New(logger)
+	fetcher := specs.New(logger, 5*time.Second, 1024*1024)
+	router := setupRouter(cfg, logger, reg, fetcher, hookManager, nil, nil, nil, health.NewChecker(nil))
+
+	addPetstore(t, router, newPetstoreUpstream(t))
+
+	if recorder := doJSON(t, router, http.MethodGet, "/apis/petstore/pets", nil); recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := doJSON(t, router, http.MethodGet, "/apis/petstore/pets", nil); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", recorder.Code)
+	}
+
+	recorder := doJSON(t, router, http.MethodGet, "/metrics", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	for _, name := range []string{
+		`swagger_mcp_proxy_requests_total{service="petstore",status="200"}`,
+		`swagger_mcp_proxy_request_duration_seconds_bucket{service="petstore",status="200"`,
+		`swagger_mcp_circuit_breaker_state{name="petstore"} 0`,
+		`swagger_mcp_ratelimit_rejected_total{reason="rate",service="petstore"}`,
+		`swagger_mcp_operation_responses_total{operation="listPets",service="petstore",status="200"}`,
+	} {
+		if !strings.Contains(body, name) {
+			t.Errorf("Expected metrics to contain %s", name)
+		}
+	}
+}
+
+// writeSpecFile writes a minimal spec with one operation to a temporary file
+func writeSpecFile(t *testing.T, name, title string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	spec := fmt.Sprintf(`openapi: 3.0.0
+info:
+  title: %s
+  version: 1.0.0
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses:
+        "200":
+          description: ok
+`, title)
+	if err := os.WriteFile(path, []byte(spec), 0o644); err != nil {
+		t.Fatalf("Failed to write spec file: %v", err)
+	}
+	return path
+}
+
+// newSpecSourceServer builds an MCP server over a fresh registry for the given sources
+func newSpecSourceServer(sources []config.SpecSource, continueOnError bool) (*config.Config, *mcp.Server, *registry.Registry) {
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Specs.DefaultTTL = "1h"
+	cfg.Specs.Sources = sources
+	cfg.Specs.ContinueOnError = continueOnError
+
+	reg := registry.New(logger)
+	fetcher := specs.New(logger, 5*time.Second, 1024*1024)
+	return cfg, mcp.NewServer(logger, cfg, reg, fetcher), reg
+}
+
+func TestLoadConfiguredSpecs(t *testing.T) {
+	cfg, mcpServer, reg := newSpecSourceServer([]config.SpecSource{
+		{ServiceName: "inventory", File: writeSpecFile(t, "inventory.yaml", "Inventory"), BaseURL: "http://127.0.0.1:0"},
+		{ServiceName: "orders", File: writeSpecFile(t, "orders.yaml", "Orders"), TTL: "30m"},
+	}, false)
+
+	if err := loadConfiguredSpecs(context.Background(), cfg, mcpServer, zap.NewNop()); err != nil {
+		t.Fatalf("Expected specs to load, got %v", err)
+	}
+
+	tests := []struct {
+		serviceName string
+		title       string
+		ttl         time.Duration
+	}{
+		{"inventory", "Inventory", 0},
+		{"orders", "Orders", 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		specInfo, _ := reg.Get(tt.serviceName)
+		if specInfo == nil {
+			t.Fatalf("Expected %s to be registered", tt.serviceName)
+		}
+		if specInfo.Spec.Info.Title != tt.title {
+			t.Errorf("Expected title %s, got %s", tt.title, specInfo.Spec.Info.Title)
+		}
+		if specInfo.TTL != tt.ttl {
+			t.Errorf("Expected %s TTL %v, got %v", tt.serviceName, tt.ttl, specInfo.TTL)
+		}
+	}
+}
+
+func TestLoadConfiguredSpecs_Failures(t *testing.T) {
+	sources := []config.SpecSource{
+		{ServiceName: "inventory", File: writeSpecFile(t, "inventory.yaml", "Inventory")},
+		{ServiceName: "missing", File: filepath.Join(t.TempDir(), "missing.yaml")},
+		{ServiceName: "empty"},
+	}
+
+	t.Run("fail fast", func(t *testing.T) {
+		cfg, mcpServer, _ := newSpecSourceServer(sources, false)
+
+		err := loadConfiguredSpecs(context.Background(), cfg, mcpServer, zap.NewNop())
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		for _, want := range []string{"missing:", "empty: spec empty needs a url or a file"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to mention %q, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("continue on error", func(t *testing.T) {
+		cfg, mcpServer, reg := newSpecSourceServer(sources, true)
+
+		if err := loadConfiguredSpecs(context.Background(), cfg, mcpServer, zap.NewNop()); err != nil {
+			t.Fatalf("Expected failures to be skipped, got %v", err)
+		}
+		if specInfo, _ := reg.Get("inventory"); specInfo == nil {
+			t.Error("Expected inventory to be registered")
+		}
+	})
+}
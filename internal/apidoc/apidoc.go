@@ -0,0 +1,190 @@
+package apidoc
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// Route documents one endpoint of the server's own HTTP API
+type Route struct {
+	Summary     string
+	Description string
+	Tag         string
+	ContentType string // of the success response; defaults to application/json
+	Status      int    // success status; defaults to 200
+	RequestBody bool   // whether the endpoint accepts a JSON object body
+}
+
+// Generator registers routes on a Gin router and describes them in an OpenAPI 3
+// document, so the document always matches what is actually served
+type Generator struct {
+	title   string
+	version string
+
+	routes []documentedRoute
+	mutex  sync.Mutex
+}
+
+// documentedRoute is a registered route with its documentation
+type documentedRoute struct {
+	method string
+	path   string
+	route  Route
+}
+
+// New creates a generator for a document with the given title and version
+func New(title, version string) *Generator {
+	return &Generator{
+		title:   title,
+		version: version,
+	}
+}
+
+// Handle registers handlers for method and relativePath on group and documents the route
+func (g *Generator) Handle(group *gin.RouterGroup, method, relativePath string, route Route, handlers ...gin.HandlerFunc) {
+	group.Handle(method, relativePath, handlers...)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.routes = append(g.routes, documentedRoute{
+		method: method,
+		path:   joinPaths(group.BasePath(), relativePath),
+		route:  route,
+	})
+}
+
+// Document builds the OpenAPI document for every route registered so far
+func (g *Generator) Document() *openapi3.T {
+	g.mutex.Lock()
+	routes := append([]documentedRoute(nil), g.routes...)
+	g.mutex.Unlock()
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].path != routes[j].path {
+			return routes[i].path < routes[j].path
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   g.title,
+			Version: g.version,
+		},
+		Paths: openapi3.NewPaths(),
+	}
+
+	tags := make(map[string]bool)
+	for _, r := range routes {
+		path, params := openAPIPath(r.path)
+		item := doc.Paths.Value(path)
+		if item == nil {
+			item = &openapi3.PathItem{}
+			doc.Paths.Set(path, item)
+		}
+		item.SetOperation(r.method, operation(r, params))
+
+		if r.route.Tag != "" && !tags[r.route.Tag] {
+			tags[r.route.Tag] = true
+			doc.Tags = append(doc.Tags, &openapi3.Tag{Name: r.route.Tag})
+		}
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+
+	return doc
+}
+
+// Handler serves the generated document as JSON
+func (g *Generator) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, g.Document())
+	}
+}
+
+// operation builds the OpenAPI operation for a documented route
+func operation(r documentedRoute, params []string) *openapi3.Operation {
+	op := openapi3.NewOperation()
+	op.OperationID = operationID(r.method, r.path)
+	op.Summary = r.route.Summary
+	op.Description = r.route.Description
+	if r.route.Tag != "" {
+		op.Tags = []string{r.route.Tag}
+	}
+
+	for _, name := range params {
+		op.AddParameter(openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema()))
+	}
+
+	if r.route.RequestBody {
+		op.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithRequired(true).
+				WithJSONSchema(openapi3.NewObjectSchema()),
+		}
+	}
+
+	status := r.route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := r.route.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	schema := openapi3.NewObjectSchema()
+	if !strings.Contains(contentType, "json") {
+		schema = openapi3.NewStringSchema()
+	}
+
+	op.Responses = openapi3.NewResponsesWithCapacity(1)
+	op.AddResponse(status, openapi3.NewResponse().
+		WithDescription(http.StatusText(status)).
+		WithContent(openapi3.NewContentWithSchema(schema, []string{contentType})))
+
+	return op
+}
+
+// openAPIPath converts a Gin path to OpenAPI form, returning the path parameter names.
+// Both :name and *name segments become {name}.
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a camel-case operation ID such as getAdminSpecs from a route
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// joinPaths joins a group base path and a relative path without doubling slashes
+func joinPaths(base, relative string) string {
+	if relative == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(relative, "/")
+}
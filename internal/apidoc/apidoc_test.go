@@ -0,0 +1,91 @@
+package apidoc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGenerator_ServesValidDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	docs := New("management API", "1.0.0")
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	docs.Handle(&router.RouterGroup, http.MethodGet, "/health", Route{Summary: "Report server health", Tag: "system"}, ok)
+	docs.Handle(&router.RouterGroup, http.MethodGet, "/metrics", Route{Summary: "Prometheus metrics", Tag: "system", ContentType: "text/plain"}, ok)
+	admin := router.Group("/admin")
+	docs.Handle(admin, http.MethodGet, "/specs", Route{Summary: "List registered specs", Tag: "specs"}, ok)
+	docs.Handle(admin, http.MethodPost, "/specs", Route{Summary: "Register a spec", Tag: "specs", RequestBody: true}, ok)
+	docs.Handle(admin, http.MethodPut, "/specs/:service/refresh", Route{Summary: "Refetch a spec", Tag: "specs"}, ok)
+	docs.Handle(admin, http.MethodDelete, "/specs/:service", Route{Summary: "Remove a spec", Tag: "specs"}, ok)
+	docs.Handle(admin, http.MethodGet, "/openapi.json", Route{Summary: "This document", Tag: "system"}, docs.Handler())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin/openapi.json", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", recorder.Code)
+	}
+
+	doc, err := openapi3.NewLoader().LoadFromData(recorder.Body.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to load served document: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("Served document is invalid: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		params int
+	}{
+		{http.MethodGet, "/health", 0},
+		{http.MethodGet, "/metrics", 0},
+		{http.MethodGet, "/admin/specs", 0},
+		{http.MethodPost, "/admin/specs", 0},
+		{http.MethodPut, "/admin/specs/{service}/refresh", 1},
+		{http.MethodDelete, "/admin/specs/{service}", 1},
+		{http.MethodGet, "/admin/openapi.json", 0},
+	}
+	for _, tt := range tests {
+		item := doc.Paths.Value(tt.path)
+		if item == nil {
+			t.Errorf("Expected path %s in document", tt.path)
+			continue
+		}
+		op := item.GetOperation(tt.method)
+		if op == nil {
+			t.Errorf("Expected %s %s in document", tt.method, tt.path)
+			continue
+		}
+		if len(op.Parameters) != tt.params {
+			t.Errorf("Expected %d parameters for %s %s, got %d", tt.params, tt.method, tt.path, len(op.Parameters))
+		}
+	}
+	if doc.Paths.Len() != 6 {
+		t.Errorf("Expected 6 documented paths, got %d", doc.Paths.Len())
+	}
+
+	metrics := doc.Paths.Value("/metrics").Get.Responses.Value("200").Value
+	if metrics.Content.Get("text/plain") == nil {
+		t.Errorf("Expected metrics to be documented as text/plain, got %v", metrics.Content)
+	}
+	if doc.Paths.Value("/admin/specs").Post.RequestBody == nil {
+		t.Error("Expected POST /admin/specs to document a request body")
+	}
+	if id := doc.Paths.Value("/admin/specs/{service}/refresh").Put.OperationID; id != "putAdminSpecsServiceRefresh" {
+		t.Errorf("Expected generated operation ID, got %s", id)
+	}
+
+	// Documented routes are served
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/admin/specs/petstore", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected documented route to be registered, got %d", recorder.Code)
+	}
+}
@@ -2,15 +2,23 @@ package auth
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/zeroLR/swagger-mcp-go/internal/models"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // Provider interface for authentication providers
@@ -52,6 +60,12 @@ func (m *Manager) RegisterProvider(authType models.AuthType, provider Provider)
 	m.logger.Info("Registered authentication provider", zap.String("type", string(authType)))
 }
 
+// GetProvider returns the provider registered for an authentication type
+func (m *Manager) GetProvider(authType models.AuthType) (Provider, bool) {
+	provider, exists := m.providers[authType]
+	return provider, exists
+}
+
 // Authenticate attempts authentication using the specified policy
 func (m *Manager) Authenticate(ctx context.Context, request *http.Request, policy *models.AuthPolicy) (*AuthContext, error) {
 	if !policy.Required {
@@ -98,9 +112,28 @@ func (m *Manager) hasRequiredScopes(userScopes, requiredScopes []string) bool {
 	return true
 }
 
+// NewProvider creates an unconfigured provider for an authentication type
+func NewProvider(authType models.AuthType, logger *zap.Logger) (Provider, error) {
+	switch authType {
+	case models.AuthTypeBasic:
+		return NewBasicAuthProvider(logger), nil
+	case models.AuthTypeBearer:
+		return NewBearerTokenProvider(logger), nil
+	case models.AuthTypeAPIKey:
+		return NewAPIKeyProvider(logger), nil
+	case models.AuthTypeOAuth2:
+		return NewOAuth2Provider(logger), nil
+	case models.AuthTypeDigest:
+		return NewDigestAuthProvider(logger), nil
+	default:
+		return nil, fmt.Errorf("unsupported authentication type: %s", authType)
+	}
+}
+
 // BasicAuthProvider implements basic authentication
 type BasicAuthProvider struct {
-	users  map[string]string // username -> password
+	users  map[string]string // username -> password, or bcrypt hash when hashed is set
+	hashed bool
 	logger *zap.Logger
 }
 
@@ -119,6 +152,9 @@ func (p *BasicAuthProvider) Type() models.AuthType {
 
 // Configure sets up the basic auth provider
 func (p *BasicAuthProvider) Configure(config map[string]interface{}) error {
+	if hashed, ok := config["hashed"].(bool); ok {
+		p.hashed = hashed
+	}
 	if users, ok := config["users"].(map[string]interface{}); ok {
 		for username, password := range users {
 			if passwordStr, ok := password.(string); ok {
@@ -136,7 +172,7 @@ func (p *BasicAuthProvider) Authenticate(ctx context.Context, request *http.Requ
 		return nil, fmt.Errorf("basic auth credentials not provided")
 	}
 
-	if storedPassword, exists := p.users[username]; exists && storedPassword == password {
+	if storedPassword, exists := p.users[username]; exists && p.checkPassword(storedPassword, password) {
 		return &AuthContext{
 			UserID:   username,
 			Username: username,
@@ -147,12 +183,32 @@ func (p *BasicAuthProvider) Authenticate(ctx context.Context, request *http.Requ
 	return nil, fmt.Errorf("invalid credentials")
 }
 
+// checkPassword compares a supplied password against the stored value
+func (p *BasicAuthProvider) checkPassword(stored, password string) bool {
+	if p.hashed {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+// Errors returned when a bearer token's time claims do not hold
+var (
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+)
+
+// defaultClockSkew is the leeway applied to exp, nbf and iat checks
+const defaultClockSkew = 60 * time.Second
+
 // BearerTokenProvider implements JWT bearer token authentication
 type BearerTokenProvider struct {
 	publicKey  *rsa.PublicKey
-	issuer     string
-	audience   string
+	hmacSecret []byte
+	issuers    []string // accepted iss values; any issuer when empty
+	audience   string   // must be among the token's aud values when set
 	jwksURL    string
+	jwks       jwksCache
+	clockSkew  time.Duration
 	httpClient *http.Client
 	logger     *zap.Logger
 }
@@ -160,6 +216,7 @@ type BearerTokenProvider struct {
 // NewBearerTokenProvider creates a new bearer token provider
 func NewBearerTokenProvider(logger *zap.Logger) *BearerTokenProvider {
 	return &BearerTokenProvider{
+		clockSkew:  defaultClockSkew,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
 		logger:     logger,
 	}
@@ -172,8 +229,14 @@ func (p *BearerTokenProvider) Type() models.AuthType {
 
 // Configure sets up the bearer token provider
 func (p *BearerTokenProvider) Configure(config map[string]interface{}) error {
-	if issuer, ok := config["issuer"].(string); ok {
-		p.issuer = issuer
+	// issuer is a single accepted issuer, issuers a list; both may be given
+	var issuers []string
+	if issuer, ok := config["issuer"].(string); ok && issuer != "" {
+		issuers = append(issuers, issuer)
+	}
+	issuers = append(issuers, stringList(config["issuers"])...)
+	if len(issuers) > 0 {
+		p.issuers = issuers
 	}
 	if audience, ok := config["audience"].(string); ok {
 		p.audience = audience
@@ -181,6 +244,19 @@ func (p *BearerTokenProvider) Configure(config map[string]interface{}) error {
 	if jwksURL, ok := config["jwksURL"].(string); ok {
 		p.jwksURL = jwksURL
 	}
+	if hmacSecret, ok := config["hmacSecret"].(string); ok {
+		p.hmacSecret = []byte(hmacSecret)
+	}
+	switch clockSkew := config["clockSkew"].(type) {
+	case time.Duration:
+		p.clockSkew = clockSkew
+	case string:
+		skew, err := time.ParseDuration(clockSkew)
+		if err != nil {
+			return fmt.Errorf("invalid clockSkew: %w", err)
+		}
+		p.clockSkew = skew
+	}
 	return nil
 }
 
@@ -197,18 +273,8 @@ func (p *BearerTokenProvider) Authenticate(ctx context.Context, request *http.Re
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-	// Parse the token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-
-		// TODO: Implement JWKS key resolution
-		// For now, return the configured public key
-		return p.publicKey, nil
-	})
-
+	// Parse the token; time claims are checked below with our own leeway
+	token, err := jwt.Parse(tokenString, p.keyFunc, jwt.WithoutClaimsValidation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
@@ -222,15 +288,19 @@ func (p *BearerTokenProvider) Authenticate(ctx context.Context, request *http.Re
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
-	// Validate issuer and audience
-	if p.issuer != "" {
-		if iss, ok := claims["iss"].(string); !ok || iss != p.issuer {
+	if err := p.validateTimeClaims(claims, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Validate issuer and audience; aud may be a single string or an array
+	if len(p.issuers) > 0 {
+		if iss, err := claims.GetIssuer(); err != nil || !slices.Contains(p.issuers, iss) {
 			return nil, fmt.Errorf("invalid issuer")
 		}
 	}
 
 	if p.audience != "" {
-		if aud, ok := claims["aud"].(string); !ok || aud != p.audience {
+		if aud, err := claims.GetAudience(); err != nil || !slices.Contains(aud, p.audience) {
 			return nil, fmt.Errorf("invalid audience")
 		}
 	}
@@ -261,11 +331,107 @@ func (p *BearerTokenProvider) Authenticate(ctx context.Context, request *http.Re
 	}, nil
 }
 
-// APIKeyProvider implements API key authentication
+// stringList reads a configured list of strings, skipping empty and non-string items
+func stringList(value interface{}) []string {
+	var list []string
+	switch items := value.(type) {
+	case []string:
+		for _, item := range items {
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range items {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+// validateTimeClaims checks exp, nbf and iat against now, allowing clockSkew on both ends
+func (p *BearerTokenProvider) validateTimeClaims(claims jwt.MapClaims, now time.Time) error {
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		return fmt.Errorf("invalid exp claim: %w", err)
+	}
+	if exp != nil && now.After(exp.Add(p.clockSkew)) {
+		return ErrTokenExpired
+	}
+
+	nbf, err := claims.GetNotBefore()
+	if err != nil {
+		return fmt.Errorf("invalid nbf claim: %w", err)
+	}
+	if nbf != nil && now.Add(p.clockSkew).Before(nbf.Time) {
+		return ErrTokenNotYetValid
+	}
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil {
+		return fmt.Errorf("invalid iat claim: %w", err)
+	}
+	if iat != nil && now.Add(p.clockSkew).Before(iat.Time) {
+		return ErrTokenNotYetValid
+	}
+
+	return nil
+}
+
+// keyFunc selects the verification key based on the token's alg header. Each
+// algorithm family is only accepted when a key of that kind is available, so an
+// RS256 token can never be checked against the HMAC secret or an EC key.
+func (p *BearerTokenProvider) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA:
+		key, err := p.publicKeyFor(token)
+		if err != nil {
+			return nil, err
+		}
+		if rsaKey, ok := key.(*rsa.PublicKey); ok {
+			return rsaKey, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		key, err := p.publicKeyFor(token)
+		if err != nil {
+			return nil, err
+		}
+		if ecKey, ok := key.(*ecdsa.PublicKey); ok {
+			return ecKey, nil
+		}
+	case *jwt.SigningMethodHMAC:
+		if len(p.hmacSecret) > 0 {
+			return p.hmacSecret, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
+
+// publicKeyFor resolves the token's kid against the JWKS endpoint when one is
+// configured, falling back to the statically configured RSA key
+func (p *BearerTokenProvider) publicKeyFor(token *jwt.Token) (interface{}, error) {
+	if p.jwksURL != "" {
+		kid, _ := token.Header["kid"].(string)
+		return p.lookupJWK(kid)
+	}
+	if p.publicKey != nil {
+		return p.publicKey, nil
+	}
+	return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+}
+
+// ErrAPIKeyExpired is returned for an API key used after its ExpiresAt
+var ErrAPIKeyExpired = errors.New("API key expired")
+
+// APIKeyProvider implements API key authentication. Keys can be deactivated at
+// runtime with SetKeyActive, so they can be rotated without a restart.
 type APIKeyProvider struct {
 	keys      map[string]*APIKeyInfo // API key -> key info
-	headerKey string                 // Header name for API key (default: "X-API-Key")
-	queryKey  string                 // Query parameter name for API key
+	keysMutex sync.RWMutex
+	headerKey string // Header name for API key (default: "X-API-Key")
+	queryKey  string // Query parameter name for API key
 	logger    *zap.Logger
 }
 
@@ -275,6 +441,8 @@ type APIKeyInfo struct {
 	Username string   `json:"username"`
 	Scopes   []string `json:"scopes"`
 	Active   bool     `json:"active"`
+	// ExpiresAt is when the key stops being accepted; zero means it never expires
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
 }
 
 // NewAPIKeyProvider creates a new API key provider
@@ -320,13 +488,42 @@ func (p *APIKeyProvider) Configure(config map[string]interface{}) error {
 				if active, ok := keyInfo["active"].(bool); ok {
 					info.Active = active
 				}
+				switch expiresAt := keyInfo["expiresAt"].(type) {
+				case time.Time:
+					info.ExpiresAt = expiresAt
+				case string:
+					t, err := time.Parse(time.RFC3339, expiresAt)
+					if err != nil {
+						return fmt.Errorf("invalid expiresAt for API key of %s: %w", info.UserID, err)
+					}
+					info.ExpiresAt = t
+				}
+				p.keysMutex.Lock()
 				p.keys[apiKey] = info
+				p.keysMutex.Unlock()
 			}
 		}
 	}
 	return nil
 }
 
+// SetKeyActive activates or deactivates an API key, returning false when the key is
+// not configured
+func (p *APIKeyProvider) SetKeyActive(key string, active bool) bool {
+	p.keysMutex.Lock()
+	defer p.keysMutex.Unlock()
+
+	keyInfo, exists := p.keys[key]
+	if !exists {
+		return false
+	}
+	// Replace rather than modify the info so concurrent readers never see it change
+	updated := *keyInfo
+	updated.Active = active
+	p.keys[key] = &updated
+	return true
+}
+
 // Authenticate validates API key authentication
 func (p *APIKeyProvider) Authenticate(ctx context.Context, request *http.Request) (*AuthContext, error) {
 	var apiKey string
@@ -345,10 +542,15 @@ func (p *APIKeyProvider) Authenticate(ctx context.Context, request *http.Request
 		return nil, fmt.Errorf("API key not provided")
 	}
 
+	p.keysMutex.RLock()
 	keyInfo, exists := p.keys[apiKey]
+	p.keysMutex.RUnlock()
 	if !exists || !keyInfo.Active {
 		return nil, fmt.Errorf("invalid or inactive API key")
 	}
+	if !keyInfo.ExpiresAt.IsZero() && !time.Now().Before(keyInfo.ExpiresAt) {
+		return nil, ErrAPIKeyExpired
+	}
 
 	return &AuthContext{
 		UserID:   keyInfo.UserID,
@@ -358,20 +560,39 @@ func (p *APIKeyProvider) Authenticate(ctx context.Context, request *http.Request
 	}, nil
 }
 
+// defaultTokenRefreshWindow is how long before expiry a cached token is refreshed
+const defaultTokenRefreshWindow = 30 * time.Second
+
 // OAuth2Provider implements OAuth2 client credentials flow
 type OAuth2Provider struct {
-	tokenURL     string
-	clientID     string
-	clientSecret string
-	httpClient   *http.Client
-	logger       *zap.Logger
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	refreshWindow time.Duration
+	httpClient    *http.Client
+	logger        *zap.Logger
+
+	// Cached client-credentials token
+	tokenMutex  sync.Mutex
+	token       *OAuth2Token
+	tokenExpiry time.Time
+}
+
+// OAuth2Token is a token issued by the OAuth2 token endpoint
+type OAuth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
 }
 
 // NewOAuth2Provider creates a new OAuth2 provider
 func NewOAuth2Provider(logger *zap.Logger) *OAuth2Provider {
 	return &OAuth2Provider{
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-		logger:     logger,
+		refreshWindow: defaultTokenRefreshWindow,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		logger:        logger,
 	}
 }
 
@@ -391,9 +612,94 @@ func (p *OAuth2Provider) Configure(config map[string]interface{}) error {
 	if clientSecret, ok := config["clientSecret"].(string); ok {
 		p.clientSecret = clientSecret
 	}
+	if scopes, ok := config["scopes"].([]interface{}); ok {
+		p.scopes = make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if scopeStr, ok := scope.(string); ok {
+				p.scopes = append(p.scopes, scopeStr)
+			}
+		}
+	}
+	switch refreshWindow := config["refreshWindow"].(type) {
+	case time.Duration:
+		p.refreshWindow = refreshWindow
+	case string:
+		window, err := time.ParseDuration(refreshWindow)
+		if err != nil {
+			return fmt.Errorf("invalid refreshWindow: %w", err)
+		}
+		p.refreshWindow = window
+	}
 	return nil
 }
 
+// GetClientCredentialsToken returns a client-credentials access token, reusing the
+// cached one until it is within the refresh window of its expiry
+func (p *OAuth2Provider) GetClientCredentialsToken(ctx context.Context) (*OAuth2Token, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+
+	if p.token != nil && time.Now().Add(p.refreshWindow).Before(p.tokenExpiry) {
+		return p.token, nil
+	}
+	return p.fetchToken(ctx)
+}
+
+// ForceRefreshToken discards any cached token and fetches a new one
+func (p *OAuth2Provider) ForceRefreshToken(ctx context.Context) (*OAuth2Token, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+
+	p.token = nil
+	return p.fetchToken(ctx)
+}
+
+// fetchToken requests a token from the token endpoint and caches it; callers hold tokenMutex
+func (p *OAuth2Provider) fetchToken(ctx context.Context) (*OAuth2Token, error) {
+	if p.tokenURL == "" {
+		return nil, fmt.Errorf("token URL not configured")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned HTTP %d", resp.StatusCode)
+	}
+
+	var token OAuth2Token
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response missing access_token")
+	}
+
+	p.token = &token
+	p.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	p.logger.Debug("Fetched OAuth2 client credentials token",
+		zap.Int("expiresIn", token.ExpiresIn))
+
+	return p.token, nil
+}
+
 // Authenticate validates OAuth2 tokens
 func (p *OAuth2Provider) Authenticate(ctx context.Context, request *http.Request) (*AuthContext, error) {
 	authHeader := request.Header.Get("Authorization")
@@ -432,19 +738,29 @@ func (m *Manager) Middleware(policy *models.AuthPolicy) func(http.Handler) http.
 			authCtx, err := m.Authenticate(r.Context(), r, policy)
 			if err != nil {
 				m.logger.Debug("Authentication failed", zap.Error(err))
+				if challenger, ok := m.providers[policy.Type].(Challenger); ok {
+					w.Header().Set("WWW-Authenticate", challenger.Challenge())
+				}
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
 			// Add auth context to request context
-			ctx := context.WithValue(r.Context(), "authContext", authCtx)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(WithAuthContext(r.Context(), authCtx)))
 		})
 	}
 }
 
+// authContextKey is the context key for the caller's authentication context
+type authContextKey struct{}
+
+// WithAuthContext returns a context carrying the caller's authentication context
+func WithAuthContext(ctx context.Context, authCtx *AuthContext) context.Context {
+	return context.WithValue(ctx, authContextKey{}, authCtx)
+}
+
 // GetAuthContext retrieves authentication context from request context
 func GetAuthContext(ctx context.Context) (*AuthContext, bool) {
-	authCtx, ok := ctx.Value("authContext").(*AuthContext)
+	authCtx, ok := ctx.Value(authContextKey{}).(*AuthContext)
 	return authCtx, ok
 }
@@ -2,12 +2,28 @@ package auth
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/zeroLR/swagger-mcp-go/internal/models"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestBasicAuthProvider(t *testing.T) {
@@ -61,6 +77,48 @@ func TestBasicAuthProvider(t *testing.T) {
 	}
 }
 
+func TestBasicAuthProvider_Hashed(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("hashedpass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hashed   bool
+		stored   string
+		password string
+		wantErr  bool
+	}{
+		{"plaintext match", false, "plainpass", "plainpass", false},
+		{"plaintext mismatch", false, "plainpass", "wrongpass", true},
+		{"bcrypt match", true, string(hash), "hashedpass", false},
+		{"bcrypt mismatch", true, string(hash), "wrongpass", true},
+		{"bcrypt hash used as password", true, string(hash), string(hash), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewBasicAuthProvider(zap.NewNop())
+			err := provider.Configure(map[string]interface{}{
+				"hashed": tt.hashed,
+				"users":  map[string]interface{}{"alice": tt.stored},
+			})
+			if err != nil {
+				t.Fatalf("Failed to configure provider: %v", err)
+			}
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.SetBasicAuth("alice", tt.password)
+
+			_, err = provider.Authenticate(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestAPIKeyProvider(t *testing.T) {
 	logger := zap.NewNop()
 	provider := NewAPIKeyProvider(logger)
@@ -136,6 +194,90 @@ func TestAPIKeyProvider(t *testing.T) {
 	}
 }
 
+func TestAPIKeyProvider_SetKeyActive(t *testing.T) {
+	provider := NewAPIKeyProvider(zap.NewNop())
+	provider.Configure(map[string]interface{}{
+		"keys": map[string]interface{}{
+			"rotating-key": map[string]interface{}{"userId": "user1"},
+		},
+	})
+
+	authenticate := func() error {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", "rotating-key")
+		_, err := provider.Authenticate(context.Background(), req)
+		return err
+	}
+
+	if err := authenticate(); err != nil {
+		t.Fatalf("Expected the configured key to be accepted, got %v", err)
+	}
+	if !provider.SetKeyActive("rotating-key", false) {
+		t.Fatal("Expected SetKeyActive to find the key")
+	}
+	if err := authenticate(); err == nil {
+		t.Errorf("Expected the deactivated key to be rejected")
+	}
+	provider.SetKeyActive("rotating-key", true)
+	if err := authenticate(); err != nil {
+		t.Errorf("Expected the reactivated key to be accepted, got %v", err)
+	}
+	if provider.SetKeyActive("unknown-key", true) {
+		t.Errorf("Expected SetKeyActive to report an unknown key")
+	}
+
+	// Toggling while requests are authenticated is safe
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(active bool) {
+			defer wg.Done()
+			provider.SetKeyActive("rotating-key", active)
+		}(i%2 == 0)
+		go func() {
+			defer wg.Done()
+			authenticate()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestAPIKeyProvider_ExpiresAt(t *testing.T) {
+	provider := NewAPIKeyProvider(zap.NewNop())
+	if err := provider.Configure(map[string]interface{}{
+		"keys": map[string]interface{}{
+			"expired-key": map[string]interface{}{"userId": "user1", "expiresAt": time.Now().Add(-time.Minute).Format(time.RFC3339)},
+			"current-key": map[string]interface{}{"userId": "user2", "expiresAt": time.Now().Add(time.Hour)},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	tests := []struct {
+		apiKey  string
+		wantErr error
+	}{
+		{"expired-key", ErrAPIKeyExpired},
+		{"current-key", nil},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-API-Key", tt.apiKey)
+		if _, err := provider.Authenticate(context.Background(), req); !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: expected error %v, got %v", tt.apiKey, tt.wantErr, err)
+		}
+	}
+
+	err := provider.Configure(map[string]interface{}{
+		"keys": map[string]interface{}{
+			"bad-key": map[string]interface{}{"userId": "user3", "expiresAt": "tomorrow"},
+		},
+	})
+	if err == nil {
+		t.Errorf("Expected an unparseable expiresAt to be rejected")
+	}
+}
+
 func TestManager(t *testing.T) {
 	logger := zap.NewNop()
 	manager := NewManager(logger)
@@ -311,3 +453,596 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestDigestResponse(t *testing.T) {
+	// The examples from RFC 7616 section 3.9.1
+	params := map[string]string{
+		"uri":    "/dir/index.html",
+		"nonce":  "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+		"nc":     "00000001",
+		"cnonce": "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ",
+		"qop":    "auth",
+	}
+	tests := []struct {
+		algorithm string
+		expected  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tt := range tests {
+		got := digestResponse(tt.algorithm, "Mufasa", "http-auth@example.org", "Circle of Life", "GET", params)
+		if got != tt.expected {
+			t.Errorf("Expected %s response %s, got %s", tt.algorithm, tt.expected, got)
+		}
+	}
+}
+
+func TestDigestAuthProvider_ChallengeResponse(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "SHA-256", "MD5-sess"} {
+		t.Run(algorithm, func(t *testing.T) {
+			logger := zap.NewNop()
+			provider := NewDigestAuthProvider(logger)
+			if err := provider.Configure(map[string]interface{}{
+				"realm":     "legacy",
+				"algorithm": algorithm,
+				"users":     map[string]interface{}{"alice": "secret"},
+			}); err != nil {
+				t.Fatalf("Configure() error = %v", err)
+			}
+			manager := NewManager(logger)
+			manager.RegisterProvider(models.AuthTypeDigest, provider)
+
+			handler := manager.Middleware(&models.AuthPolicy{Type: models.AuthTypeDigest, Required: true})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					authCtx, _ := GetAuthContext(r.Context())
+					w.Write([]byte(authCtx.UserID))
+				}))
+			send := func(authorization string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest("GET", "/admin/specs?limit=5", nil)
+				if authorization != "" {
+					req.Header.Set("Authorization", authorization)
+				}
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, req)
+				return recorder
+			}
+
+			// Without credentials the client is challenged with a server nonce
+			recorder := send("")
+			if recorder.Code != http.StatusUnauthorized {
+				t.Fatalf("Expected 401 without credentials, got %d", recorder.Code)
+			}
+			challenge := recorder.Header().Get("WWW-Authenticate")
+			scheme, rest, _ := strings.Cut(challenge, " ")
+			params := parseDigestParams(rest)
+			if scheme != "Digest" || params["nonce"] == "" || params["realm"] != "legacy" || params["algorithm"] != algorithm || params["qop"] != "auth" {
+				t.Fatalf("Expected a digest challenge, got %q", challenge)
+			}
+
+			authorize := func(password, nc string) string {
+				response := map[string]string{
+					"uri":    "/admin/specs?limit=5",
+					"nonce":  params["nonce"],
+					"nc":     nc,
+					"cnonce": "0a4f113b",
+					"qop":    "auth",
+				}
+				digest := digestResponse(algorithm, "alice", "legacy", password, "GET", response)
+				return fmt.Sprintf(`Digest username="alice", realm="legacy", nonce="%s", uri="%s", algorithm=%s, qop=auth, nc=%s, cnonce="%s", response="%s", opaque="%s"`,
+					response["nonce"], response["uri"], algorithm, nc, response["cnonce"], digest, params["opaque"])
+			}
+
+			first := authorize("secret", "00000001")
+			tests := []struct {
+				name          string
+				authorization string
+				expected      int
+			}{
+				{"valid response", first, http.StatusOK},
+				{"replayed nonce count", first, http.StatusUnauthorized},
+				{"next nonce count", authorize("secret", "00000002"), http.StatusOK},
+				{"wrong password", authorize("wrong", "00000003"), http.StatusUnauthorized},
+				{"unknown nonce", strings.Replace(authorize("secret", "00000004"), params["nonce"], "forged", 1), http.StatusUnauthorized},
+			}
+			for _, tt := range tests {
+				recorder := send(tt.authorization)
+				if recorder.Code != tt.expected {
+					t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, recorder.Code)
+				}
+				if recorder.Code == http.StatusOK && recorder.Body.String() != "alice" {
+					t.Errorf("%s: expected user alice, got %q", tt.name, recorder.Body.String())
+				}
+				if recorder.Code == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") == "" {
+					t.Errorf("%s: expected a fresh challenge with the 401", tt.name)
+				}
+			}
+		})
+	}
+}
+
+func TestDigestAuthProvider_ExpiredNonce(t *testing.T) {
+	provider := NewDigestAuthProvider(zap.NewNop())
+	provider.Configure(map[string]interface{}{"nonceTTL": "1ms"})
+	_, rest, _ := strings.Cut(provider.Challenge(), " ")
+	nonce := parseDigestParams(rest)["nonce"]
+	time.Sleep(5 * time.Millisecond)
+
+	if err := provider.useNonce(nonce, 1); err == nil || err.Error() != "nonce expired" {
+		t.Errorf("Expected an expired nonce to be rejected, got %v", err)
+	}
+}
+
+func TestBearerTokenProvider_HMAC(t *testing.T) {
+	logger := zap.NewNop()
+	provider := NewBearerTokenProvider(logger)
+
+	err := provider.Configure(map[string]interface{}{
+		"hmacSecret": "shared-secret",
+		"issuer":     "https://issuer.example.com",
+		"audience":   "api",
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   "user-1",
+		"name":  "Test User",
+		"scope": "read write",
+		"iss":   "https://issuer.example.com",
+		"aud":   "api",
+	}
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	wrongIssuer := jwt.MapClaims{"sub": "user-1", "iss": "https://evil.example.com", "aud": "api"}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid HS256 token", sign(jwt.SigningMethodHS256, []byte("shared-secret"), claims), false},
+		{"wrong secret", sign(jwt.SigningMethodHS256, []byte("other-secret"), claims), true},
+		{"RS256 token with only HMAC configured", sign(jwt.SigningMethodRS256, rsaKey, claims), true},
+		{"wrong issuer", sign(jwt.SigningMethodHS256, []byte("shared-secret"), wrongIssuer), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			authCtx, err := provider.Authenticate(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr {
+				if authCtx.UserID != "user-1" {
+					t.Errorf("Expected user ID user-1, got %s", authCtx.UserID)
+				}
+				if len(authCtx.Scopes) != 2 {
+					t.Errorf("Expected 2 scopes, got %d", len(authCtx.Scopes))
+				}
+			}
+		})
+	}
+}
+
+func TestBearerTokenProvider_AudiencesAndIssuers(t *testing.T) {
+	provider := NewBearerTokenProvider(zap.NewNop())
+	err := provider.Configure(map[string]interface{}{
+		"hmacSecret": "shared-secret",
+		"issuer":     "https://issuer.example.com",
+		"issuers":    []interface{}{"https://login.example.com", "https://legacy.example.com"},
+		"audience":   "api",
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	sign := func(claims jwt.MapClaims) string {
+		claims["sub"] = "user-1"
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("shared-secret"))
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr bool
+	}{
+		{"single aud", jwt.MapClaims{"iss": "https://issuer.example.com", "aud": "api"}, false},
+		{"array aud containing the audience", jwt.MapClaims{"iss": "https://issuer.example.com", "aud": []string{"web", "api"}}, false},
+		{"array aud without the audience", jwt.MapClaims{"iss": "https://issuer.example.com", "aud": []string{"web", "admin"}}, true},
+		{"mismatching aud", jwt.MapClaims{"iss": "https://issuer.example.com", "aud": "web"}, true},
+		{"missing aud", jwt.MapClaims{"iss": "https://issuer.example.com"}, true},
+		{"issuer from the list", jwt.MapClaims{"iss": "https://legacy.example.com", "aud": "api"}, false},
+		{"unlisted issuer", jwt.MapClaims{"iss": "https://evil.example.com", "aud": "api"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+sign(tt.claims))
+
+			_, err := provider.Authenticate(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBearerTokenProvider_TimeClaims(t *testing.T) {
+	logger := zap.NewNop()
+	provider := NewBearerTokenProvider(logger)
+
+	err := provider.Configure(map[string]interface{}{
+		"hmacSecret": "shared-secret",
+		"clockSkew":  "30s",
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	now := time.Now()
+	sign := func(claims jwt.MapClaims) string {
+		claims["sub"] = "user-1"
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("shared-secret"))
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{"valid window", sign(jwt.MapClaims{"iat": now.Unix(), "nbf": now.Unix(), "exp": now.Add(time.Hour).Unix()}), nil},
+		{"no time claims", sign(jwt.MapClaims{}), nil},
+		{"expired", sign(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}), ErrTokenExpired},
+		{"expired within skew", sign(jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}), nil},
+		{"not yet valid", sign(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()}), ErrTokenNotYetValid},
+		{"not before within skew", sign(jwt.MapClaims{"nbf": now.Add(10 * time.Second).Unix()}), nil},
+		{"issued in the future", sign(jwt.MapClaims{"iat": now.Add(time.Minute).Unix()}), ErrTokenNotYetValid},
+		{"issued within skew", sign(jwt.MapClaims{"iat": now.Add(10 * time.Second).Unix()}), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			_, err := provider.Authenticate(context.Background(), req)
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Errorf("Authenticate() unexpected error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestBearerTokenProvider_DefaultClockSkew(t *testing.T) {
+	provider := NewBearerTokenProvider(zap.NewNop())
+	if provider.clockSkew != 60*time.Second {
+		t.Errorf("Expected default clock skew 60s, got %v", provider.clockSkew)
+	}
+
+	if err := provider.Configure(map[string]interface{}{"clockSkew": "soon"}); err == nil {
+		t.Errorf("Expected error for invalid clockSkew")
+	}
+}
+
+func TestBearerTokenProvider_ES256(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	otherECKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	encode := func(i *big.Int, size int) string {
+		return base64.RawURLEncoding.EncodeToString(i.FillBytes(make([]byte, size)))
+	}
+
+	// One key set serving both an EC and an RSA key
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "EC", "kid": "ec-1", "use": "sig", "crv": "P-256",
+				"x": encode(ecKey.X, 32), "y": encode(ecKey.Y, 32),
+			},
+			{
+				"kty": "RSA", "kid": "rsa-1", "use": "sig",
+				"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+		},
+	}
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	defer jwksServer.Close()
+
+	provider := NewBearerTokenProvider(zap.NewNop())
+	if err := provider.Configure(map[string]interface{}{"jwksURL": jwksServer.URL}); err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}) string {
+		token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": "user-1"})
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid ES256 token", sign(jwt.SigningMethodES256, "ec-1", ecKey), false},
+		{"valid RS256 token from same key set", sign(jwt.SigningMethodRS256, "rsa-1", rsaKey), false},
+		{"ES256 signed by another key", sign(jwt.SigningMethodES256, "ec-1", otherECKey), true},
+		{"ES256 token naming the RSA key", sign(jwt.SigningMethodES256, "rsa-1", ecKey), true},
+		{"RS256 token naming the EC key", sign(jwt.SigningMethodRS256, "ec-1", rsaKey), true},
+		{"unknown key ID", sign(jwt.SigningMethodES256, "missing", ecKey), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			authCtx, err := provider.Authenticate(context.Background(), req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && authCtx.UserID != "user-1" {
+				t.Errorf("Expected user ID user-1, got %s", authCtx.UserID)
+			}
+		})
+	}
+}
+
+func TestParseJWK(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	key, err := parseJWK(jsonWebKey{
+		Kty: "EC",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+		Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+	})
+	if err != nil {
+		t.Fatalf("parseJWK() error = %v", err)
+	}
+	if !ecKey.PublicKey.Equal(key) {
+		t.Errorf("Expected parsed key to equal the derived public key")
+	}
+
+	invalid := []jsonWebKey{
+		{Kty: "EC", Crv: "P-192", X: "AQ", Y: "AQ"},
+		{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"},
+		{Kty: "oct"},
+	}
+	for _, jwk := range invalid {
+		if _, err := parseJWK(jwk); err == nil {
+			t.Errorf("Expected error for JWK %+v", jwk)
+		}
+	}
+}
+
+func TestOAuth2Provider_TokenCache(t *testing.T) {
+	var hits atomic.Int32
+	expiresIn := 3600
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		if clientID, secret, _ := r.BasicAuth(); clientID != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   expiresIn,
+		})
+	}))
+	defer tokenServer.Close()
+
+	provider := NewOAuth2Provider(zap.NewNop())
+	err := provider.Configure(map[string]interface{}{
+		"tokenURL":     tokenServer.URL,
+		"clientID":     "client",
+		"clientSecret": "secret",
+	})
+	if err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+
+	// Concurrent callers share a single token request
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := provider.GetClientCredentialsToken(context.Background())
+			if err != nil {
+				t.Errorf("GetClientCredentialsToken() error = %v", err)
+				return
+			}
+			if token.AccessToken != "token-1" {
+				t.Errorf("Expected cached token-1, got %s", token.AccessToken)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits.Load() != 1 {
+		t.Errorf("Expected token endpoint to be hit once, got %d", hits.Load())
+	}
+
+	// Forcing a refresh bypasses the cache
+	token, err := provider.ForceRefreshToken(context.Background())
+	if err != nil {
+		t.Fatalf("ForceRefreshToken() error = %v", err)
+	}
+	if token.AccessToken != "token-2" || hits.Load() != 2 {
+		t.Errorf("Expected a fresh token-2 after forced refresh, got %s (%d hits)", token.AccessToken, hits.Load())
+	}
+
+	// Tokens expiring within the refresh window are not reused
+	expiresIn = 10
+	provider.ForceRefreshToken(context.Background())
+	provider.GetClientCredentialsToken(context.Background())
+	if hits.Load() != 4 {
+		t.Errorf("Expected a token inside the refresh window to be refetched, got %d hits", hits.Load())
+	}
+}
+
+func TestPolicyFromSpec(t *testing.T) {
+	const petstore = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  %s
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "security": [{"petstore_auth": ["read:pets"]}],
+        "responses": {"200": {"description": "ok"}}
+      },
+      "post": {
+        "operationId": "createPet",
+        "security": [{"petstore_auth": ["write:pets", "read:pets"]}],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+      "petstore_auth": {
+        "type": "oauth2",
+        "flows": {
+          "clientCredentials": {
+            "tokenUrl": "https://auth.example.com/token",
+            "scopes": {"read:pets": "read pets", "write:pets": "modify pets"}
+          }
+        }
+      }%s
+    }
+  }
+}`
+
+	load := func(security, extraSchemes string) *openapi3.T {
+		spec, err := openapi3.NewLoader().LoadFromData([]byte(fmt.Sprintf(petstore, security, extraSchemes)))
+		if err != nil {
+			t.Fatalf("Failed to load spec: %v", err)
+		}
+		return spec
+	}
+
+	tests := []struct {
+		name           string
+		spec           *openapi3.T
+		expectedType   models.AuthType
+		expectedScopes []string
+	}{
+		{
+			name:           "operation-level oauth2 scopes",
+			spec:           load("", ""),
+			expectedType:   models.AuthTypeOAuth2,
+			expectedScopes: []string{"read:pets", "write:pets"},
+		},
+		{
+			name:         "top-level bearer requirement wins",
+			spec:         load(`"security": [{"bearerAuth": []}],`, ""),
+			expectedType: models.AuthTypeBearer,
+		},
+		{
+			name:         "api key scheme",
+			spec:         load(`"security": [{"apiKey": []}],`, `, "apiKey": {"type": "apiKey", "in": "header", "name": "X-Pet-Key"}`),
+			expectedType: models.AuthTypeAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := PolicyFromSpec(tt.spec)
+			if policy == nil {
+				t.Fatalf("Expected a policy")
+			}
+			if policy.Type != tt.expectedType {
+				t.Errorf("Expected type %s, got %s", tt.expectedType, policy.Type)
+			}
+			if policy.Required {
+				t.Errorf("Derived policies should not be required")
+			}
+			if fmt.Sprint(policy.Scopes) != fmt.Sprint(tt.expectedScopes) {
+				t.Errorf("Expected scopes %v, got %v", tt.expectedScopes, policy.Scopes)
+			}
+
+			switch policy.Type {
+			case models.AuthTypeOAuth2:
+				if policy.Config["tokenURL"] != "https://auth.example.com/token" {
+					t.Errorf("Expected tokenURL from client credentials flow, got %v", policy.Config)
+				}
+			case models.AuthTypeAPIKey:
+				if policy.Config["headerKey"] != "X-Pet-Key" {
+					t.Errorf("Expected headerKey X-Pet-Key, got %v", policy.Config)
+				}
+			}
+		})
+	}
+
+	if policy := PolicyFromSpec(&openapi3.T{OpenAPI: "3.0.0"}); policy != nil {
+		t.Errorf("Expected no policy for a spec without security schemes, got %+v", policy)
+	}
+}
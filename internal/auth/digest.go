@@ -0,0 +1,279 @@
+package auth
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"go.uber.org/zap"
+)
+
+// defaultNonceTTL is how long a digest challenge's nonce can be answered
+const defaultNonceTTL = 5 * time.Minute
+
+// maxDigestNonces bounds the outstanding nonces kept by a digest provider
+const maxDigestNonces = 10000
+
+// Challenger is implemented by providers that tell clients how to authenticate when
+// credentials are missing or rejected, as the value of a WWW-Authenticate header
+type Challenger interface {
+	Challenge() string
+}
+
+// DigestAuthProvider implements HTTP Digest authentication (RFC 7616). Each challenge
+// carries a fresh server nonce, which stays valid for nonceTTL; the nonce count of
+// every response must increase so a captured request cannot be replayed.
+type DigestAuthProvider struct {
+	users     map[string]string // username -> password
+	realm     string
+	algorithm string // MD5, MD5-sess, SHA-256 or SHA-256-sess
+	nonceTTL  time.Duration
+	opaque    string
+
+	nonces map[string]*digestNonce
+	mutex  sync.Mutex
+	logger *zap.Logger
+}
+
+// digestNonce tracks a nonce issued in a challenge
+type digestNonce struct {
+	expires   time.Time
+	lastCount uint64
+}
+
+// NewDigestAuthProvider creates a new digest auth provider
+func NewDigestAuthProvider(logger *zap.Logger) *DigestAuthProvider {
+	return &DigestAuthProvider{
+		users:     make(map[string]string),
+		realm:     "swagger-mcp-go",
+		algorithm: "MD5",
+		nonceTTL:  defaultNonceTTL,
+		opaque:    rand.Text(),
+		nonces:    make(map[string]*digestNonce),
+		logger:    logger,
+	}
+}
+
+// Type returns the authentication type
+func (p *DigestAuthProvider) Type() models.AuthType {
+	return models.AuthTypeDigest
+}
+
+// Configure sets up the digest auth provider
+func (p *DigestAuthProvider) Configure(config map[string]interface{}) error {
+	if realm, ok := config["realm"].(string); ok && realm != "" {
+		p.realm = realm
+	}
+	if algorithm, ok := config["algorithm"].(string); ok && algorithm != "" {
+		if digestHash(algorithm) == nil {
+			return fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+		}
+		p.algorithm = algorithm
+	}
+	switch nonceTTL := config["nonceTTL"].(type) {
+	case time.Duration:
+		p.nonceTTL = nonceTTL
+	case string:
+		ttl, err := time.ParseDuration(nonceTTL)
+		if err != nil {
+			return fmt.Errorf("invalid nonceTTL: %w", err)
+		}
+		p.nonceTTL = ttl
+	}
+	if users, ok := config["users"].(map[string]interface{}); ok {
+		for username, password := range users {
+			if passwordStr, ok := password.(string); ok {
+				p.users[username] = passwordStr
+			}
+		}
+	}
+	return nil
+}
+
+// Challenge issues a new nonce and returns the WWW-Authenticate value asking for it
+func (p *DigestAuthProvider) Challenge() string {
+	nonce := rand.Text()
+
+	p.mutex.Lock()
+	now := time.Now()
+	if len(p.nonces) >= maxDigestNonces {
+		p.pruneNoncesLocked(now)
+	}
+	p.nonces[nonce] = &digestNonce{expires: now.Add(p.nonceTTL)}
+	p.mutex.Unlock()
+
+	return fmt.Sprintf(`Digest realm="%s", qop="auth", algorithm=%s, nonce="%s", opaque="%s"`,
+		p.realm, p.algorithm, nonce, p.opaque)
+}
+
+// Authenticate validates digest credentials answering one of the provider's challenges
+func (p *DigestAuthProvider) Authenticate(ctx context.Context, request *http.Request) (*AuthContext, error) {
+	authHeader := request.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, fmt.Errorf("digest credentials not provided")
+	}
+	scheme, rest, _ := strings.Cut(authHeader, " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+	params := parseDigestParams(rest)
+
+	username := params["username"]
+	if username == "" || params["nonce"] == "" || params["response"] == "" {
+		return nil, fmt.Errorf("incomplete digest credentials")
+	}
+	if params["realm"] != p.realm {
+		return nil, fmt.Errorf("invalid realm")
+	}
+	if uri := params["uri"]; uri != request.URL.RequestURI() {
+		return nil, fmt.Errorf("digest uri %q does not match the request", uri)
+	}
+	algorithm := params["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	if !strings.EqualFold(algorithm, p.algorithm) {
+		return nil, fmt.Errorf("unexpected digest algorithm: %s", algorithm)
+	}
+	qop := params["qop"]
+	if qop != "auth" {
+		return nil, fmt.Errorf("unsupported qop: %q", qop)
+	}
+	count, err := strconv.ParseUint(params["nc"], 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce count: %q", params["nc"])
+	}
+	if params["cnonce"] == "" {
+		return nil, fmt.Errorf("missing cnonce")
+	}
+
+	password, exists := p.users[username]
+	if !exists {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+	expected := digestResponse(algorithm, username, p.realm, password, request.Method, params)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(params["response"]))) != 1 {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	// Only a valid response may advance the nonce count
+	if err := p.useNonce(params["nonce"], count); err != nil {
+		return nil, err
+	}
+
+	return &AuthContext{
+		UserID:   username,
+		Username: username,
+		Valid:    true,
+	}, nil
+}
+
+// useNonce accepts count for an issued, unexpired nonce when it exceeds every count
+// already seen with it
+func (p *DigestAuthProvider) useNonce(nonce string, count uint64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	state, exists := p.nonces[nonce]
+	if !exists {
+		return fmt.Errorf("unknown nonce")
+	}
+	if time.Now().After(state.expires) {
+		delete(p.nonces, nonce)
+		return fmt.Errorf("nonce expired")
+	}
+	if count <= state.lastCount {
+		return fmt.Errorf("nonce count %d already used", count)
+	}
+	state.lastCount = count
+	return nil
+}
+
+// pruneNoncesLocked drops expired nonces, and arbitrary others if that does not make
+// room for a new one
+func (p *DigestAuthProvider) pruneNoncesLocked(now time.Time) {
+	for nonce, state := range p.nonces {
+		if now.After(state.expires) {
+			delete(p.nonces, nonce)
+		}
+	}
+	for nonce := range p.nonces {
+		if len(p.nonces) < maxDigestNonces {
+			break
+		}
+		delete(p.nonces, nonce)
+	}
+}
+
+// digestResponse computes the response a client with password sends for params:
+// H(HA1:nonce:nc:cnonce:qop:HA2) with HA1 = H(username:realm:password), extended
+// with the nonces for -sess algorithms, and HA2 = H(method:uri)
+func digestResponse(algorithm, username, realm, password, method string, params map[string]string) string {
+	newHash := digestHash(algorithm)
+	h := func(parts ...string) string {
+		hasher := newHash()
+		hasher.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	ha1 := h(username, realm, password)
+	if strings.HasSuffix(strings.ToLower(algorithm), "-sess") {
+		ha1 = h(ha1, params["nonce"], params["cnonce"])
+	}
+	ha2 := h(method, params["uri"])
+	return h(ha1, params["nonce"], params["nc"], params["cnonce"], params["qop"], ha2)
+}
+
+// digestHash returns the hash constructor for a digest algorithm, or nil when the
+// algorithm is not supported
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.ToUpper(algorithm) {
+	case "MD5", "MD5-SESS":
+		return md5.New
+	case "SHA-256", "SHA-256-SESS":
+		return sha256.New
+	default:
+		return nil
+	}
+}
+
+// parseDigestParams parses the comma-separated key=value and key="value" pairs of a
+// Digest authorization header. Commas inside quoted values are kept.
+func parseDigestParams(header string) map[string]string {
+	params := make(map[string]string)
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		key, rest, found := strings.Cut(header, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+		header = rest
+	}
+	return params
+}
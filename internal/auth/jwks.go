@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// jwksRefreshInterval is the minimum time between key set fetches triggered by unknown key IDs
+const jwksRefreshInterval = 30 * time.Second
+
+// jsonWebKey is a single key from a JWKS document (RFC 7517)
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksCache holds the verification keys fetched from a JWKS endpoint, keyed by kid
+type jwksCache struct {
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+// key returns the cached key for kid, or nil if unknown
+func (c *jwksCache) key(kid string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys[kid]
+}
+
+// lookupJWK resolves kid from the configured JWKS endpoint, refetching the key set
+// when the kid is unknown so rotated keys are picked up
+func (p *BearerTokenProvider) lookupJWK(kid string) (interface{}, error) {
+	if key := p.jwks.key(kid); key != nil {
+		return key, nil
+	}
+
+	p.jwks.mu.Lock()
+	defer p.jwks.mu.Unlock()
+
+	if key := p.jwks.keys[kid]; key != nil {
+		return key, nil
+	}
+	if time.Since(p.jwks.fetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown key ID: %s", kid)
+	}
+
+	keys, err := p.fetchJWKS(context.Background())
+	p.jwks.fetchedAt = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	p.jwks.keys = keys
+
+	if key := keys[kid]; key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID: %s", kid)
+}
+
+// fetchJWKS downloads and parses the key set, skipping keys it cannot use
+func (p *BearerTokenProvider) fetchJWKS(ctx context.Context) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.jwksURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(document.Keys))
+	for _, jwk := range document.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			p.logger.Debug("Skipping unusable JWK", zap.String("kid", jwk.Kid), zap.Error(err))
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+// parseJWK converts a JWK into an *rsa.PublicKey or *ecdsa.PublicKey
+func parseJWK(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(jwk.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %w", err)
+		}
+		e, err := decodeJWKInt(jwk.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %w", err)
+		}
+		if !e.IsInt64() {
+			return nil, fmt.Errorf("RSA exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", jwk.Crv)
+		}
+		x, err := decodeJWKInt(jwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %w", err)
+		}
+		y, err := decodeJWKInt(jwk.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC point: %w", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", jwk.Kty)
+	}
+}
+
+// decodeJWKInt decodes a base64url-encoded big-endian integer
+func decodeJWKInt(value string) (*big.Int, error) {
+	if value == "" {
+		return nil, fmt.Errorf("missing value")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
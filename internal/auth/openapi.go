@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+)
+
+// PolicyFromSpec derives a default authentication policy from a spec's declared
+// security schemes. The scheme referenced by the top-level security requirement is
+// preferred, then the first one referenced by an operation, then the first declared.
+// Scopes requested for that scheme anywhere in the spec are collected into the policy.
+// The policy is never Required so existing services keep working until an operator
+// opts in. It returns nil when the spec declares no scheme we can map.
+func PolicyFromSpec(spec *openapi3.T) *models.AuthPolicy {
+	if spec == nil || spec.Components == nil || len(spec.Components.SecuritySchemes) == 0 {
+		return nil
+	}
+
+	requirements := append(openapi3.SecurityRequirements{}, spec.Security...)
+	if spec.Paths != nil {
+		for _, path := range spec.Paths.InMatchingOrder() {
+			operations := spec.Paths.Value(path).Operations()
+			for _, method := range sortedKeys(operations) {
+				if operation := operations[method]; operation.Security != nil {
+					requirements = append(requirements, *operation.Security...)
+				}
+			}
+		}
+	}
+
+	schemeName := ""
+	for _, requirement := range requirements {
+		for _, name := range sortedKeys(requirement) {
+			if _, ok := mapSecurityScheme(spec.Components.SecuritySchemes[name]); ok {
+				schemeName = name
+				break
+			}
+		}
+		if schemeName != "" {
+			break
+		}
+	}
+	if schemeName == "" {
+		for _, name := range sortedKeys(spec.Components.SecuritySchemes) {
+			if _, ok := mapSecurityScheme(spec.Components.SecuritySchemes[name]); ok {
+				schemeName = name
+				break
+			}
+		}
+	}
+	if schemeName == "" {
+		return nil
+	}
+
+	scheme := spec.Components.SecuritySchemes[schemeName].Value
+	authType, _ := mapSecurityScheme(spec.Components.SecuritySchemes[schemeName])
+
+	policy := &models.AuthPolicy{
+		Type:     authType,
+		Config:   schemeConfig(scheme),
+		Required: false,
+	}
+
+	seen := make(map[string]bool)
+	for _, requirement := range requirements {
+		for _, scope := range requirement[schemeName] {
+			if !seen[scope] {
+				seen[scope] = true
+				policy.Scopes = append(policy.Scopes, scope)
+			}
+		}
+	}
+	sort.Strings(policy.Scopes)
+
+	return policy
+}
+
+// mapSecurityScheme maps an OpenAPI security scheme to our auth type
+func mapSecurityScheme(ref *openapi3.SecuritySchemeRef) (models.AuthType, bool) {
+	if ref == nil || ref.Value == nil {
+		return "", false
+	}
+
+	scheme := ref.Value
+	switch scheme.Type {
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
+			return models.AuthTypeBearer, true
+		case "basic":
+			return models.AuthTypeBasic, true
+		case "digest":
+			return models.AuthTypeDigest, true
+		}
+	case "apiKey":
+		return models.AuthTypeAPIKey, true
+	case "oauth2":
+		return models.AuthTypeOAuth2, true
+	}
+	return "", false
+}
+
+// schemeConfig carries over the provider settings a scheme declares
+func schemeConfig(scheme *openapi3.SecurityScheme) map[string]interface{} {
+	config := make(map[string]interface{})
+
+	switch scheme.Type {
+	case "apiKey":
+		switch scheme.In {
+		case "header":
+			config["headerKey"] = scheme.Name
+		case "query":
+			config["queryKey"] = scheme.Name
+		}
+	case "oauth2":
+		if scheme.Flows != nil && scheme.Flows.ClientCredentials != nil {
+			config["tokenURL"] = scheme.Flows.ClientCredentials.TokenURL
+		}
+	}
+
+	return config
+}
+
+// sortedKeys returns a map's keys in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
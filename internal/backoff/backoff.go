@@ -0,0 +1,28 @@
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// MaxDelay caps the wait between two attempts
+const MaxDelay = 30 * time.Second
+
+// Delay returns the wait before the retry following attempt: base doubled per earlier
+// attempt and capped at MaxDelay, with the upper half randomised so clients do not
+// retry in lockstep
+func Delay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > MaxDelay {
+		delay = MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
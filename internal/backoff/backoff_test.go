@@ -0,0 +1,31 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		attempt  int
+		expected time.Duration
+	}{
+		{name: "first retry", base: time.Second, attempt: 1, expected: time.Second},
+		{name: "doubles per attempt", base: time.Second, attempt: 3, expected: 4 * time.Second},
+		{name: "capped", base: time.Second, attempt: 10, expected: MaxDelay},
+		{name: "disabled", base: 0, attempt: 2, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				delay := Delay(tt.base, tt.attempt)
+				if delay < tt.expected/2 || delay > tt.expected {
+					t.Fatalf("Expected a delay between %v and %v, got %v", tt.expected/2, tt.expected, delay)
+				}
+			}
+		})
+	}
+}
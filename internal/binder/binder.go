@@ -0,0 +1,663 @@
+package binder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+	"github.com/zeroLR/swagger-mcp-go/internal/ratelimit"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+	"github.com/zeroLR/swagger-mcp-go/internal/specs"
+	"go.uber.org/zap"
+)
+
+// maxMultipartMemory is how much of a multipart body is held in memory before files spill to disk
+const maxMultipartMemory = 32 << 20
+
+// mockStatusParam is the query parameter choosing the status of a mocked response
+const mockStatusParam = "__status"
+
+// Binder resolves /apis/{serviceName}/... requests to parsed routes and proxies them upstream
+type Binder struct {
+	registry    *registry.Registry
+	config      *config.Config
+	logger      *zap.Logger
+	hookManager *hooks.Manager
+	breakers    *circuitbreaker.Manager
+	rateLimiter *ratelimit.Manager
+	authManager *auth.Manager
+	drainer     *proxy.Drainer
+
+	services map[string]*boundService
+	mutex    sync.RWMutex
+}
+
+// boundService holds the routes and proxy engine built from one registered spec
+type boundService struct {
+	specInfo *models.SpecInfo
+	routes   []boundRoute
+	engine   *proxy.Engine
+}
+
+// boundRoute is a parsed route with its path template split into segments
+type boundRoute struct {
+	route    parser.RouteConfig
+	segments []string
+	literals int
+}
+
+// New creates a new route binder
+func New(logger *zap.Logger, cfg *config.Config, reg *registry.Registry) *Binder {
+	return &Binder{
+		registry: reg,
+		config:   cfg,
+		logger:   logger,
+		breakers: circuitbreaker.NewManager(logger.Named("circuitbreaker"), cfg.Upstream.CircuitBreaker.Threshold > 0),
+		services: make(map[string]*boundService),
+	}
+}
+
+// SetHookManager runs the manager's request and response hooks around proxied calls.
+// Services bound afterwards pick it up, so call it before serving requests.
+func (b *Binder) SetHookManager(manager *hooks.Manager) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.hookManager = manager
+	b.services = make(map[string]*boundService)
+}
+
+// SetRateLimiter checks proxied requests against the manager's rate and concurrency
+// limiters. Requests carry their operation ID in the context, so operation limiters
+// take precedence.
+func (b *Binder) SetRateLimiter(manager *ratelimit.Manager) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.rateLimiter = manager
+}
+
+// SetAuthManager authenticates proxied requests against their service's auth policy
+// before the rate limiter runs, so limiters keyed by user and the upstream identity
+// headers see the caller's identity.
+// Requests that fail a policy requiring authentication are rejected with 401.
+func (b *Binder) SetAuthManager(manager *auth.Manager) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.authManager = manager
+}
+
+// SetDrainer tracks proxied upstream calls with drainer, so shutdown can wait for them.
+// Services bound afterwards pick it up, so call it before serving requests.
+func (b *Binder) SetDrainer(drainer *proxy.Drainer) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.drainer = drainer
+	b.services = make(map[string]*boundService)
+}
+
+// SetBreakers guards proxied upstream calls with manager's circuit breakers instead of
+// the binder's own, so an upstream called through MCP tools and /apis alike has a single
+// breaker. Services bound afterwards pick it up, so call it before serving requests.
+func (b *Binder) SetBreakers(manager *circuitbreaker.Manager) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.breakers = manager
+	b.services = make(map[string]*boundService)
+}
+
+// Breakers returns the circuit breakers guarding proxied upstream calls
+func (b *Binder) Breakers() *circuitbreaker.Manager {
+	return b.breakers
+}
+
+// Handler returns the Gin handler for the /apis/*path catch-all route
+func (b *Binder) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serviceName, path := splitServicePath(c.Param("path"))
+		if serviceName == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No service registered for this path"})
+			return
+		}
+
+		service, err := b.service(serviceName)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		if service == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No service registered for this path"})
+			return
+		}
+
+		route, pathParams, methodAllowed := service.match(c.Request.Method, path)
+		if route == nil {
+			if methodAllowed {
+				c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed for this path"})
+			} else {
+				c.JSON(http.StatusNotFound, gin.H{"error": "No route matches this path"})
+			}
+			return
+		}
+
+		if !b.registry.IsOperationEnabled(serviceName, route.OperationID) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": fmt.Sprintf("Operation %s is disabled", route.OperationID),
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(ratelimit.WithOperationID(c.Request.Context(), route.OperationID))
+		if b.config.Upstream.ForwardedHeaders {
+			c.Request = c.Request.WithContext(proxy.WithForwardedRequest(c.Request.Context(), c.Request))
+		}
+		if b.config.Upstream.Mock {
+			if err := withMockStatus(c); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		b.authenticate(c, service.specInfo.AuthPolicy, func() {
+			b.limit(c, serviceName, func() {
+				if maxBytes := b.config.Server.MaxRequestBytes; maxBytes > 0 && c.Request.Body != nil {
+					c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+				}
+				params, err := extractParams(c.Request, route, pathParams)
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+						"error": fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit),
+					})
+					return
+				}
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+					return
+				}
+
+				// Streaming keeps Server-Sent Events and other long-lived responses flowing
+				if err := service.engine.StreamRoute(c.Request.Context(), route, params, c.Writer); err != nil {
+					var validationErr *proxy.ValidationError
+					if errors.As(err, &validationErr) {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "request validation failed", "errors": validationErr.Errors})
+						return
+					}
+					b.logger.Warn("Proxy request failed",
+						zap.String("serviceName", serviceName),
+						zap.String("operationID", route.OperationID),
+						zap.Error(err))
+					if !c.Writer.Written() {
+						c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+					}
+				}
+			})
+		})
+	}
+}
+
+// withMockStatus applies the ?__status= override, which makes a mocked call answer with
+// that status and its declared response
+func withMockStatus(c *gin.Context) error {
+	value := c.Query(mockStatusParam)
+	if value == "" {
+		return nil
+	}
+
+	status, err := strconv.Atoi(value)
+	if err != nil || status < 100 || status > 599 {
+		return fmt.Errorf("invalid %s %q: must be an HTTP status code", mockStatusParam, value)
+	}
+	c.Request = c.Request.WithContext(proxy.WithMockStatus(c.Request.Context(), status))
+	return nil
+}
+
+// authenticate runs next behind the auth manager's middleware for policy, which puts
+// the caller's auth context on the request, or writes a 401 itself when authentication
+// the policy requires fails
+func (b *Binder) authenticate(c *gin.Context, policy *models.AuthPolicy, next func()) {
+	b.mutex.RLock()
+	manager := b.authManager
+	b.mutex.RUnlock()
+	if manager == nil {
+		next()
+		return
+	}
+
+	manager.Middleware(policy)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		c.Request = r
+		next()
+	})).ServeHTTP(c.Writer, c.Request)
+}
+
+// limit runs next behind the rate limiter's middleware, which sets the X-RateLimit
+// headers, holds a concurrency slot while next runs, and writes the response itself
+// when the request is rejected
+func (b *Binder) limit(c *gin.Context, serviceName string, next func()) {
+	b.mutex.RLock()
+	manager := b.rateLimiter
+	b.mutex.RUnlock()
+	if manager == nil {
+		next()
+		return
+	}
+
+	manager.Middleware(serviceName)(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		c.Request = r
+		next()
+	})).ServeHTTP(c.Writer, c.Request)
+}
+
+// service returns the bound service for a name, rebuilding it when the registry
+// holds a different spec than the one last bound and dropping it once removed
+func (b *Binder) service(serviceName string) (*boundService, error) {
+	specInfo, _ := b.registry.Get(serviceName)
+
+	b.mutex.RLock()
+	service := b.services[serviceName]
+	b.mutex.RUnlock()
+
+	if specInfo == nil {
+		if service != nil {
+			b.Unbind(serviceName)
+		}
+		return nil, nil
+	}
+	if service != nil && service.specInfo == specInfo {
+		return service, nil
+	}
+
+	return b.bind(specInfo)
+}
+
+// Bind parses a spec and makes its routes available under /apis/{serviceName}.
+// Requests bind registered specs on demand, so calling this ahead of time is optional.
+func (b *Binder) Bind(specInfo *models.SpecInfo) error {
+	_, err := b.bind(specInfo)
+	return err
+}
+
+// bind builds and stores the bound service for a spec
+func (b *Binder) bind(specInfo *models.SpecInfo) (*boundService, error) {
+	if specInfo.Spec == nil {
+		return nil, fmt.Errorf("service %s has no parsed spec", specInfo.ServiceName)
+	}
+
+	p := parser.New(b.logger.Named("parser"), "")
+	p.SetMaxSchemaDepth(b.config.MCP.MaxSchemaDepth)
+	if err := p.ParseSpec(specInfo.Spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec for %s: %w", specInfo.ServiceName, err)
+	}
+
+	baseURL, err := upstreamBaseURL(specInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve upstream for %s: %w", specInfo.ServiceName, err)
+	}
+
+	upstream := b.config.Upstream
+	tlsConfig, err := proxy.NewTLSConfig(upstream.TLS.InsecureSkipVerify, upstream.TLS.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS for %s: %w", specInfo.ServiceName, err)
+	}
+
+	identityHeaders, err := proxy.NewIdentityHeaders(upstream.IdentityHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure identity headers for %s: %w", specInfo.ServiceName, err)
+	}
+	trustedProxies, err := proxy.NewTrustedProxies(upstream.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure trusted proxies for %s: %w", specInfo.ServiceName, err)
+	}
+
+	engine := proxy.New(b.logger.Named("proxy"), upstream.Timeout)
+	engine.SetTransport(upstream.MaxIdleConnsPerHost, upstream.IdleConnTimeout, tlsConfig)
+	engine.SetServiceName(specInfo.ServiceName)
+	engine.SetExpectContinue(upstream.ExpectContinueTimeout, upstream.ExpectContinueThreshold)
+	engine.SetMaxDecodedBytes(upstream.MaxDecodedBytes)
+	engine.SetStreamIdleTimeout(upstream.StreamIdleTimeout)
+	engine.SetRetry(upstream.RetryCount+1, upstream.RetryDelay, upstream.RetryableStatusCodes)
+	engine.SetBaseURL(baseURL)
+	engine.SetMock(upstream.Mock)
+	if specInfo.Headers != nil {
+		engine.SetHeaders(specInfo.Headers)
+	}
+	engine.SetHooks(b.hookManager)
+	engine.SetRequestRecorder(b.registry)
+	engine.SetDrainer(b.drainer)
+	engine.SetIdentityHeaders(identityHeaders)
+	engine.SetTrustedProxies(trustedProxies)
+	if upstream.CircuitBreaker.Threshold > 0 {
+		// Breakers live in the binder so their state survives rebinding
+		engine.SetCircuitBreaker(b.breakers, circuitbreaker.Config{
+			MaxFailures:  upstream.CircuitBreaker.Threshold,
+			ResetTimeout: upstream.CircuitBreaker.Timeout,
+		}, upstream.CircuitBreaker.Fallback)
+		engine.SetHealthURL(b.config.UpstreamHealthURL(specInfo.ServiceName))
+	}
+
+	service := &boundService{
+		specInfo: specInfo,
+		engine:   engine,
+	}
+	for _, route := range p.GetRoutes() {
+		segments := splitPath(route.Path)
+		literals := 0
+		for _, segment := range segments {
+			if !isTemplate(segment) {
+				literals++
+			}
+		}
+		service.routes = append(service.routes, boundRoute{route: route, segments: segments, literals: literals})
+	}
+
+	// Prefer the most specific template when several match, e.g. /pets/mine over /pets/{id}
+	sort.SliceStable(service.routes, func(i, j int) bool {
+		return service.routes[i].literals > service.routes[j].literals
+	})
+
+	b.mutex.Lock()
+	b.services[specInfo.ServiceName] = service
+	b.mutex.Unlock()
+
+	b.logger.Info("Bound service routes",
+		zap.String("serviceName", specInfo.ServiceName),
+		zap.Int("routeCount", len(service.routes)))
+
+	return service, nil
+}
+
+// Unbind removes a service's routes
+func (b *Binder) Unbind(serviceName string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if _, exists := b.services[serviceName]; exists {
+		delete(b.services, serviceName)
+		b.logger.Info("Unbound service routes", zap.String("serviceName", serviceName))
+	}
+}
+
+// match finds the route for a method and path, returning the captured path
+// parameters and whether the path matched under some other method
+func (s *boundService) match(method, path string) (*parser.RouteConfig, map[string]string, bool) {
+	segments := splitPath(path)
+	pathMatched := false
+
+	for i := range s.routes {
+		candidate := &s.routes[i]
+		params, ok := matchSegments(candidate.segments, segments)
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(candidate.route.Method, method) {
+			pathMatched = true
+			continue
+		}
+		return &candidate.route, params, false
+	}
+
+	return nil, nil, pathMatched
+}
+
+// matchSegments matches request path segments against a template
+func matchSegments(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range template {
+		if isTemplate(segment) {
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params[segment[1:len(segment)-1]] = value
+			continue
+		}
+		if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// extractParams collects path, query, header and body values into the engine's params map
+func extractParams(r *http.Request, route *parser.RouteConfig, pathParams map[string]string) (map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	for name, value := range pathParams {
+		params[name] = value
+	}
+
+	query := r.URL.Query()
+	for _, param := range route.Parameters {
+		switch param.In {
+		case "query":
+			if value, ok := queryValue(query, &param); ok {
+				params[param.Name] = value
+			}
+		case "header":
+			if value := r.Header.Get(param.Name); value != "" {
+				params[param.Name] = value
+			}
+		}
+	}
+
+	if route.RequestBody != nil && r.Body != nil {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		if len(data) > 0 {
+			body, err := decodeBody(route.RequestBody.ContentType, r.Header.Get("Content-Type"), data)
+			if err != nil {
+				return nil, err
+			}
+			params[route.BodyArgument()] = body
+		}
+	}
+
+	return params, nil
+}
+
+// queryValue reads a query parameter back into the value the engine serializes again
+// with the same style: arrays from repeated values or the style's delimiter, objects
+// from name[key]=value with deepObject or key,value,... with form and no explode.
+// Repeated values of other parameters are kept as an array.
+func queryValue(query url.Values, param *parser.ParameterConfig) (interface{}, bool) {
+	style, explode := "form", true
+	if param.Style != "" {
+		style, explode = param.Style, param.Explode
+	}
+
+	if style == "deepObject" {
+		object := make(map[string]interface{})
+		prefix := param.Name + "["
+		for key, values := range query {
+			if strings.HasPrefix(key, prefix) && strings.HasSuffix(key, "]") {
+				setDeepObject(object, strings.Split(key[len(prefix):len(key)-1], "]["), collapse(values))
+			}
+		}
+		return object, len(object) > 0
+	}
+
+	values, ok := query[param.Name]
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	if explode || len(values) > 1 {
+		if param.Type == "array" {
+			return items(values), true
+		}
+		return collapse(values), true
+	}
+
+	delimiter := ","
+	switch style {
+	case "spaceDelimited":
+		delimiter = " "
+	case "pipeDelimited":
+		delimiter = "|"
+	}
+	switch param.Type {
+	case "array":
+		return items(strings.Split(values[0], delimiter)), true
+	case "object":
+		parts := strings.Split(values[0], delimiter)
+		object := make(map[string]interface{}, len(parts)/2)
+		for i := 0; i+1 < len(parts); i += 2 {
+			object[parts[i]] = parts[i+1]
+		}
+		return object, true
+	}
+	return values[0], true
+}
+
+// setDeepObject sets value at the nested keys of a deepObject parameter, creating the
+// intermediate objects of name[a][b]=value
+func setDeepObject(object map[string]interface{}, keys []string, value interface{}) {
+	for _, key := range keys[:len(keys)-1] {
+		nested, ok := object[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			object[key] = nested
+		}
+		object = nested
+	}
+	object[keys[len(keys)-1]] = value
+}
+
+// decodeBody converts a raw request body into the value the engine re-encodes upstream.
+// header is the request's own Content-Type, which carries the multipart boundary.
+func decodeBody(contentType, header string, data []byte) (interface{}, error) {
+	switch contentType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid form body: %w", err)
+		}
+		form := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			form[key] = collapse(value)
+		}
+		return form, nil
+	case "multipart/form-data":
+		return decodeMultipart(header, data)
+	case "text/plain":
+		return string(data), nil
+	default:
+		var body interface{}
+		if err := json.Unmarshal(data, &body); err != nil {
+			return nil, fmt.Errorf("invalid JSON body: %w", err)
+		}
+		return body, nil
+	}
+}
+
+// decodeMultipart converts a multipart/form-data body into fields, with file contents
+// base64-encoded as the engine expects them. A field sent several times becomes an array.
+func decodeMultipart(header string, data []byte) (interface{}, error) {
+	_, params, err := mime.ParseMediaType(header)
+	if err != nil || params["boundary"] == "" {
+		return nil, fmt.Errorf("invalid multipart body: missing boundary")
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(data), params["boundary"]).ReadForm(maxMultipartMemory)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipart body: %w", err)
+	}
+	defer form.RemoveAll()
+
+	fields := make(map[string]interface{}, len(form.Value)+len(form.File))
+	for name, values := range form.Value {
+		fields[name] = collapse(values)
+	}
+	for name, files := range form.File {
+		contents := make([]string, 0, len(files))
+		for _, file := range files {
+			f, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+			}
+			contents = append(contents, base64.StdEncoding.EncodeToString(content))
+		}
+		fields[name] = collapse(contents)
+	}
+	return fields, nil
+}
+
+// collapse returns a single value as is and several as an array
+func collapse(values []string) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	return items(values)
+}
+
+// items converts values into an array argument
+func items(values []string) []interface{} {
+	array := make([]interface{}, len(values))
+	for i, value := range values {
+		array[i] = value
+	}
+	return array
+}
+
+// upstreamBaseURL picks the service's configured base URL, or else the spec's first
+// server as resolved by specs.BaseURL
+func upstreamBaseURL(specInfo *models.SpecInfo) (string, error) {
+	if specInfo.BaseURL != "" {
+		return specInfo.BaseURL, nil
+	}
+	return specs.BaseURL(specInfo)
+}
+
+// splitServicePath splits "/{serviceName}/rest" into the service name and "/rest"
+func splitServicePath(path string) (string, string) {
+	path = strings.TrimPrefix(path, "/")
+	serviceName, rest, _ := strings.Cut(path, "/")
+	return serviceName, "/" + rest
+}
+
+// splitPath splits a URL path into its non-empty segments
+func splitPath(path string) []string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	segments := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// isTemplate reports whether a path segment is a {parameter} placeholder
+func isTemplate(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
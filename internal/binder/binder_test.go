@@ -0,0 +1,750 @@
+package binder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/plugins"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+	"github.com/zeroLR/swagger-mcp-go/internal/ratelimit"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+	"go.uber.org/zap"
+)
+
+const petstoreSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "servers": [{"url": "%s"}],
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "ok"}}
+      },
+      "post": {
+        "operationId": "createPet",
+        "requestBody": {"content": {"application/json": {"schema": {"type": "object"}}}},
+        "responses": {"201": {"description": "created"}}
+      }
+    },
+    "/pets/mine": {
+      "get": {"operationId": "listMyPets", "responses": {"200": {"description": "ok"}}}
+    },
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+          {"name": "X-Trace", "in": "header", "schema": {"type": "string"}}
+        ],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+func addPetstore(t *testing.T, reg *registry.Registry, upstreamURL string) {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(fmt.Sprintf(petstoreSpec, upstreamURL)))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	reg.Add(&models.SpecInfo{
+		ServiceName: "petstore",
+		URL:         upstreamURL + "/openapi.json",
+		Spec:        spec,
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+}
+
+func TestBinder_ProxiesRegisteredRoutes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "petstore")
+		status := http.StatusOK
+		if r.Method == http.MethodPost {
+			status = http.StatusCreated
+		}
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"method":%q,"path":%q,"query":%q,"trace":%q,"body":%q}`,
+			r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Trace"), string(body))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	reg := registry.New(zap.NewNop())
+	routeBinder := New(zap.NewNop(), cfg, reg)
+
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	addPetstore(t, reg, upstream.URL)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		headers        map[string]string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{"query parameters", "GET", "/apis/petstore/pets?limit=2&ignored=x", "", nil, http.StatusOK,
+			[]string{`"path":"/pets"`, `"query":"limit=2"`}},
+		{"literal segment beats template", "GET", "/apis/petstore/pets/mine", "", nil, http.StatusOK,
+			[]string{`"path":"/pets/mine"`}},
+		{"path and header parameters", "GET", "/apis/petstore/pets/42", "", map[string]string{"X-Trace": "abc"}, http.StatusOK,
+			[]string{`"path":"/pets/42"`, `"trace":"abc"`}},
+		{"JSON body", "POST", "/apis/petstore/pets", `{"name":"Rex"}`, map[string]string{"Content-Type": "application/json"}, http.StatusCreated,
+			[]string{`"method":"POST"`, `\"name\":\"Rex\"`}},
+		{"unknown service", "GET", "/apis/unknown/pets", "", nil, http.StatusNotFound, nil},
+		{"unknown path", "GET", "/apis/petstore/owners", "", nil, http.StatusNotFound, nil},
+		{"wrong method", "DELETE", "/apis/petstore/pets", "", nil, http.StatusMethodNotAllowed, nil},
+		{"invalid JSON body", "POST", "/apis/petstore/pets", `{`, nil, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, proxyServer.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("Failed to create request: %v", err)
+			}
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, resp.StatusCode, body)
+			}
+			for _, expected := range tt.expectedBody {
+				if !strings.Contains(string(body), expected) {
+					t.Errorf("Expected body to contain %s, got %s", expected, body)
+				}
+			}
+			if tt.expectedStatus < 300 && resp.Header.Get("X-Upstream") != "petstore" {
+				t.Errorf("Expected upstream headers to be copied, got %v", resp.Header)
+			}
+		})
+	}
+}
+
+func TestBinder_OperationRateLimit(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+	addPetstore(t, reg, upstream.URL)
+
+	manager := ratelimit.NewManager(logger, true)
+	defer manager.Stop()
+	manager.SetServiceLimiter("petstore", ratelimit.NewTokenBucketLimiter(ratelimit.Config{RequestsPerMinute: 100}, logger))
+	manager.SetOperationLimiter("petstore", "listPets", ratelimit.NewTokenBucketLimiter(ratelimit.Config{RequestsPerMinute: 1}, logger))
+
+	routeBinder := New(logger, cfg, reg)
+	routeBinder.SetRateLimiter(manager)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	if recorder := get("/apis/petstore/pets"); recorder.Code != http.StatusOK {
+		t.Fatalf("Expected first listPets call to succeed, got %d", recorder.Code)
+	}
+	recorder := get("/apis/petstore/pets")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected second listPets call to be rate limited, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("Expected the operation limit in X-RateLimit-Limit, got %q", recorder.Header().Get("X-RateLimit-Limit"))
+	}
+
+	// Other operations only count against the service limit
+	recorder = get("/apis/petstore/pets/42")
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected getPet to succeed, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("X-RateLimit-Limit") != "100" {
+		t.Errorf("Expected the service limit in X-RateLimit-Limit, got %q", recorder.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestBinder_RateLimitPerUser(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(fmt.Sprintf(petstoreSpec, upstream.URL)))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	reg.Add(&models.SpecInfo{
+		ServiceName: "petstore",
+		Spec:        spec,
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+		AuthPolicy:  &models.AuthPolicy{Type: models.AuthTypeBasic, Required: true},
+	})
+
+	basic := auth.NewBasicAuthProvider(logger)
+	basic.Configure(map[string]interface{}{"users": map[string]interface{}{"alice": "secret", "bob": "secret"}})
+	authManager := auth.NewManager(logger)
+	authManager.RegisterProvider(models.AuthTypeBasic, basic)
+
+	manager := ratelimit.NewManager(logger, true)
+	defer manager.Stop()
+	manager.SetGlobalLimiter(ratelimit.NewTokenBucketLimiter(ratelimit.Config{
+		RequestsPerMinute: 1,
+		KeyGenerator:      ratelimit.UserBasedKeyGenerator,
+	}, logger))
+
+	routeBinder := New(logger, cfg, reg)
+	routeBinder.SetAuthManager(authManager)
+	routeBinder.SetRateLimiter(manager)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	get := func(username string) int {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/apis/petstore/pets", nil)
+		if username != "" {
+			req.SetBasicAuth(username, "secret")
+		}
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	tests := []struct {
+		username string
+		expected int
+	}{
+		{"alice", http.StatusOK},
+		{"alice", http.StatusTooManyRequests},
+		{"bob", http.StatusOK},
+		{"", http.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		if code := get(tt.username); code != tt.expected {
+			t.Errorf("Request %d as %q: expected %d, got %d", i, tt.username, tt.expected, code)
+		}
+	}
+}
+
+func TestBinder_Mock(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no upstream call in mock mode, got %s %s", r.Method, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Upstream.Mock = true
+
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+	addPetstore(t, reg, upstream.URL)
+
+	routeBinder := New(logger, cfg, reg)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"declared success response", "/apis/petstore/pets", http.StatusOK},
+		{"requested status", "/apis/petstore/pets?__status=404", http.StatusNotFound},
+		{"invalid requested status", "/apis/petstore/pets?__status=abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.expectedStatus != http.StatusBadRequest && recorder.Header().Get(proxy.MockHeader) != "true" {
+				t.Errorf("Expected %s header", proxy.MockHeader)
+			}
+		})
+	}
+}
+
+func TestBinder_FollowsRegistry(t *testing.T) {
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+	}
+	first := newUpstream("first")
+	defer first.Close()
+	second := newUpstream("second")
+	defer second.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	reg := registry.New(zap.NewNop())
+	routeBinder := New(zap.NewNop(), cfg, reg)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	get := func(path string) (int, string) {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder.Code, recorder.Body.String()
+	}
+
+	if code, _ := get("/apis/petstore/pets"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 before registration, got %d", code)
+	}
+
+	addPetstore(t, reg, first.URL)
+	if code, body := get("/apis/petstore/pets"); code != http.StatusOK || body != "first" {
+		t.Errorf("Expected first upstream, got %d %s", code, body)
+	}
+
+	// Re-registering rebinds to the new spec
+	addPetstore(t, reg, second.URL)
+	if code, body := get("/apis/petstore/pets"); code != http.StatusOK || body != "second" {
+		t.Errorf("Expected second upstream after update, got %d %s", code, body)
+	}
+
+	// Renewing an unchanged spec keeps the bound routes
+	bound := routeBinder.services["petstore"]
+	reg.Touch("petstore", time.Now(), `"v2"`, "")
+	if code, body := get("/apis/petstore/pets"); code != http.StatusOK || body != "second" {
+		t.Errorf("Expected second upstream after renewal, got %d %s", code, body)
+	}
+	if routeBinder.services["petstore"] != bound {
+		t.Errorf("Expected the routes not to be rebound for an unchanged spec")
+	}
+
+	// Disabled operations are unavailable while siblings keep working
+	reg.SetOperationEnabled("petstore", "listPets", false)
+	if code, _ := get("/apis/petstore/pets"); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for disabled operation, got %d", code)
+	}
+	if code, _ := get("/apis/petstore/pets/1"); code != http.StatusOK {
+		t.Errorf("Expected sibling operation to work, got %d", code)
+	}
+
+	reg.Remove("petstore")
+	if code, _ := get("/apis/petstore/pets/1"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 after removal, got %d", code)
+	}
+}
+
+func TestBinder_RunsTransformPlugins(t *testing.T) {
+	var pluginHeader string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pluginHeader = r.Header.Get("X-Transform-Plugin")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	hookManager := hooks.NewManager(zap.NewNop())
+	if err := plugins.NewRegistry(zap.NewNop(), hookManager).Register(plugins.NewExampleTransformPlugin(zap.NewNop())); err != nil {
+		t.Fatalf("Failed to register plugin: %v", err)
+	}
+
+	reg := registry.New(zap.NewNop())
+	routeBinder := New(zap.NewNop(), cfg, reg)
+	routeBinder.SetHookManager(hookManager)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	addPetstore(t, reg, upstream.URL)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/apis/petstore/pets/1", nil))
+
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"id":"1"}` {
+		t.Fatalf("Expected upstream response, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("X-Response-Transformed") != "true" {
+		t.Errorf("Expected X-Response-Transformed on the proxied response, got %v", recorder.Header())
+	}
+	if recorder.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected upstream headers to be kept, got %v", recorder.Header())
+	}
+	if pluginHeader != "example-transform" {
+		t.Errorf("Expected pre-request transform to reach the upstream, got %q", pluginHeader)
+	}
+}
+
+func TestBinder_RejectsInvalidRequests(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	hookManager := hooks.NewManager(zap.NewNop())
+	hookManager.RegisterHook(hooks.NewRequestValidationHook(zap.NewNop(), hooks.PriorityHigh))
+
+	reg := registry.New(zap.NewNop())
+	routeBinder := New(zap.NewNop(), cfg, reg)
+	routeBinder.SetHookManager(hookManager)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	addPetstore(t, reg, upstream.URL)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apis/petstore/pets?limit=abc", nil))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for an invalid limit, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	var body struct {
+		Errors []proxy.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Errors) != 1 || body.Errors[0].In != "query" || body.Errors[0].Name != "limit" {
+		t.Errorf("Expected a violation for the limit query parameter, got %+v", body.Errors)
+	}
+	if calls != 0 {
+		t.Errorf("Expected the invalid request not to reach the upstream, got %d calls", calls)
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apis/petstore/pets?limit=10", nil))
+	if recorder.Code != http.StatusOK || calls != 1 {
+		t.Errorf("Expected a valid request to be proxied, got %d with %d calls", recorder.Code, calls)
+	}
+}
+
+func TestBinder_MaxRequestBytes(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Server.MaxRequestBytes = 64
+
+	reg := registry.New(zap.NewNop())
+	addPetstore(t, reg, upstream.URL)
+	router := gin.New()
+	router.Any("/apis/*path", New(zap.NewNop(), cfg, reg).Handler())
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/apis/petstore/pets", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := post(`{"name": "Rex"}`); recorder.Code != http.StatusCreated {
+		t.Fatalf("Expected a small body to be proxied, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	recorder := post(`{"name": "` + strings.Repeat("x", 64) + `"}`)
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized body, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if calls != 1 {
+		t.Errorf("Expected the oversized body not to reach the upstream, got %d calls", calls)
+	}
+}
+
+func TestBinder_SharedBreakers(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Upstream.CircuitBreaker.Threshold = 3
+
+	reg := registry.New(zap.NewNop())
+	addPetstore(t, reg, upstream.URL)
+	shared := circuitbreaker.NewManager(zap.NewNop(), true)
+	routeBinder := New(zap.NewNop(), cfg, reg)
+	routeBinder.SetBreakers(shared)
+	router := gin.New()
+	router.Any("/apis/*path", routeBinder.Handler())
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apis/petstore/pets", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if names := shared.ListBreakers(); len(names) != 1 || names[0] != "petstore" {
+		t.Errorf("Expected the call to go through the shared petstore breaker, got %v", names)
+	}
+	if routeBinder.Breakers() != shared {
+		t.Error("Expected the binder to report the shared breakers")
+	}
+}
+
+func TestBinder_UpdatedHeaders(t *testing.T) {
+	var tenant string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant = r.Header.Get("X-Tenant-Id")
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	reg := registry.New(zap.NewNop())
+	addPetstore(t, reg, upstream.URL)
+	router := gin.New()
+	router.Any("/apis/*path", New(zap.NewNop(), cfg, reg).Handler())
+
+	for _, expected := range []string{"acme", "globex"} {
+		reg.SetHeaders("petstore", map[string]string{"X-Tenant-Id": expected}, false)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/apis/petstore/pets", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		if tenant != expected {
+			t.Errorf("Expected the updated header %q upstream, got %q", expected, tenant)
+		}
+	}
+}
+
+func TestBinder_ForwardedHeaders(t *testing.T) {
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	for _, enabled := range []bool{true, false} {
+		gin.SetMode(gin.TestMode)
+		cfg := &config.Config{}
+		cfg.Upstream.Timeout = 5 * time.Second
+		cfg.Upstream.ForwardedHeaders = enabled
+		cfg.Upstream.TrustedProxies = []string{"10.0.0.0/8"}
+
+		reg := registry.New(zap.NewNop())
+		addPetstore(t, reg, upstream.URL)
+		router := gin.New()
+		router.Any("/apis/*path", New(zap.NewNop(), cfg, reg).Handler())
+
+		req := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/apis/petstore/pets", nil)
+		req.RemoteAddr = "10.0.0.2:52100"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d %s", recorder.Code, recorder.Body.String())
+		}
+		if !enabled {
+			for _, name := range []string{"X-Forwarded-For", "X-Forwarded-Proto", "X-Forwarded-Host"} {
+				if value := received.Get(name); value != "" {
+					t.Errorf("Expected no %s when disabled, got %q", name, value)
+				}
+			}
+			continue
+		}
+		if got := received.Get("X-Forwarded-For"); got != "198.51.100.1, 10.0.0.2" {
+			t.Errorf("Expected the client appended to X-Forwarded-For, got %q", got)
+		}
+		if got := received.Get("X-Forwarded-Proto"); got != "http" {
+			t.Errorf("Expected X-Forwarded-Proto http, got %q", got)
+		}
+		if got := received.Get("X-Forwarded-Host"); got != "gateway.example.com" {
+			t.Errorf("Expected X-Forwarded-Host gateway.example.com, got %q", got)
+		}
+	}
+}
+
+const searchSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Search", "version": "1.0.0"},
+  "servers": [{"url": "%s"}],
+  "paths": {
+    "/search": {
+      "get": {
+        "operationId": "search",
+        "parameters": [
+          {"name": "tag", "in": "query", "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "id", "in": "query", "style": "pipeDelimited", "explode": false,
+           "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "filter", "in": "query", "style": "deepObject", "explode": true,
+           "schema": {"type": "object", "additionalProperties": {"type": "string"}}}
+        ],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+func TestBinder_QueryStyles(t *testing.T) {
+	var received url.Values
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.URL.Query()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(fmt.Sprintf(searchSpec, upstream.URL)))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	reg := registry.New(zap.NewNop())
+	reg.Add(&models.SpecInfo{ServiceName: "search", Spec: spec, FetchedAt: time.Now(), TTL: time.Hour})
+	router := gin.New()
+	router.Any("/apis/*path", New(zap.NewNop(), cfg, reg).Handler())
+
+	tests := []struct {
+		name     string
+		query    string
+		expected url.Values
+	}{
+		{"repeated array values", "tag=a&tag=b", url.Values{"tag": {"a", "b"}}},
+		{"single array value", "tag=a", url.Values{"tag": {"a"}}},
+		{"delimited array", "id=1|2|3", url.Values{"id": {"1|2|3"}}},
+		{"deep object", "filter[name]=rex&filter[status]=open", url.Values{"filter[name]": {"rex"}, "filter[status]": {"open"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			req := httptest.NewRequest(http.MethodGet, "/apis/search/search?"+tt.query, nil)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+			if !reflect.DeepEqual(received, tt.expected) {
+				t.Errorf("Expected upstream query %v, got %v", tt.expected, received)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_RepeatedFormFields(t *testing.T) {
+	body, err := decodeBody("application/x-www-form-urlencoded", "application/x-www-form-urlencoded", []byte("name=rex&tag=a&tag=b"))
+	if err != nil {
+		t.Fatalf("decodeBody() error = %v", err)
+	}
+
+	fields := body.(map[string]interface{})
+	if fields["name"] != "rex" {
+		t.Errorf("Expected name rex, got %v", fields["name"])
+	}
+	if tags := fmt.Sprint(fields["tag"]); tags != "[a b]" {
+		t.Errorf("Expected repeated field as an array, got %v", tags)
+	}
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("caption", "sunset")
+	writer.WriteField("label", "beach")
+	writer.WriteField("label", "sun")
+	file, _ := writer.CreateFormFile("photo", "sunset.jpg")
+	file.Write([]byte("\xff\xd8jpeg"))
+	writer.Close()
+
+	body, err := decodeBody("multipart/form-data", writer.FormDataContentType(), buf.Bytes())
+	if err != nil {
+		t.Fatalf("decodeBody() error = %v", err)
+	}
+
+	fields := body.(map[string]interface{})
+	if fields["caption"] != "sunset" {
+		t.Errorf("Expected caption sunset, got %v", fields["caption"])
+	}
+	if labels := fmt.Sprint(fields["label"]); labels != "[beach sun]" {
+		t.Errorf("Expected repeated field as an array, got %v", labels)
+	}
+	if fields["photo"] != base64.StdEncoding.EncodeToString([]byte("\xff\xd8jpeg")) {
+		t.Errorf("Expected base64 file content, got %v", fields["photo"])
+	}
+
+	if _, err := decodeBody("multipart/form-data", "multipart/form-data", buf.Bytes()); err == nil {
+		t.Error("Expected an error without a boundary")
+	}
+}
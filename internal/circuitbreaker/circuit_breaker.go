@@ -3,6 +3,8 @@ package circuitbreaker
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/http"
 	"sync"
 	"time"
 
@@ -37,6 +39,82 @@ type Config struct {
 	ResetTimeout     time.Duration `yaml:"resetTimeout" json:"resetTimeout"`
 	SuccessThreshold int           `yaml:"successThreshold" json:"successThreshold"`
 	Timeout          time.Duration `yaml:"timeout" json:"timeout"`
+	// FailureRateThreshold, when above zero, replaces the consecutive-failure trigger:
+	// the breaker opens once more than this fraction (0-1) of the calls made within
+	// RollingWindow failed, counting the last 100 calls at most and only once there
+	// are at least MinRequests of them
+	FailureRateThreshold float64       `yaml:"failureRateThreshold" json:"failureRateThreshold"`
+	RollingWindow        time.Duration `yaml:"rollingWindow" json:"rollingWindow"`
+	MinRequests          int           `yaml:"minRequests" json:"minRequests"`
+
+	// HealthProbe, when set, replaces the real request as the half-open probe so
+	// recovery is detected without risking side effects
+	HealthProbe HealthProbeFunc `yaml:"-" json:"-"`
+	// OnStateChange, when set, is called after every state transition
+	OnStateChange StateChangeFunc `yaml:"-" json:"-"`
+	// IsFailure, when set, decides which results count against the breaker, e.g. so
+	// that a client error response does not; by default any error does
+	IsFailure FailureFunc `yaml:"-" json:"-"`
+}
+
+// FailureFunc classifies the outcome of a call as a failure or not. Timeouts and
+// cancellations are passed with a nil result.
+type FailureFunc func(err error, result interface{}) bool
+
+// StateChangeFunc is told when the breaker called name moves from one state to another.
+// It runs without the breaker's lock held, so it may call back into the breaker.
+type StateChangeFunc func(name string, from, to State)
+
+// stateChange is a transition waiting to be reported to OnStateChange
+type stateChange struct {
+	from, to State
+}
+
+// HealthProbeFunc checks whether the protected dependency has recovered
+type HealthProbeFunc func(ctx context.Context) error
+
+// HTTPHealthProbe returns a probe that GETs healthURL and treats any 2xx as healthy.
+// It returns nil when no URL is configured so the breaker keeps probing with real requests.
+func HTTPHealthProbe(client *http.Client, healthURL string) HealthProbeFunc {
+	if healthURL == "" {
+		return nil
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("health check returned HTTP %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// OpenError is returned when the breaker rejects a call without executing it
+type OpenError struct {
+	Name       string
+	State      State
+	RetryAfter time.Duration // until the breaker next lets a call through
+	Cause      error         // the failed health probe, if any
+}
+
+func (e *OpenError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("circuit breaker '%s' is %s: health probe failed: %v", e.Name, e.State, e.Cause)
+	}
+	return fmt.Sprintf("circuit breaker '%s' is %s", e.Name, e.State)
+}
+
+func (e *OpenError) Unwrap() error {
+	return e.Cause
 }
 
 // ExecutorFunc represents a function that can be executed by the circuit breaker
@@ -53,10 +131,14 @@ type CircuitBreaker struct {
 	successes       int
 	lastFailureTime time.Time
 	nextAttempt     time.Time
+	outcomes        *outcomeRing
 	mutex           sync.RWMutex
 	logger          *zap.Logger
 	name            string
 
+	// pendingChanges are reported to OnStateChange once the mutex is released
+	pendingChanges []stateChange
+
 	// Metrics
 	totalRequests  int64
 	totalFailures  int64
@@ -80,12 +162,19 @@ func NewCircuitBreaker(name string, config Config, logger *zap.Logger) *CircuitB
 	if config.Timeout <= 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.RollingWindow <= 0 {
+		config.RollingWindow = 10 * time.Second
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
 
 	return &CircuitBreaker{
-		config: config,
-		state:  StateClosed,
-		logger: logger,
-		name:   name,
+		config:   config,
+		state:    StateClosed,
+		logger:   logger,
+		name:     name,
+		outcomes: newOutcomeRing(rollingWindowCapacity),
 	}
 }
 
@@ -104,26 +193,45 @@ func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, executor Exec
 	case StateOpen:
 		if time.Now().Before(cb.nextAttempt) {
 			cb.totalRejected++
-			cb.mutex.Unlock()
-			err := fmt.Errorf("circuit breaker '%s' is open", cb.name)
+			err := &OpenError{Name: cb.name, State: StateOpen, RetryAfter: time.Until(cb.nextAttempt)}
+			cb.unlock()
 			if fallback != nil {
 				return fallback(ctx, err)
 			}
 			return nil, err
 		}
 		// Time to attempt reset
-		cb.state = StateHalfOpen
-		cb.logger.Info("Circuit breaker transitioning to half-open",
-			zap.String("name", cb.name))
+		cb.setState(StateHalfOpen)
+		if cb.config.HealthProbe != nil {
+			cb.unlock()
+			if err := cb.probeHealth(ctx); err != nil {
+				if fallback != nil {
+					return fallback(ctx, err)
+				}
+				return nil, err
+			}
+			break
+		}
 		fallthrough
 
 	case StateHalfOpen:
+		if cb.config.HealthProbe != nil {
+			// Another caller is running the health probe
+			cb.totalRejected++
+			cb.unlock()
+			err := &OpenError{Name: cb.name, State: StateHalfOpen}
+			if fallback != nil {
+				return fallback(ctx, err)
+			}
+			return nil, err
+		}
+
 		// Allow limited requests through
-		cb.mutex.Unlock()
+		cb.unlock()
 
 	case StateClosed:
 		// Normal operation
-		cb.mutex.Unlock()
+		cb.unlock()
 	}
 
 	// Execute with timeout
@@ -139,15 +247,15 @@ func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, executor Exec
 	select {
 	case <-done:
 		// Execution completed
-		cb.onResult(err)
+		cb.onResult(err, result)
 		return result, err
 
 	case <-time.After(cb.config.Timeout):
 		// Execution timed out
 		cb.mutex.Lock()
 		cb.totalTimeouts++
-		cb.mutex.Unlock()
-		cb.onResult(fmt.Errorf("execution timeout"))
+		cb.unlock()
+		cb.onResult(fmt.Errorf("execution timeout"), nil)
 
 		timeoutErr := fmt.Errorf("circuit breaker '%s' execution timeout", cb.name)
 		if fallback != nil {
@@ -157,17 +265,46 @@ func (cb *CircuitBreaker) ExecuteWithFallback(ctx context.Context, executor Exec
 
 	case <-ctx.Done():
 		// Context cancelled
-		cb.onResult(ctx.Err())
+		cb.onResult(ctx.Err(), nil)
 		return nil, ctx.Err()
 	}
 }
 
+// probeHealth runs the health probe until SuccessThreshold consecutive checks pass,
+// closing the breaker, or reopens it on the first failure
+func (cb *CircuitBreaker) probeHealth(ctx context.Context) error {
+	for i := 0; i < cb.config.SuccessThreshold; i++ {
+		probeCtx, cancel := context.WithTimeout(ctx, cb.config.Timeout)
+		err := cb.config.HealthProbe(probeCtx)
+		cancel()
+
+		cb.mutex.Lock()
+		if err != nil {
+			cb.onFailure()
+			retryAfter := time.Until(cb.nextAttempt)
+			cb.unlock()
+			cb.logger.Debug("Circuit breaker health probe failed",
+				zap.String("name", cb.name),
+				zap.Error(err))
+			return &OpenError{Name: cb.name, State: StateOpen, RetryAfter: retryAfter, Cause: err}
+		}
+		cb.onSuccess()
+		cb.unlock()
+	}
+	return nil
+}
+
 // onResult handles the result of an execution
-func (cb *CircuitBreaker) onResult(err error) {
+func (cb *CircuitBreaker) onResult(err error, result interface{}) {
+	failed := err != nil
+	if cb.config.IsFailure != nil {
+		failed = cb.config.IsFailure(err, result)
+	}
+
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	defer cb.unlock()
 
-	if err != nil {
+	if failed {
 		cb.onFailure()
 	} else {
 		cb.onSuccess()
@@ -183,7 +320,12 @@ func (cb *CircuitBreaker) onFailure() {
 
 	switch cb.state {
 	case StateClosed:
-		if cb.failures >= cb.config.MaxFailures {
+		if cb.usesFailureRate() {
+			cb.outcomes.add(outcome{at: cb.lastFailureTime, failed: true})
+			if cb.failureRateExceeded() {
+				cb.setState(StateOpen)
+			}
+		} else if cb.failures >= cb.config.MaxFailures {
 			cb.setState(StateOpen)
 		}
 	case StateHalfOpen:
@@ -191,6 +333,21 @@ func (cb *CircuitBreaker) onFailure() {
 	}
 }
 
+// usesFailureRate reports whether the breaker trips on its rolling failure rate
+// rather than on consecutive failures
+func (cb *CircuitBreaker) usesFailureRate() bool {
+	return cb.config.FailureRateThreshold > 0
+}
+
+// failureRateExceeded reports whether enough calls in the rolling window failed to open
+func (cb *CircuitBreaker) failureRateExceeded() bool {
+	total, failed := cb.outcomes.tally(time.Now().Add(-cb.config.RollingWindow))
+	if total < cb.config.MinRequests {
+		return false
+	}
+	return float64(failed)/float64(total) > cb.config.FailureRateThreshold
+}
+
 // onSuccess handles a successful execution
 func (cb *CircuitBreaker) onSuccess() {
 	cb.totalSuccesses++
@@ -198,6 +355,9 @@ func (cb *CircuitBreaker) onSuccess() {
 	switch cb.state {
 	case StateClosed:
 		cb.failures = 0
+		if cb.usesFailureRate() {
+			cb.outcomes.add(outcome{at: time.Now()})
+		}
 	case StateHalfOpen:
 		cb.successes++
 		if cb.successes >= cb.config.SuccessThreshold {
@@ -221,6 +381,8 @@ func (cb *CircuitBreaker) setState(state State) {
 	case StateClosed:
 		cb.failures = 0
 		cb.successes = 0
+		// Outcomes from before the breaker opened must not trip it again
+		cb.outcomes.reset()
 		cb.logger.Info("Circuit breaker closed",
 			zap.String("name", cb.name))
 	case StateHalfOpen:
@@ -234,6 +396,20 @@ func (cb *CircuitBreaker) setState(state State) {
 			zap.String("name", cb.name),
 			zap.String("from", oldState.String()),
 			zap.String("to", state.String()))
+		if cb.config.OnStateChange != nil {
+			cb.pendingChanges = append(cb.pendingChanges, stateChange{from: oldState, to: state})
+		}
+	}
+}
+
+// unlock releases the write lock, then reports the state changes made while it was held
+func (cb *CircuitBreaker) unlock() {
+	changes := cb.pendingChanges
+	cb.pendingChanges = nil
+	cb.mutex.Unlock()
+
+	for _, change := range changes {
+		cb.config.OnStateChange(cb.name, change.from, change.to)
 	}
 }
 
@@ -268,7 +444,7 @@ func (cb *CircuitBreaker) GetStats() map[string]interface{} {
 // Reset manually resets the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
 	cb.mutex.Lock()
-	defer cb.mutex.Unlock()
+	defer cb.unlock()
 
 	cb.setState(StateClosed)
 	cb.logger.Info("Circuit breaker manually reset", zap.String("name", cb.name))
@@ -276,10 +452,11 @@ func (cb *CircuitBreaker) Reset() {
 
 // Manager manages multiple circuit breakers
 type Manager struct {
-	breakers map[string]*CircuitBreaker
-	mutex    sync.RWMutex
-	logger   *zap.Logger
-	enabled  bool
+	breakers    map[string]*CircuitBreaker
+	subscribers []StateChangeFunc
+	mutex       sync.RWMutex
+	logger      *zap.Logger
+	enabled     bool
 }
 
 // NewManager creates a new circuit breaker manager
@@ -300,8 +477,17 @@ func (m *Manager) GetOrCreate(name string, config Config) *CircuitBreaker {
 		return breaker
 	}
 
+	own := config.OnStateChange
+	config.OnStateChange = func(name string, from, to State) {
+		if own != nil {
+			own(name, from, to)
+		}
+		m.notify(name, from, to)
+	}
+
 	breaker := NewCircuitBreaker(name, config, m.logger.Named("cb"))
 	m.breakers[name] = breaker
+	stateGauge.WithLabelValues(name).Set(float64(StateClosed))
 
 	m.logger.Info("Created circuit breaker",
 		zap.String("name", name),
@@ -311,6 +497,28 @@ func (m *Manager) GetOrCreate(name string, config Config) *CircuitBreaker {
 	return breaker
 }
 
+// Subscribe calls fn on every state change of the manager's breakers, including
+// those created later
+func (m *Manager) Subscribe(fn StateChangeFunc) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// notify records a state change and passes it to the subscribers
+func (m *Manager) notify(name string, from, to State) {
+	stateGauge.WithLabelValues(name).Set(float64(to))
+
+	m.mutex.RLock()
+	subscribers := make([]StateChangeFunc, len(m.subscribers))
+	copy(subscribers, m.subscribers)
+	m.mutex.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(name, from, to)
+	}
+}
+
 // Execute executes a function with circuit breaker protection
 func (m *Manager) Execute(name string, config Config, ctx context.Context, executor ExecutorFunc) (interface{}, error) {
 	if !m.enabled {
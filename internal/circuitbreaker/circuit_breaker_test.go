@@ -3,6 +3,9 @@ package circuitbreaker
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -171,6 +174,255 @@ func TestCircuitBreaker_HalfOpenFailure(t *testing.T) {
 	}
 }
 
+// runPattern executes one call per entry of pattern, failing where it is 'F'
+func runPattern(cb *CircuitBreaker, pattern string) {
+	for _, c := range pattern {
+		failed := c == 'F'
+		cb.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+			if failed {
+				return nil, fmt.Errorf("failure")
+			}
+			return "success", nil
+		})
+	}
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		pattern   string
+		wantState State
+	}{
+		// Never three failures in a row, but two thirds of all calls fail
+		{"consecutive mode ignores interleaved failures", 0, "FFSFFSFFSFFS", StateClosed},
+		{"rate mode trips on interleaved failures", 0.5, "FFSFFSFFSFFS", StateOpen},
+		{"rate mode stays closed below threshold", 0.5, "FSSFSSFSSFSS", StateClosed},
+		{"rate mode waits for minimum requests", 0.5, "FFFF", StateClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb := NewCircuitBreaker("test-cb", Config{
+				MaxFailures:          3,
+				ResetTimeout:         time.Second,
+				Timeout:              100 * time.Millisecond,
+				FailureRateThreshold: tt.threshold,
+				RollingWindow:        10 * time.Second,
+				MinRequests:          6,
+			}, zap.NewNop())
+
+			runPattern(cb, tt.pattern)
+			if state := cb.GetState(); state != tt.wantState {
+				t.Errorf("Expected state %s, got %s", tt.wantState, state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_FailureRateWindowExpires(t *testing.T) {
+	cb := NewCircuitBreaker("test-cb", Config{
+		ResetTimeout:         time.Second,
+		Timeout:              100 * time.Millisecond,
+		FailureRateThreshold: 0.5,
+		RollingWindow:        100 * time.Millisecond,
+		MinRequests:          4,
+	}, zap.NewNop())
+
+	runPattern(cb, "FFF")
+	time.Sleep(150 * time.Millisecond)
+
+	// The earlier failures fell out of the window, so 1 of 4 failed
+	runPattern(cb, "SSSF")
+	if state := cb.GetState(); state != StateClosed {
+		t.Errorf("Expected old failures to be forgotten, got state %s", state)
+	}
+
+	// 4 of 7 failed
+	runPattern(cb, "FFF")
+	if state := cb.GetState(); state != StateOpen {
+		t.Errorf("Expected breaker to open above the threshold, got state %s", state)
+	}
+}
+
+func TestCircuitBreaker_IsFailure(t *testing.T) {
+	cb := NewCircuitBreaker("test-cb", Config{
+		MaxFailures:  3,
+		ResetTimeout: time.Minute,
+		Timeout:      100 * time.Millisecond,
+		IsFailure: func(err error, result interface{}) bool {
+			status, _ := result.(int)
+			return err != nil || status >= 500
+		},
+	}, zap.NewNop())
+
+	respond := func(status int) {
+		cb.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+			return status, nil
+		})
+	}
+
+	// Client errors are not failures
+	for i := 0; i < 10; i++ {
+		respond(404)
+	}
+	if cb.GetState() != StateClosed {
+		t.Errorf("Circuit breaker should stay closed on client errors")
+	}
+
+	// Server errors are
+	for i := 0; i < 3; i++ {
+		respond(503)
+	}
+	if cb.GetState() != StateOpen {
+		t.Errorf("Circuit breaker should open on server errors")
+	}
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	var changes []string
+	var cb *CircuitBreaker
+	cb = NewCircuitBreaker("test-cb", Config{
+		MaxFailures:      2,
+		ResetTimeout:     100 * time.Millisecond,
+		SuccessThreshold: 1,
+		Timeout:          100 * time.Millisecond,
+		OnStateChange: func(name string, from, to State) {
+			// Calling back into the breaker must not deadlock
+			cb.GetStats()
+			changes = append(changes, fmt.Sprintf("%s:%s->%s", name, from, to))
+		},
+	}, zap.NewNop())
+
+	runPattern(cb, "FF")
+	time.Sleep(150 * time.Millisecond)
+	runPattern(cb, "S")
+
+	expected := []string{"test-cb:closed->open", "test-cb:open->half-open", "test-cb:half-open->closed"}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+
+	// Repeated transitions to the same state are not reported
+	cb.Reset()
+	if len(changes) != len(expected) {
+		t.Errorf("Expected no change for resetting a closed breaker, got %v", changes)
+	}
+}
+
+func TestManager_Subscribe(t *testing.T) {
+	manager := NewManager(zap.NewNop(), true)
+
+	var own, subscribed []string
+	manager.Subscribe(func(name string, from, to State) {
+		subscribed = append(subscribed, fmt.Sprintf("%s:%s->%s", name, from, to))
+	})
+
+	config := Config{
+		MaxFailures:  1,
+		ResetTimeout: time.Minute,
+		Timeout:      100 * time.Millisecond,
+		OnStateChange: func(name string, from, to State) {
+			own = append(own, fmt.Sprintf("%s:%s->%s", name, from, to))
+		},
+	}
+	manager.Execute("test-service", config, context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("failure")
+	})
+
+	cb, _ := manager.GetBreaker("test-service")
+	cb.Reset()
+
+	expected := []string{"test-service:closed->open", "test-service:open->closed"}
+	if fmt.Sprint(subscribed) != fmt.Sprint(expected) {
+		t.Errorf("Expected subscriber to see %v, got %v", expected, subscribed)
+	}
+	if fmt.Sprint(own) != fmt.Sprint(expected) {
+		t.Errorf("Expected the breaker's own callback to still run, got %v", own)
+	}
+}
+
+func TestCircuitBreaker_HealthProbe(t *testing.T) {
+	var healthy atomic.Bool
+	var healthChecks atomic.Int32
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthChecks.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	config := Config{
+		MaxFailures:      1,
+		ResetTimeout:     50 * time.Millisecond,
+		SuccessThreshold: 2,
+		Timeout:          time.Second,
+		HealthProbe:      HTTPHealthProbe(upstream.Client(), upstream.URL+"/health"),
+	}
+
+	cb := NewCircuitBreaker("test-cb", config, zap.NewNop())
+
+	var operations atomic.Int32
+	operation := func(ctx context.Context) (interface{}, error) {
+		operations.Add(1)
+		return "success", nil
+	}
+
+	// Open the circuit
+	cb.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, fmt.Errorf("failure")
+	})
+	if cb.GetState() != StateOpen {
+		t.Fatalf("Circuit breaker should be open")
+	}
+
+	// Unhealthy probe keeps the circuit open without running the operation
+	time.Sleep(75 * time.Millisecond)
+	if _, err := cb.Execute(context.Background(), operation); err == nil {
+		t.Errorf("Expected error while upstream is unhealthy")
+	}
+	if cb.GetState() != StateOpen {
+		t.Errorf("Circuit breaker should reopen after a failed health probe, got %s", cb.GetState().String())
+	}
+	if operations.Load() != 0 {
+		t.Errorf("Expected no real operations while probing, got %d", operations.Load())
+	}
+	if healthChecks.Load() != 1 {
+		t.Errorf("Expected 1 health check, got %d", healthChecks.Load())
+	}
+
+	// Healthy probes close the circuit before the operation runs
+	healthy.Store(true)
+	time.Sleep(75 * time.Millisecond)
+	if _, err := cb.Execute(context.Background(), func(ctx context.Context) (interface{}, error) {
+		if cb.GetState() != StateClosed {
+			t.Errorf("Operation should only run once the circuit is closed, got %s", cb.GetState().String())
+		}
+		return operation(ctx)
+	}); err != nil {
+		t.Errorf("Expected success after healthy probes: %v", err)
+	}
+	if cb.GetState() != StateClosed {
+		t.Errorf("Circuit breaker should be closed, got %s", cb.GetState().String())
+	}
+	if healthChecks.Load() != 3 {
+		t.Errorf("Expected %d health checks in total, got %d", 3, healthChecks.Load())
+	}
+	if operations.Load() != 1 {
+		t.Errorf("Expected 1 real operation, got %d", operations.Load())
+	}
+}
+
+func TestHTTPHealthProbe_NoURL(t *testing.T) {
+	if HTTPHealthProbe(http.DefaultClient, "") != nil {
+		t.Errorf("Expected no probe without a health URL so the breaker falls back to real requests")
+	}
+}
+
 func TestCircuitBreaker_Timeout(t *testing.T) {
 	config := Config{
 		MaxFailures:      3,
@@ -0,0 +1,13 @@
+package circuitbreaker
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// stateGauge reports each managed breaker's state: 0 closed, 1 open, 2 half-open
+var stateGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "swagger_mcp_circuit_breaker_state",
+	Help: "Circuit breaker state by name: 0 closed, 1 open, 2 half-open.",
+}, []string{"name"})
+
+func init() {
+	prometheus.MustRegister(stateGauge)
+}
@@ -0,0 +1,54 @@
+package circuitbreaker
+
+import "time"
+
+// rollingWindowCapacity is how many recent outcomes the failure-rate trigger looks at
+const rollingWindowCapacity = 100
+
+// outcome is the result of one call, as recorded for the failure-rate trigger
+type outcome struct {
+	at     time.Time
+	failed bool
+}
+
+// outcomeRing holds the most recent call outcomes, overwriting the oldest when full
+type outcomeRing struct {
+	entries []outcome
+	next    int
+	count   int
+}
+
+// newOutcomeRing creates a ring holding up to capacity outcomes
+func newOutcomeRing(capacity int) *outcomeRing {
+	return &outcomeRing{entries: make([]outcome, capacity)}
+}
+
+// add records an outcome
+func (r *outcomeRing) add(o outcome) {
+	r.entries[r.next] = o
+	r.next = (r.next + 1) % len(r.entries)
+	if r.count < len(r.entries) {
+		r.count++
+	}
+}
+
+// tally counts the recorded outcomes, and the failed ones, at or after since
+func (r *outcomeRing) tally(since time.Time) (total, failed int) {
+	for i := 0; i < r.count; i++ {
+		o := r.entries[i]
+		if o.at.Before(since) {
+			continue
+		}
+		total++
+		if o.failed {
+			failed++
+		}
+	}
+	return total, failed
+}
+
+// reset forgets all recorded outcomes
+func (r *outcomeRing) reset() {
+	r.next = 0
+	r.count = 0
+}
@@ -47,39 +47,132 @@ func Load(configPath string) (*Config, error) {
 	return &config, nil
 }
 
+// UpstreamHealthURL returns the health check URL configured under health.upstreams for
+// serviceName, matched regardless of case as the loader lowercases map keys, or ""
+func (c *Config) UpstreamHealthURL(serviceName string) string {
+	for name, healthURL := range c.Health.Upstreams {
+		if strings.EqualFold(name, serviceName) {
+			return healthURL
+		}
+	}
+	return ""
+}
+
 func setDefaults() {
 	viper.SetDefault("server.host", "0.0.0.0")
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.readTimeout", "30s")
 	viper.SetDefault("server.writeTimeout", "30s")
+	viper.SetDefault("server.shutdownTimeout", "30s")
+	viper.SetDefault("server.maxRequestBytes", 10485760)
 
 	viper.SetDefault("mcp.enabled", true)
 	viper.SetDefault("mcp.host", "0.0.0.0")
 	viper.SetDefault("mcp.port", 8081)
+	viper.SetDefault("mcp.maxSchemaDepth", 5)
+	viper.SetDefault("mcp.maxSessions", 100)
+	viper.SetDefault("mcp.maxRequestBytes", 1048576)
+	viper.SetDefault("mcp.toolCallsPerMinute", 0)
+	viper.SetDefault("mcp.toolIdleTimeout", "0s")
+	viper.SetDefault("mcp.toolNameSeparator", "_")
+	viper.SetDefault("mcp.specNotifications", false)
 
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.format", "json")
+	viper.SetDefault("logging.redact.headers", []string{})
+	viper.SetDefault("logging.redact.bodyFields", []string{})
 
 	viper.SetDefault("metrics.enabled", true)
 	viper.SetDefault("metrics.path", "/metrics")
 
+	viper.SetDefault("health.timeout", "5s")
+
 	viper.SetDefault("tracing.enabled", false)
 	viper.SetDefault("tracing.serviceName", "swagger-mcp-go")
 
+	viper.SetDefault("plugins.builtin", false)
+
+	viper.SetDefault("validation.request.mode", "off")
+
+	viper.SetDefault("cache.enabled", false)
+	viper.SetDefault("cache.defaultTTL", "1m")
+	viper.SetDefault("cache.maxEntries", 1000)
+
 	viper.SetDefault("upstream.timeout", "30s")
 	viper.SetDefault("upstream.retryCount", 3)
 	viper.SetDefault("upstream.retryDelay", "1s")
+	viper.SetDefault("upstream.retryableStatusCodes", []int{502, 503, 504})
+	viper.SetDefault("upstream.expectContinueTimeout", "1s")
+	viper.SetDefault("upstream.expectContinueThreshold", 0)
+	viper.SetDefault("upstream.autoIfMatch", false)
+	viper.SetDefault("upstream.mock", false)
+	viper.SetDefault("upstream.forwardedHeaders", false)
+	viper.SetDefault("upstream.trustedProxies", []string{})
+	viper.SetDefault("upstream.maxIdleConnsPerHost", 10)
+	viper.SetDefault("upstream.idleConnTimeout", "90s")
+	viper.SetDefault("upstream.maxDecodedBytes", 33554432)
+	viper.SetDefault("upstream.streamIdleTimeout", "5m")
+	viper.SetDefault("upstream.tls.insecureSkipVerify", false)
 	viper.SetDefault("upstream.circuitBreaker.threshold", 5)
 	viper.SetDefault("upstream.circuitBreaker.timeout", "60s")
+	viper.SetDefault("upstream.circuitBreaker.fallback", false)
+
+	viper.SetDefault("janitor.interval", "1m")
+	viper.SetDefault("janitor.jitter", "10s")
+	viper.SetDefault("janitor.sessionIdleTimeout", "30m")
 
 	viper.SetDefault("specs.defaultTTL", "1h")
 	viper.SetDefault("specs.maxSize", "10MB")
+	viper.SetDefault("specs.continueOnError", false)
+	viper.SetDefault("specs.allowInvalid", false)
 
 	viper.SetDefault("policies.rateLimit.enabled", false)
 	viper.SetDefault("policies.rateLimit.requestsPerMinute", 100)
+	viper.SetDefault("policies.rateLimit.keyBy", "ip")
+	viper.SetDefault("policies.concurrency.maxInFlight", 0)
 	viper.SetDefault("policies.cors.enabled", true)
 	viper.SetDefault("policies.cors.allowOrigins", []string{"*"})
 	viper.SetDefault("policies.cors.allowMethods", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"})
+	viper.SetDefault("policies.cors.allowHeaders", []string{"Origin", "Content-Type", "Authorization"})
+	viper.SetDefault("policies.cors.allowCredentials", false)
+	viper.SetDefault("policies.cors.maxAge", "0s")
+}
+
+// SpecSource is a spec registered at startup, fetched from URL or read from File.
+// BaseURL overrides the spec's servers for MCP tools, and ServerVariables fills in the
+// variables of their URLs; TTL defaults to specs.defaultTTL for URLs, while file specs
+// only expire when given one.
+type SpecSource struct {
+	ServiceName     string            `yaml:"serviceName"`
+	URL             string            `yaml:"url"`
+	File            string            `yaml:"file"`
+	TTL             string            `yaml:"ttl"`
+	Headers         map[string]string `yaml:"headers"`
+	BaseURL         string            `yaml:"baseURL"`
+	ServerVariables map[string]string `yaml:"serverVariables"`
+}
+
+// BodyDefault lists fields added to the JSON request bodies sent to a service, for
+// every operation or only the one named by Operation. Fields is a JSON object, e.g.
+// {"apiVersion": "v2"}, so field names keep their case.
+type BodyDefault struct {
+	Service   string `yaml:"service"`
+	Operation string `yaml:"operation"`
+	Fields    string `yaml:"fields"`
+}
+
+// RedactRules lists headers and dot-separated JSON body field paths, e.g.
+// card.number, whose values are masked in logs
+type RedactRules struct {
+	Headers    []string `yaml:"headers"`
+	BodyFields []string `yaml:"bodyFields"`
+}
+
+// PluginConfig names a plugin factory to load at startup and the configuration its
+// plugin is initialized with
+type PluginConfig struct {
+	Name   string                 `yaml:"name"`
+	Config map[string]interface{} `yaml:"config"`
 }
 
 // Config represents the application configuration
@@ -89,17 +182,33 @@ type Config struct {
 		Port         int           `yaml:"port"`
 		ReadTimeout  time.Duration `yaml:"readTimeout"`
 		WriteTimeout time.Duration `yaml:"writeTimeout"`
+		// ShutdownTimeout bounds how long shutdown waits for in-flight requests
+		ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+		// MaxRequestBytes bounds the bodies of requests proxied through /apis
+		MaxRequestBytes int64 `yaml:"maxRequestBytes"`
 	} `yaml:"server"`
 
 	MCP struct {
-		Enabled bool   `yaml:"enabled"`
-		Host    string `yaml:"host"`
-		Port    int    `yaml:"port"`
+		Enabled            bool          `yaml:"enabled"`
+		Host               string        `yaml:"host"`
+		Port               int           `yaml:"port"`
+		MaxSchemaDepth     int           `yaml:"maxSchemaDepth"`
+		MaxSessions        int           `yaml:"maxSessions"`
+		MaxRequestBytes    int64         `yaml:"maxRequestBytes"`
+		ToolCallsPerMinute int           `yaml:"toolCallsPerMinute"`
+		ToolIdleTimeout    time.Duration `yaml:"toolIdleTimeout"`
+		ToolNameSeparator  string        `yaml:"toolNameSeparator"`
+		SpecNotifications  bool          `yaml:"specNotifications"`
 	} `yaml:"mcp"`
 
 	Logging struct {
 		Level  string `yaml:"level"`
 		Format string `yaml:"format"`
+		Redact struct {
+			Headers    []string               `yaml:"headers"`
+			BodyFields []string               `yaml:"bodyFields"`
+			Services   map[string]RedactRules `yaml:"services"`
+		} `yaml:"redact"`
 	} `yaml:"logging"`
 
 	Metrics struct {
@@ -107,6 +216,12 @@ type Config struct {
 		Path    string `yaml:"path"`
 	} `yaml:"metrics"`
 
+	Health struct {
+		Critical  []string          `yaml:"critical"`
+		Upstreams map[string]string `yaml:"upstreams"`
+		Timeout   time.Duration     `yaml:"timeout"`
+	} `yaml:"health"`
+
 	Tracing struct {
 		Enabled     bool   `yaml:"enabled"`
 		Endpoint    string `yaml:"endpoint"`
@@ -114,20 +229,39 @@ type Config struct {
 	} `yaml:"tracing"`
 
 	Upstream struct {
-		Timeout        time.Duration `yaml:"timeout"`
-		RetryCount     int           `yaml:"retryCount"`
-		RetryDelay     time.Duration `yaml:"retryDelay"`
-		CircuitBreaker struct {
+		Timeout                 time.Duration     `yaml:"timeout"`
+		RetryCount              int               `yaml:"retryCount"`
+		RetryDelay              time.Duration     `yaml:"retryDelay"`
+		RetryableStatusCodes    []int             `yaml:"retryableStatusCodes"`
+		ExpectContinueTimeout   time.Duration     `yaml:"expectContinueTimeout"`
+		ExpectContinueThreshold int64             `yaml:"expectContinueThreshold"`
+		AutoIfMatch             bool              `yaml:"autoIfMatch"`
+		Mock                    bool              `yaml:"mock"`
+		ForwardedHeaders        bool              `yaml:"forwardedHeaders"`
+		TrustedProxies          []string          `yaml:"trustedProxies"`
+		IdentityHeaders         map[string]string `yaml:"identityHeaders"`
+		BodyDefaults            []BodyDefault     `yaml:"bodyDefaults"`
+		MaxIdleConnsPerHost     int               `yaml:"maxIdleConnsPerHost"`
+		IdleConnTimeout         time.Duration     `yaml:"idleConnTimeout"`
+		MaxDecodedBytes         int64             `yaml:"maxDecodedBytes"`
+		StreamIdleTimeout       time.Duration     `yaml:"streamIdleTimeout"`
+		CircuitBreaker          struct {
 			Threshold int           `yaml:"threshold"`
 			Timeout   time.Duration `yaml:"timeout"`
+			Fallback  bool          `yaml:"fallback"`
 		} `yaml:"circuitBreaker"`
+		TLS struct {
+			InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+			CAFile             string `yaml:"caFile"`
+		} `yaml:"tls"`
 	} `yaml:"upstream"`
 
 	Auth struct {
 		JWT struct {
-			JWKSURL  string `yaml:"jwksURL"`
-			Issuer   string `yaml:"issuer"`
-			Audience string `yaml:"audience"`
+			JWKSURL  string   `yaml:"jwksURL"`
+			Issuer   string   `yaml:"issuer"`
+			Issuers  []string `yaml:"issuers"`
+			Audience string   `yaml:"audience"`
 		} `yaml:"jwt"`
 		OAuth2 struct {
 			TokenURL     string `yaml:"tokenURL"`
@@ -136,20 +270,60 @@ type Config struct {
 		} `yaml:"oauth2"`
 	} `yaml:"auth"`
 
+	Janitor struct {
+		Interval           time.Duration `yaml:"interval"`
+		Jitter             time.Duration `yaml:"jitter"`
+		SessionIdleTimeout time.Duration `yaml:"sessionIdleTimeout"`
+	} `yaml:"janitor"`
+
+	Plugins struct {
+		Builtin bool           `yaml:"builtin"`
+		Load    []PluginConfig `yaml:"load"`
+	} `yaml:"plugins"`
+
+	Validation struct {
+		Request struct {
+			Mode     string            `yaml:"mode"`
+			Services map[string]string `yaml:"services"`
+		} `yaml:"request"`
+		Response struct {
+			Services []string `yaml:"services"`
+		} `yaml:"response"`
+	} `yaml:"validation"`
+
+	Cache struct {
+		Enabled    bool          `yaml:"enabled"`
+		DefaultTTL time.Duration `yaml:"defaultTTL"`
+		MaxEntries int           `yaml:"maxEntries"`
+	} `yaml:"cache"`
+
 	Specs struct {
-		DefaultTTL string `yaml:"defaultTTL"`
-		MaxSize    string `yaml:"maxSize"`
+		DefaultTTL      string       `yaml:"defaultTTL"`
+		MaxSize         string       `yaml:"maxSize"`
+		Sources         []SpecSource `yaml:"sources"`
+		ContinueOnError bool         `yaml:"continueOnError"`
+		AllowInvalid    bool         `yaml:"allowInvalid"`
 	} `yaml:"specs"`
 
 	Policies struct {
 		RateLimit struct {
 			Enabled           bool `yaml:"enabled"`
 			RequestsPerMinute int  `yaml:"requestsPerMinute"`
+			// KeyBy is "ip" to limit each client address or "user" to limit each
+			// authenticated user
+			KeyBy string `yaml:"keyBy"`
 		} `yaml:"rateLimit"`
+		Concurrency struct {
+			MaxInFlight int            `yaml:"maxInFlight"`
+			Services    map[string]int `yaml:"services"`
+		} `yaml:"concurrency"`
 		CORS struct {
-			Enabled      bool     `yaml:"enabled"`
-			AllowOrigins []string `yaml:"allowOrigins"`
-			AllowMethods []string `yaml:"allowMethods"`
+			Enabled          bool          `yaml:"enabled"`
+			AllowOrigins     []string      `yaml:"allowOrigins"`
+			AllowMethods     []string      `yaml:"allowMethods"`
+			AllowHeaders     []string      `yaml:"allowHeaders"`
+			AllowCredentials bool          `yaml:"allowCredentials"`
+			MaxAge           time.Duration `yaml:"maxAge"`
 		} `yaml:"cors"`
 	} `yaml:"policies"`
 }
@@ -158,4 +332,9 @@ func expandEnvVars(config *Config) {
 	// Expand environment variables in sensitive fields
 	config.Auth.OAuth2.ClientID = os.ExpandEnv(config.Auth.OAuth2.ClientID)
 	config.Auth.OAuth2.ClientSecret = os.ExpandEnv(config.Auth.OAuth2.ClientSecret)
+	for _, source := range config.Specs.Sources {
+		for name, value := range source.Headers {
+			source.Headers[name] = os.ExpandEnv(value)
+		}
+	}
 }
@@ -0,0 +1,226 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/plugins"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+)
+
+// Status is the health of a subsystem or of the server as a whole
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Subsystem names used by the built-in checks, and in health.critical
+const (
+	SubsystemPlugins         = "plugins"
+	SubsystemCircuitBreakers = "circuitBreakers"
+	SubsystemRegistry        = "registry"
+	SubsystemUpstreams       = "upstreams"
+)
+
+// Result is the outcome of checking one subsystem
+type Result struct {
+	Status   Status      `json:"status"`
+	Critical bool        `json:"critical"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// Check reports the health of one subsystem
+type Check func(ctx context.Context) Result
+
+// Report is the aggregated health of every registered subsystem
+type Report struct {
+	Status     Status            `json:"status"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Subsystems map[string]Result `json:"subsystems"`
+}
+
+// Checker aggregates the health of the server's subsystems. The server is unhealthy
+// when a critical subsystem is, and degraded when any other subsystem is not healthy.
+type Checker struct {
+	checks   map[string]Check
+	critical map[string]bool
+	breakers []*circuitbreaker.Manager
+	mutex    sync.RWMutex
+}
+
+// NewChecker creates a checker treating the named subsystems as critical
+func NewChecker(critical []string) *Checker {
+	criticalSet := make(map[string]bool, len(critical))
+	for _, name := range critical {
+		criticalSet[name] = true
+	}
+	return &Checker{
+		checks:   make(map[string]Check),
+		critical: criticalSet,
+	}
+}
+
+// Register adds a subsystem check, replacing any check registered under name
+func (c *Checker) Register(name string, check Check) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checks[name] = check
+}
+
+// AddBreakers includes manager's circuit breakers in the circuitBreakers subsystem
+func (c *Checker) AddBreakers(manager *circuitbreaker.Manager) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.breakers = append(c.breakers, manager)
+}
+
+// Check runs every subsystem check and aggregates the results
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mutex.RLock()
+	checks := make(map[string]Check, len(c.checks)+1)
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	if len(c.breakers) > 0 {
+		checks[SubsystemCircuitBreakers] = breakerCheck(append([]*circuitbreaker.Manager(nil), c.breakers...))
+	}
+	c.mutex.RUnlock()
+
+	report := Report{
+		Status:     StatusHealthy,
+		Timestamp:  time.Now(),
+		Subsystems: make(map[string]Result, len(checks)),
+	}
+	for name, check := range checks {
+		result := check(ctx)
+		result.Critical = c.critical[name]
+		report.Subsystems[name] = result
+
+		switch {
+		case result.Status == StatusHealthy:
+		case result.Critical && result.Status == StatusUnhealthy:
+			report.Status = StatusUnhealthy
+		case report.Status == StatusHealthy:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// HTTPStatus is the status code reporting r: 503 when unhealthy, otherwise 200
+func (r Report) HTTPStatus() int {
+	if r.Status == StatusUnhealthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// PluginCheck reports the plugins' own health, unhealthy when any plugin is
+func PluginCheck(pluginRegistry *plugins.Registry) Check {
+	return func(ctx context.Context) Result {
+		plugins := pluginRegistry.Health()
+		result := Result{Status: StatusHealthy, Details: plugins}
+		for _, status := range plugins {
+			if !status.Healthy {
+				result.Status = StatusUnhealthy
+			}
+		}
+		return result
+	}
+}
+
+// RegistryCheck reports how many specs are registered
+func RegistryCheck(reg *registry.Registry) Check {
+	return func(ctx context.Context) Result {
+		return Result{Status: StatusHealthy, Details: map[string]int{"specs": len(reg.List())}}
+	}
+}
+
+// UpstreamCheck GETs each upstream's health URL, unhealthy when any fails to answer
+// with a 2xx within timeout
+func UpstreamCheck(client *http.Client, upstreams map[string]string, timeout time.Duration) Check {
+	return func(ctx context.Context) Result {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		details := make(map[string]string, len(upstreams))
+		var mutex sync.Mutex
+		var wg sync.WaitGroup
+		for name, healthURL := range upstreams {
+			wg.Add(1)
+			go func(name, healthURL string) {
+				defer wg.Done()
+				status := "ok"
+				if err := circuitbreaker.HTTPHealthProbe(client, healthURL)(ctx); err != nil {
+					status = err.Error()
+				}
+				mutex.Lock()
+				details[name] = status
+				mutex.Unlock()
+			}(name, healthURL)
+		}
+		wg.Wait()
+
+		result := Result{Status: StatusHealthy, Details: details}
+		for _, status := range details {
+			if status != "ok" {
+				result.Status = StatusUnhealthy
+			}
+		}
+		return result
+	}
+}
+
+// breakerCheck reports the state of every circuit breaker: unhealthy when one is open,
+// degraded while one is half-open. A breaker kept by several managers reports its
+// worst state.
+func breakerCheck(managers []*circuitbreaker.Manager) Check {
+	return func(ctx context.Context) Result {
+		states := make(map[string]circuitbreaker.State)
+		for _, manager := range managers {
+			for _, name := range manager.ListBreakers() {
+				breaker, ok := manager.GetBreaker(name)
+				if !ok {
+					continue
+				}
+				if state := breaker.GetState(); severity(state) >= severity(states[name]) {
+					states[name] = state
+				}
+			}
+		}
+
+		result := Result{Status: StatusHealthy}
+		details := make(map[string]string, len(states))
+		for name, state := range states {
+			details[name] = state.String()
+			switch state {
+			case circuitbreaker.StateOpen:
+				result.Status = StatusUnhealthy
+			case circuitbreaker.StateHalfOpen:
+				if result.Status == StatusHealthy {
+					result.Status = StatusDegraded
+				}
+			}
+		}
+		result.Details = details
+		return result
+	}
+}
+
+// severity orders breaker states from closed to open
+func severity(state circuitbreaker.State) int {
+	switch state {
+	case circuitbreaker.StateOpen:
+		return 2
+	case circuitbreaker.StateHalfOpen:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/plugins"
+	"go.uber.org/zap"
+)
+
+// statusPlugin is a plugin reporting a fixed health
+type statusPlugin struct {
+	name    string
+	healthy bool
+}
+
+func (p *statusPlugin) Name() string                                   { return p.name }
+func (p *statusPlugin) Type() plugins.PluginType                       { return plugins.PluginTypeProcessor }
+func (p *statusPlugin) Version() string                                { return "1.0.0" }
+func (p *statusPlugin) Description() string                            { return "" }
+func (p *statusPlugin) Initialize(config map[string]interface{}) error { return nil }
+func (p *statusPlugin) Start(ctx context.Context) error                { return nil }
+func (p *statusPlugin) Stop() error                                    { return nil }
+func (p *statusPlugin) Health() plugins.HealthStatus {
+	return plugins.HealthStatus{Healthy: p.healthy, Message: "test"}
+}
+
+func pluginRegistry(t *testing.T, healthy bool) *plugins.Registry {
+	t.Helper()
+	logger := zap.NewNop()
+	registry := plugins.NewRegistry(logger, hooks.NewManager(logger))
+	for _, plugin := range []*statusPlugin{{name: "ok", healthy: true}, {name: "flaky", healthy: healthy}} {
+		if err := registry.Register(plugin); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	return registry
+}
+
+func TestChecker_Plugins(t *testing.T) {
+	tests := []struct {
+		name           string
+		healthy        bool
+		critical       []string
+		expectedStatus Status
+		expectedCode   int
+	}{
+		{"all plugins healthy", true, nil, StatusHealthy, http.StatusOK},
+		{"unhealthy plugin degrades", false, nil, StatusDegraded, http.StatusOK},
+		{"unhealthy critical plugins", false, []string{SubsystemPlugins}, StatusUnhealthy, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(tt.critical)
+			checker.Register(SubsystemPlugins, PluginCheck(pluginRegistry(t, tt.healthy)))
+
+			report := checker.Check(context.Background())
+			if report.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, report.Status)
+			}
+			if report.HTTPStatus() != tt.expectedCode {
+				t.Errorf("Expected HTTP %d, got %d", tt.expectedCode, report.HTTPStatus())
+			}
+
+			plugins := report.Subsystems[SubsystemPlugins]
+			expected := StatusHealthy
+			if !tt.healthy {
+				expected = StatusUnhealthy
+			}
+			if plugins.Status != expected || plugins.Critical != (tt.critical != nil) {
+				t.Errorf("Expected plugins %s (critical %v), got %+v", expected, tt.critical != nil, plugins)
+			}
+		})
+	}
+}
+
+func TestChecker_CircuitBreakers(t *testing.T) {
+	logger := zap.NewNop()
+	binderBreakers := circuitbreaker.NewManager(logger, true)
+	mcpBreakers := circuitbreaker.NewManager(logger, true)
+	config := circuitbreaker.Config{MaxFailures: 1, ResetTimeout: time.Minute, SuccessThreshold: 1, Timeout: time.Second}
+
+	binderBreakers.GetOrCreate("petstore", config)
+	mcpBreakers.Execute("petstore", config, context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("boom")
+	})
+
+	checker := NewChecker([]string{SubsystemCircuitBreakers})
+	checker.AddBreakers(binderBreakers)
+	checker.AddBreakers(mcpBreakers)
+
+	report := checker.Check(context.Background())
+	if report.Status != StatusUnhealthy {
+		t.Errorf("Expected an open critical breaker to make the server unhealthy, got %s", report.Status)
+	}
+	details, _ := report.Subsystems[SubsystemCircuitBreakers].Details.(map[string]string)
+	if details["petstore"] != "open" {
+		t.Errorf("Expected the breaker's worst state to be reported, got %v", details)
+	}
+}
+
+func TestUpstreamCheck(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer up.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	check := UpstreamCheck(http.DefaultClient, map[string]string{"petstore": up.URL, "inventory": down.URL}, time.Second)
+	result := check(context.Background())
+	if result.Status != StatusUnhealthy {
+		t.Errorf("Expected an unreachable upstream to be unhealthy, got %s", result.Status)
+	}
+	details := result.Details.(map[string]string)
+	if details["petstore"] != "ok" || details["inventory"] == "ok" {
+		t.Errorf("Expected per-upstream results, got %v", details)
+	}
+}
@@ -0,0 +1,287 @@
+package hooks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CachedResponseKey is the HookContext.Metadata key a pre-request hook sets to a
+// *ResponseContext to answer the call without reaching the upstream
+const CachedResponseKey = "cachedResponse"
+
+// CallerHeadersKey is the HookContext.Metadata key under which the proxy lists, as a
+// []string, the request headers carrying per-call credentials or the caller's identity
+const CallerHeadersKey = "callerHeaders"
+
+// credentialHeaders always tell callers apart, whoever set them
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// cacheEntry is a stored response and the time it stops being served
+type cacheEntry struct {
+	serviceName string
+	response    ResponseContext
+	expires     time.Time
+}
+
+// ResponseCache keeps successful GET responses keyed by service, method, path, sorted
+// query and caller credentials so repeated identical calls skip the upstream without
+// one caller being served another's response. Its hooks serve hits before the call
+// and store fresh responses after it.
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]cacheEntry
+	mutex      sync.RWMutex
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// NewResponseCache creates a cache keeping responses for ttl unless their
+// Cache-Control max-age says otherwise
+func NewResponseCache(logger *zap.Logger, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// SetMaxEntries caps the number of cached responses; once full, storing a response
+// evicts the expired ones or else the one closest to expiring. Zero means no limit.
+func (c *ResponseCache) SetMaxEntries(maxEntries int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.maxEntries = maxEntries
+}
+
+// Hooks returns the cache's pre-request hook, serving hits after every other
+// pre-request hook has shaped the request, and its post-response hook, storing the
+// upstream response before other post-response hooks change it
+func (c *ResponseCache) Hooks() []Hook {
+	return []Hook{
+		&CacheHook{cache: c, hookType: HookTypePreRequest, priority: PriorityLow},
+		&CacheHook{cache: c, hookType: HookTypePostResponse, priority: PriorityHigh},
+	}
+}
+
+// Invalidate drops the cached responses of serviceName, or every response when
+// serviceName is empty, and returns how many were dropped
+func (c *ResponseCache) Invalidate(serviceName string) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	dropped := 0
+	for key, entry := range c.entries {
+		if serviceName == "" || entry.serviceName == serviceName {
+			delete(c.entries, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// Prune drops the responses expired at now and returns how many were dropped
+func (c *ResponseCache) Prune(now time.Time) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.pruneLocked(now)
+}
+
+// pruneLocked drops the responses expired at now; the caller holds the write lock
+func (c *ResponseCache) pruneLocked(now time.Time) int {
+	dropped := 0
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// lookup returns a copy of the fresh response cached for hookCtx's request
+func (c *ResponseCache) lookup(hookCtx *HookContext) (*ResponseContext, bool) {
+	key := cacheKey(hookCtx)
+	c.mutex.RLock()
+	entry, ok := c.entries[key]
+	c.mutex.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(entry.expires) {
+		c.mutex.Lock()
+		if current, ok := c.entries[key]; ok && !c.now().Before(current.expires) {
+			delete(c.entries, key)
+		}
+		c.mutex.Unlock()
+		return nil, false
+	}
+
+	response := copyResponse(entry.response)
+	return &response, true
+}
+
+// store keeps a copy of the response to hookCtx's request unless the upstream forbids it
+func (c *ResponseCache) store(hookCtx *HookContext) {
+	resp := hookCtx.Response
+	ttl, ok := cacheTTL(resp.Headers["Cache-Control"], c.ttl)
+	if !ok {
+		return
+	}
+
+	key := cacheKey(hookCtx)
+	now := c.now()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, exists := c.entries[key]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+	c.entries[key] = cacheEntry{
+		serviceName: hookCtx.Request.ServiceName,
+		response:    copyResponse(*resp),
+		expires:     now.Add(ttl),
+	}
+}
+
+// evictLocked makes room for one entry, dropping the expired responses or, when none
+// has expired, the one closest to expiring; the caller holds the write lock
+func (c *ResponseCache) evictLocked(now time.Time) {
+	if c.pruneLocked(now) > 0 {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	for key, entry := range c.entries {
+		if oldestKey == "" || entry.expires.Before(oldest) {
+			oldestKey, oldest = key, entry.expires
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// CacheHook is one side of a ResponseCache: before the upstream call it places a
+// cached response in the hook metadata, after it it stores the upstream response
+type CacheHook struct {
+	cache    *ResponseCache
+	hookType HookType
+	priority Priority
+}
+
+func (h *CacheHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	if hookCtx.Request == nil || hookCtx.Request.Method != http.MethodGet {
+		return nil
+	}
+
+	switch h.hookType {
+	case HookTypePreRequest:
+		if response, ok := h.cache.lookup(hookCtx); ok {
+			hookCtx.Metadata[CachedResponseKey] = response
+			h.cache.logger.Debug("Serving response from cache",
+				zap.String("service", hookCtx.Request.ServiceName),
+				zap.String("operationID", hookCtx.Request.OperationID))
+		}
+	case HookTypePostResponse:
+		// A response served from the cache is already stored
+		if _, hit := hookCtx.Metadata[CachedResponseKey]; hit {
+			return nil
+		}
+		response := hookCtx.Response
+		if response == nil || response.Error != nil || response.StatusCode < 200 || response.StatusCode >= 300 {
+			return nil
+		}
+		h.cache.store(hookCtx)
+	}
+	return nil
+}
+
+func (h *CacheHook) Type() HookType {
+	return h.hookType
+}
+
+func (h *CacheHook) Priority() Priority {
+	return h.priority
+}
+
+func (h *CacheHook) Name() string {
+	return "response-cache-" + string(h.hookType)
+}
+
+// cacheKey identifies a request by service, method, path, query, the query encoded
+// with its keys sorted, and caller
+func cacheKey(hookCtx *HookContext) string {
+	req := hookCtx.Request
+	return strings.Join([]string{req.ServiceName, req.Method, req.Path, url.Values(req.QueryParams).Encode(), callerDigest(hookCtx)}, " ")
+}
+
+// callerDigest hashes the values of the credential headers and of the headers the
+// proxy listed under CallerHeadersKey, so responses are only shared between calls
+// made with the same credentials and identity, without keeping them in the key
+func callerDigest(hookCtx *HookContext) string {
+	names := append([]string(nil), credentialHeaders...)
+	if callerHeaders, ok := hookCtx.Metadata[CallerHeadersKey].([]string); ok {
+		names = append(names, callerHeaders...)
+	}
+	for i, name := range names {
+		names[i] = http.CanonicalHeaderKey(name)
+	}
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	hash := sha256.New()
+	found := false
+	for _, name := range names {
+		for header, value := range hookCtx.Request.Headers {
+			if http.CanonicalHeaderKey(header) == name {
+				hash.Write([]byte(name + ": " + value + "\n"))
+				found = true
+			}
+		}
+	}
+	if !found {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// cacheTTL returns how long a response with the given Cache-Control header may be
+// served, or false when it must not be cached
+func cacheTTL(cacheControl string, defaultTTL time.Duration) (time.Duration, bool) {
+	ttl := defaultTTL
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store", "no-cache", "private":
+			return 0, false
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err == nil {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return ttl, ttl > 0
+}
+
+// copyResponse copies resp's headers and body so cached entries are not changed by
+// the hooks handling the response they were taken from or served to
+func copyResponse(resp ResponseContext) ResponseContext {
+	headers := make(map[string]string, len(resp.Headers))
+	for name, value := range resp.Headers {
+		headers[name] = value
+	}
+	resp.Headers = headers
+	resp.Body = append([]byte(nil), resp.Body...)
+	return resp
+}
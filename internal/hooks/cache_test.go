@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// cacheCall runs a request through the cache's pre-request hook and, on a miss,
+// stores the response through its post-response hook. It reports whether the
+// response came from the cache.
+func cacheCall(t *testing.T, cache *ResponseCache, req *RequestContext, resp *ResponseContext) (*ResponseContext, bool) {
+	t.Helper()
+	return cacheCallAs(t, cache, req, nil, resp)
+}
+
+// cacheCallAs is cacheCall for a request whose callerHeaders identify the caller
+func cacheCallAs(t *testing.T, cache *ResponseCache, req *RequestContext, callerHeaders []string, resp *ResponseContext) (*ResponseContext, bool) {
+	t.Helper()
+	hooks := cache.Hooks()
+	hookCtx := &HookContext{Request: req, Metadata: make(map[string]interface{})}
+	if callerHeaders != nil {
+		hookCtx.Metadata[CallerHeadersKey] = callerHeaders
+	}
+	if err := hooks[0].Execute(context.Background(), hookCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if cached, ok := hookCtx.Metadata[CachedResponseKey].(*ResponseContext); ok {
+		return cached, true
+	}
+
+	hookCtx.Response = resp
+	if err := hooks[1].Execute(context.Background(), hookCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	return resp, false
+}
+
+func TestResponseCache(t *testing.T) {
+	listPets := func(query map[string][]string) *RequestContext {
+		return &RequestContext{ServiceName: "petstore", Method: http.MethodGet, Path: "/pets", QueryParams: query}
+	}
+	ok := func(cacheControl string) *ResponseContext {
+		return &ResponseContext{StatusCode: http.StatusOK, Headers: map[string]string{"Cache-Control": cacheControl}, Body: []byte(`[]`)}
+	}
+
+	t.Run("query order does not matter", func(t *testing.T) {
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cacheCall(t, cache, listPets(map[string][]string{"limit": {"10"}, "tag": {"dog"}}), ok(""))
+		resp, hit := cacheCall(t, cache, listPets(map[string][]string{"tag": {"dog"}, "limit": {"10"}}), ok(""))
+		if !hit || string(resp.Body) != `[]` {
+			t.Errorf("Expected a cache hit, got hit=%v body %q", hit, resp.Body)
+		}
+		if _, hit := cacheCall(t, cache, listPets(map[string][]string{"limit": {"20"}}), ok("")); hit {
+			t.Error("Expected a different query to miss")
+		}
+	})
+
+	t.Run("no-store and errors are not cached", func(t *testing.T) {
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cacheCall(t, cache, listPets(nil), ok("no-store"))
+		cacheCall(t, cache, listPets(map[string][]string{"page": {"2"}}), &ResponseContext{StatusCode: http.StatusInternalServerError})
+		if len(cache.entries) != 0 {
+			t.Errorf("Expected nothing cached, got %d entries", len(cache.entries))
+		}
+	})
+
+	t.Run("max-age overrides the default TTL", func(t *testing.T) {
+		now := time.Now()
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cache.now = func() time.Time { return now }
+		cacheCall(t, cache, listPets(nil), ok("public, max-age=5"))
+
+		now = now.Add(10 * time.Second)
+		if _, hit := cacheCall(t, cache, listPets(nil), ok("")); hit {
+			t.Error("Expected the response to expire after max-age")
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cacheCall(t, cache, listPets(nil), ok(""))
+		cacheCall(t, cache, &RequestContext{ServiceName: "inventory", Method: http.MethodGet, Path: "/items"}, ok(""))
+
+		if dropped := cache.Invalidate("petstore"); dropped != 1 {
+			t.Errorf("Expected 1 entry dropped, got %d", dropped)
+		}
+		if _, hit := cacheCall(t, cache, listPets(nil), ok("")); hit {
+			t.Error("Expected the invalidated response to miss")
+		}
+		if dropped := cache.Invalidate(""); dropped != 2 {
+			t.Errorf("Expected every entry dropped, got %d", dropped)
+		}
+	})
+	t.Run("callers do not share responses", func(t *testing.T) {
+		as := func(headers map[string]string) *RequestContext {
+			req := listPets(nil)
+			req.Headers = headers
+			return req
+		}
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cacheCall(t, cache, as(map[string]string{"Authorization": "Bearer alice"}), ok(""))
+
+		if _, hit := cacheCall(t, cache, as(map[string]string{"Authorization": "Bearer bob"}), ok("")); hit {
+			t.Error("Expected other credentials to miss")
+		}
+		if _, hit := cacheCall(t, cache, as(nil), ok("")); hit {
+			t.Error("Expected an anonymous call to miss")
+		}
+		if _, hit := cacheCall(t, cache, as(map[string]string{"authorization": "Bearer alice"}), ok("")); !hit {
+			t.Error("Expected the same credentials to hit")
+		}
+
+		identity := []string{"X-User-Id"}
+		cacheCallAs(t, cache, as(map[string]string{"X-User-Id": "alice"}), identity, ok(""))
+		if _, hit := cacheCallAs(t, cache, as(map[string]string{"X-User-Id": "bob"}), identity, ok("")); hit {
+			t.Error("Expected another identity to miss")
+		}
+		for key := range cache.entries {
+			if strings.Contains(key, "alice") {
+				t.Errorf("Expected credentials to be hashed, got key %q", key)
+			}
+		}
+	})
+
+	t.Run("size cap and prune", func(t *testing.T) {
+		now := time.Now()
+		cache := NewResponseCache(zap.NewNop(), time.Minute)
+		cache.now = func() time.Time { return now }
+		cache.SetMaxEntries(2)
+
+		cacheCall(t, cache, listPets(map[string][]string{"page": {"1"}}), ok("max-age=10"))
+		cacheCall(t, cache, listPets(map[string][]string{"page": {"2"}}), ok("max-age=30"))
+		cacheCall(t, cache, listPets(map[string][]string{"page": {"3"}}), ok(""))
+		if len(cache.entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(cache.entries))
+		}
+		if _, hit := cacheCall(t, cache, listPets(map[string][]string{"page": {"1"}}), ok("max-age=10")); hit {
+			t.Error("Expected the entry closest to expiring to be evicted")
+		}
+
+		// Page 1 is cached again for 10 seconds, evicting page 2
+		now = now.Add(45 * time.Second)
+		if pruned := cache.Prune(now); pruned != 1 {
+			t.Errorf("Expected 1 expired entry pruned, got %d", pruned)
+		}
+		if len(cache.entries) != 1 {
+			t.Errorf("Expected 1 entry left, got %d", len(cache.entries))
+		}
+	})
+}
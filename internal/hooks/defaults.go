@@ -0,0 +1,136 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"mime"
+)
+
+// BodyDefaultsHook adds constant fields, such as an API version an upstream expects on
+// every call, to JSON request bodies. Fields are set for all of a service's operations
+// or for a single one, the latter taking precedence, and only where the body does not
+// already have them, so values supplied by the client are kept.
+type BodyDefaultsHook struct {
+	priority Priority
+	services map[string]map[string]interface{}            // service -> fields for every operation
+	routes   map[string]map[string]map[string]interface{} // service -> operation ID -> fields
+}
+
+// NewBodyDefaultsHook creates a hook without defaults
+func NewBodyDefaultsHook(priority Priority) *BodyDefaultsHook {
+	return &BodyDefaultsHook{
+		priority: priority,
+		services: make(map[string]map[string]interface{}),
+		routes:   make(map[string]map[string]map[string]interface{}),
+	}
+}
+
+// SetDefaults adds fields to the defaults of serviceName's operation operationID, or
+// of all its operations when operationID is empty
+func (h *BodyDefaultsHook) SetDefaults(serviceName, operationID string, fields map[string]interface{}) {
+	if operationID == "" {
+		h.services[serviceName] = mergeFields(h.services[serviceName], fields)
+		return
+	}
+	if h.routes[serviceName] == nil {
+		h.routes[serviceName] = make(map[string]map[string]interface{})
+	}
+	h.routes[serviceName][operationID] = mergeFields(h.routes[serviceName][operationID], fields)
+}
+
+// ParseBodyDefaults decodes fields written as a JSON object, e.g. {"apiVersion": "v2"}
+func ParseBodyDefaults(fields string) (map[string]interface{}, error) {
+	var defaults map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(fields)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&defaults); err != nil || defaults == nil {
+		return nil, fmt.Errorf("fields must be a JSON object: %q", fields)
+	}
+	return defaults, nil
+}
+
+func (h *BodyDefaultsHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	req := hookCtx.Request
+	if req == nil || len(req.Body) == 0 || !isJSONContent(req.Headers) {
+		return nil
+	}
+	if len(h.Defaults(req.ServiceName, req.OperationID)) == 0 {
+		return nil
+	}
+
+	// Bodies other than objects have no fields to fill in
+	var body map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(req.Body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&body); err != nil || body == nil {
+		return nil
+	}
+	if !h.Fill(req.ServiceName, req.OperationID, body) {
+		return nil
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+	req.Body = data
+	return nil
+}
+
+// Fill adds the defaults of serviceName's operation operationID that body lacks,
+// reporting whether it added any. Callers that build bodies themselves, such as MCP
+// tools validating their arguments, use it to fill them in ahead of the hook.
+func (h *BodyDefaultsHook) Fill(serviceName, operationID string, body map[string]interface{}) bool {
+	added := false
+	for name, value := range h.Defaults(serviceName, operationID) {
+		if _, exists := body[name]; !exists {
+			body[name] = value
+			added = true
+		}
+	}
+	return added
+}
+
+// Defaults returns the fields added to the bodies of serviceName's operation operationID
+func (h *BodyDefaultsHook) Defaults(serviceName, operationID string) map[string]interface{} {
+	operation := h.routes[serviceName][operationID]
+	if len(operation) == 0 {
+		return h.services[serviceName]
+	}
+	return mergeFields(maps.Clone(h.services[serviceName]), operation)
+}
+
+// AppliesTo reports whether any defaults are set for serviceName
+func (h *BodyDefaultsHook) AppliesTo(serviceName string) bool {
+	return len(h.services[serviceName]) > 0 || len(h.routes[serviceName]) > 0
+}
+
+func (h *BodyDefaultsHook) Type() HookType {
+	return HookTypePreRequest
+}
+
+func (h *BodyDefaultsHook) Priority() Priority {
+	return h.priority
+}
+
+func (h *BodyDefaultsHook) Name() string {
+	return "body-defaults"
+}
+
+// mergeFields copies fields into dst, allocating it when nil, and returns it
+func mergeFields(dst, fields map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(fields))
+	}
+	maps.Copy(dst, fields)
+	return dst
+}
+
+// isJSONContent reports whether headers declare an application/json body
+func isJSONContent(headers map[string]string) bool {
+	mediaType, _, err := mime.ParseMediaType(headers["Content-Type"])
+	return err == nil && mediaType == "application/json"
+}
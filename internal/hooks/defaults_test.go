@@ -0,0 +1,107 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestBodyDefaultsHook(t *testing.T) {
+	hook := NewBodyDefaultsHook(PriorityHigh)
+	hook.SetDefaults("billing", "", map[string]interface{}{"apiVersion": "2024-01", "source": "mcp"})
+	hook.SetDefaults("billing", "createInvoice", map[string]interface{}{"source": "mcp-invoices", "channel": "api"})
+
+	tests := []struct {
+		name        string
+		operationID string
+		contentType string
+		body        string
+		expected    string
+	}{
+		{
+			name:        "defaults injected",
+			operationID: "createCustomer",
+			contentType: "application/json",
+			body:        `{"name": "Acme"}`,
+			expected:    `{"apiVersion":"2024-01","name":"Acme","source":"mcp"}`,
+		},
+		{
+			name:        "client values kept",
+			operationID: "createCustomer",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"apiVersion": "2023-06", "amount": 12345678901234567890}`,
+			expected:    `{"amount":12345678901234567890,"apiVersion":"2023-06","source":"mcp"}`,
+		},
+		{
+			name:        "body with every field untouched",
+			operationID: "createCustomer",
+			contentType: "application/json",
+			body:        `{"apiVersion": "2023-06", "source": null}`,
+			expected:    `{"apiVersion": "2023-06", "source": null}`,
+		},
+		{
+			name:        "operation defaults take precedence",
+			operationID: "createInvoice",
+			contentType: "application/json",
+			body:        `{}`,
+			expected:    `{"apiVersion":"2024-01","channel":"api","source":"mcp-invoices"}`,
+		},
+		{
+			name:        "non-JSON body untouched",
+			operationID: "createCustomer",
+			contentType: "application/x-www-form-urlencoded",
+			body:        `name=Acme`,
+			expected:    `name=Acme`,
+		},
+		{
+			name:        "JSON array untouched",
+			operationID: "createCustomer",
+			contentType: "application/json",
+			body:        `[1, 2]`,
+			expected:    `[1, 2]`,
+		},
+		{
+			name:        "empty body untouched",
+			operationID: "createCustomer",
+			contentType: "application/json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookCtx := &HookContext{Request: &RequestContext{
+				ServiceName: "billing",
+				OperationID: tt.operationID,
+				Headers:     map[string]string{"Content-Type": tt.contentType},
+				Body:        []byte(tt.body),
+			}}
+			if err := hook.Execute(context.Background(), hookCtx); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if string(hookCtx.Request.Body) != tt.expected {
+				t.Errorf("Expected body %s, got %s", tt.expected, hookCtx.Request.Body)
+			}
+		})
+	}
+
+	if !hook.AppliesTo("billing") || hook.AppliesTo("petstore") {
+		t.Errorf("Expected the hook to apply only to services with defaults")
+	}
+}
+
+func TestParseBodyDefaults(t *testing.T) {
+	fields, err := ParseBodyDefaults(`{"apiVersion": "v2", "limits": {"maxItems": 10}}`)
+	if err != nil {
+		t.Fatalf("ParseBodyDefaults() error = %v", err)
+	}
+	data, _ := json.Marshal(fields)
+	if string(data) != `{"apiVersion":"v2","limits":{"maxItems":10}}` {
+		t.Errorf("Expected field names to keep their case, got %s", data)
+	}
+
+	for _, invalid := range []string{"", "[1]", "null", "apiVersion: v2"} {
+		if _, err := ParseBodyDefaults(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+}
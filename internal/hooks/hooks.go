@@ -4,8 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/zeroLR/swagger-mcp-go/internal/redact"
 	"go.uber.org/zap"
 )
 
@@ -38,6 +44,9 @@ type RequestContext struct {
 	Body        []byte                 `json:"body,omitempty"`
 	Parameters  map[string]interface{} `json:"parameters"`
 	StartTime   time.Time              `json:"startTime"`
+
+	// Route locates the operation in its spec, when known, for validation hooks
+	Route *routers.Route `json:"-"`
 }
 
 // ResponseContext contains information about the response
@@ -69,9 +78,27 @@ type Hook interface {
 	Name() string
 }
 
+// ServiceScopedHook is implemented by hooks that only run for some services. Hooks
+// that don't implement it run for every service.
+type ServiceScopedHook interface {
+	Hook
+	// AppliesTo reports whether the hook runs for requests to serviceName
+	AppliesTo(serviceName string) bool
+}
+
+// appliesTo reports whether hook runs for the request in hookCtx
+func appliesTo(hook Hook, hookCtx *HookContext) bool {
+	scoped, ok := hook.(ServiceScopedHook)
+	if !ok || hookCtx.Request == nil {
+		return true
+	}
+	return scoped.AppliesTo(hookCtx.Request.ServiceName)
+}
+
 // Manager manages request/response hooks
 type Manager struct {
 	hooks  map[HookType][]Hook
+	mutex  sync.RWMutex
 	logger *zap.Logger
 }
 
@@ -89,8 +116,12 @@ func NewManager(logger *zap.Logger) *Manager {
 
 // RegisterHook registers a hook with the manager
 func (m *Manager) RegisterHook(hook Hook) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	hookType := hook.Type()
-	m.hooks[hookType] = append(m.hooks[hookType], hook)
+	hooks := make([]Hook, 0, len(m.hooks[hookType])+1)
+	m.hooks[hookType] = append(append(hooks, m.hooks[hookType]...), hook)
 
 	// Sort hooks by priority (highest first)
 	m.sortHooksByPriority(hookType)
@@ -101,6 +132,32 @@ func (m *Manager) RegisterHook(hook Hook) {
 		zap.Int("priority", int(hook.Priority())))
 }
 
+// UnregisterHook removes every hook named name, of any type, and reports whether one
+// was registered. The remaining hooks keep their priority order.
+func (m *Manager) UnregisterHook(name string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	removed := false
+	for hookType, hooks := range m.hooks {
+		// Build a new slice so executions already holding the old one are unaffected
+		remaining := make([]Hook, 0, len(hooks))
+		for _, hook := range hooks {
+			if hook.Name() == name {
+				removed = true
+				continue
+			}
+			remaining = append(remaining, hook)
+		}
+		m.hooks[hookType] = remaining
+	}
+
+	if removed {
+		m.logger.Info("Unregistered hook", zap.String("name", name))
+	}
+	return removed
+}
+
 // ExecutePreRequestHooks executes all pre-request hooks
 func (m *Manager) ExecutePreRequestHooks(ctx context.Context, hookCtx *HookContext) error {
 	return m.executeHooks(ctx, HookTypePreRequest, hookCtx)
@@ -118,9 +175,15 @@ func (m *Manager) ExecuteErrorHooks(ctx context.Context, hookCtx *HookContext) e
 
 // executeHooks executes all hooks of a given type
 func (m *Manager) executeHooks(ctx context.Context, hookType HookType, hookCtx *HookContext) error {
+	m.mutex.RLock()
 	hooks := m.hooks[hookType]
+	m.mutex.RUnlock()
 
 	for _, hook := range hooks {
+		if !appliesTo(hook, hookCtx) {
+			continue
+		}
+
 		start := time.Now()
 		err := hook.Execute(ctx, hookCtx)
 		duration := time.Since(start)
@@ -143,22 +206,24 @@ func (m *Manager) executeHooks(ctx context.Context, hookType HookType, hookCtx *
 	return nil
 }
 
-// sortHooksByPriority sorts hooks by priority (highest first)
+// sortHooksByPriority sorts hooks by priority (highest first), then by name, so hooks
+// of equal priority run in a deterministic order. Hooks sharing both keep their
+// registration order.
 func (m *Manager) sortHooksByPriority(hookType HookType) {
 	hooks := m.hooks[hookType]
-
-	// Simple bubble sort by priority
-	for i := 0; i < len(hooks)-1; i++ {
-		for j := 0; j < len(hooks)-i-1; j++ {
-			if hooks[j].Priority() < hooks[j+1].Priority() {
-				hooks[j], hooks[j+1] = hooks[j+1], hooks[j]
-			}
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].Priority() != hooks[j].Priority() {
+			return hooks[i].Priority() > hooks[j].Priority()
 		}
-	}
+		return hooks[i].Name() < hooks[j].Name()
+	})
 }
 
 // GetRegisteredHooks returns all registered hooks
 func (m *Manager) GetRegisteredHooks() map[HookType][]Hook {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
 	result := make(map[HookType][]Hook)
 	for hookType, hooks := range m.hooks {
 		result[hookType] = make([]Hook, len(hooks))
@@ -169,43 +234,72 @@ func (m *Manager) GetRegisteredHooks() map[HookType][]Hook {
 
 // Built-in hooks
 
-// LoggingHook logs request and response information
+// LoggingHook logs request and response information. At debug level it also logs
+// headers and JSON bodies, with sensitive values masked by its redactor.
 type LoggingHook struct {
 	logger   *zap.Logger
 	priority Priority
+	redactor *redact.Redactor
 }
 
-// NewLoggingHook creates a new logging hook
+// NewLoggingHook creates a new logging hook redacting redact.DefaultHeaders
 func NewLoggingHook(logger *zap.Logger, priority Priority) *LoggingHook {
 	return &LoggingHook{
 		logger:   logger,
 		priority: priority,
+		redactor: redact.New(nil, nil),
 	}
 }
 
+// SetRedactor masks the headers and body fields chosen by redactor in logged requests
+// and responses
+func (h *LoggingHook) SetRedactor(redactor *redact.Redactor) {
+	h.redactor = redactor
+}
+
 func (h *LoggingHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	serviceName := hookCtx.Request.ServiceName
 	if hookCtx.Response != nil {
 		// Post-response logging
-		h.logger.Info("Request completed",
-			zap.String("service", hookCtx.Request.ServiceName),
+		fields := []zap.Field{
+			zap.String("service", serviceName),
 			zap.String("operation", hookCtx.Request.OperationID),
 			zap.String("method", hookCtx.Request.Method),
 			zap.String("path", hookCtx.Request.Path),
 			zap.Int("statusCode", hookCtx.Response.StatusCode),
 			zap.Duration("responseTime", hookCtx.Response.ResponseTime),
-			zap.String("upstreamUrl", hookCtx.Response.UpstreamURL))
+			zap.String("upstreamUrl", hookCtx.Response.UpstreamURL),
+		}
+		h.logger.Info("Request completed", append(fields, h.payloadFields(serviceName, hookCtx.Response.Headers, hookCtx.Response.Body)...)...)
 	} else {
 		// Pre-request logging
-		h.logger.Info("Processing request",
-			zap.String("service", hookCtx.Request.ServiceName),
+		fields := []zap.Field{
+			zap.String("service", serviceName),
 			zap.String("operation", hookCtx.Request.OperationID),
 			zap.String("method", hookCtx.Request.Method),
 			zap.String("path", hookCtx.Request.Path),
-			zap.Int("paramCount", len(hookCtx.Request.Parameters)))
+			zap.Int("paramCount", len(hookCtx.Request.Parameters)),
+		}
+		h.logger.Info("Processing request", append(fields, h.payloadFields(serviceName, hookCtx.Request.Headers, hookCtx.Request.Body)...)...)
 	}
 	return nil
 }
 
+// payloadFields returns the redacted headers and JSON body to log at debug level
+func (h *LoggingHook) payloadFields(serviceName string, headers map[string]string, body []byte) []zap.Field {
+	if !h.logger.Core().Enabled(zap.DebugLevel) {
+		return nil
+	}
+
+	fields := []zap.Field{zap.Any("headers", h.redactor.Headers(serviceName, headers))}
+	if len(body) > 0 {
+		if document, ok := h.redactor.Body(serviceName, body); ok {
+			fields = append(fields, zap.Any("body", document))
+		}
+	}
+	return fields
+}
+
 func (h *LoggingHook) Type() HookType {
 	return HookTypePreRequest // This hook can be registered for multiple types
 }
@@ -218,6 +312,23 @@ func (h *LoggingHook) Name() string {
 	return "logging"
 }
 
+// operationResponses counts responses seen by MetricsHook, labelled by service, operation and status
+var operationResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "swagger_mcp_operation_responses_total",
+	Help: "Number of upstream responses per operation, recorded by the metrics hook.",
+}, []string{"service", "operation", "status"})
+
+// operationDuration observes response times seen by MetricsHook, labelled by service and operation
+var operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "swagger_mcp_operation_response_duration_seconds",
+	Help:    "Upstream response time per operation, recorded by the metrics hook.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"service", "operation"})
+
+func init() {
+	prometheus.MustRegister(operationResponses, operationDuration)
+}
+
 // MetricsHook collects metrics about requests and responses
 type MetricsHook struct {
 	priority Priority
@@ -241,8 +352,12 @@ func (h *MetricsHook) Execute(ctx context.Context, hookCtx *HookContext) error {
 			zap.Int("statusCode", hookCtx.Response.StatusCode),
 			zap.Duration("responseTime", hookCtx.Response.ResponseTime))
 
-		// TODO: Integrate with Prometheus metrics
-		// This would increment counters, update histograms, etc.
+		status := "error"
+		if hookCtx.Response.StatusCode > 0 {
+			status = strconv.Itoa(hookCtx.Response.StatusCode)
+		}
+		operationResponses.WithLabelValues(hookCtx.Request.ServiceName, hookCtx.Request.OperationID, status).Inc()
+		operationDuration.WithLabelValues(hookCtx.Request.ServiceName, hookCtx.Request.OperationID).Observe(hookCtx.Response.ResponseTime.Seconds())
 	}
 	return nil
 }
@@ -307,48 +422,6 @@ func (h *SecurityHeadersHook) Name() string {
 	return "security-headers"
 }
 
-// RequestValidationHook validates request parameters and body
-type RequestValidationHook struct {
-	priority Priority
-	logger   *zap.Logger
-}
-
-// NewRequestValidationHook creates a new request validation hook
-func NewRequestValidationHook(logger *zap.Logger, priority Priority) *RequestValidationHook {
-	return &RequestValidationHook{
-		priority: priority,
-		logger:   logger,
-	}
-}
-
-func (h *RequestValidationHook) Execute(ctx context.Context, hookCtx *HookContext) error {
-	// Validate request parameters
-	if hookCtx.Request.Parameters == nil {
-		return fmt.Errorf("missing request parameters")
-	}
-
-	// Basic validation - check for required parameters
-	// TODO: Implement proper OpenAPI schema validation
-	h.logger.Debug("Validating request",
-		zap.String("service", hookCtx.Request.ServiceName),
-		zap.String("operation", hookCtx.Request.OperationID),
-		zap.Int("paramCount", len(hookCtx.Request.Parameters)))
-
-	return nil
-}
-
-func (h *RequestValidationHook) Type() HookType {
-	return HookTypePreRequest
-}
-
-func (h *RequestValidationHook) Priority() Priority {
-	return h.priority
-}
-
-func (h *RequestValidationHook) Name() string {
-	return "request-validation"
-}
-
 // ErrorHandlingHook handles and formats errors
 type ErrorHandlingHook struct {
 	priority Priority
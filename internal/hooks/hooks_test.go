@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/zeroLR/swagger-mcp-go/internal/redact"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 // Test hook implementation
@@ -37,6 +41,26 @@ func (h *testHook) Name() string {
 	return h.name
 }
 
+// recordingHook appends its name, and tag when set, to order when executed
+type recordingHook struct {
+	name     string
+	tag      string
+	priority Priority
+	order    *[]string
+}
+
+func (h *recordingHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	entry := h.name
+	if h.tag != "" {
+		entry += "/" + h.tag
+	}
+	*h.order = append(*h.order, entry)
+	return nil
+}
+func (h *recordingHook) Type() HookType     { return HookTypePreRequest }
+func (h *recordingHook) Priority() Priority { return h.priority }
+func (h *recordingHook) Name() string       { return h.name }
+
 func TestManager_RegisterHook(t *testing.T) {
 	logger := zap.NewNop()
 	manager := NewManager(logger)
@@ -115,6 +139,99 @@ func TestManager_ExecutePreRequestHooks(t *testing.T) {
 	}
 }
 
+func TestManager_EqualPriorityOrder(t *testing.T) {
+	manager := NewManager(zap.NewNop())
+
+	var order []string
+	for _, name := range []string{"transform", "audit", "validation", "metrics"} {
+		manager.RegisterHook(&recordingHook{name: name, priority: PriorityMedium, order: &order})
+	}
+	manager.RegisterHook(&recordingHook{name: "security", priority: PriorityHigh, order: &order})
+	manager.RegisterHook(&recordingHook{name: "audit", priority: PriorityMedium, order: &order, tag: "second"})
+
+	hookCtx := &HookContext{Request: &RequestContext{}, Metadata: make(map[string]interface{})}
+	for i := 0; i < 3; i++ {
+		order = nil
+		if err := manager.ExecutePreRequestHooks(context.Background(), hookCtx); err != nil {
+			t.Fatalf("ExecutePreRequestHooks() error = %v", err)
+		}
+
+		expected := []string{"security", "audit", "audit/second", "metrics", "transform", "validation"}
+		if strings.Join(order, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected execution order %v, got %v", expected, order)
+		}
+	}
+}
+
+func TestManager_UnregisterHook(t *testing.T) {
+	manager := NewManager(zap.NewNop())
+
+	kept := &testHook{name: "kept", hookType: HookTypePreRequest, priority: PriorityLow}
+	removed := &testHook{name: "removed", hookType: HookTypePreRequest, priority: PriorityHigh}
+	manager.RegisterHook(kept)
+	manager.RegisterHook(removed)
+
+	if !manager.UnregisterHook("removed") {
+		t.Errorf("Expected the registered hook to be removed")
+	}
+	if manager.UnregisterHook("missing") {
+		t.Errorf("Expected removing an unknown hook to report false")
+	}
+
+	hookCtx := &HookContext{Request: &RequestContext{}, Metadata: make(map[string]interface{})}
+	if err := manager.ExecutePreRequestHooks(context.Background(), hookCtx); err != nil {
+		t.Fatalf("ExecutePreRequestHooks() error = %v", err)
+	}
+	if !kept.executed || removed.executed {
+		t.Errorf("Expected only the remaining hook to execute, got kept=%v removed=%v", kept.executed, removed.executed)
+	}
+	if hooks := manager.GetRegisteredHooks()[HookTypePreRequest]; len(hooks) != 1 || hooks[0].Name() != "kept" {
+		t.Errorf("Expected only the kept hook registered, got %d hooks", len(hooks))
+	}
+}
+
+// scopedHook is a testHook that only runs for service
+type scopedHook struct {
+	testHook
+	service string
+}
+
+func (h *scopedHook) AppliesTo(serviceName string) bool {
+	return serviceName == h.service
+}
+
+func TestManager_ServiceScopedHooks(t *testing.T) {
+	tests := []struct {
+		serviceName    string
+		expectScoped   bool
+		expectUnscoped bool
+	}{
+		{"petstore", true, true},
+		{"inventory", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serviceName, func(t *testing.T) {
+			manager := NewManager(zap.NewNop())
+			scoped := &scopedHook{testHook: testHook{name: "scoped", hookType: HookTypePreRequest}, service: "petstore"}
+			unscoped := &testHook{name: "unscoped", hookType: HookTypePreRequest}
+			manager.RegisterHook(scoped)
+			manager.RegisterHook(unscoped)
+
+			hookCtx := &HookContext{Request: &RequestContext{ServiceName: tt.serviceName}, Metadata: make(map[string]interface{})}
+			if err := manager.ExecutePreRequestHooks(context.Background(), hookCtx); err != nil {
+				t.Fatalf("ExecutePreRequestHooks() error = %v", err)
+			}
+			if scoped.executed != tt.expectScoped {
+				t.Errorf("Expected scoped hook executed=%v, got %v", tt.expectScoped, scoped.executed)
+			}
+			if unscoped.executed != tt.expectUnscoped {
+				t.Errorf("Expected unscoped hook executed=%v, got %v", tt.expectUnscoped, unscoped.executed)
+			}
+		})
+	}
+}
+
 func TestLoggingHook(t *testing.T) {
 	logger := zap.NewNop()
 	hook := NewLoggingHook(logger, PriorityMedium)
@@ -161,6 +278,61 @@ func TestLoggingHook(t *testing.T) {
 	}
 }
 
+func TestLoggingHook_Redaction(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	hook := NewLoggingHook(zap.New(core), PriorityMedium)
+	hook.SetRedactor(redact.New(nil, []string{"password"}))
+
+	hookCtx := &HookContext{
+		Request: &RequestContext{
+			ServiceName: "accounts",
+			OperationID: "login",
+			Method:      "POST",
+			Path:        "/login",
+			Headers:     map[string]string{"Authorization": "Basic c2VjcmV0", "Content-Type": "application/json"},
+			Body:        []byte(`{"user": "ann", "password": "hunter2"}`),
+		},
+		Metadata: make(map[string]interface{}),
+	}
+	if err := hook.Execute(context.Background(), hookCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	hookCtx.Response = &ResponseContext{
+		StatusCode: 200,
+		Headers:    map[string]string{"Set-Cookie": "session=abc"},
+	}
+	if err := hook.Execute(context.Background(), hookCtx); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("Expected two log entries, got %d", len(entries))
+	}
+
+	request := entries[0].ContextMap()
+	headers, _ := request["headers"].(map[string]string)
+	if headers["Authorization"] != "***" || headers["Content-Type"] != "application/json" {
+		t.Errorf("Expected only Authorization to be redacted, got %v", headers)
+	}
+	body, _ := request["body"].(map[string]interface{})
+	if body["password"] != "***" || body["user"] != "ann" {
+		t.Errorf("Expected the password to be redacted, got %v", request["body"])
+	}
+
+	response, _ := entries[1].ContextMap()["headers"].(map[string]string)
+	if response["Set-Cookie"] != "***" {
+		t.Errorf("Expected Set-Cookie to be redacted, got %v", response)
+	}
+
+	// Headers and bodies are left out above debug level
+	core, logs = observer.New(zapcore.InfoLevel)
+	NewLoggingHook(zap.New(core), PriorityMedium).Execute(context.Background(), &HookContext{Request: hookCtx.Request})
+	if fields := logs.All()[0].ContextMap(); fields["headers"] != nil || fields["body"] != nil {
+		t.Errorf("Expected no headers or body at info level, got %v", fields)
+	}
+}
+
 func TestMetricsHook(t *testing.T) {
 	logger := zap.NewNop()
 	hook := NewMetricsHook(logger, PriorityLow)
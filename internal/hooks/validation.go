@@ -0,0 +1,431 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// ValidationMode selects what a validation hook does about a call that violates the spec
+type ValidationMode string
+
+const (
+	// ValidationOff skips validation
+	ValidationOff ValidationMode = "off"
+	// ValidationLenient logs and records violations but lets the call through
+	ValidationLenient ValidationMode = "lenient"
+	// ValidationStrict rejects calls with violations
+	ValidationStrict ValidationMode = "strict"
+)
+
+// ParseValidationMode parses off, lenient or strict; an empty value means off
+func ParseValidationMode(value string) (ValidationMode, error) {
+	switch mode := ValidationMode(strings.ToLower(value)); mode {
+	case "":
+		return ValidationOff, nil
+	case ValidationOff, ValidationLenient, ValidationStrict:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid validation mode %q: must be off, lenient or strict", value)
+}
+
+// RequestViolationsKey is the HookContext.Metadata key holding a request's violations
+const RequestViolationsKey = "requestViolations"
+
+// ResponseViolationsKey is the HookContext.Metadata key holding an upstream response's violations
+const ResponseViolationsKey = "responseViolations"
+
+// responseViolationsTotal counts upstream responses that did not match their spec
+var responseViolationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "swagger_mcp_response_violations_total",
+	Help: "Number of upstream responses that did not match the operation's declared responses.",
+}, []string{"service", "operation"})
+
+func init() {
+	prometheus.MustRegister(responseViolationsTotal)
+}
+
+// Violation locates a single place where a call does not match the spec
+type Violation struct {
+	In      string `json:"in"`                // path, query, header, cookie, body, status or response
+	Name    string `json:"name,omitempty"`    // parameter name
+	Pointer string `json:"pointer,omitempty"` // JSON pointer into the body
+	Message string `json:"message"`
+}
+
+// String formats the violation as "in: <location>, name|pointer: <where>: <message>"
+func (v Violation) String() string {
+	if v.Name != "" {
+		return fmt.Sprintf("in: %s, name: %s: %s", v.In, v.Name, v.Message)
+	}
+	return fmt.Sprintf("in: %s, pointer: %s: %s", v.In, v.Pointer, v.Message)
+}
+
+// ValidationError is returned by a strict validation hook, listing every violation
+type ValidationError struct {
+	Violations []Violation `json:"violations"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return "request validation failed: " + strings.Join(messages, "; ")
+}
+
+// RequestValidationHook validates request parameters and body against the operation's
+// OpenAPI definition. Path, query and header values are decoded to their declared types
+// before their schemas are checked, so "42" satisfies an integer parameter.
+type RequestValidationHook struct {
+	priority Priority
+	logger   *zap.Logger
+	mode     ValidationMode
+	services map[string]ValidationMode
+}
+
+// NewRequestValidationHook creates a new request validation hook, strict for every service
+func NewRequestValidationHook(logger *zap.Logger, priority Priority) *RequestValidationHook {
+	return &RequestValidationHook{
+		priority: priority,
+		logger:   logger,
+		mode:     ValidationStrict,
+		services: make(map[string]ValidationMode),
+	}
+}
+
+// SetMode sets the mode for services without one of their own. Call it before
+// registering the hook.
+func (h *RequestValidationHook) SetMode(mode ValidationMode) {
+	h.mode = mode
+}
+
+// SetServiceMode overrides the mode for a single service. Call it before registering
+// the hook.
+func (h *RequestValidationHook) SetServiceMode(serviceName string, mode ValidationMode) {
+	h.services[serviceName] = mode
+}
+
+// modeFor returns the mode applied to serviceName's calls
+func (h *RequestValidationHook) modeFor(serviceName string) ValidationMode {
+	if mode, ok := h.services[serviceName]; ok {
+		return mode
+	}
+	return h.mode
+}
+
+func (h *RequestValidationHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	// Validate request parameters
+	if hookCtx.Request.Parameters == nil {
+		return fmt.Errorf("missing request parameters")
+	}
+
+	route := hookCtx.Request.Route
+	mode := h.modeFor(hookCtx.Request.ServiceName)
+	if mode == ValidationOff || route == nil {
+		return nil
+	}
+
+	req, err := validationRequest(ctx, hookCtx.Request)
+	if err != nil {
+		return err
+	}
+	err = openapi3filter.ValidateRequest(ctx, &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams(route.PathItem.Parameters, route.Operation.Parameters, hookCtx.Request.Parameters),
+		Route:      route,
+		Options: &openapi3filter.Options{
+			// Unknown content types are passed through rather than reported
+			ExcludeRequestBody:  openapi3filter.RegisteredBodyDecoder(contentType(req.Header)) == nil && len(hookCtx.Request.Body) > 0,
+			MultiError:          true,
+			AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			SkipSettingDefaults: true,
+		},
+	})
+	if err == nil {
+		return nil
+	}
+
+	violations := requestViolations(err)
+	hookCtx.Metadata[RequestViolationsKey] = violations
+	if mode == ValidationStrict {
+		return &ValidationError{Violations: violations}
+	}
+
+	h.logger.Warn("Request does not match the spec",
+		zap.String("service", hookCtx.Request.ServiceName),
+		zap.String("operation", hookCtx.Request.OperationID),
+		zap.Any("violations", violations))
+	return nil
+}
+
+func (h *RequestValidationHook) Type() HookType {
+	return HookTypePreRequest
+}
+
+func (h *RequestValidationHook) Priority() Priority {
+	return h.priority
+}
+
+func (h *RequestValidationHook) Name() string {
+	return "request-validation"
+}
+
+// ResponseValidationHook checks upstream responses against the operation's declared
+// responses, for contract testing. Violations are recorded in the hook context's
+// metadata, logged and counted; the response itself is passed on unchanged.
+type ResponseValidationHook struct {
+	priority Priority
+	logger   *zap.Logger
+	services map[string]bool
+}
+
+// NewResponseValidationHook creates a response validation hook for the named services,
+// or for every service when the list contains "*"
+func NewResponseValidationHook(logger *zap.Logger, priority Priority, services []string) *ResponseValidationHook {
+	enabled := make(map[string]bool, len(services))
+	for _, serviceName := range services {
+		enabled[serviceName] = true
+	}
+	return &ResponseValidationHook{
+		priority: priority,
+		logger:   logger,
+		services: enabled,
+	}
+}
+
+func (h *ResponseValidationHook) Execute(ctx context.Context, hookCtx *HookContext) error {
+	route := hookCtx.Request.Route
+	if hookCtx.Response == nil || route == nil || route.Operation.Responses.Len() == 0 {
+		return nil
+	}
+	violations, err := h.validate(ctx, hookCtx)
+	if err != nil {
+		return err
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	hookCtx.Metadata[ResponseViolationsKey] = violations
+	responseViolationsTotal.WithLabelValues(hookCtx.Request.ServiceName, hookCtx.Request.OperationID).Inc()
+	h.logger.Warn("Upstream response does not match the spec",
+		zap.String("service", hookCtx.Request.ServiceName),
+		zap.String("operation", hookCtx.Request.OperationID),
+		zap.Int("statusCode", hookCtx.Response.StatusCode),
+		zap.Any("violations", violations))
+	return nil
+}
+
+// AppliesTo reports whether responses from serviceName are validated
+func (h *ResponseValidationHook) AppliesTo(serviceName string) bool {
+	return h.services[serviceName] || h.services["*"]
+}
+
+// validate returns the ways the response departs from the operation's declared responses
+func (h *ResponseValidationHook) validate(ctx context.Context, hookCtx *HookContext) ([]Violation, error) {
+	route := hookCtx.Request.Route
+	status := hookCtx.Response.StatusCode
+	if route.Operation.Responses.Status(status) == nil && route.Operation.Responses.Default() == nil {
+		return []Violation{{In: "status", Message: fmt.Sprintf("status %d is not declared", status)}}, nil
+	}
+
+	req, err := validationRequest(ctx, hookCtx.Request)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header, len(hookCtx.Response.Headers))
+	for name, value := range hookCtx.Response.Headers {
+		header.Set(name, value)
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams(route.PathItem.Parameters, route.Operation.Parameters, hookCtx.Request.Parameters),
+			Route:      route,
+		},
+		Status: status,
+		Header: header,
+		Options: &openapi3filter.Options{
+			// Bodies in formats openapi3filter cannot decode are not checked
+			ExcludeResponseBody: openapi3filter.RegisteredBodyDecoder(contentType(header)) == nil,
+			MultiError:          true,
+		},
+	}
+	input.SetBodyBytes(hookCtx.Response.Body)
+
+	if err := openapi3filter.ValidateResponse(ctx, input); err != nil {
+		return responseViolations(err), nil
+	}
+	return nil, nil
+}
+
+func (h *ResponseValidationHook) Type() HookType {
+	return HookTypePostResponse
+}
+
+func (h *ResponseValidationHook) Priority() Priority {
+	return h.priority
+}
+
+func (h *ResponseValidationHook) Name() string {
+	return "response-validation"
+}
+
+// validationRequest rebuilds the outgoing request from the hook context for openapi3filter
+func validationRequest(ctx context.Context, reqCtx *RequestContext) (*http.Request, error) {
+	target := url.URL{Path: reqCtx.Path, RawQuery: url.Values(reqCtx.QueryParams).Encode()}
+	req, err := http.NewRequestWithContext(ctx, reqCtx.Method, target.String(), bytes.NewReader(reqCtx.Body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for validation: %w", err)
+	}
+	for name, value := range reqCtx.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// pathParams formats the path parameter arguments the way they are written into the URL
+func pathParams(pathItemParams, operationParams openapi3.Parameters, params map[string]interface{}) map[string]string {
+	values := make(map[string]string)
+	for _, parameters := range []openapi3.Parameters{pathItemParams, operationParams} {
+		for _, parameterRef := range parameters {
+			if parameterRef.Value == nil || parameterRef.Value.In != openapi3.ParameterInPath {
+				continue
+			}
+			if value, ok := params[parameterRef.Value.Name]; ok {
+				values[parameterRef.Value.Name] = fmt.Sprintf("%v", value)
+			}
+		}
+	}
+	return values
+}
+
+// contentType returns the media type of the Content-Type header, without parameters
+func contentType(header http.Header) string {
+	mediaType, _, _ := strings.Cut(header.Get("Content-Type"), ";")
+	return strings.TrimSpace(mediaType)
+}
+
+// requestViolations converts openapi3filter's errors into violations, one per failing
+// parameter and one per failing body field
+func requestViolations(err error) []Violation {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var violations []Violation
+		for _, inner := range e {
+			violations = append(violations, requestViolations(inner)...)
+		}
+		return violations
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			return []Violation{{In: e.Parameter.In, Name: e.Parameter.Name, Message: requestErrorMessage(e)}}
+		}
+
+		if violations := BodyViolations(e.Err); len(violations) > 0 {
+			return violations
+		}
+		return []Violation{{In: "body", Pointer: "/", Message: requestErrorMessage(e)}}
+	}
+	return []Violation{{In: "request", Message: err.Error()}}
+}
+
+// responseViolations converts a ValidateResponse error into violations, one per failing
+// body field when the body does not match its schema
+func responseViolations(err error) []Violation {
+	var responseErr *openapi3filter.ResponseError
+	if !errors.As(err, &responseErr) {
+		return []Violation{{In: "response", Message: err.Error()}}
+	}
+
+	violations := BodyViolations(responseErr.Err)
+	if len(violations) == 0 || !strings.Contains(responseErr.Reason, "body") {
+		return []Violation{{In: "response", Message: responseErr.Error()}}
+	}
+	return violations
+}
+
+// BodyViolations converts the schema errors within err into violations, one per
+// failing body field located by its JSON pointer. It returns nil when err holds no
+// schema errors.
+func BodyViolations(err error) []Violation {
+	var schemaErrors []*openapi3.SchemaError
+	collectSchemaErrors(err, &schemaErrors)
+	if len(schemaErrors) == 0 {
+		return nil
+	}
+
+	violations := make([]Violation, 0, len(schemaErrors))
+	for _, schemaErr := range schemaErrors {
+		violations = append(violations, Violation{
+			In:      "body",
+			Pointer: jsonPointer(schemaErr.JSONPointer()),
+			Message: schemaErr.Reason,
+		})
+	}
+	return violations
+}
+
+// requestErrorMessage describes a parameter or body error without the location prefix
+// RequestError.Error adds
+func requestErrorMessage(err *openapi3filter.RequestError) string {
+	var schemaErrors []*openapi3.SchemaError
+	collectSchemaErrors(err.Err, &schemaErrors)
+	if len(schemaErrors) > 0 {
+		reasons := make([]string, len(schemaErrors))
+		for i, schemaErr := range schemaErrors {
+			reasons[i] = schemaErr.Reason
+		}
+		return strings.Join(reasons, "; ")
+	}
+
+	if err.Err == nil {
+		return err.Reason
+	}
+	if err.Reason == "" || err.Reason == err.Err.Error() {
+		return err.Err.Error()
+	}
+	return err.Reason + ": " + err.Err.Error()
+}
+
+// collectSchemaErrors flattens kin-openapi's nested multi-errors
+func collectSchemaErrors(err error, out *[]*openapi3.SchemaError) {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		for _, e := range multi {
+			collectSchemaErrors(e, out)
+		}
+		return
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		*out = append(*out, schemaErr)
+	}
+}
+
+// jsonPointer builds an RFC 6901 pointer from path segments
+func jsonPointer(segments []string) string {
+	if len(segments) == 0 {
+		return "/"
+	}
+
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, segment := range segments {
+		b.WriteByte('/')
+		b.WriteString(escaper.Replace(segment))
+	}
+	return b.String()
+}
@@ -0,0 +1,319 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"go.uber.org/zap"
+)
+
+const validationSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+      "put": {
+        "operationId": "updatePet",
+        "parameters": [
+          {"name": "notify", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "X-Request-Id", "in": "header", "required": true, "schema": {"type": "string"}}
+        ],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {
+            "type": "object",
+            "required": ["name"],
+            "properties": {"name": {"type": "string"}, "age": {"type": "integer", "minimum": 0}}
+          }}}
+        },
+        "responses": {
+          "200": {
+            "description": "ok",
+            "content": {"application/json": {"schema": {
+              "type": "object",
+              "required": ["id", "name"],
+              "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}
+            }}}
+          },
+          "404": {"description": "not found"}
+        }
+      }
+    }
+  }
+}`
+
+// validationRoute loads validationSpec and locates its updatePet operation
+func validationRoute(t *testing.T) *routers.Route {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(validationSpec))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	pathItem := spec.Paths.Value("/pets/{id}")
+	return &routers.Route{
+		Spec:      spec,
+		Path:      "/pets/{id}",
+		PathItem:  pathItem,
+		Method:    http.MethodPut,
+		Operation: pathItem.Put,
+	}
+}
+
+// validationContext builds the hook context of an updatePet call
+func validationContext(route *routers.Route, id interface{}, query map[string][]string, headers map[string]string, body string) *HookContext {
+	headers["Content-Type"] = "application/json"
+	return &HookContext{
+		Request: &RequestContext{
+			ServiceName: "petstore",
+			OperationID: "updatePet",
+			Method:      http.MethodPut,
+			Path:        "/pets/7",
+			Headers:     headers,
+			QueryParams: query,
+			Body:        []byte(body),
+			Parameters:  map[string]interface{}{"id": id},
+			StartTime:   time.Now(),
+			Route:       route,
+		},
+		Metadata: make(map[string]interface{}),
+	}
+}
+
+func TestRequestValidationHook_Strict(t *testing.T) {
+	route := validationRoute(t)
+	hook := NewRequestValidationHook(zap.NewNop(), PriorityHigh)
+
+	tests := []struct {
+		name     string
+		id       interface{}
+		query    map[string][]string
+		headers  map[string]string
+		body     string
+		expected []Violation
+	}{
+		{
+			name:    "valid request",
+			id:      float64(7),
+			query:   map[string][]string{"notify": {"true"}},
+			headers: map[string]string{"X-Request-Id": "abc"},
+			body:    `{"name": "Rex", "age": 3}`,
+		},
+		{
+			name:     "missing required body field",
+			id:       "7",
+			headers:  map[string]string{"X-Request-Id": "abc"},
+			body:     `{"age": 3}`,
+			expected: []Violation{{In: "body", Pointer: "/name", Message: `property "name" is missing`}},
+		},
+		{
+			name:    "wrong types",
+			id:      "seven",
+			query:   map[string][]string{"notify": {"maybe"}},
+			headers: map[string]string{"X-Request-Id": "abc"},
+			body:    `{"name": "Rex", "age": -1}`,
+			expected: []Violation{
+				{In: "path", Name: "id"},
+				{In: "query", Name: "notify"},
+				{In: "body", Pointer: "/age", Message: "number must be at least 0"},
+			},
+		},
+		{
+			name:     "missing required header",
+			id:       "7",
+			headers:  map[string]string{},
+			body:     `{"name": "Rex"}`,
+			expected: []Violation{{In: "header", Name: "X-Request-Id"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookCtx := validationContext(route, tt.id, tt.query, tt.headers, tt.body)
+			err := hook.Execute(context.Background(), hookCtx)
+
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("Expected valid request, got %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Violations) != len(tt.expected) {
+				t.Fatalf("Expected %d violations, got %+v", len(tt.expected), validationErr.Violations)
+			}
+			for i, expected := range tt.expected {
+				got := validationErr.Violations[i]
+				if got.In != expected.In || got.Name != expected.Name || got.Pointer != expected.Pointer {
+					t.Errorf("Expected violation at %+v, got %+v", expected, got)
+				}
+				if expected.Message != "" && got.Message != expected.Message {
+					t.Errorf("Expected message %q, got %q", expected.Message, got.Message)
+				}
+				if got.Message == "" {
+					t.Errorf("Expected a message for %+v", got)
+				}
+			}
+			if _, ok := hookCtx.Metadata[RequestViolationsKey]; !ok {
+				t.Errorf("Expected violations in metadata")
+			}
+		})
+	}
+}
+
+func TestRequestValidationHook_Modes(t *testing.T) {
+	route := validationRoute(t)
+	hook := NewRequestValidationHook(zap.NewNop(), PriorityHigh)
+	hook.SetMode(ValidationLenient)
+	hook.SetServiceMode("legacy", ValidationOff)
+
+	// Lenient mode lets the call through but records what was wrong
+	hookCtx := validationContext(route, "7", nil, map[string]string{"X-Request-Id": "abc"}, `{}`)
+	if err := hook.Execute(context.Background(), hookCtx); err != nil {
+		t.Errorf("Expected lenient mode to allow the call, got %v", err)
+	}
+	violations, _ := hookCtx.Metadata[RequestViolationsKey].([]Violation)
+	if len(violations) != 1 {
+		t.Errorf("Expected 1 recorded violation, got %v", hookCtx.Metadata[RequestViolationsKey])
+	}
+
+	// A service turned off is not validated at all
+	hookCtx = validationContext(route, "7", nil, map[string]string{"X-Request-Id": "abc"}, `{}`)
+	hookCtx.Request.ServiceName = "legacy"
+	if err := hook.Execute(context.Background(), hookCtx); err != nil {
+		t.Errorf("Expected no validation for legacy, got %v", err)
+	}
+	if _, ok := hookCtx.Metadata[RequestViolationsKey]; ok {
+		t.Errorf("Expected no violations recorded for legacy")
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ValidationMode
+		wantErr  bool
+	}{
+		{"", ValidationOff, false},
+		{"off", ValidationOff, false},
+		{"Strict", ValidationStrict, false},
+		{"lenient", ValidationLenient, false},
+		{"loose", "", true},
+	}
+
+	for _, tt := range tests {
+		mode, err := ParseValidationMode(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseValidationMode(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if mode != tt.expected {
+			t.Errorf("Expected %q for %q, got %q", tt.expected, tt.value, mode)
+		}
+	}
+}
+
+func TestResponseValidationHook(t *testing.T) {
+	route := validationRoute(t)
+	hook := NewResponseValidationHook(zap.NewNop(), PriorityHigh, []string{"petstore"})
+	manager := NewManager(zap.NewNop())
+	manager.RegisterHook(hook)
+
+	if hook.Type() != HookTypePostResponse {
+		t.Errorf("Expected post-response hook type")
+	}
+
+	tests := []struct {
+		name        string
+		serviceName string
+		status      int
+		contentType string
+		body        string
+		expected    []Violation
+	}{
+		{
+			name:        "conforming response",
+			serviceName: "petstore",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id": 7, "name": "Rex"}`,
+		},
+		{
+			name:        "body does not match schema",
+			serviceName: "petstore",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id": "seven"}`,
+			expected: []Violation{
+				{In: "body", Pointer: "/id", Message: "value must be an integer"},
+				{In: "body", Pointer: "/name", Message: `property "name" is missing`},
+			},
+		},
+		{
+			name:        "undeclared status",
+			serviceName: "petstore",
+			status:      http.StatusInternalServerError,
+			contentType: "text/plain",
+			body:        "boom",
+			expected:    []Violation{{In: "status", Message: "status 500 is not declared"}},
+		},
+		{
+			name:        "no declared schema",
+			serviceName: "petstore",
+			status:      http.StatusNotFound,
+			contentType: "text/plain",
+			body:        "not found",
+		},
+		{
+			name:        "service not opted in",
+			serviceName: "inventory",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"id": "seven"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hookCtx := validationContext(route, "7", nil, map[string]string{"X-Request-Id": "abc"}, `{"name": "Rex"}`)
+			hookCtx.Request.ServiceName = tt.serviceName
+			hookCtx.Response = &ResponseContext{
+				StatusCode: tt.status,
+				Headers:    map[string]string{"Content-Type": tt.contentType},
+				Body:       []byte(tt.body),
+			}
+
+			if err := manager.ExecutePostResponseHooks(context.Background(), hookCtx); err != nil {
+				t.Fatalf("Expected the response to pass through, got %v", err)
+			}
+			if string(hookCtx.Response.Body) != tt.body || hookCtx.Response.StatusCode != tt.status {
+				t.Errorf("Expected the response to be left unchanged, got %d %s", hookCtx.Response.StatusCode, hookCtx.Response.Body)
+			}
+
+			violations, _ := hookCtx.Metadata[ResponseViolationsKey].([]Violation)
+			if len(violations) != len(tt.expected) {
+				t.Fatalf("Expected %d violations, got %+v", len(tt.expected), violations)
+			}
+			for i, expected := range tt.expected {
+				if violations[i] != expected {
+					t.Errorf("Expected violation %+v, got %+v", expected, violations[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJSONPointerEscaping(t *testing.T) {
+	if pointer := jsonPointer([]string{"a/b", "c~d", "0"}); pointer != "/a~1b/c~0d/0" {
+		t.Errorf("Expected escaped pointer /a~1b/c~0d/0, got %s", pointer)
+	}
+}
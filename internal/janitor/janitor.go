@@ -0,0 +1,111 @@
+package janitor
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// reclaimedTotal counts entries removed by the janitor, labelled by store
+var reclaimedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "swagger_mcp_janitor_reclaimed_total",
+	Help: "Number of stale entries reclaimed by the background janitor.",
+}, []string{"store"})
+
+func init() {
+	prometheus.MustRegister(reclaimedTotal)
+}
+
+// PruneFunc removes entries that are stale at now and returns how many were reclaimed
+type PruneFunc func(now time.Time) int
+
+// Janitor runs every registered prune task from a single background goroutine
+type Janitor struct {
+	interval time.Duration
+	jitter   time.Duration
+	logger   *zap.Logger
+
+	tasks []task
+	mutex sync.Mutex
+}
+
+// task is a named prune function
+type task struct {
+	name  string
+	prune PruneFunc
+}
+
+// New creates a janitor that runs every interval plus up to jitter, so that
+// several instances do not prune in lockstep
+func New(logger *zap.Logger, interval, jitter time.Duration) *Janitor {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	if jitter < 0 {
+		jitter = 0
+	}
+
+	return &Janitor{
+		interval: interval,
+		jitter:   jitter,
+		logger:   logger,
+	}
+}
+
+// Register adds a prune task; name labels its reclaimed count in metrics and logs
+func (j *Janitor) Register(name string, prune PruneFunc) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.tasks = append(j.tasks, task{name: name, prune: prune})
+}
+
+// Start runs the prune tasks in the background until ctx is cancelled
+func (j *Janitor) Start(ctx context.Context) {
+	go func() {
+		timer := time.NewTimer(j.nextDelay())
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-timer.C:
+				j.RunOnce(now)
+				timer.Reset(j.nextDelay())
+			}
+		}
+	}()
+}
+
+// RunOnce runs every prune task and returns the number of entries each reclaimed
+func (j *Janitor) RunOnce(now time.Time) map[string]int {
+	j.mutex.Lock()
+	tasks := append([]task(nil), j.tasks...)
+	j.mutex.Unlock()
+
+	reclaimed := make(map[string]int, len(tasks))
+	for _, t := range tasks {
+		count := t.prune(now)
+		reclaimed[t.name] += count
+		if count > 0 {
+			reclaimedTotal.WithLabelValues(t.name).Add(float64(count))
+			j.logger.Debug("Pruned stale entries",
+				zap.String("store", t.name),
+				zap.Int("reclaimed", count))
+		}
+	}
+	return reclaimed
+}
+
+// nextDelay returns the interval plus a random jitter
+func (j *Janitor) nextDelay() time.Duration {
+	if j.jitter <= 0 {
+		return j.interval
+	}
+	return j.interval + rand.N(j.jitter)
+}
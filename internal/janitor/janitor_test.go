@@ -0,0 +1,90 @@
+package janitor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+func TestJanitor_RunOnce(t *testing.T) {
+	j := New(zap.NewNop(), time.Minute, 0)
+
+	entries := map[string]time.Time{
+		"old":   time.Now().Add(-2 * time.Hour),
+		"older": time.Now().Add(-3 * time.Hour),
+		"fresh": time.Now(),
+	}
+	j.Register("test_entries", func(now time.Time) int {
+		removed := 0
+		for key, seen := range entries {
+			if now.Sub(seen) > time.Hour {
+				delete(entries, key)
+				removed++
+			}
+		}
+		return removed
+	})
+	j.Register("test_empty", func(now time.Time) int { return 0 })
+
+	before := testutil.ToFloat64(reclaimedTotal.WithLabelValues("test_entries"))
+	reclaimed := j.RunOnce(time.Now())
+
+	if reclaimed["test_entries"] != 2 {
+		t.Errorf("Expected 2 entries reclaimed, got %d", reclaimed["test_entries"])
+	}
+	if reclaimed["test_empty"] != 0 {
+		t.Errorf("Expected nothing reclaimed from empty store, got %d", reclaimed["test_empty"])
+	}
+	if _, ok := entries["fresh"]; !ok || len(entries) != 1 {
+		t.Errorf("Expected only the fresh entry to remain, got %v", entries)
+	}
+	if got := testutil.ToFloat64(reclaimedTotal.WithLabelValues("test_entries")) - before; got != 2 {
+		t.Errorf("Expected reclaimed metric to grow by 2, got %v", got)
+	}
+}
+
+func TestJanitor_Start(t *testing.T) {
+	j := New(zap.NewNop(), 10*time.Millisecond, 5*time.Millisecond)
+
+	var runs atomic.Int32
+	j.Register("test_runs", func(now time.Time) int {
+		runs.Add(1)
+		return 0
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	j.Start(ctx)
+
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if runs.Load() < 2 {
+		t.Fatalf("Expected janitor to run repeatedly, ran %d times", runs.Load())
+	}
+
+	cancel()
+	time.Sleep(30 * time.Millisecond)
+	stopped := runs.Load()
+	time.Sleep(50 * time.Millisecond)
+	if runs.Load() != stopped {
+		t.Errorf("Expected janitor to stop after cancellation, ran %d more times", runs.Load()-stopped)
+	}
+}
+
+func TestJanitor_NextDelay(t *testing.T) {
+	j := New(zap.NewNop(), time.Minute, 10*time.Second)
+	for i := 0; i < 100; i++ {
+		if delay := j.nextDelay(); delay < time.Minute || delay >= time.Minute+10*time.Second {
+			t.Fatalf("Expected delay within [1m, 1m10s), got %v", delay)
+		}
+	}
+
+	if delay := New(zap.NewNop(), 0, 0).nextDelay(); delay != time.Minute {
+		t.Errorf("Expected default interval of 1m, got %v", delay)
+	}
+}
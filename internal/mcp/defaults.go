@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"maps"
+	"mime"
+	"slices"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+)
+
+// applyBodyDefaults adds the configured body defaults missing from a tool call's JSON
+// body argument, creating the body when the call has none, so arguments are validated
+// with them in place. The body is filled in a copy, leaving the caller's map unchanged.
+func (s *Server) applyBodyDefaults(serviceName string, route *parser.RouteConfig, params map[string]interface{}) {
+	if s.bodyDefaults == nil || !hasJSONBody(route) {
+		return
+	}
+	if len(s.bodyDefaults.Defaults(serviceName, route.OperationID)) == 0 {
+		return
+	}
+
+	bodyArgument := route.BodyArgument()
+	switch body := params[bodyArgument].(type) {
+	case map[string]interface{}:
+		filled := maps.Clone(body)
+		s.bodyDefaults.Fill(serviceName, route.OperationID, filled)
+		params[bodyArgument] = filled
+	case nil:
+		filled := make(map[string]interface{})
+		s.bodyDefaults.Fill(serviceName, route.OperationID, filled)
+		params[bodyArgument] = filled
+	}
+}
+
+// relaxDefaultedFields stops a tool's input schema from requiring the body fields the
+// body defaults supply, and the body itself when nothing else in it is required
+func (s *Server) relaxDefaultedFields(serviceName string, route *parser.RouteConfig) {
+	if s.bodyDefaults == nil || !hasJSONBody(route) {
+		return
+	}
+	defaults := s.bodyDefaults.Defaults(serviceName, route.OperationID)
+	if len(defaults) == 0 {
+		return
+	}
+
+	bodyArgument := route.BodyArgument()
+	body, ok := route.Tool.InputSchema.Properties[bodyArgument].(map[string]interface{})
+	if !ok {
+		return
+	}
+	required, _ := body["required"].([]string)
+	kept := slices.DeleteFunc(slices.Clone(required), func(name string) bool {
+		_, defaulted := defaults[name]
+		return defaulted
+	})
+	if len(kept) == len(required) {
+		return
+	}
+
+	body = maps.Clone(body)
+	if len(kept) > 0 {
+		body["required"] = kept
+	} else {
+		delete(body, "required")
+		route.Tool.InputSchema.Required = slices.DeleteFunc(slices.Clone(route.Tool.InputSchema.Required), func(name string) bool {
+			return name == bodyArgument
+		})
+	}
+	properties := maps.Clone(route.Tool.InputSchema.Properties)
+	properties[bodyArgument] = body
+	route.Tool.InputSchema.Properties = properties
+}
+
+// addRouteTool registers the tool of a route with the defaulted body fields relaxed,
+// so it is advertised the same way whether registered with its service or re-enabled
+func (s *Server) addRouteTool(serviceName string, route *parser.RouteConfig, engine *proxy.Engine) {
+	s.relaxDefaultedFields(serviceName, route)
+	s.mcpServer.AddTool(route.Tool, s.createToolHandler(serviceName, route, engine.GetExecutor(route)))
+}
+
+// hasJSONBody reports whether a route sends an application/json request body
+func hasJSONBody(route *parser.RouteConfig) bool {
+	if route.RequestBody == nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(route.RequestBody.ContentType)
+	return err == nil && mediaType == "application/json"
+}
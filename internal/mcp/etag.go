@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// etagCache remembers the last ETag seen per resource within each client session
+type etagCache struct {
+	mu       sync.Mutex
+	sessions map[string]*etagSession
+}
+
+// etagSession holds one session's ETags and when the session last used them
+type etagSession struct {
+	etags    map[string]string // resource path -> ETag
+	lastUsed time.Time
+}
+
+// newETagCache creates an empty ETag cache
+func newETagCache() *etagCache {
+	return &etagCache{
+		sessions: make(map[string]*etagSession),
+	}
+}
+
+// Get returns the last ETag seen for a resource in the caller's session
+func (c *etagCache) Get(ctx context.Context, resource string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	session := c.sessions[sessionID(ctx)]
+	if session == nil {
+		return "", false
+	}
+	session.lastUsed = time.Now()
+	etag, ok := session.etags[resource]
+	return etag, ok
+}
+
+// Set records the ETag for a resource in the caller's session
+func (c *etagCache) Set(ctx context.Context, resource, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := sessionID(ctx)
+	session := c.sessions[id]
+	if session == nil {
+		session = &etagSession{etags: make(map[string]string)}
+		c.sessions[id] = session
+	}
+	session.etags[resource] = etag
+	session.lastUsed = time.Now()
+}
+
+// Delete forgets the ETag for a resource in the caller's session
+func (c *etagCache) Delete(ctx context.Context, resource string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if session := c.sessions[sessionID(ctx)]; session != nil {
+		delete(session.etags, resource)
+		session.lastUsed = time.Now()
+	}
+}
+
+// DropSession forgets every ETag recorded for a session
+func (c *etagCache) DropSession(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sessions, id)
+}
+
+// Prune drops sessions not used since cutoff and returns how many ETags were removed
+func (c *etagCache) Prune(cutoff time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for id, session := range c.sessions {
+		if session.lastUsed.Before(cutoff) {
+			removed += len(session.etags)
+			delete(c.sessions, id)
+		}
+	}
+	return removed
+}
+
+// sessionID returns the MCP client session ID from the context, or "" outside a session
+func sessionID(ctx context.Context) string {
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
@@ -0,0 +1,232 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/zeroLR/swagger-mcp-go/internal/ratelimit"
+	"go.uber.org/zap"
+)
+
+// Reasons carried in the data of limit errors so clients can tell them apart
+const (
+	limitSessions = "session_limit_exceeded"
+	limitPayload  = "payload_too_large"
+	limitToolRate = "tool_rate_limit_exceeded"
+)
+
+// limitError is the structured payload describing which limit rejected a request
+type limitError struct {
+	Reason     string `json:"reason"`
+	Limit      int64  `json:"limit"`
+	RetryAfter string `json:"retryAfter,omitempty"`
+}
+
+// sessionLimiter tracks open MCP sessions, and when each was last seen, against a configured maximum
+type sessionLimiter struct {
+	max    int
+	mu     sync.Mutex
+	active map[string]time.Time
+}
+
+// newSessionLimiter creates a session limiter; a max of zero or less means unlimited
+func newSessionLimiter(max int) *sessionLimiter {
+	return &sessionLimiter{
+		max:    max,
+		active: make(map[string]time.Time),
+	}
+}
+
+// Add records an open session
+func (l *sessionLimiter) Add(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active[id] = time.Now()
+}
+
+// Touch marks a tracked session as seen now
+func (l *sessionLimiter) Touch(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.active[id]; ok {
+		l.active[id] = time.Now()
+	}
+}
+
+// Tracked reports whether a session is open and has not been pruned
+func (l *sessionLimiter) Tracked(id string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.active[id]
+	return ok
+}
+
+// Remove forgets a closed session
+func (l *sessionLimiter) Remove(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.active, id)
+}
+
+// Prune forgets sessions not seen since cutoff and returns how many were removed.
+// Streamable HTTP clients that never send DELETE would otherwise hold their slot forever.
+func (l *sessionLimiter) Prune(cutoff time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+	for id, lastSeen := range l.active {
+		if lastSeen.Before(cutoff) {
+			delete(l.active, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Full reports whether opening another session would exceed the maximum
+func (l *sessionLimiter) Full() bool {
+	if l.max <= 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.active) >= l.max
+}
+
+// limitedSessionIDs wraps the streamable HTTP session ID manager so the sessions it
+// issues and terminates are counted. Streamable HTTP does not fire session hooks for POSTs.
+type limitedSessionIDs struct {
+	mcpserver.SessionIdManager
+	sessions *sessionLimiter
+}
+
+// Generate issues a new session ID and counts it as open
+func (m *limitedSessionIDs) Generate() string {
+	id := m.SessionIdManager.Generate()
+	m.sessions.Add(id)
+	return id
+}
+
+// Validate reports sessions the limiter no longer tracks as terminated, so a session
+// pruned for idleness cannot keep being used without a slot; its client gets a 404
+// and starts a new, counted session
+func (m *limitedSessionIDs) Validate(sessionID string) (bool, error) {
+	terminated, err := m.SessionIdManager.Validate(sessionID)
+	if err != nil || terminated {
+		return terminated, err
+	}
+	return !m.sessions.Tracked(sessionID), nil
+}
+
+// Terminate closes a session and releases its slot
+func (m *limitedSessionIDs) Terminate(sessionID string) (bool, error) {
+	notAllowed, err := m.SessionIdManager.Terminate(sessionID)
+	if err == nil && !notAllowed {
+		m.sessions.Remove(sessionID)
+	}
+	return notAllowed, err
+}
+
+// limitHandler rejects oversized payloads and new sessions beyond the configured maximum
+// before they reach the MCP transport, and records activity on existing sessions
+func (s *Server) limitHandler(next http.Handler) http.Handler {
+	maxBytes := s.config.MCP.MaxRequestBytes
+	maxSessions := s.config.MCP.MaxSessions
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(mcpserver.HeaderKeySessionID); id != "" {
+			s.sessions.Touch(id)
+		}
+
+		var body []byte
+		if r.Method == http.MethodPost && r.Body != nil && (maxBytes > 0 || maxSessions > 0) {
+			reader := io.Reader(r.Body)
+			if maxBytes > 0 {
+				reader = io.LimitReader(r.Body, maxBytes+1)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			if maxBytes > 0 && int64(len(data)) > maxBytes {
+				s.logger.Warn("Rejected oversized MCP request", zap.Int64("maxRequestBytes", maxBytes))
+				writeLimitError(w, http.StatusRequestEntityTooLarge,
+					fmt.Sprintf("request payload exceeds %d bytes", maxBytes),
+					limitError{Reason: limitPayload, Limit: maxBytes})
+				return
+			}
+			body = data
+			r.Body = io.NopCloser(bytes.NewReader(data))
+		}
+
+		if s.opensSession(r, body) && s.sessions.Full() {
+			s.logger.Warn("Rejected MCP session", zap.Int("maxSessions", maxSessions))
+			writeLimitError(w, http.StatusServiceUnavailable,
+				fmt.Sprintf("maximum of %d concurrent sessions reached", maxSessions),
+				limitError{Reason: limitSessions, Limit: int64(maxSessions)})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// opensSession reports whether a request starts a new session: an SSE stream, or a
+// streamable HTTP initialize request
+func (s *Server) opensSession(r *http.Request, body []byte) bool {
+	if s.mode == ServerModeSSE {
+		return r.Method == http.MethodGet
+	}
+	if r.Method != http.MethodPost || r.Header.Get(mcpserver.HeaderKeySessionID) != "" {
+		return false
+	}
+
+	var message struct {
+		Method mcp.MCPMethod `json:"method"`
+	}
+	return json.Unmarshal(body, &message) == nil && message.Method == mcp.MethodInitialize
+}
+
+// writeLimitError writes a JSON-RPC error describing the limit that was hit
+func writeLimitError(w http.ResponseWriter, status int, message string, data limitError) {
+	response := mcp.NewJSONRPCError(mcp.NewRequestId(nil), mcp.INVALID_REQUEST, message, data)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// toolRateLimit returns middleware that limits tool calls per client session
+func toolRateLimit(limiter ratelimit.Limiter) mcpserver.ToolHandlerMiddleware {
+	return func(next mcpserver.ToolHandlerFunc) mcpserver.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			allowed, retryAfter := limiter.Allow(sessionID(ctx))
+			if !allowed {
+				limit := int64(limiter.Config().RequestsPerMinute)
+				result := mcp.NewToolResultStructured(
+					limitError{Reason: limitToolRate, Limit: limit, RetryAfter: retryAfter.String()},
+					fmt.Sprintf("tool call rate limit of %d per minute exceeded, retry after %s", limit, retryAfter),
+				)
+				result.IsError = true
+				return result, nil
+			}
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+	"go.uber.org/zap"
+)
+
+// MethodNotificationSpecsChanged is the notification sent to every connected client
+// when a spec is added to, updated in or removed from the registry
+const MethodNotificationSpecsChanged = "notifications/specs/changed"
+
+// BridgeSpecEvents starts a goroutine keeping the spec resources in step with spec
+// events from the registry, until ctx is done or events is closed. With
+// mcp.specNotifications enabled, which also declares the tools listChanged
+// capability, connected clients are notified of each event as well.
+// The registry has a single event channel, so nothing else should consume it.
+func (s *Server) BridgeSpecEvents(ctx context.Context, events <-chan registry.SpecEvent) {
+	go func() {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				switch event.Type {
+				case registry.SpecEventAdded, registry.SpecEventUpdated, registry.SpecEventRemoved:
+				default:
+					continue
+				}
+				// Specs also change outside this server, e.g. through the admin API
+				s.syncSpecResources(event.ServiceName)
+				if !s.config.MCP.SpecNotifications {
+					continue
+				}
+				s.logger.Debug("Notifying clients of spec event",
+					zap.String("eventType", string(event.Type)),
+					zap.String("serviceName", event.ServiceName))
+				s.mcpServer.SendNotificationToAllClients(MethodNotificationSpecsChanged, specEventParams(event))
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// specEventParams describes the spec an event is about, leaving out the parsed
+// document and the headers, which may hold credentials
+func specEventParams(event registry.SpecEvent) map[string]any {
+	params := map[string]any{
+		"type":        string(event.Type),
+		"serviceName": event.ServiceName,
+		"timestamp":   event.Timestamp.Format(time.RFC3339Nano),
+	}
+	if info := event.SpecInfo; info != nil {
+		params["url"] = info.URL
+		if info.Spec != nil && info.Spec.Info != nil {
+			params["title"] = info.Spec.Info.Title
+			params["version"] = info.Spec.Info.Version
+		}
+	}
+	return params
+}
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+)
+
+// specResourceURI is the URI of the resource holding a service's OpenAPI document
+func specResourceURI(serviceName string) string {
+	return "spec://" + serviceName
+}
+
+// operationsResourceURI is the URI of the resource listing a service's operations
+func operationsResourceURI(serviceName string) string {
+	return specResourceURI(serviceName) + "/operations"
+}
+
+// syncSpecResources registers a service's spec and operation list as resources while
+// it is in the registry, and removes them once it is gone. Clients are told the
+// resource list changed either way.
+func (s *Server) syncSpecResources(serviceName string) {
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil || specInfo.Spec == nil {
+		s.mcpServer.DeleteResources(specResourceURI(serviceName), operationsResourceURI(serviceName))
+		return
+	}
+
+	title := serviceName
+	if info := specInfo.Spec.Info; info != nil && info.Title != "" {
+		title = info.Title
+	}
+	s.mcpServer.AddResources(
+		mcpserver.ServerResource{
+			Resource: mcp.NewResource(specResourceURI(serviceName), serviceName,
+				mcp.WithResourceDescription(fmt.Sprintf("OpenAPI document of %s", title)),
+				mcp.WithMIMEType("application/json")),
+			Handler: s.readSpecResource(serviceName),
+		},
+		mcpserver.ServerResource{
+			Resource: mcp.NewResource(operationsResourceURI(serviceName), serviceName+" operations",
+				mcp.WithResourceDescription(fmt.Sprintf("Operations of %s", title)),
+				mcp.WithMIMEType("application/json")),
+			Handler: s.readOperationsResource(serviceName),
+		},
+	)
+}
+
+// readSpecResource returns a handler serving a service's current OpenAPI document
+func (s *Server) readSpecResource(serviceName string) mcpserver.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		specInfo, _ := s.registry.Get(serviceName)
+		if specInfo == nil || specInfo.Spec == nil {
+			return nil, fmt.Errorf("service not found: %s", serviceName)
+		}
+		return jsonResource(request.Params.URI, specInfo.Spec)
+	}
+}
+
+// readOperationsResource returns a handler listing a service's current operations
+func (s *Server) readOperationsResource(serviceName string) mcpserver.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		specInfo, _ := s.registry.Get(serviceName)
+		if specInfo == nil || specInfo.Spec == nil {
+			return nil, fmt.Errorf("service not found: %s", serviceName)
+		}
+		return jsonResource(request.Params.URI, map[string]interface{}{
+			"serviceName": serviceName,
+			"routes":      s.routeInfos(specInfo),
+		})
+	}
+}
+
+// jsonResource encodes value as the JSON text contents of the resource at uri
+func jsonResource(uri string, value interface{}) ([]mcp.ResourceContents, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", uri, err)
+	}
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
@@ -2,18 +2,27 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/mark3labs/mcp-go/mcp"
 	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
 	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/janitor"
 	"github.com/zeroLR/swagger-mcp-go/internal/models"
 	"github.com/zeroLR/swagger-mcp-go/internal/parser"
 	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+	"github.com/zeroLR/swagger-mcp-go/internal/ratelimit"
 	"github.com/zeroLR/swagger-mcp-go/internal/registry"
 	"github.com/zeroLR/swagger-mcp-go/internal/specs"
 	"go.uber.org/zap"
@@ -35,30 +44,149 @@ type Server struct {
 	logger      *zap.Logger
 	config      *config.Config
 	mcpServer   *mcpserver.MCPServer
-	parser      *parser.Parser
-	proxyEngine *proxy.Engine
+	authManager *auth.Manager
+	etags       *etagCache
+	sessions    *sessionLimiter
+	toolLimiter *ratelimit.TokenBucketLimiter
+	hookManager *hooks.Manager
+	breakers    *circuitbreaker.Manager
+	drainer     *proxy.Drainer
 	mode        ServerMode
+
+	// bodyDefaults is the hook manager's body defaults hook, which also fills in the
+	// body argument of tool calls before it is validated
+	bodyDefaults *hooks.BodyDefaultsHook
+
+	// tools holds each service's operation tools; toolOwners maps every registered
+	// tool name to its service ("" for management tools) to detect collisions
+	tools      map[string]*serviceTools
+	toolOwners map[string]string
+	mutex      sync.RWMutex
+}
+
+// serviceTools holds the proxy engine and tool names for one service's operations,
+// plus what is needed to register them again after idle eviction
+type serviceTools struct {
+	engine   *proxy.Engine
+	names    map[string]string // operation ID -> tool name
+	baseURL  string
+	headers  map[string]string
+	lastUsed time.Time
+	idle     bool // tools were unregistered for inactivity
 }
 
 // NewServer creates a new MCP server instance
 func NewServer(logger *zap.Logger, cfg *config.Config, reg *registry.Registry, fetcher *specs.Fetcher) *Server {
-	mcpServer := mcpserver.NewMCPServer(
-		"swagger-mcp-go",
-		"1.0.0",
-	)
-
-	proxyEngine := proxy.New(logger.Named("proxy"), cfg.Upstream.Timeout)
-	parser := parser.New(logger.Named("parser"), "")
-
-	return &Server{
+	server := &Server{
 		registry:    reg,
 		fetcher:     fetcher,
 		logger:      logger,
 		config:      cfg,
-		mcpServer:   mcpServer,
-		parser:      parser,
-		proxyEngine: proxyEngine,
+		authManager: newAuthManager(logger.Named("auth"), cfg),
+		etags:       newETagCache(),
+		sessions:    newSessionLimiter(cfg.MCP.MaxSessions),
+		breakers:    circuitbreaker.NewManager(logger.Named("circuitbreaker"), cfg.Upstream.CircuitBreaker.Threshold > 0),
 		mode:        ServerModeSTDIO, // Default mode
+		tools:       make(map[string]*serviceTools),
+		toolOwners:  make(map[string]string),
+	}
+
+	hooks := &mcpserver.Hooks{}
+	hooks.AddOnRegisterSession(func(ctx context.Context, session mcpserver.ClientSession) {
+		// Streamable HTTP sessions are counted by limitedSessionIDs instead
+		if server.mode == ServerModeSSE {
+			server.sessions.Add(session.SessionID())
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session mcpserver.ClientSession) {
+		server.etags.DropSession(session.SessionID())
+		if server.mode == ServerModeSSE {
+			server.sessions.Remove(session.SessionID())
+		}
+		if server.toolLimiter != nil {
+			server.toolLimiter.Reset(session.SessionID())
+		}
+	})
+
+	options := []mcpserver.ServerOption{
+		mcpserver.WithHooks(hooks),
+		mcpserver.WithResourceCapabilities(false, true),
+	}
+	if cfg.MCP.SpecNotifications {
+		options = append(options, mcpserver.WithToolCapabilities(true))
+	}
+	if cfg.MCP.ToolCallsPerMinute > 0 {
+		server.toolLimiter = ratelimit.NewTokenBucketLimiter(ratelimit.Config{
+			RequestsPerMinute: cfg.MCP.ToolCallsPerMinute,
+		}, logger.Named("ratelimit"))
+		options = append(options, mcpserver.WithToolHandlerMiddleware(toolRateLimit(server.toolLimiter)))
+	}
+
+	server.mcpServer = mcpserver.NewMCPServer("swagger-mcp-go", "1.0.0", options...)
+	server.registerManagementTools()
+
+	return server
+}
+
+// newAuthManager creates an auth manager with providers configured from cfg
+func newAuthManager(logger *zap.Logger, cfg *config.Config) *auth.Manager {
+	manager := auth.NewManager(logger)
+
+	bearer := auth.NewBearerTokenProvider(logger.Named("bearer"))
+	if err := bearer.Configure(map[string]interface{}{
+		"jwksURL":  cfg.Auth.JWT.JWKSURL,
+		"issuer":   cfg.Auth.JWT.Issuer,
+		"issuers":  cfg.Auth.JWT.Issuers,
+		"audience": cfg.Auth.JWT.Audience,
+	}); err != nil {
+		logger.Warn("Failed to configure bearer token provider", zap.Error(err))
+	}
+	manager.RegisterProvider(models.AuthTypeBearer, bearer)
+
+	oauth2 := auth.NewOAuth2Provider(logger.Named("oauth2"))
+	if err := oauth2.Configure(map[string]interface{}{
+		"tokenURL":     cfg.Auth.OAuth2.TokenURL,
+		"clientID":     cfg.Auth.OAuth2.ClientID,
+		"clientSecret": cfg.Auth.OAuth2.ClientSecret,
+	}); err != nil {
+		logger.Warn("Failed to configure OAuth2 provider", zap.Error(err))
+	}
+	manager.RegisterProvider(models.AuthTypeOAuth2, oauth2)
+
+	return manager
+}
+
+// AuthManager returns the auth manager used to authenticate callers
+func (s *Server) AuthManager() *auth.Manager {
+	return s.authManager
+}
+
+// SetAuthManager replaces the auth manager used to authenticate callers
+func (s *Server) SetAuthManager(manager *auth.Manager) {
+	s.authManager = manager
+}
+
+// RegisterJanitorTasks has j prune per-session state idle for longer than idleTimeout,
+// and unregister the tools of services idle for longer than mcp.toolIdleTimeout.
+// A zero timeout disables the corresponding pruning.
+func (s *Server) RegisterJanitorTasks(j *janitor.Janitor, idleTimeout time.Duration) {
+	if idleTimeout > 0 {
+		j.Register("mcp_etags", func(now time.Time) int {
+			return s.etags.Prune(now.Add(-idleTimeout))
+		})
+		j.Register("mcp_sessions", func(now time.Time) int {
+			// SSE sessions are released by the unregister hook when their stream closes
+			if s.mode == ServerModeSSE {
+				return 0
+			}
+			return s.sessions.Prune(now.Add(-idleTimeout))
+		})
+	}
+
+	if toolIdleTimeout := s.config.MCP.ToolIdleTimeout; toolIdleTimeout > 0 {
+		j.Register("mcp_idle_tools", func(now time.Time) int {
+			return s.evictIdleTools(now.Add(-toolIdleTimeout))
+		})
 	}
 }
 
@@ -67,6 +195,27 @@ func (s *Server) SetMode(mode ServerMode) {
 	s.mode = mode
 }
 
+// SetHookManager runs the manager's request and response hooks around upstream calls
+// made by operation tools. Call it before registering specs; existing tools keep their engines.
+func (s *Server) SetHookManager(manager *hooks.Manager) {
+	s.hookManager = manager
+	s.bodyDefaults = nil
+	if manager == nil {
+		return
+	}
+	for _, hook := range manager.GetRegisteredHooks()[hooks.HookTypePreRequest] {
+		if defaults, ok := hook.(*hooks.BodyDefaultsHook); ok {
+			s.bodyDefaults = defaults
+		}
+	}
+}
+
+// SetDrainer tracks upstream calls made by operation tools with drainer, so shutdown
+// can wait for them. Call it before registering specs; existing tools keep their engines.
+func (s *Server) SetDrainer(drainer *proxy.Drainer) {
+	s.drainer = drainer
+}
+
 // LoadSpecFromURL loads an OpenAPI spec from URL and registers tools
 func (s *Server) LoadSpecFromURL(ctx context.Context, url, serviceName string, headers map[string]string, baseURL string) error {
 	// Fetch the spec
@@ -74,20 +223,21 @@ func (s *Server) LoadSpecFromURL(ctx context.Context, url, serviceName string, h
 	if err != nil {
 		return fmt.Errorf("failed to fetch spec: %w", err)
 	}
+	specInfo.BaseURL = baseURL
 
 	// Add to registry
-	if err := s.registry.Add(specInfo); err != nil {
+	if err := s.addToRegistry(specInfo); err != nil {
 		return fmt.Errorf("failed to add spec to registry: %w", err)
 	}
 
 	// Parse and register tools
-	return s.registerToolsFromSpec(specInfo, baseURL, headers)
+	return s.registerToolsFromSpec(specInfo, "", headers)
 }
 
 // LoadSpecFromFile loads an OpenAPI spec from file and registers tools
 func (s *Server) LoadSpecFromFile(specFile, baseURL string, headers map[string]string) error {
 	// Read and parse spec file
-	spec, err := s.loadSpecFile(specFile)
+	spec, validationErrors, err := s.loadSpecFile(specFile)
 	if err != nil {
 		return fmt.Errorf("failed to load spec file: %w", err)
 	}
@@ -101,39 +251,205 @@ func (s *Server) LoadSpecFromFile(specFile, baseURL string, headers map[string]s
 		FetchedAt:   time.Now(),
 		TTL:         0, // No expiration for file-based specs
 		Headers:     headers,
+		BaseURL:     baseURL,
+
+		ValidationErrors: validationErrors,
 	}
 
 	// Add to registry
-	if err := s.registry.Add(specInfo); err != nil {
+	if err := s.addToRegistry(specInfo); err != nil {
 		return fmt.Errorf("failed to add spec to registry: %w", err)
 	}
 
 	// Parse and register tools
-	return s.registerToolsFromSpec(specInfo, baseURL, headers)
+	return s.registerToolsFromSpec(specInfo, "", headers)
+}
+
+// LoadSpecSource registers a spec listed in the configuration and its tools, fetching
+// it from the source's URL or reading it from its file
+func (s *Server) LoadSpecSource(ctx context.Context, source config.SpecSource) error {
+	if source.ServiceName == "" {
+		return fmt.Errorf("spec source has no serviceName")
+	}
+
+	var specInfo *models.SpecInfo
+	switch {
+	case source.URL != "" && source.File != "":
+		return fmt.Errorf("spec %s sets both url and file", source.ServiceName)
+
+	case source.URL != "":
+		ttl, err := s.parseTTL(source.TTL)
+		if err != nil {
+			return err
+		}
+		specInfo, err = s.fetcher.FetchSpec(ctx, source.URL, source.ServiceName, source.Headers, ttl)
+		if err != nil {
+			return fmt.Errorf("failed to fetch spec: %w", err)
+		}
+
+	case source.File != "":
+		var ttl time.Duration
+		if source.TTL != "" {
+			var err error
+			if ttl, err = s.parseTTL(source.TTL); err != nil {
+				return err
+			}
+		}
+		spec, validationErrors, err := s.loadSpecFile(source.File)
+		if err != nil {
+			return fmt.Errorf("failed to load spec file: %w", err)
+		}
+		specInfo = &models.SpecInfo{
+			ID:          fmt.Sprintf("file:%s", source.File),
+			ServiceName: source.ServiceName,
+			URL:         source.File,
+			Spec:        spec,
+			FetchedAt:   time.Now(),
+			TTL:         ttl,
+			Headers:     source.Headers,
+
+			ValidationErrors: validationErrors,
+		}
+
+	default:
+		return fmt.Errorf("spec %s needs a url or a file", source.ServiceName)
+	}
+	specInfo.BaseURL = source.BaseURL
+	specInfo.ServerVariables = source.ServerVariables
+
+	if err := s.addToRegistry(specInfo); err != nil {
+		return fmt.Errorf("failed to add spec to registry: %w", err)
+	}
+	return s.registerToolsFromSpec(specInfo, "", source.Headers)
 }
 
-// registerToolsFromSpec parses a spec and registers MCP tools
+// addToRegistry registers a spec, attaching the auth policy derived from its
+// security schemes when none has been set. A spec whose upstream cannot be resolved
+// from its servers is rejected unless the service has a base URL of its own.
+func (s *Server) addToRegistry(specInfo *models.SpecInfo) error {
+	if specInfo.BaseURL == "" {
+		if _, err := serverBaseURL(specInfo); err != nil {
+			return err
+		}
+	}
+	if specInfo.AuthPolicy == nil {
+		specInfo.AuthPolicy = auth.PolicyFromSpec(specInfo.Spec)
+	}
+	if err := s.registry.Add(specInfo); err != nil {
+		return err
+	}
+	s.syncSpecResources(specInfo.ServiceName)
+	return nil
+}
+
+// serverBaseURL resolves the spec's first server with specs.BaseURL, naming the
+// service in the error
+func serverBaseURL(specInfo *models.SpecInfo) (string, error) {
+	baseURL, err := specs.BaseURL(specInfo)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve upstream for %s: %w", specInfo.ServiceName, err)
+	}
+	return baseURL, nil
+}
+
+// newEngine creates a proxy engine for one service with the configured upstream behaviour
+func (s *Server) newEngine(serviceName, baseURL string, headers map[string]string) *proxy.Engine {
+	upstream := s.config.Upstream
+
+	engine := proxy.New(s.logger.Named("proxy"), upstream.Timeout)
+	engine.SetServiceName(serviceName)
+	tlsConfig, err := proxy.NewTLSConfig(upstream.TLS.InsecureSkipVerify, upstream.TLS.CAFile)
+	if err != nil {
+		// Checked at startup, so only a CA file changed since then ends up here
+		s.logger.Error("Failed to configure upstream TLS, using the defaults",
+			zap.String("serviceName", serviceName),
+			zap.Error(err))
+	}
+	engine.SetTransport(upstream.MaxIdleConnsPerHost, upstream.IdleConnTimeout, tlsConfig)
+	engine.SetBaseURL(baseURL)
+	engine.SetBaseURLResolver(s.registry)
+	engine.SetHeaders(headers)
+	engine.SetExpectContinue(upstream.ExpectContinueTimeout, upstream.ExpectContinueThreshold)
+	engine.SetMaxDecodedBytes(upstream.MaxDecodedBytes)
+	engine.SetRetry(upstream.RetryCount+1, upstream.RetryDelay, upstream.RetryableStatusCodes)
+	engine.SetHooks(s.hookManager)
+	engine.SetRequestRecorder(s.registry)
+	engine.SetMock(upstream.Mock)
+	engine.SetDrainer(s.drainer)
+	if identityHeaders, err := proxy.NewIdentityHeaders(upstream.IdentityHeaders); err != nil {
+		// Checked at startup like the TLS configuration
+		s.logger.Error("Invalid upstream identity headers, not sending them",
+			zap.String("serviceName", serviceName),
+			zap.Error(err))
+	} else {
+		engine.SetIdentityHeaders(identityHeaders)
+	}
+	if upstream.CircuitBreaker.Threshold > 0 {
+		engine.SetCircuitBreaker(s.breakers, circuitbreaker.Config{
+			MaxFailures:  upstream.CircuitBreaker.Threshold,
+			ResetTimeout: upstream.CircuitBreaker.Timeout,
+		}, upstream.CircuitBreaker.Fallback)
+		engine.SetHealthURL(s.config.UpstreamHealthURL(serviceName))
+	}
+	return engine
+}
+
+// registerToolsFromSpec parses a spec and registers one MCP tool per operation,
+// replacing any tools from an earlier registration of the same service
 func (s *Server) registerToolsFromSpec(specInfo *models.SpecInfo, baseURL string, headers map[string]string) error {
-	// Set up proxy engine
-	if baseURL != "" {
-		s.proxyEngine.SetBaseURL(baseURL)
-	} else if len(specInfo.Spec.Servers) > 0 {
-		s.proxyEngine.SetBaseURL(specInfo.Spec.Servers[0].URL)
+	if specInfo.Spec == nil {
+		return fmt.Errorf("service %s has no parsed spec", specInfo.ServiceName)
 	}
-	s.proxyEngine.SetHeaders(headers)
+
+	// Each service gets its own engine so base URLs and headers do not leak between
+	// services. The engine prefers the base URL on the registry entry, looked up per call.
+	if baseURL == "" {
+		var err error
+		if baseURL, err = serverBaseURL(specInfo); err != nil && specInfo.BaseURL == "" {
+			return err
+		}
+	}
+	engine := s.newEngine(specInfo.ServiceName, baseURL, headers)
 
 	// Parse the OpenAPI spec
-	if err := s.parser.ParseSpec(specInfo.Spec); err != nil {
+	p := parser.New(s.logger.Named("parser"), "")
+	p.SetMaxSchemaDepth(s.config.MCP.MaxSchemaDepth)
+	if err := p.ParseSpec(specInfo.Spec); err != nil {
 		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
 	}
+	routes := p.GetRoutes()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.unregisterToolsLocked(specInfo.ServiceName)
+
+	tools := &serviceTools{
+		engine:   engine,
+		names:    make(map[string]string, len(routes)),
+		baseURL:  baseURL,
+		headers:  headers,
+		lastUsed: time.Now(),
+	}
+	s.tools[specInfo.ServiceName] = tools
 
 	// Register tools
-	routes := s.parser.GetRoutes()
-	for _, route := range routes {
-		executor := s.proxyEngine.GetExecutor(&route)
-		handler := s.createToolHandler(&route, executor)
+	registered := 0
+	for i := range routes {
+		route := &routes[i]
+		route.Tool.Name = s.toolNameLocked(specInfo.ServiceName, route.Tool.Name)
+		tools.names[route.OperationID] = route.Tool.Name
+		s.toolOwners[route.Tool.Name] = specInfo.ServiceName
+
+		if !s.registry.IsOperationEnabled(specInfo.ServiceName, route.OperationID) {
+			s.logger.Info("Skipping disabled operation",
+				zap.String("serviceName", specInfo.ServiceName),
+				zap.String("operationID", route.OperationID))
+			continue
+		}
 
-		s.mcpServer.AddTool(route.Tool, handler)
+		s.addRouteTool(specInfo.ServiceName, route, engine)
+		registered++
 		s.logger.Info("Registered MCP tool",
 			zap.String("name", route.Tool.Name),
 			zap.String("method", route.Method),
@@ -142,23 +458,197 @@ func (s *Server) registerToolsFromSpec(specInfo *models.SpecInfo, baseURL string
 
 	s.logger.Info("Successfully registered OpenAPI spec as MCP tools",
 		zap.String("serviceName", specInfo.ServiceName),
-		zap.Int("toolCount", len(routes)))
+		zap.Int("toolCount", registered))
 
 	return nil
 }
 
+// toolNameLocked returns the tool name for an operation, prefixing it with the
+// service name and mcp.toolNameSeparator when another service or a management tool
+// already uses it, and numbering it should the prefixed name be taken as well
+func (s *Server) toolNameLocked(serviceName, name string) string {
+	owner, taken := s.toolOwners[name]
+	if !taken || owner == serviceName {
+		return name
+	}
+
+	separator := s.config.MCP.ToolNameSeparator
+	if separator == "" {
+		separator = "_"
+	}
+	renamed := serviceName + separator + name
+	for i := 2; ; i++ {
+		if prefixedOwner, taken := s.toolOwners[renamed]; !taken || prefixedOwner == serviceName {
+			break
+		}
+		renamed = fmt.Sprintf("%s%s%s%s%d", serviceName, separator, name, separator, i)
+	}
+
+	s.logger.Info("Renamed tool whose name another service uses",
+		zap.String("serviceName", serviceName),
+		zap.String("tool", name),
+		zap.String("owner", owner),
+		zap.String("renamedTo", renamed))
+	return renamed
+}
+
+// evictIdleTools unregisters the operation tools of services unused since cutoff to
+// reclaim client context. Registry entries and tool names are kept so the tools come
+// back unchanged on the next access. It returns how many tools were removed.
+func (s *Server) evictIdleTools(cutoff time.Time) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	removed := 0
+	for serviceName, tools := range s.tools {
+		if tools.idle || !tools.lastUsed.Before(cutoff) {
+			continue
+		}
+
+		names := make([]string, 0, len(tools.names))
+		for _, name := range tools.names {
+			names = append(names, name)
+		}
+		s.mcpServer.DeleteTools(names...)
+		tools.idle = true
+		removed += len(names)
+
+		s.logger.Info("Unregistered tools of idle service",
+			zap.String("serviceName", serviceName),
+			zap.Time("lastUsed", tools.lastUsed))
+	}
+	return removed
+}
+
+// touchService records use of a service, registering its tools again if they were
+// evicted for inactivity
+func (s *Server) touchService(serviceName string) {
+	s.mutex.Lock()
+	tools := s.tools[serviceName]
+	if tools == nil {
+		s.mutex.Unlock()
+		return
+	}
+	tools.lastUsed = time.Now()
+	idle, baseURL, headers := tools.idle, tools.baseURL, tools.headers
+	s.mutex.Unlock()
+
+	if !idle {
+		return
+	}
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return
+	}
+	if err := s.registerToolsFromSpec(specInfo, baseURL, headers); err != nil {
+		s.logger.Warn("Failed to restore tools of idle service",
+			zap.String("serviceName", serviceName),
+			zap.Error(err))
+	}
+}
+
+// setHeaders stores new headers for a service and rebuilds its tools' engine so
+// proxied calls send them. Tools evicted for inactivity pick them up when restored.
+// It returns the resulting headers and whether the service exists.
+func (s *Server) setHeaders(serviceName string, headers map[string]string, replace bool) (map[string]string, bool) {
+	effective, ok := s.registry.SetHeaders(serviceName, headers, replace)
+	if !ok {
+		return nil, false
+	}
+
+	s.mutex.Lock()
+	tools := s.tools[serviceName]
+	if tools == nil {
+		s.mutex.Unlock()
+		return effective, true
+	}
+	tools.headers = effective
+	idle, baseURL := tools.idle, tools.baseURL
+	s.mutex.Unlock()
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if idle || specInfo == nil {
+		return effective, true
+	}
+	if err := s.registerToolsFromSpec(specInfo, baseURL, effective); err != nil {
+		s.logger.Warn("Failed to apply new headers to tools",
+			zap.String("serviceName", serviceName),
+			zap.Error(err))
+	}
+	return effective, true
+}
+
+// unregisterTools removes every operation tool registered for a service
+func (s *Server) unregisterTools(serviceName string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.unregisterToolsLocked(serviceName)
+}
+
+// unregisterToolsLocked removes a service's operation tools; the caller holds s.mutex
+func (s *Server) unregisterToolsLocked(serviceName string) {
+	tools, exists := s.tools[serviceName]
+	if !exists {
+		return
+	}
+
+	names := make([]string, 0, len(tools.names))
+	for _, name := range tools.names {
+		names = append(names, name)
+		delete(s.toolOwners, name)
+	}
+	s.mcpServer.DeleteTools(names...)
+	delete(s.tools, serviceName)
+}
+
 // createToolHandler creates an MCP tool handler for a route
-func (s *Server) createToolHandler(route *parser.RouteConfig, executor func(context.Context, map[string]interface{}) (*proxy.Response, error)) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+func (s *Server) createToolHandler(serviceName string, route *parser.RouteConfig, executor func(context.Context, map[string]interface{}) (*proxy.Response, error)) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		s.logger.Debug("Executing tool",
 			zap.String("tool", route.Tool.Name),
 			zap.String("operationID", route.OperationID))
 
+		if !s.registry.IsOperationEnabled(serviceName, route.OperationID) {
+			return mcp.NewToolResultError(fmt.Sprintf("Operation %s of service %s is disabled", route.OperationID, serviceName)), nil
+		}
+		s.touchService(serviceName)
+
 		// Get parameters from request
 		params := request.GetArguments()
+		if params == nil {
+			params = make(map[string]interface{})
+		}
+		resource := proxy.ResourcePath(route, params)
+
+		if specInfo, _ := s.registry.Get(serviceName); specInfo != nil && specInfo.ApplyParameterDefaults {
+			proxy.ApplyParameterDefaults(route, params)
+		}
+		s.applyBodyDefaults(serviceName, route, params)
+
+		// Reject malformed arguments before they reach the upstream
+		if err := proxy.ValidateRequest(route, params); err != nil {
+			s.logger.Debug("Tool arguments failed validation",
+				zap.String("tool", route.Tool.Name),
+				zap.Error(err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
 
-		// Execute the request
-		resp, err := executor(ctx, params)
+		// Thread the last-seen ETag into mutating calls that did not supply one
+		if s.config.Upstream.AutoIfMatch && parser.IsMutatingMethod(route.Method) {
+			if _, ok := params[parser.IfMatchParam]; !ok {
+				if etag, ok := s.etags.Get(ctx, resource); ok {
+					params[parser.IfMatchParam] = etag
+				}
+			}
+		}
+
+		// Execute the request, giving up on multipart bodies too large to return as parts
+		resp, err := executor(proxy.WithMaxMultipartBytes(ctx, proxy.DefaultMaxMultipartBytes), params)
+		var validationErr *proxy.ValidationError
+		if errors.As(err, &validationErr) {
+			return mcp.NewToolResultError(validationErr.Error()), nil
+		}
 		if err != nil {
 			s.logger.Error("Tool execution failed",
 				zap.String("tool", route.Tool.Name),
@@ -179,8 +669,45 @@ func (s *Server) createToolHandler(route *parser.RouteConfig, executor func(cont
 			zap.String("tool", route.Tool.Name),
 			zap.Int("statusCode", resp.StatusCode))
 
-		return mcp.NewToolResultText(string(resp.Body)), nil
+		result := upstreamResult(resp)
+
+		// Surface the ETag so it can be sent back as If-Match on a later update
+		if etag := resp.Headers.Get("ETag"); etag != "" {
+			result.Meta = mcp.NewMetaFromMap(map[string]any{"etag": etag})
+			s.etags.Set(ctx, resource, etag)
+		} else if strings.EqualFold(route.Method, http.MethodDelete) {
+			s.etags.Delete(ctx, resource)
+		}
+
+		return result, nil
+	}
+}
+
+// upstreamResult converts a successful upstream response into a tool result. A JSON
+// body becomes structured content, as do the parts of a multipart/mixed body; the
+// text fallback carries the status code.
+func upstreamResult(resp *proxy.Response) *mcp.CallToolResult {
+	if resp.IsMultipartMixed() {
+		parts, err := proxy.ParseMultipartMixed(resp, proxy.DefaultMaxMultipartBytes)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse multipart response: %v", err))
+		}
+		return mcp.NewToolResultStructured(map[string]interface{}{"parts": parts},
+			fmt.Sprintf("HTTP %d: multipart/mixed response with %d parts", resp.StatusCode, len(parts)))
+	}
+
+	text := fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(resp.Body))
+
+	var body interface{}
+	if len(resp.Body) == 0 || json.Unmarshal(resp.Body, &body) != nil {
+		return mcp.NewToolResultText(text)
+	}
+
+	// Structured content must be an object, so arrays and scalars are wrapped
+	if _, ok := body.(map[string]interface{}); !ok {
+		body = map[string]interface{}{"result": body}
 	}
+	return mcp.NewToolResultStructured(body, text)
 }
 
 // Start starts the MCP server in the configured mode
@@ -211,10 +738,9 @@ func (s *Server) startHTTP(ctx context.Context) error {
 	addr := fmt.Sprintf("%s:%d", s.config.MCP.Host, s.config.MCP.Port)
 	s.logger.Info("Starting MCP server in HTTP mode", zap.String("address", addr))
 
-	httpServer := mcpserver.NewStreamableHTTPServer(s.mcpServer)
 	server := &http.Server{
 		Addr:    addr,
-		Handler: httpServer,
+		Handler: s.httpHandler(),
 	}
 
 	// Start server in goroutine
@@ -237,15 +763,23 @@ func (s *Server) startHTTP(ctx context.Context) error {
 	}
 }
 
+// httpHandler builds the streamable HTTP transport wrapped in the configured limits
+func (s *Server) httpHandler() http.Handler {
+	sessionIDs := &limitedSessionIDs{
+		SessionIdManager: &mcpserver.InsecureStatefulSessionIdManager{},
+		sessions:         s.sessions,
+	}
+	return s.limitHandler(mcpserver.NewStreamableHTTPServer(s.mcpServer, mcpserver.WithSessionIdManager(sessionIDs)))
+}
+
 // startSSE starts the server in SSE mode
 func (s *Server) startSSE(ctx context.Context) error {
 	addr := fmt.Sprintf("%s:%d", s.config.MCP.Host, s.config.MCP.Port)
 	s.logger.Info("Starting MCP server in SSE mode", zap.String("address", addr))
 
-	sseServer := mcpserver.NewSSEServer(s.mcpServer)
 	server := &http.Server{
 		Addr:    addr,
-		Handler: sseServer,
+		Handler: s.limitHandler(mcpserver.NewSSEServer(s.mcpServer)),
 	}
 
 	// Start server in goroutine
@@ -268,29 +802,40 @@ func (s *Server) startSSE(ctx context.Context) error {
 	}
 }
 
+// Breakers returns the circuit breakers guarding operation tools' upstream calls
+func (s *Server) Breakers() *circuitbreaker.Manager {
+	return s.breakers
+}
+
 // Stop stops the MCP server
 func (s *Server) Stop() error {
 	s.logger.Info("Stopping MCP server")
+	if s.toolLimiter != nil {
+		s.toolLimiter.Stop()
+	}
 	return nil
 }
 
-// loadSpecFile loads an OpenAPI specification from a file
-func (s *Server) loadSpecFile(specFile string) (*openapi3.T, error) {
-	loader := openapi3.NewLoader()
-	loader.IsExternalRefsAllowed = false // Security: disable external refs
-
-	spec, err := loader.LoadFromFile(specFile)
+// loadSpecFile loads an OpenAPI specification from a file. With specs.allowInvalid a
+// spec that fails validation is returned along with its problems.
+func (s *Server) loadSpecFile(specFile string) (*openapi3.T, []string, error) {
+	spec, err := specs.LoadSpecFromFile(specFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load OpenAPI spec from file: %w", err)
+		return nil, nil, fmt.Errorf("failed to load OpenAPI spec from file: %w", err)
 	}
 
 	// Validate the spec
-	ctx := context.Background()
-	if err := spec.Validate(ctx); err != nil {
-		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	validationErrors, err := specs.CheckSpec(context.Background(), spec, s.config.Specs.AllowInvalid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(validationErrors) > 0 {
+		s.logger.Warn("OpenAPI spec failed validation, registering it anyway",
+			zap.String("file", specFile),
+			zap.Strings("errors", validationErrors))
 	}
 
-	return spec, nil
+	return spec, validationErrors, nil
 }
 
 // Legacy methods for compatibility
@@ -307,16 +852,23 @@ func (s *Server) AddSpec(ctx context.Context, url, serviceName string, headers m
 		return nil, fmt.Errorf("failed to fetch spec: %w", err)
 	}
 
-	if err := s.registry.Add(spec); err != nil {
+	if err := s.addToRegistry(spec); err != nil {
 		return nil, fmt.Errorf("failed to add spec to registry: %w", err)
 	}
 
+	if err := s.registerToolsFromSpec(spec, "", spec.Headers); err != nil {
+		return nil, fmt.Errorf("failed to register tools: %w", err)
+	}
+
 	return spec, nil
 }
 
 // RemoveSpec removes a specification
 func (s *Server) RemoveSpec(serviceName string) bool {
-	return s.registry.Remove(serviceName)
+	s.unregisterTools(serviceName)
+	removed := s.registry.Remove(serviceName)
+	s.syncSpecResources(serviceName)
+	return removed
 }
 
 // GetStats returns statistics
@@ -0,0 +1,2199 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/janitor"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+	"github.com/zeroLR/swagger-mcp-go/internal/specs"
+	"go.uber.org/zap"
+)
+
+const testSpecJSON = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "summary": "List pets",
+        "tags": ["pets"],
+        "responses": {"200": {"description": "ok"}}
+      }
+    },
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "summary": "Get a pet",
+        "tags": ["pets"],
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	return newTestServerWithConfig(t, nil)
+}
+
+// newTestServerWithConfig creates a test server, letting configure adjust the config first
+func newTestServerWithConfig(t *testing.T, configure func(cfg *config.Config)) *Server {
+	t.Helper()
+
+	logger := zap.NewNop()
+	cfg := &config.Config{}
+	cfg.Upstream.Timeout = 5 * time.Second
+	cfg.Specs.DefaultTTL = "1h"
+	if configure != nil {
+		configure(cfg)
+	}
+
+	reg := registry.New(logger)
+	fetcher := specs.New(logger, 5*time.Second, 1024*1024)
+	return NewServer(logger, cfg, reg, fetcher)
+}
+
+func loadTestSpec(t *testing.T, data string) *openapi3.T {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to load test spec: %v", err)
+	}
+	return spec
+}
+
+func registerTestSpec(t *testing.T, s *Server, serviceName, data string) *models.SpecInfo {
+	t.Helper()
+
+	specInfo := &models.SpecInfo{
+		ID:          serviceName + ":test",
+		ServiceName: serviceName,
+		URL:         "http://example.com/" + serviceName + ".json",
+		Spec:        loadTestSpec(t, data),
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	}
+	if err := s.registry.Add(specInfo); err != nil {
+		t.Fatalf("Failed to register spec: %v", err)
+	}
+	return specInfo
+}
+
+// callTool invokes a tool through the MCP JSON-RPC handler
+func callTool(t *testing.T, s *Server, name string, args map[string]interface{}) *mcp.CallToolResult {
+	t.Helper()
+
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "tools/call",
+		"params": map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+
+	response, ok := s.mcpServer.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("Tool %s returned a JSON-RPC error", name)
+	}
+	result, ok := response.Result.(mcp.CallToolResult)
+	if !ok {
+		t.Fatalf("Unexpected result type %T", response.Result)
+	}
+	return &result
+}
+
+// listToolNames returns the names of the currently registered tools
+func listToolNames(t *testing.T, s *Server) map[string]bool {
+	t.Helper()
+
+	message := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+	response, ok := s.mcpServer.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("tools/list returned a JSON-RPC error")
+	}
+	result, ok := response.Result.(mcp.ListToolsResult)
+	if !ok {
+		t.Fatalf("Unexpected result type %T", response.Result)
+	}
+
+	names := make(map[string]bool, len(result.Tools))
+	for _, tool := range result.Tools {
+		names[tool.Name] = true
+	}
+	return names
+}
+
+// structuredContent round-trips a tool's structured content through JSON
+func structuredContent(t *testing.T, result *mcp.CallToolResult) map[string]interface{} {
+	t.Helper()
+
+	if result.IsError {
+		t.Fatalf("Tool returned error: %v", result.Content)
+	}
+
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		t.Fatalf("Failed to encode structured content: %v", err)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		t.Fatalf("Failed to decode structured content: %v", err)
+	}
+	return content
+}
+
+func TestListServices_Pagination(t *testing.T) {
+	s := newTestServer(t)
+	for _, serviceName := range []string{"users", "billing", "orders", "inventory", "payments"} {
+		registerTestSpec(t, s, serviceName, testSpecJSON)
+	}
+
+	tests := []struct {
+		name          string
+		args          map[string]interface{}
+		expected      []string
+		expectedTotal float64
+	}{
+		{"all", nil, []string{"billing", "inventory", "orders", "payments", "users"}, 5},
+		{"page", map[string]interface{}{"limit": 2, "offset": 1}, []string{"inventory", "orders"}, 5},
+		{"filter by name", map[string]interface{}{"filter": "ERS"}, []string{"orders", "users"}, 2},
+		{"filter by title", map[string]interface{}{"filter": "pet store", "offset": 3}, []string{"payments", "users"}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := structuredContent(t, callTool(t, s, "listServices", tt.args))
+			var names []string
+			for _, service := range content["services"].([]interface{}) {
+				names = append(names, service.(map[string]interface{})["serviceName"].(string))
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+			if content["total"] != tt.expectedTotal || content["count"] != float64(len(tt.expected)) {
+				t.Errorf("Expected total %v and count %d, got %v and %v", tt.expectedTotal, len(tt.expected), content["total"], content["count"])
+			}
+		})
+	}
+
+	if result := callTool(t, s, "listServices", map[string]interface{}{"limit": -1}); !result.IsError {
+		t.Errorf("Expected a negative limit to be rejected")
+	}
+}
+
+func TestListServices_ValidationErrors(t *testing.T) {
+	s := newTestServer(t)
+	registerTestSpec(t, s, "petstore", testSpecJSON)
+	invalid := registerTestSpec(t, s, "store", storeSpecJSON)
+	invalid.ValidationErrors = []string{`unsupported 'type' value "strin"`}
+
+	content := structuredContent(t, callTool(t, s, "listServices", nil))
+	for _, item := range content["services"].([]interface{}) {
+		service := item.(map[string]interface{})
+		switch service["serviceName"] {
+		case "petstore":
+			if service["valid"] != true || service["validationErrors"] != nil {
+				t.Errorf("Expected petstore to be reported valid, got %v", service)
+			}
+		case "store":
+			errors, _ := service["validationErrors"].([]interface{})
+			if service["valid"] != false || len(errors) != 1 {
+				t.Errorf("Expected store to be reported invalid with its error, got %v", service)
+			}
+		}
+	}
+}
+
+func TestServiceMetadata(t *testing.T) {
+	s := newTestServer(t)
+	registerTestSpec(t, s, "petstore", testSpecJSON)
+
+	result := callTool(t, s, "setServiceMetadata", map[string]interface{}{
+		"serviceName": "petstore",
+		"metadata": map[string]interface{}{
+			"team":    "pets",
+			"runbook": "https://runbooks.example.com/petstore",
+		},
+	})
+	content := structuredContent(t, result)
+	if content["metadata"].(map[string]interface{})["team"] != "pets" {
+		t.Errorf("Expected team metadata in result, got %v", content)
+	}
+
+	// Surfaced by listServices
+	content = structuredContent(t, callTool(t, s, "listServices", nil))
+	services := content["services"].([]interface{})
+	if len(services) != 1 {
+		t.Fatalf("Expected 1 service, got %d", len(services))
+	}
+	service := services[0].(map[string]interface{})
+	if service["metadata"].(map[string]interface{})["runbook"] != "https://runbooks.example.com/petstore" {
+		t.Errorf("Expected runbook metadata in listServices, got %v", service)
+	}
+	if service["routeCount"].(float64) != 2 {
+		t.Errorf("Expected 2 routes, got %v", service["routeCount"])
+	}
+
+	// Surfaced by inspectRoute
+	content = structuredContent(t, callTool(t, s, "inspectRoute", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+	}))
+	service = content["service"].(map[string]interface{})
+	if service["metadata"].(map[string]interface{})["team"] != "pets" {
+		t.Errorf("Expected team metadata in inspectRoute, got %v", service)
+	}
+	if routes := content["routes"].([]interface{}); len(routes) != 1 {
+		t.Errorf("Expected 1 route, got %d", len(routes))
+	}
+
+	// Unknown service
+	if result := callTool(t, s, "setServiceMetadata", map[string]interface{}{
+		"serviceName": "missing",
+		"metadata":    map[string]interface{}{"team": "x"},
+	}); !result.IsError {
+		t.Errorf("Expected error for unknown service")
+	}
+}
+
+func TestAddSpecWithMetadata(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(testSpecJSON))
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	result := callTool(t, s, "addSpec", map[string]interface{}{
+		"url":         upstream.URL,
+		"serviceName": "petstore",
+		"ttl":         "30m",
+		"metadata":    map[string]interface{}{"owner": "team-pets"},
+	})
+	content := structuredContent(t, result)
+	if content["title"] != "Pet Store" {
+		t.Errorf("Expected title Pet Store, got %v", content["title"])
+	}
+
+	specInfo, _ := s.registry.Get("petstore")
+	if specInfo == nil || specInfo.Metadata["owner"] != "team-pets" {
+		t.Fatalf("Expected owner metadata to be stored, got %+v", specInfo)
+	}
+	if specInfo.TTL != 30*time.Minute {
+		t.Errorf("Expected TTL 30m, got %v", specInfo.TTL)
+	}
+}
+
+func TestUpdateSpecHeaders(t *testing.T) {
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	specInfo.Headers = map[string]string{"Authorization": "Bearer old"}
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, specInfo.Headers); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		expected map[string]string
+	}{
+		{
+			name:     "merge adds a header",
+			args:     map[string]interface{}{"headers": map[string]interface{}{"X-Tenant-Id": "acme"}},
+			expected: map[string]string{"Authorization": "Bearer old", "X-Tenant-Id": "acme"},
+		},
+		{
+			name:     "merge matches names regardless of case",
+			args:     map[string]interface{}{"headers": map[string]interface{}{"authorization": "Bearer new"}},
+			expected: map[string]string{"authorization": "Bearer new", "X-Tenant-Id": "acme"},
+		},
+		{
+			name:     "replace drops the other headers",
+			args:     map[string]interface{}{"headers": map[string]interface{}{"X-Tenant-Id": "globex"}, "replace": true},
+			expected: map[string]string{"X-Tenant-Id": "globex"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args["serviceName"] = "petstore"
+			content := structuredContent(t, callTool(t, s, "updateSpecHeaders", tt.args))
+			headers := content["headers"].(map[string]interface{})
+			if len(headers) != len(tt.expected) {
+				t.Errorf("Expected headers %v, got %v", tt.expected, headers)
+			}
+			for name, value := range tt.expected {
+				if headers[name] != value {
+					t.Errorf("Expected %s: %s, got %v", name, value, headers)
+				}
+			}
+
+			stored, _ := s.registry.Get("petstore")
+			if len(stored.Headers) != len(tt.expected) {
+				t.Errorf("Expected the registry to store %v, got %v", tt.expected, stored.Headers)
+			}
+
+			if result := callTool(t, s, "listPets", nil); result.IsError {
+				t.Fatalf("listPets failed: %v", result.Content)
+			}
+			for name, value := range tt.expected {
+				if received.Get(name) != value {
+					t.Errorf("Expected upstream to receive %s: %s, got %q", name, value, received.Get(name))
+				}
+			}
+			if _, replaced := tt.args["replace"]; replaced && received.Get("Authorization") != "" {
+				t.Errorf("Expected replaced headers not to be sent, got Authorization %q", received.Get("Authorization"))
+			}
+		})
+	}
+
+	result := callTool(t, s, "updateSpecHeaders", map[string]interface{}{
+		"serviceName": "missing",
+		"headers":     map[string]interface{}{"X-Tenant-Id": "acme"},
+	})
+	if !result.IsError {
+		t.Errorf("Expected an error for an unknown service")
+	}
+}
+
+func TestToolNameCollisions(t *testing.T) {
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"store": "` + name + `"}`))
+		}))
+	}
+
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.ToolNameSeparator = "__"
+	})
+	// A service whose own operation is named like the prefixed tool of another
+	prefixedSpec := strings.Replace(testSpecJSON, `"operationId": "getPet"`, `"operationId": "zoo__getPet"`, 1)
+	for _, service := range []struct{ name, spec string }{
+		{"petstore", testSpecJSON},
+		{"shelter", prefixedSpec},
+		{"zoo", testSpecJSON},
+	} {
+		upstream := newUpstream(service.name)
+		defer upstream.Close()
+		specInfo := registerTestSpec(t, s, service.name, service.spec)
+		if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+			t.Fatalf("Failed to register tools: %v", err)
+		}
+	}
+
+	expected := map[string]string{
+		"getPet":         "petstore",
+		"zoo__getPet":    "shelter",
+		"zoo__getPet__2": "zoo",
+	}
+	for tool, store := range expected {
+		content := structuredContent(t, callTool(t, s, tool, map[string]interface{}{"id": "1"}))
+		if content["store"] != store {
+			t.Errorf("Expected %s to call the %s upstream, got %v", tool, store, content)
+		}
+	}
+
+	// Operation IDs keep resolving to each service's own route
+	s.mutex.RLock()
+	zooName := s.tools["zoo"].names["getPet"]
+	s.mutex.RUnlock()
+	if zooName != "zoo__getPet__2" {
+		t.Errorf("Expected zoo's getPet to map to zoo__getPet__2, got %s", zooName)
+	}
+	content := structuredContent(t, callTool(t, s, "getOperationSchema", map[string]interface{}{
+		"serviceName": "zoo",
+		"operationId": "getPet",
+	}))
+	if content["path"] != "/pets/{id}" {
+		t.Errorf("Expected zoo's getPet route, got %v", content)
+	}
+}
+
+func TestSetBaseURL(t *testing.T) {
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id": "1", "store": "` + name + `"}`))
+		}))
+	}
+	petstore := newUpstream("petstore")
+	defer petstore.Close()
+	zoo := newUpstream("zoo")
+	defer zoo.Close()
+
+	s := newTestServer(t)
+	for _, serviceName := range []string{"petstore", "zoo"} {
+		specInfo := registerTestSpec(t, s, serviceName, testSpecJSON)
+		if err := s.registerToolsFromSpec(specInfo, "http://127.0.0.1:0", nil); err != nil {
+			t.Fatalf("Failed to register tools: %v", err)
+		}
+	}
+
+	for serviceName, upstream := range map[string]*httptest.Server{"petstore": petstore, "zoo": zoo} {
+		content := structuredContent(t, callTool(t, s, "setBaseURL", map[string]interface{}{
+			"serviceName": serviceName,
+			"baseURL":     upstream.URL + "/",
+		}))
+		if content["baseURL"] != upstream.URL+"/" {
+			t.Errorf("Expected base URL %s/, got %v", upstream.URL, content)
+		}
+	}
+
+	// Each service's tools call its own upstream without being registered again
+	content := structuredContent(t, callTool(t, s, "getPet", map[string]interface{}{"id": "1"}))
+	if content["store"] != "petstore" {
+		t.Errorf("Expected getPet to call the petstore upstream, got %v", content)
+	}
+	content = structuredContent(t, callTool(t, s, "zoo_getPet", map[string]interface{}{"id": "1"}))
+	if content["store"] != "zoo" {
+		t.Errorf("Expected zoo_getPet to call the zoo upstream, got %v", content)
+	}
+
+	// Clearing the base URL falls back to the upstream the tools were registered with
+	callTool(t, s, "setBaseURL", map[string]interface{}{"serviceName": "zoo", "baseURL": ""})
+	if result := callTool(t, s, "zoo_getPet", map[string]interface{}{"id": "1"}); !result.IsError {
+		t.Errorf("Expected zoo_getPet to call the unreachable registered upstream, got %v", result.Content)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"serviceName": "missing", "baseURL": petstore.URL},
+		{"serviceName": "petstore", "baseURL": "petstore.example.com"},
+	} {
+		if result := callTool(t, s, "setBaseURL", args); !result.IsError {
+			t.Errorf("Expected an error for %v", args)
+		}
+	}
+}
+
+func TestETagPassThrough(t *testing.T) {
+	var lastIfMatch string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("ETag", `"v1"`)
+			w.Write([]byte(`{"id":"1","name":"Rex"}`))
+		case http.MethodPut:
+			lastIfMatch = r.Header.Get("If-Match")
+			if lastIfMatch != `"v1"` {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			w.Header().Set("ETag", `"v2"`)
+			w.Write([]byte(`{"id":"1","name":"Max"}`))
+		}
+	}))
+	defer upstream.Close()
+
+	const specJSON = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+      "get": {"operationId": "getPet", "responses": {"200": {"description": "ok"}}},
+      "put": {
+        "operationId": "updatePet",
+        "requestBody": {"content": {"application/json": {"schema": {"type": "object"}}}},
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", specJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	// ETag is surfaced in the result metadata
+	result := callTool(t, s, "getPet", map[string]interface{}{"id": "1"})
+	if result.Meta == nil || result.Meta.AdditionalFields["etag"] != `"v1"` {
+		t.Fatalf("Expected etag in result metadata, got %+v", result.Meta)
+	}
+
+	// An explicit _ifMatch is forwarded
+	result = callTool(t, s, "updatePet", map[string]interface{}{
+		"id":       "1",
+		"body":     map[string]interface{}{"name": "Max"},
+		"_ifMatch": `"v0"`,
+	})
+	if !result.IsError || lastIfMatch != `"v0"` {
+		t.Errorf("Expected stale If-Match to be forwarded and rejected, got %q", lastIfMatch)
+	}
+
+	// Without auto-threading nothing is sent
+	callTool(t, s, "updatePet", map[string]interface{}{"id": "1", "body": map[string]interface{}{}})
+	if lastIfMatch != "" {
+		t.Errorf("Expected no If-Match without autoIfMatch, got %q", lastIfMatch)
+	}
+
+	// With auto-threading the last-seen ETag is used
+	s.config.Upstream.AutoIfMatch = true
+	result = callTool(t, s, "updatePet", map[string]interface{}{"id": "1", "body": map[string]interface{}{}})
+	if result.IsError || lastIfMatch != `"v1"` {
+		t.Errorf("Expected threaded If-Match %q, got %q", `"v1"`, lastIfMatch)
+	}
+	if etag, _ := s.etags.Get(context.Background(), "/pets/1"); etag != `"v2"` {
+		t.Errorf("Expected cached ETag to advance to %q, got %q", `"v2"`, etag)
+	}
+}
+
+func TestValidateRequestTool(t *testing.T) {
+	const specJSON = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "post": {
+        "operationId": "createPet",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer"}}],
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {
+            "type": "object",
+            "required": ["name"],
+            "properties": {"name": {"type": "string"}}
+          }}}
+        },
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", specJSON)
+
+	content := structuredContent(t, callTool(t, s, "validateRequest", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "createPet",
+		"arguments": map[string]interface{}{
+			"limit": "many",
+			"body":  map[string]interface{}{"name": float64(1)},
+		},
+	}))
+	if content["valid"] != false {
+		t.Fatalf("Expected invalid request, got %v", content)
+	}
+
+	errs := content["errors"].([]interface{})
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	queryErr := errs[0].(map[string]interface{})
+	if queryErr["in"] != "query" || queryErr["name"] != "limit" {
+		t.Errorf("Expected query error for limit, got %v", queryErr)
+	}
+	bodyErr := errs[1].(map[string]interface{})
+	if bodyErr["in"] != "body" || bodyErr["pointer"] != "/name" {
+		t.Errorf("Expected body error at /name, got %v", bodyErr)
+	}
+
+	// The same validation runs before proxying
+	if err := s.registerToolsFromSpec(specInfo, "http://127.0.0.1:0", nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+	result := callTool(t, s, "createPet", map[string]interface{}{"limit": "many", "body": map[string]interface{}{"name": "Rex"}})
+	if !result.IsError {
+		t.Fatalf("Expected validation error from proxied tool")
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "in: query, name: limit") {
+		t.Errorf("Expected error to locate the query parameter, got %q", text)
+	}
+}
+
+func TestSetOperationEnabled(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	result := callTool(t, s, "setOperationEnabled", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+		"enabled":     false,
+	})
+	content := structuredContent(t, result)
+	if disabled := content["disabledOperations"].([]interface{}); len(disabled) != 1 || disabled[0] != "getPet" {
+		t.Errorf("Expected getPet to be disabled, got %v", content)
+	}
+
+	tools := listToolNames(t, s)
+	if tools["getPet"] {
+		t.Errorf("Expected getPet tool to be removed")
+	}
+	if !tools["listPets"] {
+		t.Errorf("Expected sibling listPets tool to remain")
+	}
+	if result := callTool(t, s, "listPets", nil); result.IsError {
+		t.Errorf("Expected sibling operation to keep working, got %v", result.Content)
+	}
+
+	// Disabled operations are not registered again when the spec is reloaded
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+	if listToolNames(t, s)["getPet"] {
+		t.Errorf("Expected getPet to stay unregistered after reload")
+	}
+
+	// Re-enabling restores the tool
+	callTool(t, s, "setOperationEnabled", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+		"enabled":     true,
+	})
+	if result := callTool(t, s, "getPet", map[string]interface{}{"id": "1"}); result.IsError {
+		t.Errorf("Expected getPet to work after re-enabling, got %v", result.Content)
+	}
+
+	// Unknown operations are rejected
+	if result := callTool(t, s, "setOperationEnabled", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "missing",
+		"enabled":     false,
+	}); !result.IsError {
+		t.Errorf("Expected error for unknown operation")
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/pets/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	callTool(t, s, "listPets", nil)
+	callTool(t, s, "getPet", map[string]interface{}{"id": "missing"})
+
+	content := structuredContent(t, callTool(t, s, "getStats", map[string]interface{}{"serviceName": "petstore"}))
+	services := content["services"].([]interface{})
+	if len(services) != 1 {
+		t.Fatalf("Expected 1 service, got %v", content)
+	}
+	stats := services[0].(map[string]interface{})
+	if stats["requestCount"] != float64(2) {
+		t.Errorf("Expected 2 requests, got %v", stats["requestCount"])
+	}
+	if stats["errorCount"] != float64(1) {
+		t.Errorf("Expected 1 error, got %v", stats["errorCount"])
+	}
+	if stats["routeCount"] != float64(2) {
+		t.Errorf("Expected 2 routes, got %v", stats["routeCount"])
+	}
+
+	if result := callTool(t, s, "getStats", map[string]interface{}{"serviceName": "missing"}); !result.IsError {
+		t.Errorf("Expected error for unknown service")
+	}
+}
+
+func TestApplyParameterDefaults(t *testing.T) {
+	var lastQuery string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastQuery = r.URL.RawQuery
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	const specJSON = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "default": 20}}],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", specJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	// Off by default
+	callTool(t, s, "listPets", nil)
+	if lastQuery != "" {
+		t.Errorf("Expected no query without applyDefaults, got %q", lastQuery)
+	}
+
+	specInfo.ApplyParameterDefaults = true
+	callTool(t, s, "listPets", nil)
+	if lastQuery != "limit=20" {
+		t.Errorf("Expected default limit to be sent, got %q", lastQuery)
+	}
+
+	// Caller-supplied values win
+	callTool(t, s, "listPets", map[string]interface{}{"limit": 5})
+	if lastQuery != "limit=5" {
+		t.Errorf("Expected caller limit to be sent, got %q", lastQuery)
+	}
+}
+
+func TestWhoami(t *testing.T) {
+	s := newTestServer(t)
+
+	manager := auth.NewManager(zap.NewNop())
+	bearer := auth.NewBearerTokenProvider(zap.NewNop())
+	if err := bearer.Configure(map[string]interface{}{"hmacSecret": "shared-secret"}); err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+	manager.RegisterProvider(models.AuthTypeBearer, bearer)
+	s.SetAuthManager(manager)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":           "user-1",
+		"name":          "Test User",
+		"scope":         "pets:read pets:write",
+		"email":         "user@example.com",
+		"refresh_token": "do-not-show",
+		"exp":           time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("shared-secret"))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	content := structuredContent(t, callTool(t, s, "whoami", map[string]interface{}{
+		"authorization": "Bearer " + token,
+	}))
+	if content["userId"] != "user-1" || content["username"] != "Test User" {
+		t.Errorf("Expected identity of user-1, got %v", content)
+	}
+	scopes := content["scopes"].([]interface{})
+	if len(scopes) != 2 || scopes[0] != "pets:read" || scopes[1] != "pets:write" {
+		t.Errorf("Expected pets:read and pets:write scopes, got %v", scopes)
+	}
+
+	claims := content["claims"].(map[string]interface{})
+	if claims["email"] != "user@example.com" {
+		t.Errorf("Expected email claim, got %v", claims)
+	}
+	if claims["refresh_token"] != "[REDACTED]" {
+		t.Errorf("Expected refresh_token claim to be redacted, got %v", claims["refresh_token"])
+	}
+
+	// Invalid credentials and unknown providers are reported as tool errors
+	if result := callTool(t, s, "whoami", map[string]interface{}{"authorization": "Bearer not-a-token"}); !result.IsError {
+		t.Errorf("Expected error for invalid token")
+	}
+	if result := callTool(t, s, "whoami", map[string]interface{}{"authType": "basic"}); !result.IsError {
+		t.Errorf("Expected error for unconfigured provider")
+	}
+}
+
+// testSession is a minimal MCP client session for exercising per-session behaviour.
+// Notifications are dropped unless notifications is set.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) SessionID() string { return s.id }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	if s.notifications != nil {
+		return s.notifications
+	}
+	return make(chan mcp.JSONRPCNotification, 1)
+}
+
+// postMCP sends a JSON-RPC message to a streamable HTTP MCP endpoint
+func postMCP(t *testing.T, url, sessionID, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	return resp
+}
+
+// assertLimitError checks an HTTP response carries a JSON-RPC error with the given limit reason
+func assertLimitError(t *testing.T, resp *http.Response, status int, reason string) {
+	t.Helper()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != status {
+		t.Fatalf("Expected status %d, got %d", status, resp.StatusCode)
+	}
+
+	var message struct {
+		Error struct {
+			Code int        `json:"code"`
+			Data limitError `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	if message.Error.Code != mcp.INVALID_REQUEST {
+		t.Errorf("Expected JSON-RPC code %d, got %d", mcp.INVALID_REQUEST, message.Error.Code)
+	}
+	if message.Error.Data.Reason != reason {
+		t.Errorf("Expected reason %s, got %s", reason, message.Error.Data.Reason)
+	}
+}
+
+const initializeMessage = `{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {"protocolVersion": "2025-06-18", "capabilities": {}, "clientInfo": {"name": "test", "version": "1.0.0"}}}`
+
+func TestSessionLimit(t *testing.T) {
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.MaxSessions = 1
+	})
+	s.SetMode(ServerModeHTTP)
+
+	server := httptest.NewServer(s.httpHandler())
+	defer server.Close()
+	url := server.URL + "/mcp"
+
+	resp := postMCP(t, url, "", initializeMessage)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected first session to open, got status %d", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("Expected a session ID for the first session")
+	}
+
+	// Requests within the open session are unaffected
+	resp = postMCP(t, url, sessionID, `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected request in existing session to succeed, got status %d", resp.StatusCode)
+	}
+
+	assertLimitError(t, postMCP(t, url, "", initializeMessage), http.StatusServiceUnavailable, limitSessions)
+
+	// Terminating the session frees its slot
+	req, _ := http.NewRequest(http.MethodDelete, url, nil)
+	req.Header.Set("Mcp-Session-Id", sessionID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	resp.Body.Close()
+
+	resp = postMCP(t, url, "", initializeMessage)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a session to open after termination, got status %d", resp.StatusCode)
+	}
+}
+
+func TestPrunedSessionIsTerminated(t *testing.T) {
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.MaxSessions = 1
+	})
+	s.SetMode(ServerModeHTTP)
+
+	server := httptest.NewServer(s.httpHandler())
+	defer server.Close()
+	url := server.URL + "/mcp"
+
+	resp := postMCP(t, url, "", initializeMessage)
+	resp.Body.Close()
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	if sessionID == "" {
+		t.Fatal("Expected a session ID")
+	}
+
+	j := janitor.New(zap.NewNop(), time.Minute, 0)
+	s.RegisterJanitorTasks(j, 30*time.Minute)
+	if reclaimed := j.RunOnce(time.Now().Add(time.Hour)); reclaimed["mcp_sessions"] != 1 {
+		t.Fatalf("Expected the idle session to be pruned, got %v", reclaimed)
+	}
+
+	// The pruned session no longer holds a slot and cannot be used without one
+	resp = postMCP(t, url, sessionID, `{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a pruned session to be reported terminated, got status %d", resp.StatusCode)
+	}
+
+	resp = postMCP(t, url, "", initializeMessage)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a new session to open in the freed slot, got status %d", resp.StatusCode)
+	}
+}
+
+func TestRequestPayloadLimit(t *testing.T) {
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.MaxRequestBytes = 512
+	})
+	s.SetMode(ServerModeHTTP)
+
+	server := httptest.NewServer(s.httpHandler())
+	defer server.Close()
+	url := server.URL + "/mcp"
+
+	resp := postMCP(t, url, "", initializeMessage)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected small request to succeed, got status %d", resp.StatusCode)
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+
+	oversized := `{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "listServices", "arguments": {"padding": "` +
+		strings.Repeat("x", 1024) + `"}}}`
+	assertLimitError(t, postMCP(t, url, sessionID, oversized), http.StatusRequestEntityTooLarge, limitPayload)
+}
+
+func TestToolCallRateLimit(t *testing.T) {
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.ToolCallsPerMinute = 2
+	})
+	defer s.Stop()
+
+	call := func(ctx context.Context) mcp.CallToolResult {
+		message := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": {"name": "listServices"}}`)
+		response, ok := s.mcpServer.HandleMessage(ctx, message).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatal("tools/call returned a JSON-RPC error")
+		}
+		return response.Result.(mcp.CallToolResult)
+	}
+
+	first := s.mcpServer.WithContext(context.Background(), &testSession{id: "first"})
+	for i := 0; i < 2; i++ {
+		if result := call(first); result.IsError {
+			t.Fatalf("Expected call %d to be allowed, got %v", i+1, result.Content)
+		}
+	}
+
+	result := call(first)
+	if !result.IsError {
+		t.Fatal("Expected third call to be rate limited")
+	}
+	data, _ := json.Marshal(result.StructuredContent)
+	var limit limitError
+	if err := json.Unmarshal(data, &limit); err != nil {
+		t.Fatalf("Failed to decode structured error: %v", err)
+	}
+	if limit.Reason != limitToolRate || limit.Limit != 2 || limit.RetryAfter == "" {
+		t.Errorf("Unexpected structured error %+v", limit)
+	}
+
+	// Each session has its own budget
+	second := s.mcpServer.WithContext(context.Background(), &testSession{id: "second"})
+	if result := call(second); result.IsError {
+		t.Errorf("Expected another session to be unaffected, got %v", result.Content)
+	}
+}
+
+func TestOperationTools(t *testing.T) {
+	newUpstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/pets":
+				w.Write([]byte(`[{"id": "1", "name": "Rex"}]`))
+			case "/pets/1":
+				w.Write([]byte(`{"id": "1", "name": "Rex", "store": "` + name + `"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "not found"}`))
+			}
+		}))
+	}
+	petstore := newUpstream("petstore")
+	defer petstore.Close()
+	zoo := newUpstream("zoo")
+	defer zoo.Close()
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, petstore.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	names := listToolNames(t, s)
+	if !names["listPets"] || !names["getPet"] {
+		t.Fatalf("Expected listPets and getPet tools, got %v", names)
+	}
+
+	// Arrays are wrapped so structured content is always an object
+	result := callTool(t, s, "listPets", nil)
+	content := structuredContent(t, result)
+	if pets, ok := content["result"].([]interface{}); !ok || len(pets) != 1 {
+		t.Errorf("Expected wrapped pet list, got %v", content)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "HTTP 200: ") {
+		t.Errorf("Expected status code in text fallback, got %q", text)
+	}
+
+	content = structuredContent(t, callTool(t, s, "getPet", map[string]interface{}{"id": "1"}))
+	if content["name"] != "Rex" || content["store"] != "petstore" {
+		t.Errorf("Expected pet from petstore, got %v", content)
+	}
+
+	// A second service with the same operation IDs gets prefixed tool names
+	specInfo = registerTestSpec(t, s, "zoo", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, zoo.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+	names = listToolNames(t, s)
+	if !names["getPet"] || !names["zoo_getPet"] || !names["zoo_listPets"] {
+		t.Fatalf("Expected prefixed tools for colliding service, got %v", names)
+	}
+
+	content = structuredContent(t, callTool(t, s, "zoo_getPet", map[string]interface{}{"id": "1"}))
+	if content["store"] != "zoo" {
+		t.Errorf("Expected zoo_getPet to call the zoo upstream, got %v", content)
+	}
+	content = structuredContent(t, callTool(t, s, "getPet", map[string]interface{}{"id": "1"}))
+	if content["store"] != "petstore" {
+		t.Errorf("Expected getPet to keep calling the petstore upstream, got %v", content)
+	}
+
+	// Upstream errors carry the status code
+	result = callTool(t, s, "getPet", map[string]interface{}{"id": "2"})
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.HasPrefix(text, "HTTP 404: ") {
+		t.Errorf("Expected HTTP 404 error result, got %q", text)
+	}
+
+	// Removing a service drops its tools
+	s.RemoveSpec("zoo")
+	names = listToolNames(t, s)
+	if names["zoo_getPet"] || names["zoo_listPets"] || !names["getPet"] {
+		t.Errorf("Expected only zoo tools to be removed, got %v", names)
+	}
+}
+
+func TestLoadSpecFromFile(t *testing.T) {
+	specYAML := `openapi: 3.0.0
+info:
+  title: Pet Store
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: ok
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: ok
+`
+
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+	}{
+		{"JSON spec", "petstore.json", testSpecJSON},
+		{"YAML spec", "petstore.yaml", specYAML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specFile := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(specFile, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("Failed to write spec file: %v", err)
+			}
+
+			s := newTestServer(t)
+			s.SetMode(ServerModeHTTP)
+			if s.mode != ServerModeHTTP {
+				t.Errorf("Expected mode %s, got %s", ServerModeHTTP, s.mode)
+			}
+
+			if err := s.LoadSpecFromFile(specFile, "http://127.0.0.1:0", nil); err != nil {
+				t.Fatalf("LoadSpecFromFile() error = %v", err)
+			}
+
+			specInfo, _ := s.registry.Get("local")
+			if specInfo == nil {
+				t.Fatal("Expected spec to be registered as service local")
+			}
+			if specInfo.Spec.Info.Title != "Pet Store" {
+				t.Errorf("Expected title Pet Store, got %s", specInfo.Spec.Info.Title)
+			}
+
+			names := listToolNames(t, s)
+			if !names["listPets"] || !names["getPet"] {
+				t.Errorf("Expected listPets and getPet tools, got %v", names)
+			}
+		})
+	}
+}
+
+func TestLoadSpecSource_ServerVariables(t *testing.T) {
+	var requestedPath string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedPath = r.URL.Path
+		w.Write([]byte(`[]`))
+	}))
+	defer upstream.Close()
+
+	spec := strings.Replace(testSpecJSON, `"paths"`, `"servers": [{
+    "url": "http://{host}/{basePath}",
+    "variables": {"host": {"default": "`+strings.TrimPrefix(upstream.URL, "http://")+`"}, "basePath": {}}
+  }],
+  "paths"`, 1)
+	specFile := filepath.Join(t.TempDir(), "petstore.json")
+	if err := os.WriteFile(specFile, []byte(spec), 0o644); err != nil {
+		t.Fatalf("Failed to write spec file: %v", err)
+	}
+
+	s := newTestServer(t)
+	err := s.LoadSpecSource(context.Background(), config.SpecSource{ServiceName: "petstore", File: specFile})
+	if err == nil || !strings.HasSuffix(err.Error(), ": basePath") {
+		t.Fatalf("Expected an error naming the variable without a default, got %v", err)
+	}
+	if specInfo, _ := s.registry.Get("petstore"); specInfo != nil {
+		t.Error("Expected the spec not to be registered")
+	}
+
+	err = s.LoadSpecSource(context.Background(), config.SpecSource{
+		ServiceName:     "petstore",
+		File:            specFile,
+		ServerVariables: map[string]string{"basePath": "v2"},
+	})
+	if err != nil {
+		t.Fatalf("LoadSpecSource() error = %v", err)
+	}
+	if result := callTool(t, s, "listPets", nil); result.IsError {
+		t.Fatalf("listPets failed: %v", result.Content)
+	}
+	if requestedPath != "/v2/pets" {
+		t.Errorf("Expected the call to go to /v2/pets, got %s", requestedPath)
+	}
+}
+
+func TestLoadSpecFromFile_Invalid(t *testing.T) {
+	s := newTestServer(t)
+
+	if err := s.LoadSpecFromFile(filepath.Join(t.TempDir(), "missing.json"), "", nil); err == nil {
+		t.Error("Expected error for missing spec file")
+	}
+
+	specFile := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(specFile, []byte(`{"openapi": "3.0.0", "paths": {}}`), 0o644); err != nil {
+		t.Fatalf("Failed to write spec file: %v", err)
+	}
+	if err := s.LoadSpecFromFile(specFile, "", nil); err == nil {
+		t.Error("Expected error for spec without info")
+	}
+	if specInfo, _ := s.registry.Get("local"); specInfo != nil {
+		t.Error("Expected invalid spec not to be registered")
+	}
+}
+
+func TestServiceInfo(t *testing.T) {
+	specJSON := `{
+  "openapi": "3.0.0",
+  "info": {
+    "title": "Pet Store",
+    "version": "1.0.0",
+    "description": "` + strings.Repeat("A friendly pet store API. ", 40) + `",
+    "termsOfService": "https://example.com/terms",
+    "contact": {"name": "Pet Team", "email": "pets@example.com"},
+    "license": {"name": "Apache 2.0", "url": "https://www.apache.org/licenses/LICENSE-2.0"}
+  },
+  "paths": {
+    "/pets": {"get": {"operationId": "listPets", "responses": {"200": {"description": "ok"}}}}
+  }
+}`
+
+	s := newTestServer(t)
+	registerTestSpec(t, s, "petstore", specJSON)
+	registerTestSpec(t, s, "plain", testSpecJSON)
+
+	content := structuredContent(t, callTool(t, s, "inspectRoute", map[string]interface{}{"serviceName": "petstore"}))
+	info, ok := content["service"].(map[string]interface{})["info"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected service info, got %v", content["service"])
+	}
+	if contact := info["contact"].(map[string]interface{}); contact["email"] != "pets@example.com" {
+		t.Errorf("Expected contact email pets@example.com, got %v", contact)
+	}
+	if license := info["license"].(map[string]interface{}); license["name"] != "Apache 2.0" {
+		t.Errorf("Expected license Apache 2.0, got %v", license)
+	}
+	if info["termsOfService"] != "https://example.com/terms" {
+		t.Errorf("Expected terms of service, got %v", info["termsOfService"])
+	}
+
+	description := info["description"].(string)
+	if runes := []rune(description); len(runes) > maxDescriptionLength || !strings.HasSuffix(description, "…") {
+		t.Errorf("Expected description truncated to %d runes, got %d: %q", maxDescriptionLength, len(runes), description)
+	}
+
+	content = structuredContent(t, callTool(t, s, "listServices", nil))
+	for _, service := range content["services"].([]interface{}) {
+		service := service.(map[string]interface{})
+		switch service["serviceName"] {
+		case "petstore":
+			info := service["info"].(map[string]interface{})
+			if info["contact"].(map[string]interface{})["email"] != "pets@example.com" {
+				t.Errorf("Expected contact email in listServices, got %v", info)
+			}
+		case "plain":
+			if _, ok := service["info"]; ok {
+				t.Errorf("Expected no info for spec without description or contact, got %v", service["info"])
+			}
+		}
+	}
+}
+
+func TestTruncateDescription(t *testing.T) {
+	tests := []struct {
+		text     string
+		max      int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"  padded  ", 10, "padded"},
+		{"the quick brown fox jumps", 16, "the quick brown…"},
+		{"abcdefghijklmnop", 8, "abcdefg…"},
+		{"héllo wörld ünïcode", 12, "héllo wörld…"},
+	}
+
+	for _, tt := range tests {
+		if got := truncateDescription(tt.text, tt.max); got != tt.expected {
+			t.Errorf("truncateDescription(%q, %d) = %q, expected %q", tt.text, tt.max, got, tt.expected)
+		}
+	}
+}
+
+func TestJanitorPrunesIdleSessionState(t *testing.T) {
+	s := newTestServer(t)
+	s.SetMode(ServerModeHTTP)
+
+	active := s.mcpServer.WithContext(context.Background(), &testSession{id: "active"})
+	idle := s.mcpServer.WithContext(context.Background(), &testSession{id: "idle"})
+	s.etags.Set(active, "/pets/1", `"a"`)
+	s.etags.Set(idle, "/pets/1", `"b"`)
+	s.etags.Set(idle, "/pets/2", `"c"`)
+	s.sessions.Add("active")
+	s.sessions.Add("idle")
+
+	// Age the idle session's state
+	s.etags.sessions["idle"].lastUsed = time.Now().Add(-time.Hour)
+	s.sessions.active["idle"] = time.Now().Add(-time.Hour)
+
+	j := janitor.New(zap.NewNop(), time.Minute, 0)
+	s.RegisterJanitorTasks(j, 30*time.Minute)
+	reclaimed := j.RunOnce(time.Now())
+
+	if reclaimed["mcp_etags"] != 2 || reclaimed["mcp_sessions"] != 1 {
+		t.Errorf("Expected 2 ETags and 1 session reclaimed, got %v", reclaimed)
+	}
+	if _, ok := s.etags.Get(idle, "/pets/1"); ok {
+		t.Error("Expected idle session ETags to be pruned")
+	}
+	if etag, ok := s.etags.Get(active, "/pets/1"); !ok || etag != `"a"` {
+		t.Error("Expected active session ETags to be kept")
+	}
+	if _, ok := s.sessions.active["active"]; !ok || len(s.sessions.active) != 1 {
+		t.Errorf("Expected only the active session to remain, got %v", s.sessions.active)
+	}
+}
+
+func TestIdleServiceTools(t *testing.T) {
+	s := newTestServerWithConfig(t, func(cfg *config.Config) {
+		cfg.MCP.ToolIdleTimeout = 30 * time.Minute
+	})
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, "http://petstore.example.com", nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	j := janitor.New(zap.NewNop(), time.Minute, 0)
+	s.RegisterJanitorTasks(j, 0)
+
+	// Recently used services keep their tools
+	if reclaimed := j.RunOnce(time.Now()); reclaimed["mcp_idle_tools"] != 0 {
+		t.Errorf("Expected no tools reclaimed for an active service, got %v", reclaimed)
+	}
+
+	s.tools["petstore"].lastUsed = time.Now().Add(-time.Hour)
+	if reclaimed := j.RunOnce(time.Now()); reclaimed["mcp_idle_tools"] != 2 {
+		t.Errorf("Expected 2 tools reclaimed, got %v", reclaimed)
+	}
+
+	names := listToolNames(t, s)
+	if names["listPets"] || names["getPet"] {
+		t.Errorf("Expected idle service tools to be unregistered, got %v", names)
+	}
+	if !names["inspectRoute"] {
+		t.Error("Expected management tools to be kept")
+	}
+	if spec, _ := s.registry.Get("petstore"); spec == nil {
+		t.Fatal("Expected registry entry to be kept")
+	}
+
+	// Any access to the service restores its tools
+	result := callTool(t, s, "inspectRoute", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+	})
+	if result.IsError {
+		t.Fatalf("inspectRoute failed: %v", result.Content)
+	}
+
+	names = listToolNames(t, s)
+	if !names["listPets"] || !names["getPet"] {
+		t.Errorf("Expected tools to be restored on use, got %v", names)
+	}
+	if s.tools["petstore"].idle {
+		t.Error("Expected service to no longer be idle")
+	}
+}
+
+func TestCallOperation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Path", r.URL.Path)
+		if r.Header.Get("X-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "unauthorized"}`))
+			return
+		}
+		w.Write([]byte(`{"id": "` + strings.TrimPrefix(r.URL.Path, "/pets/") + `", "name": "Rex"}`))
+	}))
+	defer upstream.Close()
+
+	specJSON := `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "servers": [{"url": "` + upstream.URL + `"}],
+  "components": {"securitySchemes": {"key": {"type": "apiKey", "in": "header", "name": "X-Key"}}},
+  "security": [{"key": []}],
+  "paths": {
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+	s := newTestServer(t)
+	specInfo := &models.SpecInfo{
+		ServiceName: "petstore",
+		Spec:        loadTestSpec(t, specJSON),
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	}
+	if err := s.addToRegistry(specInfo); err != nil {
+		t.Fatalf("Failed to register spec: %v", err)
+	}
+
+	// Credentials are attached where the service's auth policy expects them
+	result := callTool(t, s, "callOperation", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+		"parameters":  map[string]interface{}{"id": "7"},
+		"apiKey":      "secret",
+	})
+	content := structuredContent(t, result)
+	if content["statusCode"] != float64(http.StatusOK) {
+		t.Errorf("Expected status 200, got %v", content["statusCode"])
+	}
+	if headers := content["headers"].(map[string]interface{}); headers["X-Request-Path"] != "/pets/7" {
+		t.Errorf("Expected upstream headers, got %v", headers)
+	}
+	if body := content["body"].(map[string]interface{}); body["id"] != "7" || body["name"] != "Rex" {
+		t.Errorf("Expected decoded upstream body, got %v", body)
+	}
+
+	result = callTool(t, s, "callOperation", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+		"parameters":  map[string]interface{}{"id": "7"},
+	})
+	if !result.IsError || !strings.HasPrefix(result.Content[0].(mcp.TextContent).Text, "HTTP 401") {
+		t.Errorf("Expected upstream 401 without credentials, got %v", result.Content)
+	}
+
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "unknown service",
+			args:     map[string]interface{}{"serviceName": "unknown", "operationId": "getPet"},
+			expected: "Service not found: unknown",
+		},
+		{
+			name:     "unknown operation",
+			args:     map[string]interface{}{"serviceName": "petstore", "operationId": "deletePet"},
+			expected: "Operation not found: deletePet",
+		},
+		{
+			name:     "invalid parameters",
+			args:     map[string]interface{}{"serviceName": "petstore", "operationId": "getPet"},
+			expected: "in: path, name: id: required parameter is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, s, "callOperation", tt.args)
+			if !result.IsError {
+				t.Fatalf("Expected tool error, got %v", result.Content)
+			}
+			if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, tt.expected) {
+				t.Errorf("Expected error containing %q, got %q", tt.expected, text)
+			}
+		})
+	}
+
+	// A required policy rejects calls without credentials before reaching the upstream
+	specInfo.AuthPolicy.Required = true
+	result = callTool(t, s, "callOperation", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "getPet",
+		"parameters":  map[string]interface{}{"id": "7"},
+	})
+	if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || text != "service requires apikey credentials" {
+		t.Errorf("Expected missing credentials error, got %q", text)
+	}
+}
+
+func TestPolicyCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		policy          *models.AuthPolicy
+		authorization   string
+		apiKey          string
+		expectedHeaders map[string]string
+		expectedQuery   map[string]string
+	}{
+		{"no policy", nil, "token", "", map[string]string{}, map[string]string{}},
+		{"bare bearer token", &models.AuthPolicy{Type: models.AuthTypeBearer}, "abc", "",
+			map[string]string{"Authorization": "Bearer abc"}, map[string]string{}},
+		{"full oauth2 header", &models.AuthPolicy{Type: models.AuthTypeOAuth2}, "Bearer abc", "",
+			map[string]string{"Authorization": "Bearer abc"}, map[string]string{}},
+		{"basic credentials", &models.AuthPolicy{Type: models.AuthTypeBasic}, "dXNlcjpwYXNz", "",
+			map[string]string{"Authorization": "Basic dXNlcjpwYXNz"}, map[string]string{}},
+		{"default api key header", &models.AuthPolicy{Type: models.AuthTypeAPIKey}, "", "k",
+			map[string]string{"X-API-Key": "k"}, map[string]string{}},
+		{"api key query", &models.AuthPolicy{Type: models.AuthTypeAPIKey, Config: map[string]interface{}{"queryKey": "api_key"}}, "", "k",
+			map[string]string{}, map[string]string{"api_key": "k"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers, query, err := policyCredentials(tt.policy, tt.authorization, tt.apiKey)
+			if err != nil {
+				t.Fatalf("policyCredentials() error = %v", err)
+			}
+			if fmt.Sprint(headers) != fmt.Sprint(tt.expectedHeaders) {
+				t.Errorf("Expected headers %v, got %v", tt.expectedHeaders, headers)
+			}
+			if fmt.Sprint(query) != fmt.Sprint(tt.expectedQuery) {
+				t.Errorf("Expected query %v, got %v", tt.expectedQuery, query)
+			}
+		})
+	}
+}
+
+func TestMultipartMixedResult(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=batch")
+		w.Write([]byte("--batch\r\nContent-Type: application/json\r\n\r\n{\"id\": \"1\"}\r\n" +
+			"--batch\r\nContent-Type: application/json\r\n\r\n{\"id\": \"2\"}\r\n--batch--\r\n"))
+	}))
+	defer upstream.Close()
+
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	result := callTool(t, s, "listPets", nil)
+	parts, ok := structuredContent(t, result)["parts"].([]interface{})
+	if !ok || len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %v", result.StructuredContent)
+	}
+	second := parts[1].(map[string]interface{})["body"].(map[string]interface{})
+	if second["id"] != "2" {
+		t.Errorf("Expected second part body to be decoded, got %v", second)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "HTTP 200: multipart/mixed response with 2 parts" {
+		t.Errorf("Unexpected text fallback %q", text)
+	}
+}
+
+func TestExportSpec(t *testing.T) {
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", testSpecJSON)
+	specInfo.AuthPolicy = &models.AuthPolicy{Type: models.AuthTypeBearer, Required: true}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			result := callTool(t, s, "exportSpec", map[string]interface{}{
+				"serviceName": "petstore",
+				"format":      format,
+			})
+			content := structuredContent(t, result)
+			if content["format"] != format {
+				t.Errorf("Expected format %s, got %v", format, content["format"])
+			}
+
+			var data []byte
+			if format == "yaml" {
+				data = []byte(content["document"].(string))
+			} else {
+				data, _ = json.Marshal(content["document"])
+			}
+
+			exported, err := openapi3.NewLoader().LoadFromData(data)
+			if err != nil {
+				t.Fatalf("Failed to load exported spec: %v", err)
+			}
+			if exported.Info.Title != "Pet Store" || exported.Info.Version != "1.0.0" {
+				t.Errorf("Expected Pet Store 1.0.0, got %s %s", exported.Info.Title, exported.Info.Version)
+			}
+			if exported.Paths.Len() != specInfo.Spec.Paths.Len() {
+				t.Errorf("Expected %d paths, got %d", specInfo.Spec.Paths.Len(), exported.Paths.Len())
+			}
+			if exported.Components != nil && len(exported.Components.SecuritySchemes) > 0 {
+				t.Errorf("Expected registry auth policy not to leak into the document")
+			}
+		})
+	}
+
+	result := callTool(t, s, "exportSpec", map[string]interface{}{"serviceName": "unknown"})
+	if !result.IsError || result.Content[0].(mcp.TextContent).Text != "Service not found: unknown" {
+		t.Errorf("Expected service not found error, got %v", result.Content)
+	}
+}
+
+const storeSpecJSON = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Store", "version": "1.0.0"},
+  "paths": {
+    "/orders": {
+      "get": {
+        "operationId": "listOrders",
+        "summary": "List orders",
+        "tags": ["orders", "admin"],
+        "responses": {"200": {"description": "ok"}}
+      },
+      "post": {
+        "operationId": "createOrder",
+        "description": "Places an order for a PET",
+        "tags": ["orders"],
+        "responses": {"201": {"description": "created"}}
+      }
+    },
+    "/inventory": {
+      "get": {
+        "operationId": "getInventory",
+        "tags": ["inventory", "admin"],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+func TestGetOperationSchema(t *testing.T) {
+	specJSON := `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets/{id}": {
+      "put": {
+        "operationId": "updatePet",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+          {"name": "notify", "in": "query", "schema": {"type": "boolean"}},
+          {"name": "X-Request-Id", "in": "header", "schema": {"type": "string"}}
+        ],
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {"type": "object", "required": ["name"], "properties": {"id": {"type": "string", "readOnly": true}, "name": {"type": "string"}}}
+    }
+  }
+}`
+	s := newTestServer(t)
+	specInfo := registerTestSpec(t, s, "petstore", specJSON)
+
+	content := structuredContent(t, callTool(t, s, "getOperationSchema", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "updatePet",
+	}))
+	if content["method"] != "PUT" || content["path"] != "/pets/{id}" {
+		t.Errorf("Expected PUT /pets/{id}, got %v %v", content["method"], content["path"])
+	}
+
+	expectedParameters := []string{"id in path required", "notify in query", "X-Request-Id in header", "body in body required"}
+	parameters := content["parameters"].([]interface{})
+	if len(parameters) != len(expectedParameters) {
+		t.Fatalf("Expected parameters %v, got %v", expectedParameters, parameters)
+	}
+	for i, expected := range expectedParameters {
+		param := parameters[i].(map[string]interface{})
+		described := fmt.Sprintf("%s in %s", param["name"], param["in"])
+		if param["required"] == true {
+			described += " required"
+		}
+		if described != expected {
+			t.Errorf("Expected parameter %q, got %q", expected, described)
+		}
+	}
+
+	// The schemas are those of the operation's tool
+	var tool mcp.Tool
+	for _, route := range s.parseRoutes(specInfo) {
+		if route.OperationID == "updatePet" {
+			tool = route.Tool
+		}
+	}
+	for field, schema := range map[string]interface{}{"inputSchema": tool.InputSchema, "outputSchema": tool.OutputSchema} {
+		data, _ := json.Marshal(schema)
+		var expected interface{}
+		json.Unmarshal(data, &expected)
+		if !reflect.DeepEqual(content[field], expected) {
+			t.Errorf("Expected %s %v, got %v", field, expected, content[field])
+		}
+	}
+	output := content["outputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := output["id"]; !ok {
+		t.Errorf("Expected the response's read-only id in the output schema, got %v", output)
+	}
+
+	if result := callTool(t, s, "getOperationSchema", map[string]interface{}{
+		"serviceName": "petstore",
+		"operationId": "deletePet",
+	}); !result.IsError {
+		t.Errorf("Expected an error for an unknown operation")
+	}
+}
+
+func TestInspectRoute_Deprecated(t *testing.T) {
+	spec := strings.Replace(testSpecJSON, `"summary": "Get a pet",`,
+		`"summary": "Get a pet", "deprecated": true, "x-sunset": "2026-06-30", "x-deprecation": "Use getAnimal instead",`, 1)
+	s := newTestServer(t)
+	registerTestSpec(t, s, "petstore", spec)
+
+	content := structuredContent(t, callTool(t, s, "inspectRoute", map[string]interface{}{"serviceName": "petstore"}))
+	for _, item := range content["routes"].([]interface{}) {
+		route := item.(map[string]interface{})
+		switch route["operationId"] {
+		case "getPet":
+			if route["deprecated"] != true || route["sunset"] != "2026-06-30" || route["deprecationNote"] != "Use getAnimal instead" {
+				t.Errorf("Expected getPet to be reported deprecated with its guidance, got %v", route)
+			}
+		default:
+			if _, ok := route["deprecated"]; ok {
+				t.Errorf("Expected %s not to be reported deprecated, got %v", route["operationId"], route)
+			}
+		}
+	}
+}
+
+func TestSearchOperations(t *testing.T) {
+	s := newTestServer(t)
+	registerTestSpec(t, s, "petstore", testSpecJSON)
+	registerTestSpec(t, s, "store", storeSpecJSON)
+
+	tests := []struct {
+		name     string
+		args     map[string]interface{}
+		expected []string
+	}{
+		{"keyword in operation ID and description", map[string]interface{}{"query": "PET"},
+			[]string{"listPets", "getPet", "createOrder"}},
+		{"keyword in path", map[string]interface{}{"query": "invent"}, []string{"getInventory"}},
+		{"keyword in summary", map[string]interface{}{"query": "list"}, []string{"listPets", "listOrders"}},
+		{"single tag", map[string]interface{}{"tags": []string{"admin"}}, []string{"listOrders", "getInventory"}},
+		{"any of several tags", map[string]interface{}{"tags": []string{"Inventory", "pets"}},
+			[]string{"listPets", "getPet", "getInventory"}},
+		{"tag and keyword", map[string]interface{}{"query": "order", "tags": []string{"admin"}}, []string{"listOrders"}},
+		{"method filter", map[string]interface{}{"method": "post"}, []string{"createOrder"}},
+		{"service filter", map[string]interface{}{"serviceName": "store", "query": "pet"}, []string{"createOrder"}},
+		{"no match", map[string]interface{}{"query": "unicorn"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := callTool(t, s, "searchOperations", tt.args)
+			if result.IsError {
+				t.Fatalf("searchOperations failed: %v", result.Content)
+			}
+
+			content := structuredContent(t, result)
+			operations, _ := content["operations"].([]interface{})
+
+			found := make([]string, 0, len(operations))
+			for _, operation := range operations {
+				found = append(found, operation.(map[string]interface{})["operationId"].(string))
+			}
+			sort.Strings(found)
+			expected := append([]string(nil), tt.expected...)
+			sort.Strings(expected)
+
+			if strings.Join(found, ",") != strings.Join(expected, ",") {
+				t.Errorf("Expected operations %v, got %v", expected, found)
+			}
+			if content["count"] != float64(len(expected)) {
+				t.Errorf("Expected count %d, got %v", len(expected), content["count"])
+			}
+		})
+	}
+
+	result := callTool(t, s, "searchOperations", map[string]interface{}{"serviceName": "missing"})
+	if !result.IsError {
+		t.Error("Expected error for unknown service")
+	}
+}
+
+func TestTestAuthPolicy(t *testing.T) {
+	s := newTestServer(t)
+
+	apiKeyConfig := map[string]interface{}{
+		"headerKey": "X-Key",
+		"keys": map[string]interface{}{
+			"k1": map[string]interface{}{"userId": "u1", "username": "alice", "scopes": []string{"read"}},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		args          map[string]interface{}
+		authenticated bool
+		errContains   string
+	}{
+		{"valid API key", map[string]interface{}{"authType": "apikey", "config": apiKeyConfig, "apiKey": "k1", "scopes": []string{"read"}}, true, ""},
+		{"unknown API key", map[string]interface{}{"authType": "apikey", "config": apiKeyConfig, "apiKey": "k2"}, false, "invalid or inactive API key"},
+		{"missing scope", map[string]interface{}{"authType": "apikey", "config": apiKeyConfig, "apiKey": "k1", "scopes": []string{"write"}}, false, "insufficient scopes"},
+		{"no credentials", map[string]interface{}{"authType": "apikey", "config": apiKeyConfig}, false, "requires apikey credentials"},
+		{"valid basic credentials", map[string]interface{}{
+			"authType":      "basic",
+			"config":        map[string]interface{}{"users": map[string]interface{}{"bob": "pw"}},
+			"authorization": "Ym9iOnB3", // bob:pw
+		}, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := structuredContent(t, callTool(t, s, "testAuthPolicy", tt.args))
+			if content["authenticated"] != tt.authenticated {
+				t.Fatalf("Expected authenticated=%v, got %v", tt.authenticated, content)
+			}
+			if tt.errContains != "" {
+				if errMsg, _ := content["error"].(string); !strings.Contains(errMsg, tt.errContains) {
+					t.Errorf("Expected error containing %q, got %q", tt.errContains, errMsg)
+				}
+			}
+		})
+	}
+
+	content := structuredContent(t, callTool(t, s, "testAuthPolicy", tests[0].args))
+	if content["userId"] != "u1" || content["username"] != "alice" {
+		t.Errorf("Expected identity of the sample key, got %v", content)
+	}
+
+	// The dry run must not leave a provider behind on the server
+	if _, ok := s.authManager.GetProvider(models.AuthTypeAPIKey); ok {
+		t.Error("Expected testAuthPolicy not to register an API key provider")
+	}
+
+	if result := callTool(t, s, "testAuthPolicy", map[string]interface{}{"authType": "kerberos"}); !result.IsError {
+		t.Error("Expected error for unsupported auth type")
+	}
+}
+
+func TestToolBodyDefaults(t *testing.T) {
+	var received map[string]interface{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "inv-1"}`))
+	}))
+	defer upstream.Close()
+
+	specJSON := `{
+  "openapi": "3.0.0",
+  "info": {"title": "Billing", "version": "1.0.0"},
+  "paths": {
+    "/invoices": {
+      "post": {
+        "operationId": "createInvoice",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {
+          "type": "object",
+          "required": ["apiVersion", "amount"],
+          "properties": {"apiVersion": {"type": "string"}, "amount": {"type": "integer"}}
+        }}}},
+        "responses": {"200": {"description": "ok"}}
+      }
+    },
+    "/refunds": {
+      "post": {
+        "operationId": "createRefund",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {
+          "type": "object",
+          "required": ["apiVersion"],
+          "properties": {"apiVersion": {"type": "string"}}
+        }}}},
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`
+
+	defaults := hooks.NewBodyDefaultsHook(hooks.PriorityHigh + 1)
+	defaults.SetDefaults("billing", "", map[string]interface{}{"apiVersion": "2024-01"})
+	manager := hooks.NewManager(zap.NewNop())
+	manager.RegisterHook(defaults)
+
+	s := newTestServer(t)
+	s.SetHookManager(manager)
+	specInfo := registerTestSpec(t, s, "billing", specJSON)
+	if err := s.registerToolsFromSpec(specInfo, upstream.URL, nil); err != nil {
+		t.Fatalf("Failed to register tools: %v", err)
+	}
+
+	listTools := func() map[string]mcp.Tool {
+		message := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}`)
+		response, ok := s.mcpServer.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("tools/list returned a JSON-RPC error")
+		}
+		tools := make(map[string]mcp.Tool)
+		for _, tool := range response.Result.(mcp.ListToolsResult).Tools {
+			tools[tool.Name] = tool
+		}
+		return tools
+	}
+	assertRelaxed := func(invoice mcp.ToolInputSchema) {
+		t.Helper()
+		body, _ := invoice.Properties["body"].(map[string]interface{})
+		if required, _ := body["required"].([]string); strings.Join(required, ",") != "amount" || !slices.Contains(invoice.Required, "body") {
+			t.Errorf("Expected only amount and the body to stay required, got %v and %v", body["required"], invoice.Required)
+		}
+	}
+
+	tools := listTools()
+	assertRelaxed(tools["createInvoice"].InputSchema)
+	if refund := tools["createRefund"].InputSchema; slices.Contains(refund.Required, "body") {
+		t.Errorf("Expected a body with only defaulted fields to be optional, got %v", refund.Required)
+	}
+
+	result := callTool(t, s, "createInvoice", map[string]interface{}{"body": map[string]interface{}{"amount": 10}})
+	if result.IsError {
+		t.Fatalf("Expected the defaulted field not to fail validation, got %+v", result.Content)
+	}
+	if received["apiVersion"] != "2024-01" || received["amount"] != float64(10) {
+		t.Errorf("Expected the default to be sent with the client's fields, got %v", received)
+	}
+
+	if result := callTool(t, s, "createRefund", map[string]interface{}{}); result.IsError {
+		t.Fatalf("Expected a missing body to be created from the defaults, got %+v", result.Content)
+	}
+	if received["apiVersion"] != "2024-01" {
+		t.Errorf("Expected the default body to be sent, got %v", received)
+	}
+
+	if result := callTool(t, s, "createInvoice", map[string]interface{}{"body": map[string]interface{}{}}); !result.IsError {
+		t.Errorf("Expected fields without defaults to stay required")
+	}
+
+	// The management tools see the defaults the same way the operation's tool does
+	received = nil
+	result = callTool(t, s, "callOperation", map[string]interface{}{
+		"serviceName": "billing",
+		"operationId": "createInvoice",
+		"parameters":  map[string]interface{}{"body": map[string]interface{}{"amount": 10}},
+	})
+	if result.IsError {
+		t.Fatalf("Expected callOperation to fill in the defaulted field, got %+v", result.Content)
+	}
+	if received["apiVersion"] != "2024-01" {
+		t.Errorf("Expected callOperation to send the default, got %v", received)
+	}
+
+	arguments := map[string]interface{}{"body": map[string]interface{}{"amount": 10}}
+	content := structuredContent(t, callTool(t, s, "validateRequest", map[string]interface{}{
+		"serviceName": "billing",
+		"operationId": "createInvoice",
+		"arguments":   arguments,
+	}))
+	if content["valid"] != true {
+		t.Errorf("Expected validateRequest to accept what the tool accepts, got %v", content["errors"])
+	}
+
+	content = structuredContent(t, callTool(t, s, "getOperationSchema", map[string]interface{}{
+		"serviceName": "billing",
+		"operationId": "createInvoice",
+	}))
+	inputSchema, _ := content["inputSchema"].(map[string]interface{})
+	properties, _ := inputSchema["properties"].(map[string]interface{})
+	schemaBody, _ := properties["body"].(map[string]interface{})
+	if required := fmt.Sprint(schemaBody["required"]); required != "[amount]" {
+		t.Errorf("Expected getOperationSchema to show the relaxed body, got required %s", required)
+	}
+
+	for _, enabled := range []bool{false, true} {
+		if result := callTool(t, s, "setOperationEnabled", map[string]interface{}{
+			"serviceName": "billing",
+			"operationId": "createInvoice",
+			"enabled":     enabled,
+		}); result.IsError {
+			t.Fatalf("setOperationEnabled(%v) failed: %+v", enabled, result.Content)
+		}
+	}
+	assertRelaxed(listTools()["createInvoice"].InputSchema)
+}
+
+func TestBridgeSpecEvents(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			s := newTestServerWithConfig(t, func(cfg *config.Config) {
+				cfg.MCP.SpecNotifications = enabled
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			session := &testSession{id: "subscriber", notifications: make(chan mcp.JSONRPCNotification, 10)}
+			if err := s.mcpServer.RegisterSession(ctx, session); err != nil {
+				t.Fatalf("Failed to register session: %v", err)
+			}
+			s.BridgeSpecEvents(ctx, s.registry.Events())
+			registerTestSpec(t, s, "petstore", testSpecJSON)
+
+			timeout := time.After(200 * time.Millisecond)
+			for {
+				select {
+				case notification := <-session.notifications:
+					if notification.Method != MethodNotificationSpecsChanged {
+						continue
+					}
+					if !enabled {
+						t.Fatalf("Expected no notification when disabled, got %v", notification)
+					}
+					params := notification.Params.AdditionalFields
+					if params["type"] != "spec.added" || params["serviceName"] != "petstore" || params["title"] != "Pet Store" {
+						t.Errorf("Expected the added petstore spec to be described, got %v", params)
+					}
+					if _, ok := params["headers"]; ok {
+						t.Errorf("Expected headers to be left out, got %v", params)
+					}
+					return
+				case <-timeout:
+					if enabled {
+						t.Fatalf("Expected a %s notification after a spec was added", MethodNotificationSpecsChanged)
+					}
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestBridgeSpecEvents_SyncsResources(t *testing.T) {
+	s := newTestServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.BridgeSpecEvents(ctx, s.registry.Events())
+
+	// waitForResource polls until the spec resource is readable, or no longer is
+	waitForResource := func(readable bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if _, ok := readResource(t, s, "spec://petstore"); ok == readable {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("Expected the spec resource readable=%v with spec notifications off", readable)
+	}
+
+	// Specs added and removed outside the server, e.g. through the admin API
+	s.registry.Add(&models.SpecInfo{
+		ServiceName: "petstore",
+		URL:         "http://example.com/petstore.json",
+		Spec:        loadTestSpec(t, testSpecJSON),
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+	waitForResource(true)
+	s.registry.Remove("petstore")
+	waitForResource(false)
+}
+
+// readResource reads a resource through the MCP JSON-RPC handler and decodes its JSON text
+func readResource(t *testing.T, s *Server, uri string) (map[string]interface{}, bool) {
+	t.Helper()
+
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]interface{}{"uri": uri},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode request: %v", err)
+	}
+
+	response, ok := s.mcpServer.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		return nil, false
+	}
+	result, ok := response.Result.(mcp.ReadResourceResult)
+	if !ok || len(result.Contents) != 1 {
+		t.Fatalf("Unexpected result %v", response.Result)
+	}
+	contents, ok := result.Contents[0].(mcp.TextResourceContents)
+	if !ok || contents.MIMEType != "application/json" {
+		t.Fatalf("Expected JSON text contents, got %v", result.Contents[0])
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(contents.Text), &document); err != nil {
+		t.Fatalf("Failed to decode resource: %v", err)
+	}
+	return document, true
+}
+
+func TestSpecResources(t *testing.T) {
+	s := newTestServer(t)
+	specInfo := &models.SpecInfo{
+		ID:          "petstore:test",
+		ServiceName: "petstore",
+		URL:         "http://example.com/petstore.json",
+		Spec:        loadTestSpec(t, testSpecJSON),
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	}
+	if err := s.addToRegistry(specInfo); err != nil {
+		t.Fatalf("Failed to register spec: %v", err)
+	}
+
+	message := []byte(`{"jsonrpc": "2.0", "id": 1, "method": "resources/list"}`)
+	response, ok := s.mcpServer.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("resources/list returned a JSON-RPC error")
+	}
+	var uris []string
+	for _, resource := range response.Result.(mcp.ListResourcesResult).Resources {
+		uris = append(uris, resource.URI)
+	}
+	sort.Strings(uris)
+	if strings.Join(uris, ",") != "spec://petstore,spec://petstore/operations" {
+		t.Errorf("Expected the spec and operations resources, got %v", uris)
+	}
+
+	document, ok := readResource(t, s, "spec://petstore")
+	if !ok {
+		t.Fatalf("Failed to read the spec resource")
+	}
+	info, _ := document["info"].(map[string]interface{})
+	if document["openapi"] != "3.0.0" || info["title"] != "Pet Store" {
+		t.Errorf("Expected the petstore OpenAPI document, got %v", document)
+	}
+	if paths, _ := document["paths"].(map[string]interface{}); paths["/pets/{id}"] == nil {
+		t.Errorf("Expected the document's paths, got %v", document["paths"])
+	}
+
+	operations, ok := readResource(t, s, "spec://petstore/operations")
+	if !ok {
+		t.Fatalf("Failed to read the operations resource")
+	}
+	var operationIDs []string
+	for _, route := range operations["routes"].([]interface{}) {
+		operationIDs = append(operationIDs, route.(map[string]interface{})["operationId"].(string))
+	}
+	sort.Strings(operationIDs)
+	if strings.Join(operationIDs, ",") != "getPet,listPets" {
+		t.Errorf("Expected the petstore operations, got %v", operationIDs)
+	}
+
+	s.RemoveSpec("petstore")
+	if _, ok := readResource(t, s, "spec://petstore"); ok {
+		t.Errorf("Expected the spec resource to be removed with its service")
+	}
+}
@@ -0,0 +1,1087 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/mark3labs/mcp-go/mcp"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// serviceSummary is the tool-facing view of a registered spec
+type serviceSummary struct {
+	ServiceName        string            `json:"serviceName"`
+	URL                string            `json:"url"`
+	BaseURL            string            `json:"baseURL,omitempty"`
+	Title              string            `json:"title,omitempty"`
+	Version            string            `json:"version,omitempty"`
+	Info               *apiInfo          `json:"info,omitempty"`
+	FetchedAt          time.Time         `json:"fetchedAt"`
+	TTL                string            `json:"ttl"`
+	Expired            bool              `json:"expired"`
+	RouteCount         int               `json:"routeCount"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	DisabledOperations []string          `json:"disabledOperations,omitempty"`
+	ApplyDefaults      bool              `json:"applyDefaults,omitempty"`
+	Valid              bool              `json:"valid"`
+	ValidationErrors   []string          `json:"validationErrors,omitempty"`
+}
+
+// operationParameter is the tool-facing view of one argument of an operation
+type operationParameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"` // path, query, header, cookie or body
+	Required bool   `json:"required"`
+}
+
+// maxDescriptionLength caps spec descriptions in tool output; longer ones are truncated
+const maxDescriptionLength = 500
+
+// maxExportPreviewLength caps the text preview of an exported spec
+const maxExportPreviewLength = 2000
+
+// apiInfo is the human context from a spec's info object
+type apiInfo struct {
+	Description    string            `json:"description,omitempty"`
+	TermsOfService string            `json:"termsOfService,omitempty"`
+	Contact        *openapi3.Contact `json:"contact,omitempty"`
+	License        *openapi3.License `json:"license,omitempty"`
+}
+
+// registerManagementTools registers the MCP tools used to administer registered specs
+func (s *Server) registerManagementTools() {
+	s.addManagementTool(mcp.NewTool("addSpec",
+		mcp.WithDescription("Fetch an OpenAPI specification from a URL and register it as a service"),
+		mcp.WithString("url", mcp.Required(), mcp.Description("URL of the OpenAPI specification")),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Name to register the service under")),
+		mcp.WithString("ttl", mcp.Description("How long the spec stays fresh, e.g. 30m or 1h")),
+		mcp.WithObject("headers", mcp.Description("Headers sent when fetching the spec")),
+		mcp.WithObject("metadata", mcp.Description("Free-form operator annotations such as team or runbook URL")),
+		mcp.WithBoolean("applyDefaults", mcp.Description("Send documented defaults for optional parameters the caller omits")),
+	), s.handleAddSpec)
+
+	s.addManagementTool(mcp.NewTool("listServices",
+		mcp.WithDescription("List registered services with their spec details and metadata, ordered by service name"),
+		mcp.WithNumber("limit", mcp.Min(0), mcp.Description("Maximum number of services to return; all when omitted")),
+		mcp.WithNumber("offset", mcp.Min(0), mcp.Description("Number of services to skip")),
+		mcp.WithString("filter", mcp.Description("Only list services whose name or title contains this text, ignoring case")),
+	), s.handleListServices)
+
+	s.addManagementTool(mcp.NewTool("inspectRoute",
+		mcp.WithDescription("Show a service's details and its routes, optionally narrowed to one operation"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("operationId", mcp.Description("Operation to inspect; all routes are returned when omitted")),
+	), s.handleInspectRoute)
+
+	s.addManagementTool(mcp.NewTool("getOperationSchema",
+		mcp.WithDescription("Get the JSON Schema of an operation's tool arguments and of its successful result, with where each parameter is sent"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("operationId", mcp.Required(), mcp.Description("Operation to describe")),
+	), s.handleGetOperationSchema)
+
+	s.addManagementTool(mcp.NewTool("setServiceMetadata",
+		mcp.WithDescription("Attach operator annotations to a service; empty values remove a key"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithObject("metadata", mcp.Required(), mcp.Description("Key/value annotations to merge")),
+	), s.handleSetServiceMetadata)
+
+	s.addManagementTool(mcp.NewTool("updateSpecHeaders",
+		mcp.WithDescription("Change the headers stored with a service, used when refetching its spec and on proxied calls, and return the resulting headers"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithObject("headers", mcp.Required(), mcp.Description("Headers to merge; empty values remove a header")),
+		mcp.WithBoolean("replace", mcp.Description("Replace the stored headers instead of merging into them")),
+	), s.handleUpdateSpecHeaders)
+
+	s.addManagementTool(mcp.NewTool("setBaseURL",
+		mcp.WithDescription("Point a service's proxied calls at another upstream, e.g. a staging server, without re-registering its spec"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("baseURL", mcp.Required(), mcp.Description("Absolute http or https URL; empty reverts to the spec's first server")),
+	), s.handleSetBaseURL)
+
+	s.addManagementTool(mcp.NewTool("validateRequest",
+		mcp.WithDescription("Check arguments for an operation without calling the upstream; errors give the parameter location and name or body JSON pointer"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("operationId", mcp.Required(), mcp.Description("Operation to validate against")),
+		mcp.WithObject("arguments", mcp.Description("Tool arguments as they would be passed to the operation")),
+	), s.handleValidateRequest)
+
+	s.addManagementTool(mcp.NewTool("setOperationEnabled",
+		mcp.WithDescription("Take a single operation of a service offline, or bring it back, without affecting the rest of the service"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("operationId", mcp.Required(), mcp.Description("Operation to enable or disable")),
+		mcp.WithBoolean("enabled", mcp.Required(), mcp.Description("Whether the operation may be called")),
+	), s.handleSetOperationEnabled)
+
+	s.addManagementTool(mcp.NewTool("whoami",
+		mcp.WithDescription("Show the identity, scopes and claims the proxy derives from a set of credentials"),
+		mcp.WithString("authType", mcp.Description("Provider to use: bearer, basic, apikey or oauth2; defaults to the service's policy, then bearer")),
+		mcp.WithString("serviceName", mcp.Description("Use this service's auth policy to pick the provider")),
+		mcp.WithString("authorization", mcp.Description("Authorization header value; the transport's header is used when omitted")),
+		mcp.WithString("apiKey", mcp.Description("API key to check with the apikey provider")),
+	), s.handleWhoami)
+
+	s.addManagementTool(mcp.NewTool("testAuthPolicy",
+		mcp.WithDescription("Dry-run an auth policy against sample credentials and show the resulting identity and scopes; nothing is stored"),
+		mcp.WithString("authType", mcp.Required(), mcp.Description("Provider to test"), mcp.Enum("bearer", "basic", "apikey", "oauth2")),
+		mcp.WithObject("config", mcp.Description("Provider configuration, as in a service's auth policy")),
+		mcp.WithArray("scopes", mcp.WithStringItems(), mcp.Description("Scopes the policy requires")),
+		mcp.WithString("authorization", mcp.Description("Sample Authorization header value or bare token")),
+		mcp.WithString("apiKey", mcp.Description("Sample API key")),
+	), s.handleTestAuthPolicy)
+
+	s.addManagementTool(mcp.NewTool("callOperation",
+		mcp.WithDescription("Invoke any operation of a registered service by operation ID and return the upstream status code, headers and body"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("operationId", mcp.Required(), mcp.Description("Operation to invoke")),
+		mcp.WithObject("parameters", mcp.Description("Path, query and header parameters by name, with the request body under body, or _body when a parameter is named body")),
+		mcp.WithString("authorization", mcp.Description("Authorization header value or bare token for services with a bearer, basic or oauth2 auth policy")),
+		mcp.WithString("apiKey", mcp.Description("API key for services with an apikey auth policy")),
+	), s.handleCallOperation)
+
+	s.addManagementTool(mcp.NewTool("exportSpec",
+		mcp.WithDescription("Return the OpenAPI document a service was registered with"),
+		mcp.WithString("serviceName", mcp.Required(), mcp.Description("Registered service name")),
+		mcp.WithString("format", mcp.Description("Output format: json (default) or yaml"), mcp.Enum("json", "yaml")),
+	), s.handleExportSpec)
+
+	s.addManagementTool(mcp.NewTool("searchOperations",
+		mcp.WithDescription("Search the operations of registered services by keyword, tag and method"),
+		mcp.WithString("query", mcp.Description("Case-insensitive text matched against operation ID, summary, description and path")),
+		mcp.WithArray("tags", mcp.WithStringItems(), mcp.Description("Only return operations with at least one of these tags")),
+		mcp.WithString("method", mcp.Description("Only return operations with this HTTP method")),
+		mcp.WithString("serviceName", mcp.Description("Only search this service")),
+	), s.handleSearchOperations)
+
+	s.addManagementTool(mcp.NewTool("getStats",
+		mcp.WithDescription("Show request, error and latency metrics for registered services; latencies are in nanoseconds"),
+		mcp.WithString("serviceName", mcp.Description("Only return this service's stats")),
+	), s.handleGetStats)
+}
+
+// addManagementTool registers a management tool and reserves its name against operation tools
+func (s *Server) addManagementTool(tool mcp.Tool, handler mcpserver.ToolHandlerFunc) {
+	s.mutex.Lock()
+	s.toolOwners[tool.Name] = ""
+	s.mutex.Unlock()
+
+	s.mcpServer.AddTool(tool, handler)
+}
+
+// handleAddSpec fetches and registers a spec
+func (s *Server) handleAddSpec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	specURL, err := request.RequireString("url")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	ttl, err := s.parseTTL(request.GetString("ttl", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	args := request.GetArguments()
+	specInfo, err := s.fetcher.FetchSpec(ctx, specURL, serviceName, toStringMap(args["headers"]), ttl)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to fetch spec: %v", err)), nil
+	}
+	specInfo.Metadata = toStringMap(args["metadata"])
+	specInfo.ApplyParameterDefaults = request.GetBool("applyDefaults", false)
+
+	if err := s.addToRegistry(specInfo); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to register spec: %v", err)), nil
+	}
+	if err := s.registerToolsFromSpec(specInfo, "", specInfo.Headers); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to register tools: %v", err)), nil
+	}
+
+	return structuredResult(s.summarizeSpec(specInfo))
+}
+
+// handleListServices lists the registered services, a page at a time when limit and
+// offset are given
+func (s *Server) handleListServices(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := request.GetInt("limit", 0)
+	offset := request.GetInt("offset", 0)
+	if limit < 0 || offset < 0 {
+		return mcp.NewToolResultError("limit and offset must not be negative"), nil
+	}
+
+	specs, total := s.registry.ListPage(request.GetString("filter", ""), offset, limit)
+	services := make([]serviceSummary, 0, len(specs))
+	for _, specInfo := range specs {
+		services = append(services, s.summarizeSpec(specInfo))
+	}
+
+	return structuredResult(map[string]interface{}{
+		"services": services,
+		"count":    len(services),
+		"total":    total,
+	})
+}
+
+// handleGetStats reports the request metrics of one or all services
+func (s *Server) handleGetStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if serviceName := request.GetString("serviceName", ""); serviceName != "" {
+		stats, exists := s.registry.ServiceStats(serviceName)
+		if !exists {
+			return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+		}
+		return structuredResult(map[string]interface{}{
+			"services": []models.ServiceStats{stats},
+			"count":    1,
+		})
+	}
+
+	stats := s.registry.AllServiceStats()
+	return structuredResult(map[string]interface{}{
+		"services": stats,
+		"count":    len(stats),
+	})
+}
+
+// handleInspectRoute describes a service and its routes
+func (s *Server) handleInspectRoute(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	s.touchService(serviceName)
+
+	operationID := request.GetString("operationId", "")
+	routes := make([]models.RouteInfo, 0)
+	for _, route := range s.routeInfos(specInfo) {
+		if operationID == "" || route.OperationID == operationID {
+			routes = append(routes, route)
+		}
+	}
+
+	if operationID != "" && len(routes) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation not found: %s", operationID)), nil
+	}
+
+	return structuredResult(map[string]interface{}{
+		"service": s.summarizeSpec(specInfo),
+		"routes":  routes,
+	})
+}
+
+// handleSearchOperations finds operations across registered services
+func (s *Server) handleSearchOperations(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query := strings.ToLower(request.GetString("query", ""))
+	tags := request.GetStringSlice("tags", nil)
+	method := request.GetString("method", "")
+	serviceName := request.GetString("serviceName", "")
+
+	var specs []*models.SpecInfo
+	if serviceName != "" {
+		specInfo, _ := s.registry.Get(serviceName)
+		if specInfo == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+		}
+		specs = []*models.SpecInfo{specInfo}
+	} else {
+		specs = s.registry.List()
+		sort.Slice(specs, func(i, j int) bool {
+			return specs[i].ServiceName < specs[j].ServiceName
+		})
+	}
+
+	operations := make([]models.RouteInfo, 0)
+	for _, specInfo := range specs {
+		for _, route := range s.parseRoutes(specInfo) {
+			if method != "" && !strings.EqualFold(route.Method, method) {
+				continue
+			}
+			if len(tags) > 0 && !hasAnyTag(route.Tags, tags) {
+				continue
+			}
+			if query != "" && !routeMatches(route, query) {
+				continue
+			}
+			operations = append(operations, models.RouteInfo{
+				Path:            route.Path,
+				Method:          route.Method,
+				ServiceName:     specInfo.ServiceName,
+				OperationID:     route.OperationID,
+				Summary:         route.Summary,
+				Tags:            route.Tags,
+				Deprecated:      route.Deprecated,
+				Sunset:          route.Sunset,
+				DeprecationNote: route.DeprecationNote,
+			})
+		}
+	}
+
+	return structuredResult(map[string]interface{}{
+		"operations": operations,
+		"count":      len(operations),
+	})
+}
+
+// handleGetOperationSchema returns the input and output schema of an operation's tool
+func (s *Server) handleGetOperationSchema(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	operationID, err := request.RequireString("operationId")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	s.touchService(serviceName)
+
+	var route *parser.RouteConfig
+	for _, candidate := range s.parseRoutes(specInfo) {
+		if candidate.OperationID == operationID {
+			route = &candidate
+			break
+		}
+	}
+	if route == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation not found: %s", operationID)), nil
+	}
+
+	// Describe the tool as registered, without the body fields the defaults supply
+	s.relaxDefaultedFields(serviceName, route)
+
+	parameters := make([]operationParameter, 0, len(route.Parameters)+1)
+	for _, param := range route.Parameters {
+		parameters = append(parameters, operationParameter{Name: param.Name, In: param.In, Required: param.Required})
+	}
+	if route.RequestBody != nil {
+		parameters = append(parameters, operationParameter{Name: route.BodyArgument(), In: "body", Required: route.RequestBody.Required})
+	}
+
+	result := map[string]interface{}{
+		"serviceName": serviceName,
+		"operationId": route.OperationID,
+		"method":      route.Method,
+		"path":        route.Path,
+		"parameters":  parameters,
+		"inputSchema": route.Tool.InputSchema,
+	}
+	// Operations without a JSON success body have no output schema
+	if route.Tool.OutputSchema.Type != "" {
+		result["outputSchema"] = route.Tool.OutputSchema
+	}
+	return structuredResult(result)
+}
+
+// handleSetServiceMetadata merges operator annotations into a service
+func (s *Server) handleSetServiceMetadata(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	metadata := toStringMap(request.GetArguments()["metadata"])
+	if metadata == nil {
+		return mcp.NewToolResultError("metadata must be an object of string values"), nil
+	}
+
+	merged, ok := s.registry.SetMetadata(serviceName, metadata)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+
+	return structuredResult(map[string]interface{}{
+		"serviceName": serviceName,
+		"metadata":    merged,
+	})
+}
+
+// handleUpdateSpecHeaders merges or replaces the headers stored with a service
+func (s *Server) handleUpdateSpecHeaders(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	headers := toStringMap(request.GetArguments()["headers"])
+	if headers == nil {
+		return mcp.NewToolResultError("headers must be an object of string values"), nil
+	}
+
+	effective, ok := s.setHeaders(serviceName, headers, request.GetBool("replace", false))
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+
+	return structuredResult(map[string]interface{}{
+		"serviceName": serviceName,
+		"headers":     effective,
+	})
+}
+
+// handleSetBaseURL sets or clears the upstream base URL of a service
+func (s *Server) handleSetBaseURL(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	baseURL, err := request.RequireString("baseURL")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if baseURL != "" {
+		parsed, err := url.Parse(baseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("baseURL must be an absolute http or https URL: %s", baseURL)), nil
+		}
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	effective := baseURL
+	if effective == "" {
+		if effective, err = serverBaseURL(specInfo); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot fall back to the spec's servers: %v", err)), nil
+		}
+	}
+
+	// Engines resolve the base URL per call, so the tools need not be rebuilt
+	if !s.registry.SetBaseURL(serviceName, baseURL) {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	return structuredResult(map[string]interface{}{
+		"serviceName": serviceName,
+		"baseURL":     effective,
+	})
+}
+
+// handleValidateRequest validates arguments against an operation's parameters and body schema
+func (s *Server) handleValidateRequest(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	operationID, err := request.RequireString("operationId")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	s.touchService(serviceName)
+
+	var route *parser.RouteConfig
+	for _, candidate := range s.parseRoutes(specInfo) {
+		if candidate.OperationID == operationID {
+			route = &candidate
+			break
+		}
+	}
+	if route == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation not found: %s", operationID)), nil
+	}
+
+	// Fill in defaults on a copy, as a tool call would before validating
+	arguments, _ := request.GetArguments()["arguments"].(map[string]interface{})
+	arguments = maps.Clone(arguments)
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	if specInfo.ApplyParameterDefaults {
+		proxy.ApplyParameterDefaults(route, arguments)
+	}
+	s.applyBodyDefaults(serviceName, route, arguments)
+
+	fieldErrors := make([]proxy.FieldError, 0)
+	if err := proxy.ValidateRequest(route, arguments); err != nil {
+		var validationErr *proxy.ValidationError
+		if !errors.As(err, &validationErr) {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		fieldErrors = validationErr.Errors
+	}
+
+	return structuredResult(map[string]interface{}{
+		"valid":  len(fieldErrors) == 0,
+		"errors": fieldErrors,
+	})
+}
+
+// handleSetOperationEnabled enables or disables one operation and updates its tool
+func (s *Server) handleSetOperationEnabled(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	operationID, err := request.RequireString("operationId")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	enabled, err := request.RequireBool("enabled")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+
+	var route *parser.RouteConfig
+	for _, candidate := range s.parseRoutes(specInfo) {
+		if candidate.OperationID == operationID {
+			route = &candidate
+			break
+		}
+	}
+	if route == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation not found: %s", operationID)), nil
+	}
+
+	if !s.registry.SetOperationEnabled(serviceName, operationID, enabled) {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+
+	// Keep the exposed tools in step with the operation state. Idle services pick up
+	// the operation state when their tools are restored.
+	var name string
+	var engine *proxy.Engine
+	s.mutex.RLock()
+	if tools := s.tools[serviceName]; tools != nil && !tools.idle {
+		name, engine = tools.names[operationID], tools.engine
+	}
+	s.mutex.RUnlock()
+	if name != "" {
+		route.Tool.Name = name
+		if enabled {
+			s.addRouteTool(serviceName, route, engine)
+		} else {
+			s.mcpServer.DeleteTools(name)
+		}
+	}
+
+	specInfo, _ = s.registry.Get(serviceName)
+	return structuredResult(map[string]interface{}{
+		"serviceName":        serviceName,
+		"operationId":        operationID,
+		"enabled":            enabled,
+		"disabledOperations": specInfo.DisabledOperations,
+	})
+}
+
+// handleWhoami authenticates the supplied credentials and reports the resulting identity
+func (s *Server) handleWhoami(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	authType := models.AuthType(request.GetString("authType", ""))
+	if serviceName := request.GetString("serviceName", ""); serviceName != "" && authType == "" {
+		specInfo, _ := s.registry.Get(serviceName)
+		if specInfo == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+		}
+		if specInfo.AuthPolicy != nil {
+			authType = specInfo.AuthPolicy.Type
+		}
+	}
+	if authType == "" {
+		authType = models.AuthTypeBearer
+	}
+
+	provider, ok := s.authManager.GetProvider(authType)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("No %s authentication provider is configured", authType)), nil
+	}
+
+	// Build the request the provider inspects, preferring explicit arguments
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	source := "transport"
+	if request.Header != nil {
+		httpRequest.Header = request.Header.Clone()
+	}
+	if authorization := request.GetString("authorization", ""); authorization != "" {
+		httpRequest.Header.Set("Authorization", authorization)
+		source = "arguments"
+	}
+	if apiKey := request.GetString("apiKey", ""); apiKey != "" {
+		httpRequest.Header.Set("X-API-Key", apiKey)
+		source = "arguments"
+	}
+
+	authCtx, err := provider.Authenticate(ctx, httpRequest)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Authentication failed (%s, credentials from %s): %v", authType, source, err)), nil
+	}
+
+	return structuredResult(map[string]interface{}{
+		"authType":         authType,
+		"credentialSource": source,
+		"userId":           authCtx.UserID,
+		"username":         authCtx.Username,
+		"scopes":           authCtx.Scopes,
+		"claims":           redactClaims(authCtx.Claims),
+	})
+}
+
+// handleTestAuthPolicy authenticates sample credentials against a policy built from the
+// arguments, using a fresh provider so the configured ones are left untouched
+func (s *Server) handleTestAuthPolicy(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	authType, err := request.RequireString("authType")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	policyConfig, _ := request.GetArguments()["config"].(map[string]interface{})
+	if policyConfig == nil {
+		policyConfig = make(map[string]interface{})
+	}
+	policy := &models.AuthPolicy{
+		Type:     models.AuthType(authType),
+		Config:   policyConfig,
+		Required: true,
+		Scopes:   request.GetStringSlice("scopes", nil),
+	}
+
+	provider, err := auth.NewProvider(policy.Type, s.logger.Named("authtest"))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if err := provider.Configure(policy.Config); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid %s policy config: %v", authType, err)), nil
+	}
+	manager := auth.NewManager(zap.NewNop())
+	manager.RegisterProvider(policy.Type, provider)
+
+	outcome := map[string]interface{}{
+		"authType":      policy.Type,
+		"authenticated": false,
+	}
+
+	headers, query, err := policyCredentials(policy, request.GetString("authorization", ""), request.GetString("apiKey", ""))
+	if err != nil {
+		outcome["error"] = err.Error()
+		return structuredResult(outcome)
+	}
+
+	// Synthetic request carrying the sample credentials where the provider looks for them
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodGet, "/", nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for name, value := range headers {
+		httpRequest.Header.Set(name, value)
+	}
+	values := httpRequest.URL.Query()
+	for name, value := range query {
+		values.Set(name, value)
+	}
+	httpRequest.URL.RawQuery = values.Encode()
+
+	authCtx, err := manager.Authenticate(ctx, httpRequest, policy)
+	if err != nil {
+		outcome["error"] = err.Error()
+		return structuredResult(outcome)
+	}
+
+	outcome["authenticated"] = true
+	outcome["userId"] = authCtx.UserID
+	outcome["username"] = authCtx.Username
+	outcome["scopes"] = authCtx.Scopes
+	outcome["claims"] = redactClaims(authCtx.Claims)
+	return structuredResult(outcome)
+}
+
+// redactClaims copies claims, masking any whose name suggests a secret
+func redactClaims(claims map[string]interface{}) map[string]interface{} {
+	if claims == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(claims))
+	for key, value := range claims {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "secret") || strings.Contains(lower, "password") ||
+			strings.Contains(lower, "token") || strings.Contains(lower, "key") {
+			redacted[key] = "[REDACTED]"
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// handleCallOperation executes one operation of a service with caller-supplied arguments
+func (s *Server) handleCallOperation(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	operationID, err := request.RequireString("operationId")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil || specInfo.Spec == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+	s.touchService(serviceName)
+
+	p := parser.New(s.logger.Named("parser"), "")
+	p.SetMaxSchemaDepth(s.config.MCP.MaxSchemaDepth)
+	if err := p.ParseSpec(specInfo.Spec); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to parse spec: %v", err)), nil
+	}
+	route := p.GetRouteByOperationID(operationID)
+	if route == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation not found: %s", operationID)), nil
+	}
+	if !s.registry.IsOperationEnabled(serviceName, operationID) {
+		return mcp.NewToolResultError(fmt.Sprintf("Operation %s of service %s is disabled", operationID, serviceName)), nil
+	}
+
+	params := make(map[string]interface{})
+	if parameters, ok := request.GetArguments()["parameters"].(map[string]interface{}); ok {
+		for name, value := range parameters {
+			params[name] = value
+		}
+	}
+	if specInfo.ApplyParameterDefaults {
+		proxy.ApplyParameterDefaults(route, params)
+	}
+	s.applyBodyDefaults(serviceName, route, params)
+	if err := proxy.ValidateRequest(route, params); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	headers, query, err := policyCredentials(specInfo.AuthPolicy,
+		request.GetString("authorization", ""), request.GetString("apiKey", ""))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	for name, value := range query {
+		params[name] = value
+	}
+
+	ctx = proxy.WithMaxMultipartBytes(proxy.WithRequestHeaders(ctx, headers), proxy.DefaultMaxMultipartBytes)
+	resp, err := s.engineFor(specInfo).ExecuteRoute(ctx, route, params)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Request failed: %v", err)), nil
+	}
+
+	responseHeaders := make(map[string]string, len(resp.Headers))
+	for name, values := range resp.Headers {
+		responseHeaders[name] = strings.Join(values, ", ")
+	}
+	var body interface{} = string(resp.Body)
+	var decoded interface{}
+	if resp.IsMultipartMixed() {
+		parts, err := proxy.ParseMultipartMixed(resp, proxy.DefaultMaxMultipartBytes)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to parse multipart response: %v", err)), nil
+		}
+		body = parts
+	} else if len(resp.Body) > 0 && json.Unmarshal(resp.Body, &decoded) == nil {
+		body = decoded
+	}
+
+	result := mcp.NewToolResultStructured(map[string]interface{}{
+		"statusCode": resp.StatusCode,
+		"headers":    responseHeaders,
+		"body":       body,
+	}, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(resp.Body)))
+	result.IsError = resp.StatusCode >= http.StatusBadRequest
+	return result, nil
+}
+
+// handleExportSpec serializes a service's parsed OpenAPI document. Only the document is
+// exported; registry-side state such as the auth policy or metadata is left out.
+func (s *Server) handleExportSpec(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	serviceName, err := request.RequireString("serviceName")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	format := request.GetString("format", "json")
+	if format != "json" && format != "yaml" {
+		return mcp.NewToolResultError(fmt.Sprintf("Unsupported format %q: must be json or yaml", format)), nil
+	}
+
+	specInfo, _ := s.registry.Get(serviceName)
+	if specInfo == nil || specInfo.Spec == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Service not found: %s", serviceName)), nil
+	}
+
+	data, err := json.Marshal(specInfo.Spec)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode spec: %v", err)), nil
+	}
+
+	var document interface{}
+	if format == "yaml" {
+		// YAML is a superset of JSON, so decoding into a node keeps the key order
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to encode spec: %v", err)), nil
+		}
+		if data, err = yaml.Marshal(&node); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to encode spec: %v", err)), nil
+		}
+		document = string(data)
+	} else if err := json.Unmarshal(data, &document); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode spec: %v", err)), nil
+	}
+
+	return mcp.NewToolResultStructured(map[string]interface{}{
+		"serviceName": serviceName,
+		"format":      format,
+		"document":    document,
+	}, truncateDescription(string(data), maxExportPreviewLength)), nil
+}
+
+// engineFor returns the proxy engine for a service's tools, or a new one built from its spec
+func (s *Server) engineFor(specInfo *models.SpecInfo) *proxy.Engine {
+	s.mutex.RLock()
+	tools := s.tools[specInfo.ServiceName]
+	s.mutex.RUnlock()
+	if tools != nil {
+		return tools.engine
+	}
+
+	baseURL, err := serverBaseURL(specInfo)
+	if err != nil && specInfo.BaseURL == "" {
+		s.logger.Warn("Calling service without an upstream base URL",
+			zap.String("serviceName", specInfo.ServiceName),
+			zap.Error(err))
+	}
+	return s.newEngine(specInfo.ServiceName, baseURL, specInfo.Headers)
+}
+
+// policyCredentials turns tool-supplied credentials into the upstream headers and query
+// parameters a service's auth policy expects
+func policyCredentials(policy *models.AuthPolicy, authorization, apiKey string) (map[string]string, map[string]string, error) {
+	headers := make(map[string]string)
+	query := make(map[string]string)
+	if policy == nil {
+		return headers, query, nil
+	}
+
+	switch policy.Type {
+	case models.AuthTypeBearer, models.AuthTypeOAuth2:
+		if authorization != "" {
+			if !strings.Contains(authorization, " ") {
+				authorization = "Bearer " + authorization
+			}
+			headers["Authorization"] = authorization
+		}
+	case models.AuthTypeBasic:
+		if authorization != "" {
+			if !strings.Contains(authorization, " ") {
+				authorization = "Basic " + authorization
+			}
+			headers["Authorization"] = authorization
+		}
+	case models.AuthTypeAPIKey:
+		if apiKey != "" {
+			if queryKey, _ := policy.Config["queryKey"].(string); queryKey != "" {
+				query[queryKey] = apiKey
+			} else {
+				headerKey, _ := policy.Config["headerKey"].(string)
+				if headerKey == "" {
+					headerKey = "X-API-Key"
+				}
+				headers[headerKey] = apiKey
+			}
+		}
+	}
+
+	if policy.Required && len(headers) == 0 && len(query) == 0 {
+		return nil, nil, fmt.Errorf("service requires %s credentials", policy.Type)
+	}
+	return headers, query, nil
+}
+
+// summarizeSpec builds the tool-facing summary for a spec
+func (s *Server) summarizeSpec(specInfo *models.SpecInfo) serviceSummary {
+	summary := serviceSummary{
+		ServiceName:        specInfo.ServiceName,
+		URL:                specInfo.URL,
+		BaseURL:            specInfo.BaseURL,
+		FetchedAt:          specInfo.FetchedAt,
+		TTL:                specInfo.TTL.String(),
+		Expired:            specInfo.TTL > 0 && time.Since(specInfo.FetchedAt) > specInfo.TTL,
+		Metadata:           specInfo.Metadata,
+		DisabledOperations: specInfo.DisabledOperations,
+		ApplyDefaults:      specInfo.ApplyParameterDefaults,
+		Valid:              specInfo.Valid(),
+		ValidationErrors:   specInfo.ValidationErrors,
+	}
+
+	if specInfo.Spec != nil {
+		if info := specInfo.Spec.Info; info != nil {
+			summary.Title = info.Title
+			summary.Version = info.Version
+			if info.Description != "" || info.TermsOfService != "" || info.Contact != nil || info.License != nil {
+				summary.Info = &apiInfo{
+					Description:    truncateDescription(info.Description, maxDescriptionLength),
+					TermsOfService: info.TermsOfService,
+					Contact:        info.Contact,
+					License:        info.License,
+				}
+			}
+		}
+		summary.RouteCount = len(s.parseRoutes(specInfo))
+	}
+
+	return summary
+}
+
+// truncateDescription shortens text to at most max runes, preferring to cut at a word boundary
+func truncateDescription(text string, max int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= max {
+		return string(runes)
+	}
+
+	cut := string(runes[:max-1])
+	if !unicode.IsSpace(runes[max-1]) {
+		if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > len(cut)/2 {
+			cut = cut[:i]
+		}
+	}
+	return strings.TrimRightFunc(cut, unicode.IsSpace) + "…"
+}
+
+// routeMatches reports whether a lower-cased query is a substring of a route's
+// operation ID, summary, description or path
+func routeMatches(route parser.RouteConfig, query string) bool {
+	for _, field := range []string{route.OperationID, route.Summary, route.Description, route.Path} {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyTag reports whether any of want appears in tags, ignoring case
+func hasAnyTag(tags, want []string) bool {
+	for _, tag := range tags {
+		for _, w := range want {
+			if strings.EqualFold(tag, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// routeInfos describes each of a service's operations
+func (s *Server) routeInfos(specInfo *models.SpecInfo) []models.RouteInfo {
+	routes := s.parseRoutes(specInfo)
+	infos := make([]models.RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		infos = append(infos, models.RouteInfo{
+			Path:            route.Path,
+			Method:          route.Method,
+			ServiceName:     specInfo.ServiceName,
+			OperationID:     route.OperationID,
+			Summary:         route.Summary,
+			Tags:            route.Tags,
+			Deprecated:      route.Deprecated,
+			Sunset:          route.Sunset,
+			DeprecationNote: route.DeprecationNote,
+		})
+	}
+	return infos
+}
+
+// parseRoutes parses a registered spec into its routes
+func (s *Server) parseRoutes(specInfo *models.SpecInfo) []parser.RouteConfig {
+	if specInfo.Spec == nil {
+		return nil
+	}
+
+	p := parser.New(s.logger.Named("parser"), "")
+	p.SetMaxSchemaDepth(s.config.MCP.MaxSchemaDepth)
+	if err := p.ParseSpec(specInfo.Spec); err != nil {
+		s.logger.Debug("Failed to parse spec routes",
+			zap.String("serviceName", specInfo.ServiceName),
+			zap.Error(err))
+		return nil
+	}
+	return p.GetRoutes()
+}
+
+// parseTTL parses a TTL argument, falling back to the configured default
+func (s *Server) parseTTL(value string) (time.Duration, error) {
+	if value == "" {
+		value = s.config.Specs.DefaultTTL
+	}
+	if value == "" {
+		return time.Hour, nil
+	}
+
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl %q: %w", value, err)
+	}
+	return ttl, nil
+}
+
+// structuredResult returns data as structured content with a JSON text fallback
+func structuredResult(data interface{}) (*mcp.CallToolResult, error) {
+	text, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to encode result: %v", err)), nil
+	}
+	return mcp.NewToolResultStructured(data, string(text)), nil
+}
+
+// toStringMap converts a JSON object argument into a string map
+func toStringMap(value interface{}) map[string]string {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	result := make(map[string]string, len(object))
+	for key, v := range object {
+		switch typed := v.(type) {
+		case string:
+			result[key] = typed
+		case nil:
+			result[key] = ""
+		default:
+			result[key] = fmt.Sprintf("%v", typed)
+		}
+	}
+	return result
+}
@@ -14,6 +14,7 @@ const (
 	AuthTypeBearer AuthType = "bearer"
 	AuthTypeOAuth2 AuthType = "oauth2"
 	AuthTypeAPIKey AuthType = "apikey"
+	AuthTypeDigest AuthType = "digest"
 )
 
 // SpecInfo holds information about a registered OpenAPI specification
@@ -26,6 +27,34 @@ type SpecInfo struct {
 	TTL         time.Duration     `json:"ttl"`
 	Headers     map[string]string `json:"headers"`
 	AuthPolicy  *AuthPolicy       `json:"authPolicy,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	// BaseURL is the upstream for proxied calls, overriding the spec's servers when set
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// ServerVariables supplies values for the spec's server URL variables, taking
+	// precedence over their defaults
+	ServerVariables map[string]string `json:"serverVariables,omitempty"`
+
+	// DisabledOperations lists operation IDs taken offline by an operator
+	DisabledOperations []string `json:"disabledOperations,omitempty"`
+
+	// ApplyParameterDefaults fills omitted optional parameters with their schema default
+	ApplyParameterDefaults bool `json:"applyParameterDefaults,omitempty"`
+
+	// ValidationErrors lists why the spec failed validation, when it was registered
+	// regardless because specs.allowInvalid is set
+	ValidationErrors []string `json:"validationErrors,omitempty"`
+
+	// ETag and LastModified are the validators the spec was served with, sent back
+	// on refresh so an unchanged document need not be downloaded again
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// Valid reports whether the spec passed validation
+func (s *SpecInfo) Valid() bool {
+	return len(s.ValidationErrors) == 0
 }
 
 // ProxyRequest represents an incoming request to be proxied
@@ -54,6 +83,11 @@ type RouteInfo struct {
 	OperationID string   `json:"operationId,omitempty"`
 	Summary     string   `json:"summary,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+
+	// Deprecated operations may carry a sunset date and guidance on what to use instead
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	Sunset          string `json:"sunset,omitempty"`
+	DeprecationNote string `json:"deprecationNote,omitempty"`
 }
 
 // ServiceStats contains performance and usage statistics
@@ -2,19 +2,48 @@ package parser
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/mark3labs/mcp-go/mcp"
 	"go.uber.org/zap"
 )
 
+// IfMatchParam is the reserved tool argument forwarded as the If-Match header on mutating calls
+const IfMatchParam = "_ifMatch"
+
+// BodyParam is the tool argument carrying the request body, unless a parameter has the same name
+const BodyParam = "body"
+
+// TimeoutExtension is the operation extension overriding the upstream timeout for its
+// calls, as a duration such as "2m" or a number of seconds
+const TimeoutExtension = "x-upstream-timeout"
+
+// Operation extensions guiding callers away from a deprecated operation: the date it
+// stops working and what to use instead
+const (
+	SunsetExtension      = "x-sunset"
+	DeprecationExtension = "x-deprecation"
+)
+
+// DefaultMaxSchemaDepth is the nesting depth beyond which generated schemas are truncated
+const DefaultMaxSchemaDepth = 5
+
 // Parser handles parsing OpenAPI specifications into MCP tools
 type Parser struct {
-	logger  *zap.Logger
-	baseURL string
-	spec    *openapi3.T
-	routes  []RouteConfig
+	logger         *zap.Logger
+	baseURL        string
+	spec           *openapi3.T
+	routes         []RouteConfig
+	maxSchemaDepth int
+
+	// operationIDs holds the declared and generated operation IDs of the spec being
+	// parsed, so generated IDs do not collide with them
+	operationIDs map[string]bool
 }
 
 // RouteConfig represents a parsed route from OpenAPI spec
@@ -24,9 +53,24 @@ type RouteConfig struct {
 	OperationID string
 	Summary     string
 	Description string
+	Tags        []string
 	Parameters  []ParameterConfig
 	RequestBody *RequestBodyConfig
+	Responses   *openapi3.Responses
 	Tool        mcp.Tool
+
+	// Timeout bounds the whole upstream call, retries included, in place of the
+	// engine's client timeout; zero keeps the client timeout
+	Timeout time.Duration
+
+	// Route locates the operation in its spec, for validating calls with openapi3filter
+	Route *routers.Route
+
+	// Deprecated is set for operations the spec marks deprecated, with the guidance of
+	// their x-sunset and x-deprecation extensions in Sunset and DeprecationNote
+	Deprecated      bool
+	Sunset          string
+	DeprecationNote string
 }
 
 // ParameterConfig represents an OpenAPI parameter
@@ -38,6 +82,8 @@ type ParameterConfig struct {
 	Description string
 	Default     interface{}
 	Enum        []interface{}
+	Style       string // serialization style, e.g. form, deepObject or matrix
+	Explode     bool   // whether array elements and object properties are sent as separate values
 }
 
 // RequestBodyConfig represents an OpenAPI request body
@@ -48,25 +94,66 @@ type RequestBodyConfig struct {
 	Description string
 }
 
+// BodyArgument returns the tool argument carrying the request body. It is BodyParam,
+// prefixed with underscores while an operation parameter already uses the name.
+func (r *RouteConfig) BodyArgument() string {
+	name := BodyParam
+	for r.hasParameter(name) {
+		name = "_" + name
+	}
+	return name
+}
+
+// hasParameter reports whether the route declares a parameter with the given name
+func (r *RouteConfig) hasParameter(name string) bool {
+	for _, param := range r.Parameters {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // New creates a new parser instance
 func New(logger *zap.Logger, baseURL string) *Parser {
 	return &Parser{
-		logger:  logger,
-		baseURL: baseURL,
-		routes:  make([]RouteConfig, 0),
+		logger:         logger,
+		baseURL:        baseURL,
+		routes:         make([]RouteConfig, 0),
+		maxSchemaDepth: DefaultMaxSchemaDepth,
 	}
 }
 
+// SetMaxSchemaDepth limits how deeply request body schemas are expanded in tool definitions.
+// Values below one restore the default.
+func (p *Parser) SetMaxSchemaDepth(depth int) {
+	if depth < 1 {
+		depth = DefaultMaxSchemaDepth
+	}
+	p.maxSchemaDepth = depth
+}
+
 // ParseSpec parses an OpenAPI specification into routes and tools
 func (p *Parser) ParseSpec(spec *openapi3.T) error {
 	p.spec = spec
 	p.routes = make([]RouteConfig, 0)
+	p.operationIDs = make(map[string]bool)
 
 	if spec.Paths == nil {
 		return fmt.Errorf("no paths found in OpenAPI specification")
 	}
 
-	for path, pathItem := range spec.Paths.Map() {
+	for _, pathItem := range spec.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.OperationID != "" {
+				p.operationIDs[operation.OperationID] = true
+			}
+		}
+	}
+
+	// Paths and methods are parsed in a fixed order so generated IDs are stable
+	for _, path := range slices.Sorted(maps.Keys(spec.Paths.Map())) {
+		pathItem := spec.Paths.Value(path)
 		if err := p.parsePath(path, pathItem); err != nil {
 			p.logger.Error("Failed to parse path", zap.String("path", path), zap.Error(err))
 			continue
@@ -83,17 +170,21 @@ func (p *Parser) ParseSpec(spec *openapi3.T) error {
 
 // parsePath processes a single path and its operations
 func (p *Parser) parsePath(path string, pathItem *openapi3.PathItem) error {
-	operations := map[string]*openapi3.Operation{
-		"GET":     pathItem.Get,
-		"POST":    pathItem.Post,
-		"PUT":     pathItem.Put,
-		"DELETE":  pathItem.Delete,
-		"PATCH":   pathItem.Patch,
-		"HEAD":    pathItem.Head,
-		"OPTIONS": pathItem.Options,
-	}
-
-	for method, operation := range operations {
+	operations := []struct {
+		method    string
+		operation *openapi3.Operation
+	}{
+		{"GET", pathItem.Get},
+		{"POST", pathItem.Post},
+		{"PUT", pathItem.Put},
+		{"DELETE", pathItem.Delete},
+		{"PATCH", pathItem.Patch},
+		{"HEAD", pathItem.Head},
+		{"OPTIONS", pathItem.Options},
+	}
+
+	for _, op := range operations {
+		method, operation := op.method, op.operation
 		if operation == nil {
 			continue
 		}
@@ -107,12 +198,42 @@ func (p *Parser) parsePath(path string, pathItem *openapi3.PathItem) error {
 			continue
 		}
 
+		route.Route = &routers.Route{
+			Spec:      p.spec,
+			Path:      path,
+			PathItem:  pathItem,
+			Method:    method,
+			Operation: operation,
+		}
 		p.routes = append(p.routes, route)
 	}
 
 	return nil
 }
 
+// parseTimeout reads an x-upstream-timeout value: a duration string or a number of seconds
+func parseTimeout(value interface{}) (time.Duration, error) {
+	var timeout time.Duration
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", TimeoutExtension, err)
+		}
+		timeout = parsed
+	case float64:
+		timeout = time.Duration(v * float64(time.Second))
+	case int:
+		timeout = time.Duration(v) * time.Second
+	default:
+		return 0, fmt.Errorf("%s must be a duration or a number of seconds, got %v", TimeoutExtension, value)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %v", TimeoutExtension, value)
+	}
+	return timeout, nil
+}
+
 // parseOperation converts an OpenAPI operation to a route config
 func (p *Parser) parseOperation(path, method string, operation *openapi3.Operation, pathParams openapi3.Parameters) (RouteConfig, error) {
 	route := RouteConfig{
@@ -121,27 +242,61 @@ func (p *Parser) parseOperation(path, method string, operation *openapi3.Operati
 		OperationID: operation.OperationID,
 		Summary:     operation.Summary,
 		Description: operation.Description,
+		Tags:        operation.Tags,
 		Parameters:  make([]ParameterConfig, 0),
+		Responses:   operation.Responses,
+		Deprecated:  operation.Deprecated,
+	}
+	if route.Deprecated {
+		route.Sunset, _ = operation.Extensions[SunsetExtension].(string)
+		route.DeprecationNote, _ = operation.Extensions[DeprecationExtension].(string)
 	}
 
-	// Generate operation ID if not provided
+	// Generate operation ID if not provided, numbering it when another operation
+	// already has the ID, e.g. getUsersId for both /users/{id} and /users/id
 	if route.OperationID == "" {
-		route.OperationID = p.generateOperationID(method, path)
+		generated := p.generateOperationID(method, path)
+		route.OperationID = generated
+		for i := 2; p.operationIDs[route.OperationID]; i++ {
+			route.OperationID = fmt.Sprintf("%s%d", generated, i)
+		}
+		if route.OperationID != generated {
+			p.logger.Info("Numbered generated operation ID taken by another operation",
+				zap.String("path", path),
+				zap.String("method", method),
+				zap.String("operationID", route.OperationID))
+		}
+		p.operationIDs[route.OperationID] = true
+	}
+
+	if value, ok := operation.Extensions[TimeoutExtension]; ok {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			p.logger.Warn("Ignoring invalid upstream timeout",
+				zap.String("operationID", route.OperationID),
+				zap.Error(err))
+		} else {
+			route.Timeout = timeout
+		}
 	}
 
 	// Parse parameters (path-level and operation-level)
 	allParams := append(pathParams, operation.Parameters...)
 	for _, paramRef := range allParams {
-		if paramRef.Value == nil {
+		value := p.parameterValue(paramRef)
+		if value == nil {
+			p.logger.Warn("Skipping unresolvable parameter",
+				zap.String("operationID", route.OperationID),
+				zap.String("ref", paramRef.Ref))
 			continue
 		}
-		param := p.parseParameter(paramRef.Value)
+		param := p.parseParameter(value)
 		route.Parameters = append(route.Parameters, param)
 	}
 
 	// Parse request body
-	if operation.RequestBody != nil && operation.RequestBody.Value != nil {
-		route.RequestBody = p.parseRequestBody(operation.RequestBody.Value)
+	if requestBody := p.requestBodyValue(operation.RequestBody); requestBody != nil {
+		route.RequestBody = p.parseRequestBody(requestBody)
 	}
 
 	// Generate MCP tool
@@ -163,8 +318,12 @@ func (p *Parser) parseParameter(param *openapi3.Parameter) ParameterConfig {
 		Description: param.Description,
 	}
 
-	if param.Schema != nil && param.Schema.Value != nil {
-		schema := param.Schema.Value
+	if method, err := param.SerializationMethod(); err == nil {
+		paramConfig.Style = method.Style
+		paramConfig.Explode = method.Explode
+	}
+
+	if schema := p.schemaValue(param.Schema); schema != nil {
 		// Handle the Types field properly - it's a slice, get the first type
 		if schema.Type != nil && len(*schema.Type) > 0 {
 			paramConfig.Type = (*schema.Type)[0]
@@ -188,11 +347,11 @@ func (p *Parser) parseRequestBody(requestBody *openapi3.RequestBody) *RequestBod
 	}
 
 	// Find the first supported content type
-	supportedTypes := []string{"application/json", "application/x-www-form-urlencoded", "text/plain"}
+	supportedTypes := []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data", "text/plain"}
 	for _, contentType := range supportedTypes {
 		if content, exists := requestBody.Content[contentType]; exists {
 			config.ContentType = contentType
-			config.Schema = content.Schema
+			config.Schema = p.resolvedSchema(content.Schema)
 			break
 		}
 	}
@@ -201,7 +360,7 @@ func (p *Parser) parseRequestBody(requestBody *openapi3.RequestBody) *RequestBod
 	if config.ContentType == "" && len(requestBody.Content) > 0 {
 		for contentType, content := range requestBody.Content {
 			config.ContentType = contentType
-			config.Schema = content.Schema
+			config.Schema = p.resolvedSchema(content.Schema)
 			break
 		}
 	}
@@ -225,6 +384,9 @@ func (p *Parser) generateMCPTool(route RouteConfig) (mcp.Tool, error) {
 	if description == "" {
 		description = fmt.Sprintf("%s %s", route.Method, route.Path)
 	}
+	if route.Deprecated {
+		description = deprecatedDescription(route, description)
+	}
 
 	// Create input schema for tool parameters
 	inputSchema := p.createInputSchema(route)
@@ -234,10 +396,26 @@ func (p *Parser) generateMCPTool(route RouteConfig) (mcp.Tool, error) {
 		Description: description,
 		InputSchema: inputSchema,
 	}
+	if outputSchema, ok := p.createOutputSchema(route); ok {
+		tool.OutputSchema = outputSchema
+	}
 
 	return tool, nil
 }
 
+// deprecatedDescription marks a deprecated operation's tool description so agents
+// prefer its replacement, adding the sunset date and guidance the spec gives
+func deprecatedDescription(route RouteConfig, description string) string {
+	notes := []string{"[DEPRECATED] " + strings.TrimSuffix(description, ".")}
+	if route.Sunset != "" {
+		notes = append(notes, "Removed after "+route.Sunset)
+	}
+	if route.DeprecationNote != "" {
+		notes = append(notes, strings.TrimSuffix(route.DeprecationNote, "."))
+	}
+	return strings.Join(notes, ". ") + "."
+}
+
 // createInputSchema creates a JSON schema for the tool parameters
 func (p *Parser) createInputSchema(route RouteConfig) mcp.ToolInputSchema {
 	properties := make(map[string]interface{})
@@ -251,11 +429,20 @@ func (p *Parser) createInputSchema(route RouteConfig) mcp.ToolInputSchema {
 		}
 	}
 
-	// Add request body as 'body' parameter if present
+	// Add request body under its own argument if present
 	if route.RequestBody != nil {
-		properties["body"] = p.requestBodyToSchema(route.RequestBody)
+		bodyArgument := route.BodyArgument()
+		properties[bodyArgument] = p.requestBodyToSchema(route.RequestBody)
 		if route.RequestBody.Required {
-			required = append(required, "body")
+			required = append(required, bodyArgument)
+		}
+	}
+
+	// Let agents send back an ETag for optimistic concurrency
+	if IsMutatingMethod(route.Method) {
+		properties[IfMatchParam] = map[string]interface{}{
+			"type":        "string",
+			"description": "ETag from a previous read, sent as the If-Match header",
 		}
 	}
 
@@ -271,6 +458,161 @@ func (p *Parser) createInputSchema(route RouteConfig) mcp.ToolInputSchema {
 	return schema
 }
 
+// createOutputSchema creates a JSON schema for the structured content of a successful
+// call from the JSON body of the operation's success response. Bodies that are not
+// objects are wrapped under "result", as tool results wrap them. It reports false
+// when the operation declares no JSON success body.
+func (p *Parser) createOutputSchema(route RouteConfig) (mcp.ToolOutputSchema, bool) {
+	response := p.successResponse(route.Responses)
+	if response == nil {
+		return mcp.ToolOutputSchema{}, false
+	}
+	mediaType := jsonMediaType(response.Content)
+	if mediaType == nil {
+		return mcp.ToolOutputSchema{}, false
+	}
+	schema := p.schemaValue(mediaType.Schema)
+	if schema == nil {
+		return mcp.ToolOutputSchema{}, false
+	}
+
+	// Tool results carry JSON objects as they are and wrap anything else under
+	// "result", so the schema is only declared when the body's kind is known
+	object, known := p.objectSchema(schema, 1)
+	if !known {
+		return mcp.ToolOutputSchema{}, false
+	}
+	body := p.responseSchemaToJSON(schema, 1)
+	if !object {
+		return mcp.ToolOutputSchema{
+			Type:       "object",
+			Properties: map[string]interface{}{"result": body},
+		}, true
+	}
+
+	outputSchema := mcp.ToolOutputSchema{Type: "object"}
+	properties, required := objectFields(body)
+	if len(properties) > 0 {
+		outputSchema.Properties = properties
+	}
+	if len(required) > 0 {
+		outputSchema.Required = required
+	}
+	return outputSchema, true
+}
+
+// objectSchema reports whether every non-null value matching schema is a JSON object,
+// or none is. known is false when values of either kind match, or the schema does
+// not say.
+func (p *Parser) objectSchema(schema *openapi3.Schema, depth int) (object, known bool) {
+	if depth > p.maxSchemaDepth {
+		return false, false
+	}
+	if schema.Type != nil && len(*schema.Type) > 0 {
+		objects, others := 0, 0
+		for _, t := range schema.Type.Slice() {
+			switch t {
+			case openapi3.TypeObject:
+				objects++
+			case openapi3.TypeNull:
+			default:
+				others++
+			}
+		}
+		return objects > 0, objects == 0 || others == 0
+	}
+
+	// A value matches every allOf member, so one member decides its kind
+	for _, ref := range schema.AllOf {
+		if member := p.schemaValue(ref); member != nil {
+			if object, known := p.objectSchema(member, depth+1); known {
+				return object, true
+			}
+		}
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf} {
+		if len(refs) == 0 {
+			continue
+		}
+		objects, others := 0, 0
+		for _, ref := range refs {
+			member := p.schemaValue(ref)
+			if member == nil {
+				return false, false
+			}
+			object, known := p.objectSchema(member, depth+1)
+			switch {
+			case !known:
+				return false, false
+			case object:
+				objects++
+			default:
+				others++
+			}
+		}
+		return objects > 0, objects == 0 || others == 0
+	}
+
+	if len(schema.Properties) > 0 || schema.AdditionalProperties.Schema != nil {
+		return true, true
+	}
+	return false, false
+}
+
+// objectFields returns the properties and required names of a converted object schema,
+// including those its allOf members add. Properties of oneOf and anyOf variants are
+// left out, as only some values have them.
+func objectFields(body map[string]interface{}) (map[string]interface{}, []string) {
+	properties := make(map[string]interface{})
+	var required []string
+	if own, ok := body["properties"].(map[string]interface{}); ok {
+		maps.Copy(properties, own)
+	}
+	if own, ok := body["required"].([]string); ok {
+		required = append(required, own...)
+	}
+	if members, ok := body["allOf"].([]interface{}); ok {
+		for _, member := range members {
+			if member, ok := member.(map[string]interface{}); ok {
+				memberProperties, memberRequired := objectFields(member)
+				maps.Copy(properties, memberProperties)
+				required = append(required, memberRequired...)
+			}
+		}
+	}
+	slices.Sort(required)
+	return properties, slices.Compact(required)
+}
+
+// successResponse returns the response of a successful call: 200 when declared, or
+// else the lowest-numbered 2xx status, with ranges such as 2XX last
+func (p *Parser) successResponse(responses *openapi3.Responses) *openapi3.Response {
+	if responses == nil {
+		return nil
+	}
+	// Codes are three characters, so sorting puts 200 first and 2XX after every number
+	for _, code := range slices.Sorted(maps.Keys(responses.Map())) {
+		if strings.HasPrefix(code, "2") {
+			return p.responseValue(responses.Value(code))
+		}
+	}
+	return nil
+}
+
+// jsonMediaType returns the application/json content, or else the first content type
+// with a JSON suffix, or nil when the body is not JSON
+func jsonMediaType(content openapi3.Content) *openapi3.MediaType {
+	if mediaType := content.Get("application/json"); mediaType != nil {
+		return mediaType
+	}
+	for _, contentType := range slices.Sorted(maps.Keys(content)) {
+		if strings.Contains(contentType, "json") {
+			return content[contentType]
+		}
+	}
+	return nil
+}
+
 // parameterToSchema converts a parameter to JSON schema format
 func (p *Parser) parameterToSchema(param ParameterConfig) map[string]interface{} {
 	schema := map[string]interface{}{
@@ -297,6 +639,18 @@ func (p *Parser) requestBodyToSchema(requestBody *RequestBodyConfig) map[string]
 	schema := map[string]interface{}{
 		"type": "object",
 	}
+	if requestBody.Schema != nil && requestBody.Schema.Value != nil {
+		schema = p.schemaToJSON(requestBody.Schema.Value, 1)
+	}
+
+	// Tools cannot send raw bytes, so file fields take base64 text
+	if properties, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, prop := range properties {
+			if requestBody.IsFileField(name) {
+				properties[name] = fileInputSchema(prop.(map[string]interface{}))
+			}
+		}
+	}
 
 	if requestBody.Description != "" {
 		schema["description"] = requestBody.Description
@@ -310,6 +664,209 @@ func (p *Parser) requestBodyToSchema(requestBody *RequestBodyConfig) map[string]
 	return schema
 }
 
+// IsFileField reports whether a multipart/form-data body property carries file content,
+// either a single file or an array of them
+func (b *RequestBodyConfig) IsFileField(name string) bool {
+	if b.ContentType != "multipart/form-data" || b.Schema == nil || b.Schema.Value == nil {
+		return false
+	}
+	prop := b.Schema.Value.Properties[name]
+	if prop == nil || prop.Value == nil {
+		return false
+	}
+	if prop.Value.Type.Is("array") && prop.Value.Items != nil && prop.Value.Items.Value != nil {
+		return isFileSchema(prop.Value.Items.Value)
+	}
+	return isFileSchema(prop.Value)
+}
+
+// isFileSchema reports whether a schema describes raw file content
+func isFileSchema(schema *openapi3.Schema) bool {
+	return schema.Type.Is("string") && schema.Format == "binary"
+}
+
+// fileInputSchema turns the JSON schema of a file field into a base64 string input
+func fileInputSchema(schema map[string]interface{}) map[string]interface{} {
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		schema["items"] = fileInputSchema(items)
+		return schema
+	}
+
+	delete(schema, "format")
+	schema["contentEncoding"] = "base64"
+	description := "Base64-encoded file content"
+	if existing, ok := schema["description"].(string); ok && existing != "" {
+		description = existing + " (" + description + ")"
+	}
+	schema["description"] = description
+	return schema
+}
+
+// IsMutatingMethod reports whether an HTTP method modifies the target resource
+func IsMutatingMethod(method string) bool {
+	switch strings.ToUpper(method) {
+	case "PUT", "PATCH", "DELETE":
+		return true
+	default:
+		return false
+	}
+}
+
+// schemaToJSON converts an OpenAPI schema describing input to JSON schema format,
+// replacing anything nested deeper than maxSchemaDepth with a generic object so tool
+// definitions stay small
+func (p *Parser) schemaToJSON(schema *openapi3.Schema, depth int) map[string]interface{} {
+	return p.convertSchema(schema, depth, false)
+}
+
+// responseSchemaToJSON converts an OpenAPI schema describing a response like schemaToJSON,
+// keeping read-only properties and dropping write-only ones instead
+func (p *Parser) responseSchemaToJSON(schema *openapi3.Schema, depth int) map[string]interface{} {
+	return p.convertSchema(schema, depth, true)
+}
+
+// convertSchema converts an OpenAPI schema for a request, or for a response when
+// response is set
+func (p *Parser) convertSchema(schema *openapi3.Schema, depth int, response bool) map[string]interface{} {
+	if depth > p.maxSchemaDepth {
+		return map[string]interface{}{
+			"type":        "object",
+			"description": fmt.Sprintf("Nested schema omitted beyond depth %d; see the API documentation for its structure", p.maxSchemaDepth),
+		}
+	}
+
+	result := make(map[string]interface{})
+	if schemaType := jsonSchemaType(schema); schemaType != nil {
+		result["type"] = schemaType
+	}
+	if schema.Format != "" {
+		result["format"] = schema.Format
+	}
+	if schema.Description != "" {
+		result["description"] = schema.Description
+	}
+	if schema.Default != nil {
+		result["default"] = schema.Default
+	}
+	if len(schema.Enum) > 0 {
+		result["enum"] = schema.Enum
+	}
+	addConstraints(result, schema)
+
+	// Read-only properties are set by the server and must not be sent, while write-only
+	// ones are never returned
+	hidden := make(map[string]bool)
+	if len(schema.Properties) > 0 {
+		properties := make(map[string]interface{}, len(schema.Properties))
+		for name, propRef := range schema.Properties {
+			prop := p.schemaValue(propRef)
+			if prop == nil {
+				continue
+			}
+			if (!response && prop.ReadOnly) || (response && prop.WriteOnly) {
+				hidden[name] = true
+				continue
+			}
+			properties[name] = p.convertSchema(prop, depth+1, response)
+		}
+		result["properties"] = properties
+	}
+	required := make([]string, 0, len(schema.Required))
+	for _, name := range schema.Required {
+		if !hidden[name] {
+			required = append(required, name)
+		}
+	}
+	if len(required) > 0 {
+		result["required"] = required
+	}
+	if schema.AdditionalProperties.Has != nil && !*schema.AdditionalProperties.Has {
+		result["additionalProperties"] = false
+	} else if additional := p.schemaValue(schema.AdditionalProperties.Schema); additional != nil {
+		result["additionalProperties"] = p.convertSchema(additional, depth+1, response)
+	}
+
+	if items := p.schemaValue(schema.Items); items != nil {
+		result["items"] = p.convertSchema(items, depth+1, response)
+	}
+
+	for keyword, refs := range map[string]openapi3.SchemaRefs{
+		"allOf": schema.AllOf,
+		"oneOf": schema.OneOf,
+		"anyOf": schema.AnyOf,
+	} {
+		if len(refs) == 0 {
+			continue
+		}
+		variants := make([]interface{}, 0, len(refs))
+		for _, ref := range refs {
+			if variant := p.schemaValue(ref); variant != nil {
+				variants = append(variants, p.convertSchema(variant, depth+1, response))
+			}
+		}
+		result[keyword] = variants
+	}
+
+	return result
+}
+
+// jsonSchemaType maps an OpenAPI type to its JSON Schema form. Nullable schemas and
+// OpenAPI 3.1 type lists become a list of types.
+func jsonSchemaType(schema *openapi3.Schema) interface{} {
+	if schema.Type == nil || len(*schema.Type) == 0 {
+		return nil
+	}
+	types := schema.Type.Slice()
+	if schema.Nullable && !schema.Type.Includes("null") {
+		types = append(types, "null")
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	list := make([]interface{}, len(types))
+	for i, t := range types {
+		list[i] = t
+	}
+	return list
+}
+
+// addConstraints copies numeric, string and array validation keywords to a JSON schema.
+// OpenAPI 3.0's boolean exclusive bounds become JSON Schema's numeric ones.
+func addConstraints(result map[string]interface{}, schema *openapi3.Schema) {
+	if schema.Min != nil {
+		if schema.ExclusiveMin {
+			result["exclusiveMinimum"] = *schema.Min
+		} else {
+			result["minimum"] = *schema.Min
+		}
+	}
+	if schema.Max != nil {
+		if schema.ExclusiveMax {
+			result["exclusiveMaximum"] = *schema.Max
+		} else {
+			result["maximum"] = *schema.Max
+		}
+	}
+	if schema.MinLength > 0 {
+		result["minLength"] = schema.MinLength
+	}
+	if schema.MaxLength != nil {
+		result["maxLength"] = *schema.MaxLength
+	}
+	if schema.Pattern != "" {
+		result["pattern"] = schema.Pattern
+	}
+	if schema.MinItems > 0 {
+		result["minItems"] = schema.MinItems
+	}
+	if schema.MaxItems != nil {
+		result["maxItems"] = *schema.MaxItems
+	}
+	if schema.UniqueItems {
+		result["uniqueItems"] = true
+	}
+}
+
 // generateOperationID creates an operation ID from method and path
 func (p *Parser) generateOperationID(method, path string) string {
 	// Convert path to camelCase and remove special characters
@@ -0,0 +1,711 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+)
+
+// nestedSpec builds a spec whose request body nests levels objects deep
+func nestedSpec(t *testing.T, levels int) *openapi3.T {
+	t.Helper()
+
+	schema := `{"type": "string"}`
+	for i := levels; i >= 1; i-- {
+		schema = fmt.Sprintf(`{"type": "object", "properties": {"level%d": %s}}`, i, schema)
+	}
+
+	data := fmt.Sprintf(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Nested", "version": "1.0.0"},
+  "paths": {
+    "/things": {
+      "post": {
+        "operationId": "createThing",
+        "requestBody": {"content": {"application/json": {"schema": %s}}},
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`, schema)
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	return spec
+}
+
+// schemaDepth counts the object levels in a generated schema and returns the innermost one
+func schemaDepth(schema map[string]interface{}) (int, map[string]interface{}) {
+	depth := 1
+	for {
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok || len(properties) == 0 {
+			return depth, schema
+		}
+		for _, prop := range properties {
+			schema = prop.(map[string]interface{})
+		}
+		depth++
+	}
+}
+
+func TestParser_MaxSchemaDepth(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxDepth      int
+		expectedDepth int
+		truncated     bool
+	}{
+		{"truncated at configured depth", 3, 4, true},
+		{"default depth", 0, DefaultMaxSchemaDepth + 1, true},
+		{"deep enough for the whole schema", 20, 11, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(zap.NewNop(), "")
+			p.SetMaxSchemaDepth(tt.maxDepth)
+
+			if err := p.ParseSpec(nestedSpec(t, 10)); err != nil {
+				t.Fatalf("ParseSpec() error = %v", err)
+			}
+
+			route := p.GetRouteByOperationID("createThing")
+			if route == nil {
+				t.Fatalf("Expected createThing route")
+			}
+
+			body := route.Tool.InputSchema.Properties["body"].(map[string]interface{})
+			depth, innermost := schemaDepth(body)
+			if depth != tt.expectedDepth {
+				t.Errorf("Expected schema depth %d, got %d", tt.expectedDepth, depth)
+			}
+
+			description, _ := innermost["description"].(string)
+			if tt.truncated != strings.Contains(description, "omitted") {
+				t.Errorf("Expected truncated = %v, got description %q", tt.truncated, description)
+			}
+			if tt.truncated && innermost["type"] != "object" {
+				t.Errorf("Expected truncated schema to be a generic object, got %v", innermost["type"])
+			}
+		})
+	}
+}
+
+func TestParser_MultipartRequestBody(t *testing.T) {
+	data := `{
+  "openapi": "3.0.0",
+  "info": {"title": "Uploads", "version": "1.0.0"},
+  "paths": {
+    "/photos": {
+      "post": {
+        "operationId": "uploadPhoto",
+        "requestBody": {
+          "required": true,
+          "content": {
+            "multipart/form-data": {
+              "schema": {
+                "type": "object",
+                "required": ["photo"],
+                "properties": {
+                  "photo": {"type": "string", "format": "binary", "description": "The image"},
+                  "thumbnails": {"type": "array", "items": {"type": "string", "format": "binary"}},
+                  "caption": {"type": "string"}
+                }
+              }
+            }
+          }
+        },
+        "responses": {"201": {"description": "created"}}
+      }
+    }
+  }
+}`
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+
+	route := p.GetRouteByOperationID("uploadPhoto")
+	if route == nil || route.RequestBody == nil {
+		t.Fatal("Expected uploadPhoto route with a request body")
+	}
+	if route.RequestBody.ContentType != "multipart/form-data" {
+		t.Errorf("Expected multipart/form-data, got %s", route.RequestBody.ContentType)
+	}
+
+	for name, isFile := range map[string]bool{"photo": true, "thumbnails": true, "caption": false, "missing": false} {
+		if route.RequestBody.IsFileField(name) != isFile {
+			t.Errorf("Expected IsFileField(%s) = %v", name, isFile)
+		}
+	}
+
+	body := route.Tool.InputSchema.Properties["body"].(map[string]interface{})
+	properties := body["properties"].(map[string]interface{})
+
+	photo := properties["photo"].(map[string]interface{})
+	if photo["type"] != "string" || photo["contentEncoding"] != "base64" || photo["format"] != nil {
+		t.Errorf("Expected photo to be a base64 string input, got %v", photo)
+	}
+	if description, _ := photo["description"].(string); !strings.Contains(description, "The image") || !strings.Contains(description, "Base64") {
+		t.Errorf("Expected photo description to keep its text and mention base64, got %q", description)
+	}
+
+	items := properties["thumbnails"].(map[string]interface{})["items"].(map[string]interface{})
+	if items["contentEncoding"] != "base64" {
+		t.Errorf("Expected thumbnail items to be base64 inputs, got %v", items)
+	}
+	if caption := properties["caption"].(map[string]interface{}); caption["contentEncoding"] != nil {
+		t.Errorf("Expected caption to stay a plain string, got %v", caption)
+	}
+}
+
+const refSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Refs", "version": "1.0.0"},
+  "paths": {
+    "/reports": {
+      "parameters": [{"$ref": "#/components/parameters/PageParam"}],
+      "post": {
+        "operationId": "reportError",
+        "parameters": [{"$ref": "#/components/parameters/Missing"}],
+        "requestBody": {"$ref": "#/components/requestBodies/ErrorReport"},
+        "responses": {"204": {"description": "reported"}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "PageParam": {"name": "page", "in": "query", "schema": {"$ref": "#/components/schemas/Page"}}
+    },
+    "requestBodies": {
+      "ErrorReport": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}}
+    },
+    "schemas": {
+      "Page": {"type": "integer", "default": 1},
+      "Error": {
+        "type": "object",
+        "required": ["code"],
+        "properties": {
+          "code": {"type": "integer"},
+          "message": {"type": "string"},
+          "cause": {"$ref": "#/components/schemas/Error"}
+        }
+      }
+    }
+  }
+}`
+
+func TestParser_ResolvesRefs(t *testing.T) {
+	// Decoding without the loader leaves every $ref unresolved
+	unresolved := &openapi3.T{}
+	if err := unresolved.UnmarshalJSON([]byte(refSpec)); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	loaded, err := openapi3.NewLoader().LoadFromData([]byte(strings.Replace(refSpec,
+		`"parameters": [{"$ref": "#/components/parameters/Missing"}],`, "", 1)))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		spec *openapi3.T
+	}{
+		{"unresolved refs", unresolved},
+		{"loader-resolved refs", loaded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(zap.NewNop(), "")
+			p.SetMaxSchemaDepth(4)
+			if err := p.ParseSpec(tt.spec); err != nil {
+				t.Fatalf("ParseSpec() error = %v", err)
+			}
+
+			route := p.GetRouteByOperationID("reportError")
+			if route == nil {
+				t.Fatal("Expected reportError route")
+			}
+
+			if len(route.Parameters) != 1 {
+				t.Fatalf("Expected the shared page parameter only, got %+v", route.Parameters)
+			}
+			page := route.Parameters[0]
+			if page.Name != "page" || page.In != "query" || page.Type != "integer" || page.Default != float64(1) {
+				t.Errorf("Expected page query parameter from the component, got %+v", page)
+			}
+
+			if route.RequestBody == nil || route.RequestBody.Schema == nil || route.RequestBody.Schema.Value == nil {
+				t.Fatal("Expected request body with the Error schema")
+			}
+
+			body := route.Tool.InputSchema.Properties["body"].(map[string]interface{})
+			properties, _ := body["properties"].(map[string]interface{})
+			if len(properties) != 3 {
+				t.Fatalf("Expected the Error properties, got %v", body)
+			}
+			if code := properties["code"].(map[string]interface{}); code["type"] != "integer" {
+				t.Errorf("Expected integer code, got %v", code)
+			}
+
+			// The self-referencing cause is expanded until the depth limit
+			depth, innermost := 1, body
+			for {
+				properties, ok := innermost["properties"].(map[string]interface{})
+				if !ok {
+					break
+				}
+				innermost = properties["cause"].(map[string]interface{})
+				depth++
+			}
+			if depth != 5 {
+				t.Errorf("Expected recursive schema to stop at depth 5, got %d", depth)
+			}
+			if description, _ := innermost["description"].(string); !strings.Contains(description, "omitted") {
+				t.Errorf("Expected truncated innermost schema, got %v", innermost)
+			}
+		})
+	}
+}
+
+func TestParser_NestedRequestBodySchema(t *testing.T) {
+	data := `{
+  "openapi": "3.0.0",
+  "info": {"title": "Petstore", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "post": {
+        "operationId": "addPet",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "responses": {"201": {"description": "created"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Category": {
+        "type": "object",
+        "properties": {"id": {"type": "integer", "format": "int64"}, "name": {"type": "string", "maxLength": 40}},
+        "additionalProperties": false
+      },
+      "Pet": {
+        "type": "object",
+        "required": ["id", "name"],
+        "properties": {
+          "id": {"type": "integer", "readOnly": true},
+          "name": {"type": "string", "minLength": 1},
+          "category": {"$ref": "#/components/schemas/Category"},
+          "tags": {"type": "array", "items": {"type": "string"}, "uniqueItems": true},
+          "status": {"type": "string", "enum": ["available", "pending", "sold"]},
+          "nickname": {"type": "string", "nullable": true},
+          "weight": {"type": "number", "minimum": 0, "exclusiveMinimum": true}
+        }
+      }
+    }
+  }
+}`
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+	route := p.GetRouteByOperationID("addPet")
+	if route == nil {
+		t.Fatal("Expected addPet route")
+	}
+
+	body := route.Tool.InputSchema.Properties["body"].(map[string]interface{})
+	if body["type"] != "object" {
+		t.Errorf("Expected object body, got %v", body["type"])
+	}
+	if fmt.Sprint(body["required"]) != "[name]" {
+		t.Errorf("Expected only name to be required once read-only id is dropped, got %v", body["required"])
+	}
+
+	properties := body["properties"].(map[string]interface{})
+	if _, ok := properties["id"]; ok {
+		t.Error("Expected read-only id to be left out of the request schema")
+	}
+
+	category := properties["category"].(map[string]interface{})
+	categoryProps := category["properties"].(map[string]interface{})
+	if category["type"] != "object" || category["additionalProperties"] != false {
+		t.Errorf("Expected closed Category object, got %v", category)
+	}
+	if name := categoryProps["name"].(map[string]interface{}); name["type"] != "string" || name["maxLength"] != uint64(40) {
+		t.Errorf("Expected category name with maxLength, got %v", name)
+	}
+	if id := categoryProps["id"].(map[string]interface{}); id["type"] != "integer" || id["format"] != "int64" {
+		t.Errorf("Expected int64 category id, got %v", id)
+	}
+
+	tags := properties["tags"].(map[string]interface{})
+	if tags["type"] != "array" || tags["uniqueItems"] != true || tags["items"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Expected unique array of strings, got %v", tags)
+	}
+
+	if status := properties["status"].(map[string]interface{}); len(status["enum"].([]interface{})) != 3 {
+		t.Errorf("Expected status enum, got %v", status)
+	}
+	if nickname := properties["nickname"].(map[string]interface{}); fmt.Sprint(nickname["type"]) != "[string null]" {
+		t.Errorf("Expected nullable nickname to accept null, got %v", nickname["type"])
+	}
+	if weight := properties["weight"].(map[string]interface{}); weight["exclusiveMinimum"] != float64(0) || weight["minimum"] != nil {
+		t.Errorf("Expected numeric exclusiveMinimum, got %v", weight)
+	}
+}
+
+func TestParser_UpstreamTimeout(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Reports", "version": "1.0.0"},
+  "paths": {
+    "/reports": {
+      "get": {"operationId": "listReports", "responses": {"200": {"description": "ok"}}},
+      "post": {"operationId": "buildReport", "x-upstream-timeout": "2m", "responses": {"200": {"description": "ok"}}}
+    },
+    "/reports/{id}": {
+      "get": {"operationId": "getReport", "x-upstream-timeout": 45, "responses": {"200": {"description": "ok"}}},
+      "delete": {"operationId": "deleteReport", "x-upstream-timeout": "soon", "responses": {"200": {"description": "ok"}}}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+
+	tests := []struct {
+		operationID string
+		expected    time.Duration
+	}{
+		{"listReports", 0},
+		{"buildReport", 2 * time.Minute},
+		{"getReport", 45 * time.Second},
+		{"deleteReport", 0},
+	}
+
+	for _, tt := range tests {
+		route := p.GetRouteByOperationID(tt.operationID)
+		if route == nil {
+			t.Fatalf("Expected %s route", tt.operationID)
+		}
+		if route.Timeout != tt.expected {
+			t.Errorf("Expected %s timeout %v, got %v", tt.operationID, tt.expected, route.Timeout)
+		}
+	}
+}
+
+func TestParser_ParameterStyle(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "paths": {
+    "/pets/{ids}": {
+      "get": {
+        "operationId": "findPets",
+        "parameters": [
+          {"name": "ids", "in": "path", "required": true, "style": "matrix", "explode": true,
+           "schema": {"type": "array", "items": {"type": "integer"}}},
+          {"name": "filter", "in": "query", "style": "deepObject", "explode": true,
+           "schema": {"type": "object", "properties": {"status": {"type": "string"}}}},
+          {"name": "tags", "in": "query", "style": "spaceDelimited", "explode": false,
+           "schema": {"type": "array", "items": {"type": "string"}}},
+          {"name": "limit", "in": "query", "schema": {"type": "integer"}}
+        ],
+        "responses": {"200": {"description": "ok"}}
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+	route := p.GetRouteByOperationID("findPets")
+	if route == nil {
+		t.Fatal("Expected findPets route")
+	}
+
+	expected := map[string]struct {
+		style   string
+		explode bool
+	}{
+		"ids":    {"matrix", true},
+		"filter": {"deepObject", true},
+		"tags":   {"spaceDelimited", false},
+		"limit":  {"form", true},
+	}
+	for _, param := range route.Parameters {
+		want := expected[param.Name]
+		if param.Style != want.style || param.Explode != want.explode {
+			t.Errorf("Expected %s style %s explode %v, got %s explode %v", param.Name, want.style, want.explode, param.Style, param.Explode)
+		}
+	}
+}
+
+func TestParser_OutputSchema(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}}
+      },
+      "post": {
+        "operationId": "createPet",
+        "responses": {
+          "202": {"description": "queued", "content": {"application/json": {"schema": {"type": "object", "properties": {"jobId": {"type": "string"}}}}}},
+          "201": {"$ref": "#/components/responses/PetCreated"},
+          "400": {"description": "invalid", "content": {"application/json": {"schema": {"type": "object", "properties": {"error": {"type": "string"}}}}}}
+        }
+      }
+    },
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "203": {"description": "cached", "content": {"application/json": {"schema": {"type": "string"}}}},
+          "200": {"description": "ok", "content": {"application/hal+json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+        }
+      },
+      "delete": {
+        "operationId": "deletePet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"204": {"description": "deleted"}}
+      },
+      "patch": {
+        "operationId": "updatePet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "object", "nullable": true, "properties": {"name": {"type": "string"}}}}}}}
+      }
+    },
+    "/pets/{id}/adoption": {
+      "get": {
+        "operationId": "getAdoption",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"allOf": [
+          {"$ref": "#/components/schemas/Pet"},
+          {"required": ["adoptedAt"], "properties": {"adoptedAt": {"type": "string", "format": "date-time"}}}
+        ]}}}}}
+      }
+    },
+    "/search": {
+      "get": {
+        "operationId": "search",
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"oneOf": [
+          {"$ref": "#/components/schemas/Pet"},
+          {"type": "string"}
+        ]}}}}}
+      }
+    }
+  },
+  "components": {
+    "responses": {
+      "PetCreated": {"description": "created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+    },
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["id", "name"],
+        "properties": {
+          "id": {"type": "string", "readOnly": true},
+          "name": {"type": "string"},
+          "owner": {"type": "object", "properties": {"email": {"type": "string", "format": "email"}}},
+          "password": {"type": "string", "writeOnly": true}
+        }
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+
+	// assertPet checks a schema against the Pet component as a response describes it
+	assertPet := func(t *testing.T, properties map[string]interface{}, required []string) {
+		t.Helper()
+		if _, ok := properties["id"]; !ok {
+			t.Errorf("Expected the read-only id to be part of the response, got %v", properties)
+		}
+		if _, ok := properties["password"]; ok {
+			t.Errorf("Expected the write-only password to be left out, got %v", properties)
+		}
+		owner, _ := properties["owner"].(map[string]interface{})
+		email, _ := owner["properties"].(map[string]interface{})["email"].(map[string]interface{})
+		if email["format"] != "email" {
+			t.Errorf("Expected nested owner.email to be described, got %v", properties["owner"])
+		}
+		if strings.Join(required, ",") != "id,name" {
+			t.Errorf("Expected id and name to be required, got %v", required)
+		}
+	}
+
+	for _, operationID := range []string{"createPet", "getPet"} {
+		t.Run(operationID, func(t *testing.T) {
+			schema := p.GetRouteByOperationID(operationID).Tool.OutputSchema
+			if schema.Type != "object" {
+				t.Fatalf("Expected an object output schema, got %+v", schema)
+			}
+			assertPet(t, schema.Properties, schema.Required)
+		})
+	}
+
+	t.Run("arrays are wrapped", func(t *testing.T) {
+		schema := p.GetRouteByOperationID("listPets").Tool.OutputSchema
+		result, _ := schema.Properties["result"].(map[string]interface{})
+		if schema.Type != "object" || result["type"] != "array" {
+			t.Fatalf("Expected the array to be wrapped under result, got %+v", schema)
+		}
+		items := result["items"].(map[string]interface{})
+		required, _ := items["required"].([]string)
+		assertPet(t, items["properties"].(map[string]interface{}), required)
+	})
+
+	t.Run("allOf composition is an object", func(t *testing.T) {
+		schema := p.GetRouteByOperationID("getAdoption").Tool.OutputSchema
+		if schema.Type != "object" || schema.Properties["result"] != nil {
+			t.Fatalf("Expected an unwrapped object output schema, got %+v", schema)
+		}
+		if _, ok := schema.Properties["adoptedAt"]; !ok {
+			t.Errorf("Expected the allOf members' properties to be merged, got %v", schema.Properties)
+		}
+		if strings.Join(schema.Required, ",") != "adoptedAt,id,name" {
+			t.Errorf("Expected the allOf members' required names, got %v", schema.Required)
+		}
+	})
+
+	t.Run("nullable object is not wrapped", func(t *testing.T) {
+		schema := p.GetRouteByOperationID("updatePet").Tool.OutputSchema
+		if schema.Type != "object" || schema.Properties["name"] == nil {
+			t.Errorf("Expected the object's own properties, got %+v", schema)
+		}
+	})
+
+	t.Run("object or scalar has no output schema", func(t *testing.T) {
+		if schema := p.GetRouteByOperationID("search").Tool.OutputSchema; schema.Type != "" {
+			t.Errorf("Expected no output schema for a body that may or may not be an object, got %+v", schema)
+		}
+	})
+
+	t.Run("no content", func(t *testing.T) {
+		if schema := p.GetRouteByOperationID("deletePet").Tool.OutputSchema; schema.Type != "" {
+			t.Errorf("Expected no output schema without a response body, got %+v", schema)
+		}
+	})
+}
+
+func TestParser_GeneratedOperationIDCollisions(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Users", "version": "1.0.0"},
+  "paths": {
+    "/users/{id}": {
+      "get": {"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+              "responses": {"200": {"description": "ok"}}}
+    },
+    "/users/id": {"get": {"responses": {"200": {"description": "ok"}}}},
+    "/users": {"get": {"operationId": "getUsersId3", "responses": {"200": {"description": "ok"}}}}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		p := New(zap.NewNop(), "")
+		if err := p.ParseSpec(spec); err != nil {
+			t.Fatalf("ParseSpec() error = %v", err)
+		}
+
+		// Paths are parsed in order, and declared IDs are never reused
+		expected := map[string]string{"/users": "getUsersId3", "/users/id": "getUsersId", "/users/{id}": "getUsersId2"}
+		for _, route := range p.GetRoutes() {
+			if route.OperationID != expected[route.Path] {
+				t.Errorf("Expected %s to get operation ID %s, got %s", route.Path, expected[route.Path], route.OperationID)
+			}
+			if route.Tool.Name != route.OperationID {
+				t.Errorf("Expected tool name %s, got %s", route.OperationID, route.Tool.Name)
+			}
+		}
+	}
+}
+
+func TestParser_DeprecatedOperations(t *testing.T) {
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(`{
+  "openapi": "3.0.0",
+  "info": {"title": "Pets", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "summary": "List pets.", "deprecated": true,
+              "x-sunset": "2026-06-30", "x-deprecation": "Use listAnimals instead.",
+              "responses": {"200": {"description": "ok"}}},
+      "post": {"operationId": "createPet", "summary": "Create a pet", "deprecated": true,
+               "responses": {"201": {"description": "created"}}}
+    },
+    "/animals": {
+      "get": {"operationId": "listAnimals", "summary": "List animals", "responses": {"200": {"description": "ok"}}}
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+
+	p := New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+
+	tests := []struct {
+		operationID string
+		expected    string
+	}{
+		{"listPets", "[DEPRECATED] List pets. Removed after 2026-06-30. Use listAnimals instead."},
+		{"createPet", "[DEPRECATED] Create a pet."},
+		{"listAnimals", "List animals"},
+	}
+	for _, tt := range tests {
+		route := p.GetRouteByOperationID(tt.operationID)
+		if route.Tool.Description != tt.expected {
+			t.Errorf("Expected %s description %q, got %q", tt.operationID, tt.expected, route.Tool.Description)
+		}
+	}
+
+	if route := p.GetRouteByOperationID("listPets"); !route.Deprecated || route.Sunset != "2026-06-30" {
+		t.Errorf("Expected listPets to be deprecated with its sunset, got %v %q", route.Deprecated, route.Sunset)
+	}
+}
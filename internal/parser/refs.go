@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxRefChain bounds how many references are followed to reach a definition, so a
+// chain of references that loops back on itself cannot hang the parser
+const maxRefChain = 16
+
+// pointerUnescaper decodes JSON pointer escapes in reference names
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// componentName returns the name a local reference such as
+// #/components/schemas/Error points to within the given components section
+func componentName(ref, section string) (string, bool) {
+	name, ok := strings.CutPrefix(ref, "#/components/"+section+"/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return pointerUnescaper.Replace(name), true
+}
+
+// parameterValue returns the parameter a reference carries, looking an unresolved
+// $ref up in the spec's components. It returns nil when the reference cannot be resolved.
+func (p *Parser) parameterValue(ref *openapi3.ParameterRef) *openapi3.Parameter {
+	for i := 0; ref != nil && i < maxRefChain; i++ {
+		if ref.Value != nil {
+			return ref.Value
+		}
+		name, ok := componentName(ref.Ref, "parameters")
+		if !ok || p.spec == nil || p.spec.Components == nil {
+			return nil
+		}
+		ref = p.spec.Components.Parameters[name]
+	}
+	return nil
+}
+
+// requestBodyValue returns the request body a reference carries, looking an unresolved
+// $ref up in the spec's components. It returns nil when the reference cannot be resolved.
+func (p *Parser) requestBodyValue(ref *openapi3.RequestBodyRef) *openapi3.RequestBody {
+	for i := 0; ref != nil && i < maxRefChain; i++ {
+		if ref.Value != nil {
+			return ref.Value
+		}
+		name, ok := componentName(ref.Ref, "requestBodies")
+		if !ok || p.spec == nil || p.spec.Components == nil {
+			return nil
+		}
+		ref = p.spec.Components.RequestBodies[name]
+	}
+	return nil
+}
+
+// responseValue returns the response a reference carries, looking an unresolved $ref
+// up in the spec's components. It returns nil when the reference cannot be resolved.
+func (p *Parser) responseValue(ref *openapi3.ResponseRef) *openapi3.Response {
+	for i := 0; ref != nil && i < maxRefChain; i++ {
+		if ref.Value != nil {
+			return ref.Value
+		}
+		name, ok := componentName(ref.Ref, "responses")
+		if !ok || p.spec == nil || p.spec.Components == nil {
+			return nil
+		}
+		ref = p.spec.Components.Responses[name]
+	}
+	return nil
+}
+
+// schemaValue returns the schema a reference carries, looking an unresolved $ref up in
+// the spec's components. It returns nil when the reference cannot be resolved.
+func (p *Parser) schemaValue(ref *openapi3.SchemaRef) *openapi3.Schema {
+	for i := 0; ref != nil && i < maxRefChain; i++ {
+		if ref.Value != nil {
+			return ref.Value
+		}
+		name, ok := componentName(ref.Ref, "schemas")
+		if !ok || p.spec == nil || p.spec.Components == nil {
+			return nil
+		}
+		ref = p.spec.Components.Schemas[name]
+	}
+	return nil
+}
+
+// resolvedSchema returns ref with its value filled in from the components when the
+// reference was left unresolved, without modifying the spec
+func (p *Parser) resolvedSchema(ref *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if ref == nil || ref.Value != nil {
+		return ref
+	}
+	if value := p.schemaValue(ref); value != nil {
+		return &openapi3.SchemaRef{Ref: ref.Ref, Value: value}
+	}
+	return ref
+}
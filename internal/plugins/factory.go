@@ -0,0 +1,67 @@
+package plugins
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Factory creates a plugin for the plugin manager to register
+type Factory func(logger *zap.Logger) Plugin
+
+var (
+	factories      = make(map[string]Factory)
+	factoriesMutex sync.RWMutex
+)
+
+func init() {
+	RegisterFactory("example-auth", func(logger *zap.Logger) Plugin { return NewExampleAuthPlugin(logger) })
+	RegisterFactory("example-transform", func(logger *zap.Logger) Plugin { return NewExampleTransformPlugin(logger) })
+}
+
+// RegisterFactory makes a plugin loadable by name from the configuration. It is
+// meant to be called from an init function and panics if name is already taken.
+func RegisterFactory(name string, factory Factory) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+
+	if factory == nil {
+		panic("plugins: RegisterFactory factory is nil for " + name)
+	}
+	if _, exists := factories[name]; exists {
+		panic("plugins: RegisterFactory called twice for " + name)
+	}
+	factories[name] = factory
+}
+
+// Factories returns the names of the registered plugin factories, sorted
+func Factories() []string {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load creates the plugin whose factory is registered under name and registers it.
+// The plugin is initialized and started along with the others by the registry.
+func (m *Manager) Load(name string) (Plugin, error) {
+	factoriesMutex.RLock()
+	factory, exists := factories[name]
+	factoriesMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("no plugin factory registered for '%s'", name)
+	}
+
+	plugin := factory(m.logger)
+	if err := m.registry.Register(plugin); err != nil {
+		return nil, err
+	}
+	return plugin, nil
+}
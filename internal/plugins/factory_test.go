@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"go.uber.org/zap"
+)
+
+// configuredPlugin records the configuration it was initialized with
+type configuredPlugin struct {
+	testPlugin
+	config map[string]interface{}
+}
+
+func (p *configuredPlugin) Initialize(config map[string]interface{}) error {
+	p.config = config
+	return p.testPlugin.Initialize(config)
+}
+
+func TestManager_LoadFromFactory(t *testing.T) {
+	var created *configuredPlugin
+	RegisterFactory("test-custom", func(logger *zap.Logger) Plugin {
+		created = &configuredPlugin{testPlugin: testPlugin{name: "custom", pluginType: PluginTypeProcessor}}
+		return created
+	})
+
+	logger := zap.NewNop()
+	manager := NewManager(logger, hooks.NewManager(logger))
+
+	plugin, err := manager.Load("test-custom")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if plugin != created {
+		t.Fatalf("Expected the factory's plugin to be returned")
+	}
+	if _, exists := manager.Registry().Get("custom"); !exists {
+		t.Errorf("Expected the plugin to be registered under its own name")
+	}
+
+	registry := manager.Registry()
+	if err := registry.Initialize(map[string]map[string]interface{}{"custom": {"endpoint": "https://example.com"}}); err != nil {
+		t.Fatalf("Initialize() error = %v", err)
+	}
+	if err := registry.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if !created.initialized || !created.started {
+		t.Errorf("Expected the plugin to be initialized and started, got initialized=%v started=%v", created.initialized, created.started)
+	}
+	if created.config["endpoint"] != "https://example.com" {
+		t.Errorf("Expected the plugin's configuration to be passed, got %v", created.config)
+	}
+
+	if _, err := manager.Load("missing"); err == nil {
+		t.Errorf("Expected an error loading an unregistered factory")
+	}
+}
+
+func TestRegisterFactory_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected registering a taken name to panic")
+		}
+	}()
+	RegisterFactory("example-auth", func(logger *zap.Logger) Plugin { return NewExampleAuthPlugin(logger) })
+}
@@ -43,6 +43,14 @@ type Plugin interface {
 	Health() HealthStatus
 }
 
+// ServiceScopedPlugin is implemented by plugins whose hooks only run for some
+// services. The hooks of other plugins run for every service.
+type ServiceScopedPlugin interface {
+	Plugin
+	// AppliesTo reports whether the plugin handles requests to serviceName
+	AppliesTo(serviceName string) bool
+}
+
 // HealthStatus represents plugin health status
 type HealthStatus struct {
 	Healthy bool   `json:"healthy"`
@@ -164,6 +172,9 @@ type Registry struct {
 	mutex         sync.RWMutex
 	logger        *zap.Logger
 	hookManager   *hooks.Manager
+
+	// pluginHooks holds the names of the hooks each plugin currently has registered
+	pluginHooks map[string][]string
 }
 
 // NewRegistry creates a new plugin registry
@@ -173,6 +184,7 @@ func NewRegistry(logger *zap.Logger, hookManager *hooks.Manager) *Registry {
 		pluginsByType: make(map[PluginType][]Plugin),
 		logger:        logger,
 		hookManager:   hookManager,
+		pluginHooks:   make(map[string][]string),
 	}
 }
 
@@ -201,16 +213,64 @@ func (r *Registry) Register(plugin Plugin) error {
 	return nil
 }
 
-// registerPluginHooks registers hooks for supported plugin types
+// Unregister removes a plugin and its hooks. The plugin is not stopped.
+func (r *Registry) Unregister(name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	plugin, exists := r.plugins[name]
+	if !exists {
+		return fmt.Errorf("plugin with name '%s' not registered", name)
+	}
+
+	r.unregisterPluginHooks(name)
+	delete(r.plugins, name)
+	pluginType := plugin.Type()
+	byType := r.pluginsByType[pluginType]
+	for i, registered := range byType {
+		if registered.Name() == name {
+			r.pluginsByType[pluginType] = append(byType[:i:i], byType[i+1:]...)
+			break
+		}
+	}
+
+	r.logger.Info("Unregistered plugin", zap.String("name", name))
+	return nil
+}
+
+// registerPluginHooks registers hooks for supported plugin types, unless the
+// plugin's hooks are already registered
 func (r *Registry) registerPluginHooks(plugin Plugin) {
+	if _, registered := r.pluginHooks[plugin.Name()]; registered {
+		return
+	}
+
+	var pluginHooks []hooks.Hook
 	switch p := plugin.(type) {
 	case ValidationPlugin:
-		hook := &validationPluginHook{plugin: p, logger: r.logger}
-		r.hookManager.RegisterHook(hook)
+		pluginHooks = append(pluginHooks, &validationPluginHook{plugin: p, logger: r.logger})
 	case TransformPlugin:
-		hook := &transformPluginHook{plugin: p, logger: r.logger}
+		// The same plugin rewrites requests before and responses after the upstream call
+		for _, hookType := range []hooks.HookType{hooks.HookTypePreRequest, hooks.HookTypePostResponse} {
+			pluginHooks = append(pluginHooks, &transformPluginHook{plugin: p, logger: r.logger, hookType: hookType})
+		}
+	}
+
+	names := make([]string, 0, len(pluginHooks))
+	for _, hook := range pluginHooks {
 		r.hookManager.RegisterHook(hook)
+		names = append(names, hook.Name())
+	}
+	r.pluginHooks[plugin.Name()] = names
+}
+
+// unregisterPluginHooks removes the hooks registered for the named plugin so they
+// stop running once the plugin is stopped or removed
+func (r *Registry) unregisterPluginHooks(name string) {
+	for _, hookName := range r.pluginHooks[name] {
+		r.hookManager.UnregisterHook(hookName)
 	}
+	delete(r.pluginHooks, name)
 }
 
 // Get retrieves a plugin by name
@@ -263,15 +323,16 @@ func (r *Registry) Initialize(configs map[string]map[string]interface{}) error {
 	return nil
 }
 
-// Start starts all plugins
+// Start starts all plugins, restoring the hooks of plugins that were stopped
 func (r *Registry) Start(ctx context.Context) error {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
 	for name, plugin := range r.plugins {
 		if err := plugin.Start(ctx); err != nil {
 			return fmt.Errorf("failed to start plugin '%s': %w", name, err)
 		}
+		r.registerPluginHooks(plugin)
 
 		r.logger.Info("Started plugin", zap.String("name", name))
 	}
@@ -279,13 +340,14 @@ func (r *Registry) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops all plugins
+// Stop stops all plugins, first removing their hooks so requests no longer reach them
 func (r *Registry) Stop() error {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
 	var errors []error
 	for name, plugin := range r.plugins {
+		r.unregisterPluginHooks(name)
 		if err := plugin.Stop(); err != nil {
 			errors = append(errors, fmt.Errorf("failed to stop plugin '%s': %w", name, err))
 		} else {
@@ -338,6 +400,10 @@ func (h *validationPluginHook) Execute(ctx context.Context, hookCtx *hooks.HookC
 	return h.plugin.ValidateRequest(ctx, validationReq)
 }
 
+func (h *validationPluginHook) AppliesTo(serviceName string) bool {
+	return pluginAppliesTo(h.plugin, serviceName)
+}
+
 func (h *validationPluginHook) Type() hooks.HookType {
 	return hooks.HookTypePreRequest
 }
@@ -352,8 +418,9 @@ func (h *validationPluginHook) Name() string {
 
 // transformPluginHook integrates transform plugins with the hook system
 type transformPluginHook struct {
-	plugin TransformPlugin
-	logger *zap.Logger
+	plugin   TransformPlugin
+	logger   *zap.Logger
+	hookType hooks.HookType
 }
 
 func (h *transformPluginHook) Execute(ctx context.Context, hookCtx *hooks.HookContext) error {
@@ -380,6 +447,7 @@ func (h *transformPluginHook) Execute(ctx context.Context, hookCtx *hooks.HookCo
 			Method:      hookCtx.Request.Method,
 			URL:         hookCtx.Request.Path,
 			Headers:     hookCtx.Request.Headers,
+			Body:        hookCtx.Request.Body,
 			Parameters:  hookCtx.Request.Parameters,
 			ServiceName: hookCtx.Request.ServiceName,
 		}
@@ -393,14 +461,19 @@ func (h *transformPluginHook) Execute(ctx context.Context, hookCtx *hooks.HookCo
 		hookCtx.Request.Method = transformed.Method
 		hookCtx.Request.Path = transformed.URL
 		hookCtx.Request.Headers = transformed.Headers
+		hookCtx.Request.Body = transformed.Body
 		hookCtx.Request.Parameters = transformed.Parameters
 	}
 
 	return nil
 }
 
+func (h *transformPluginHook) AppliesTo(serviceName string) bool {
+	return pluginAppliesTo(h.plugin, serviceName)
+}
+
 func (h *transformPluginHook) Type() hooks.HookType {
-	return hooks.HookTypePreRequest // This hook can be registered for both types
+	return h.hookType
 }
 
 func (h *transformPluginHook) Priority() hooks.Priority {
@@ -411,6 +484,12 @@ func (h *transformPluginHook) Name() string {
 	return fmt.Sprintf("transform-plugin-%s", h.plugin.Name())
 }
 
+// pluginAppliesTo reports whether plugin handles requests to serviceName
+func pluginAppliesTo(plugin Plugin, serviceName string) bool {
+	scoped, ok := plugin.(ServiceScopedPlugin)
+	return !ok || scoped.AppliesTo(serviceName)
+}
+
 // Built-in example plugins
 
 // ExampleAuthPlugin demonstrates an authentication plugin
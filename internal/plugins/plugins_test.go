@@ -380,6 +380,86 @@ func TestPluginHookIntegration(t *testing.T) {
 	}
 }
 
+func TestPluginHookRemoval(t *testing.T) {
+	logger := zap.NewNop()
+	hookManager := hooks.NewManager(logger)
+	registry := NewRegistry(logger, hookManager)
+
+	validationPlugin := &testValidationPlugin{
+		testPlugin: testPlugin{name: "test-validation", pluginType: PluginTypeValidation},
+	}
+	if err := registry.Register(validationPlugin); err != nil {
+		t.Fatalf("Failed to register validation plugin: %v", err)
+	}
+
+	validated := func() bool {
+		validationPlugin.validateCalled = false
+		hookCtx := &hooks.HookContext{Request: &hooks.RequestContext{}, Metadata: make(map[string]interface{})}
+		if err := hookManager.ExecutePreRequestHooks(context.Background(), hookCtx); err != nil {
+			t.Fatalf("Hook execution should not fail: %v", err)
+		}
+		return validationPlugin.validateCalled
+	}
+
+	if err := registry.Stop(); err != nil {
+		t.Fatalf("Failed to stop plugins: %v", err)
+	}
+	if validated() {
+		t.Errorf("Stopped plugin should not be called")
+	}
+
+	if err := registry.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start plugins: %v", err)
+	}
+	if !validated() {
+		t.Errorf("Restarted plugin should be called")
+	}
+
+	if err := registry.Unregister("test-validation"); err != nil {
+		t.Fatalf("Failed to unregister plugin: %v", err)
+	}
+	if validated() {
+		t.Errorf("Unregistered plugin should not be called")
+	}
+	if _, exists := registry.Get("test-validation"); exists {
+		t.Errorf("Unregistered plugin should not be found")
+	}
+	if err := registry.Unregister("test-validation"); err == nil {
+		t.Errorf("Expected an error unregistering an unknown plugin")
+	}
+}
+
+func TestServiceScopedPlugin(t *testing.T) {
+	logger := zap.NewNop()
+	hookManager := hooks.NewManager(logger)
+	registry := NewRegistry(logger, hookManager)
+
+	plugin := &scopedValidationPlugin{
+		testValidationPlugin: testValidationPlugin{testPlugin: testPlugin{name: "petstore-only", pluginType: PluginTypeValidation}},
+		service:              "petstore",
+	}
+	if err := registry.Register(plugin); err != nil {
+		t.Fatalf("Failed to register plugin: %v", err)
+	}
+
+	for _, tt := range []struct {
+		serviceName string
+		expected    bool
+	}{
+		{"inventory", false},
+		{"petstore", true},
+	} {
+		plugin.validateCalled = false
+		hookCtx := &hooks.HookContext{Request: &hooks.RequestContext{ServiceName: tt.serviceName}, Metadata: make(map[string]interface{})}
+		if err := hookManager.ExecutePreRequestHooks(context.Background(), hookCtx); err != nil {
+			t.Fatalf("Hook execution should not fail: %v", err)
+		}
+		if plugin.validateCalled != tt.expected {
+			t.Errorf("Expected plugin called=%v for %s, got %v", tt.expected, tt.serviceName, plugin.validateCalled)
+		}
+	}
+}
+
 // Test helper structs
 
 type testPlugin struct {
@@ -444,3 +524,12 @@ func (p *testTransformPlugin) TransformResponse(ctx context.Context, resp *Trans
 	p.transformResponseCalled = true
 	return resp, nil
 }
+
+type scopedValidationPlugin struct {
+	testValidationPlugin
+	service string
+}
+
+func (p *scopedValidationPlugin) AppliesTo(serviceName string) bool {
+	return serviceName == p.service
+}
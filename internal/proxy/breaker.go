@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/backoff"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"go.uber.org/zap"
+)
+
+// CircuitBreakerHeader marks responses produced by an open circuit breaker rather than the upstream
+const CircuitBreakerHeader = "X-Circuit-Breaker"
+
+// maxFallbackResponses bounds the last-good responses kept per engine for fallback
+const maxFallbackResponses = 256
+
+// isUpstreamFailure counts transport errors and 5xx responses against the breaker;
+// client errors are the caller's mistake and say nothing about the upstream's health
+func isUpstreamFailure(err error, result interface{}) bool {
+	if err != nil {
+		return true
+	}
+	response, ok := result.(*Response)
+	return !ok || response.StatusCode >= http.StatusInternalServerError
+}
+
+// SetCircuitBreaker sends upstream calls through manager's breaker for the engine's
+// service. While the breaker is open calls are answered with 503 and Retry-After
+// instead of reaching the upstream, or, when fallback is set, with the last good
+// response to the same GET request if there is one.
+func (e *Engine) SetCircuitBreaker(manager *circuitbreaker.Manager, config circuitbreaker.Config, fallback bool) {
+	e.breakers = manager
+	e.breakerConfig = config
+	e.breakerFallback = fallback
+	e.lastGood = make(map[string]*Response)
+}
+
+// SetHealthURL has a half-open breaker GET healthURL to learn whether the upstream has
+// recovered, closing on a 2xx, instead of letting a real call through. An empty URL
+// keeps the real-call trial.
+func (e *Engine) SetHealthURL(healthURL string) {
+	e.healthURL = healthURL
+}
+
+// sendThroughBreaker performs the upstream call under the service's circuit breaker
+func (e *Engine) sendThroughBreaker(ctx context.Context, client *http.Client, req *http.Request, stream bool) (*Response, error) {
+	name := e.serviceName
+	if name == "" {
+		name = e.baseURL
+	}
+
+	config := e.breakerConfig
+	if config.Timeout <= 0 && e.client.Timeout > 0 {
+		// Give every attempt of a retried call the chance to finish
+		attempts := e.MaxAttempts()
+		config.Timeout = e.client.Timeout*time.Duration(attempts) + backoff.MaxDelay*time.Duration(attempts-1)
+	}
+	config.IsFailure = isUpstreamFailure
+	if config.HealthProbe == nil {
+		config.HealthProbe = circuitbreaker.HTTPHealthProbe(e.client, e.healthURL)
+	}
+
+	result, err := e.breakers.Execute(name, config, ctx, func(ctx context.Context) (interface{}, error) {
+		return e.send(client, req, stream)
+	})
+
+	var open *circuitbreaker.OpenError
+	switch {
+	case err == nil:
+		response := result.(*Response)
+		e.rememberGood(req, response)
+		return response, nil
+	case errors.As(err, &open):
+		return e.openResponse(req, open), nil
+	default:
+		return nil, err
+	}
+}
+
+// openResponse answers a call rejected by an open breaker
+func (e *Engine) openResponse(req *http.Request, open *circuitbreaker.OpenError) *Response {
+	if e.breakerFallback {
+		if cached := e.lastGoodResponse(req); cached != nil {
+			e.logger.Debug("Serving last good response while circuit is open",
+				zap.String("service", open.Name),
+				zap.String("url", req.URL.String()))
+			cached.Headers.Set(CircuitBreakerHeader, "fallback")
+			return cached
+		}
+	}
+
+	retryAfter := int(math.Ceil(open.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"error":      open.Error(),
+		"retryAfter": retryAfter,
+	})
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Retry-After", strconv.Itoa(retryAfter))
+	headers.Set(CircuitBreakerHeader, "open")
+	return &Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Headers:    headers,
+		Body:       body,
+	}
+}
+
+// rememberGood keeps successful GET responses for fallback while the breaker is open
+func (e *Engine) rememberGood(req *http.Request, response *Response) {
+	if !e.breakerFallback || req.Method != http.MethodGet || response.StatusCode >= 300 || response.stream != nil {
+		return
+	}
+
+	e.lastGoodMutex.Lock()
+	defer e.lastGoodMutex.Unlock()
+
+	key := req.URL.String()
+	if _, exists := e.lastGood[key]; !exists && len(e.lastGood) >= maxFallbackResponses {
+		for evict := range e.lastGood {
+			delete(e.lastGood, evict)
+			break
+		}
+	}
+	e.lastGood[key] = copyResponse(response)
+}
+
+// lastGoodResponse returns a copy of the last good response to the same GET request
+func (e *Engine) lastGoodResponse(req *http.Request) *Response {
+	if req.Method != http.MethodGet {
+		return nil
+	}
+
+	e.lastGoodMutex.Lock()
+	defer e.lastGoodMutex.Unlock()
+
+	if cached, ok := e.lastGood[req.URL.String()]; ok {
+		return copyResponse(cached)
+	}
+	return nil
+}
+
+// copyResponse copies a response so later hooks cannot alter a cached one
+func copyResponse(response *Response) *Response {
+	headers := response.Headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	return &Response{
+		StatusCode: response.StatusCode,
+		Headers:    headers,
+		Body:       append([]byte(nil), response.Body...),
+	}
+}
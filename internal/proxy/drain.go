@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Drainer tracks the upstream calls in flight across the engines that share it, so
+// shutdown can wait for them to finish. Once draining starts, new calls are answered
+// with 503 Service Unavailable instead of reaching the upstream.
+type Drainer struct {
+	mutex    sync.Mutex
+	draining bool
+	inFlight int
+	drained  chan struct{}
+}
+
+// NewDrainer creates a drainer that accepts calls until Drain is called
+func NewDrainer() *Drainer {
+	return &Drainer{drained: make(chan struct{})}
+}
+
+// SetDrainer tracks the engine's calls with drainer. A nil drainer disables tracking.
+func (e *Engine) SetDrainer(drainer *Drainer) {
+	e.drainer = drainer
+}
+
+// Drain stops accepting calls and waits for those in flight to finish, or for ctx to
+// be done, in which case the error says how many calls are still running
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.mutex.Lock()
+	if !d.draining {
+		d.draining = true
+		if d.inFlight == 0 {
+			close(d.drained)
+		}
+	}
+	d.mutex.Unlock()
+
+	select {
+	case <-d.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d requests still in flight: %w", d.InFlight(), ctx.Err())
+	}
+}
+
+// Draining reports whether Drain has been called
+func (d *Drainer) Draining() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.draining
+}
+
+// InFlight returns the number of calls currently running
+func (d *Drainer) InFlight() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	return d.inFlight
+}
+
+// begin counts a new call, returning false once draining has started. A nil drainer
+// accepts every call.
+func (d *Drainer) begin() bool {
+	if d == nil {
+		return true
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.draining {
+		return false
+	}
+	d.inFlight++
+	return true
+}
+
+// end marks a call counted by begin as finished
+func (d *Drainer) end() {
+	if d == nil {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.inFlight--
+	if d.draining && d.inFlight == 0 {
+		close(d.drained)
+	}
+}
+
+// drainingResponse answers a call made after draining started
+func drainingResponse() *Response {
+	body, _ := json.Marshal(map[string]interface{}{
+		"error": "server is shutting down",
+	})
+
+	headers := make(http.Header)
+	headers.Set("Content-Type", "application/json")
+	return &Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Headers:    headers,
+		Body:       body,
+	}
+}
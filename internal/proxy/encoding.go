@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxDecodedBytes bounds the size of a response body after its Content-Encoding
+// is undone, so a small compressed body cannot expand without limit
+const DefaultMaxDecodedBytes = 32 * 1024 * 1024
+
+// compressedBodyKey is the context key asking for upstream bodies exactly as sent
+type compressedBodyKey struct{}
+
+// WithCompressedBody returns a context whose calls return the upstream body as sent,
+// still compressed and with its Content-Encoding header, rather than decoded
+func WithCompressedBody(ctx context.Context) context.Context {
+	return context.WithValue(ctx, compressedBodyKey{}, true)
+}
+
+// wantsCompressedBody reports whether ctx was made by WithCompressedBody
+func wantsCompressedBody(ctx context.Context) bool {
+	compressed, _ := ctx.Value(compressedBodyKey{}).(bool)
+	return compressed
+}
+
+// SetMaxDecodedBytes bounds the size of decoded response bodies; responses that
+// decode to more fail. Zero or less removes the bound.
+func (e *Engine) SetMaxDecodedBytes(maxBytes int64) {
+	e.maxDecodedBytes = maxBytes
+}
+
+// decodeBody undoes a gzip or deflate Content-Encoding, applied in the order listed,
+// and removes the Content-Encoding and Content-Length headers that described the
+// encoded bytes. Bodies in any other encoding are returned unchanged. Decoding fails
+// once a body exceeds maxBytes, unless maxBytes is zero or less.
+func decodeBody(body []byte, header http.Header, maxBytes int64) ([]byte, error) {
+	value := header.Get("Content-Encoding")
+	if value == "" || len(body) == 0 {
+		return body, nil
+	}
+
+	var encodings []string
+	for _, encoding := range strings.Split(value, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		switch encoding {
+		case "", "identity":
+		case "gzip", "x-gzip", "deflate":
+			encodings = append(encodings, encoding)
+		default:
+			return body, nil
+		}
+	}
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		decoded, err := decode(body, encodings[i], maxBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s response body: %w", encodings[i], err)
+		}
+		body = decoded
+	}
+
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	return body, nil
+}
+
+// decode reverses a single content encoding, reading at most maxBytes of output.
+// Deflate is meant to be zlib-wrapped, but some servers send a raw deflate stream,
+// which is accepted too.
+func decode(body []byte, encoding string, maxBytes int64) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch encoding {
+	case "gzip", "x-gzip":
+		reader, err = gzip.NewReader(bytes.NewReader(body))
+	case "deflate":
+		reader, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			reader, err = flate.NewReader(bytes.NewReader(body)), nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if maxBytes <= 0 {
+		return io.ReadAll(reader)
+	}
+	decoded, err := io.ReadAll(io.LimitReader(reader, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(decoded)) > maxBytes {
+		return nil, fmt.Errorf("decoded body exceeds %d bytes", maxBytes)
+	}
+	return decoded, nil
+}
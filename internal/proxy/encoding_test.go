@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+// compress encodes body with the named content encoding
+func compress(t *testing.T, encoding string, body string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buf)
+	case "deflate":
+		writer = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		writer, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	}
+	writer.Write([]byte(body))
+	writer.Close()
+	return buf.Bytes()
+}
+
+func TestEngine_DecodesCompressedResponses(t *testing.T) {
+	const body = `{"id":1,"name":"Rex"}`
+
+	tests := []struct {
+		name     string
+		encoding string
+		header   string
+		raw      bool
+	}{
+		{"gzip", "gzip", "gzip", false},
+		{"deflate", "deflate", "deflate", false},
+		{"raw deflate", "raw-deflate", "deflate", false},
+		{"compressed body requested", "gzip", "gzip", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := compress(t, tt.encoding, body)
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", tt.header)
+				w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+				w.Write(encoded)
+			}))
+			defer upstream.Close()
+
+			// An explicit Accept-Encoding stops the transport from decoding gzip itself
+			engine := New(zap.NewNop(), 5*time.Second)
+			engine.SetBaseURL(upstream.URL)
+			engine.SetHeaders(map[string]string{"Accept-Encoding": "gzip, deflate"})
+
+			ctx := context.Background()
+			if tt.raw {
+				ctx = WithCompressedBody(ctx)
+			}
+			route := &parser.RouteConfig{Path: "/pets/1", Method: "GET", OperationID: "getPet"}
+			response, err := engine.ExecuteRoute(ctx, route, map[string]interface{}{})
+			if err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+
+			if tt.raw {
+				if !bytes.Equal(response.Body, encoded) {
+					t.Errorf("Expected the compressed bytes, got %q", response.Body)
+				}
+				if response.Headers.Get("Content-Encoding") != tt.header {
+					t.Errorf("Expected Content-Encoding to be kept, got %q", response.Headers.Get("Content-Encoding"))
+				}
+				return
+			}
+			if string(response.Body) != body {
+				t.Errorf("Expected decoded body %s, got %q", body, response.Body)
+			}
+			if encoding := response.Headers.Get("Content-Encoding"); encoding != "" {
+				t.Errorf("Expected Content-Encoding to be removed, got %q", encoding)
+			}
+			if length := response.Headers.Get("Content-Length"); length != "" {
+				t.Errorf("Expected the encoded Content-Length to be removed, got %q", length)
+			}
+		})
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		expected string
+		wantErr  bool
+	}{
+		{"no encoding", "", []byte("plain"), "plain", false},
+		{"identity", "identity", []byte("plain"), "plain", false},
+		{"unsupported encoding", "br", []byte("brotli"), "brotli", false},
+		{"stacked encodings", "deflate, gzip", nil, "twice", false},
+		{"corrupt gzip", "gzip", []byte("not gzip"), "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == nil {
+				body = compress(t, "gzip", string(compress(t, "deflate", tt.expected)))
+			}
+			header := http.Header{"Content-Encoding": {tt.encoding}}
+
+			decoded, err := decodeBody(body, header, DefaultMaxDecodedBytes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(decoded) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, decoded)
+			}
+		})
+	}
+}
+
+func TestDecodeBody_MaxBytes(t *testing.T) {
+	body := compress(t, "gzip", strings.Repeat("a", 1024))
+
+	if _, err := decodeBody(body, http.Header{"Content-Encoding": {"gzip"}}, 1023); err == nil {
+		t.Error("Expected an error for a body decoding past the limit")
+	}
+	decoded, err := decodeBody(body, http.Header{"Content-Encoding": {"gzip"}}, 1024)
+	if err != nil || len(decoded) != 1024 {
+		t.Errorf("Expected a body of exactly the limit to decode, got %d bytes, error %v", len(decoded), err)
+	}
+}
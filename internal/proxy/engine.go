@@ -9,18 +9,80 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
 	"github.com/zeroLR/swagger-mcp-go/internal/parser"
 	"go.uber.org/zap"
 )
 
 // Engine handles proxying requests to upstream APIs
 type Engine struct {
-	client  *http.Client
-	logger  *zap.Logger
-	baseURL string
-	headers map[string]string
+	client *http.Client
+	// streamClient has no overall timeout so streamed bodies can stay open; the wait for
+	// response headers is bounded by its transport and silence by streamIdleTimeout.
+	// Its own transport keeps long-lived streams out of the pool of regular calls.
+	streamClient      *http.Client
+	streamTransport   *http.Transport
+	streamIdleTimeout time.Duration
+	// routeClient sends calls of routes with a Timeout of their own, which the route's
+	// deadline bounds instead of the client and response header timeouts
+	routeClient    *http.Client
+	transport      *http.Transport
+	routeTransport *http.Transport
+	logger         *zap.Logger
+	baseURL        string
+	headers        map[string]string
+
+	// expectContinueThreshold is the body size from which Expect: 100-continue is sent
+	expectContinueThreshold int64
+
+	// maxDecodedBytes bounds response bodies once their Content-Encoding is undone
+	maxDecodedBytes int64
+
+	serviceName string
+	hooks       *hooks.Manager
+
+	breakers        *circuitbreaker.Manager
+	breakerConfig   circuitbreaker.Config
+	breakerFallback bool
+	healthURL       string
+	lastGood        map[string]*Response
+	lastGoodMutex   sync.Mutex
+
+	maxAttempts     int
+	retryDelay      time.Duration
+	retryableStatus map[int]bool
+
+	recorder RequestRecorder
+
+	// baseURLs supplies a per-service base URL that takes precedence over baseURL
+	baseURLs BaseURLResolver
+
+	// mock answers calls from the spec instead of the upstream
+	mock bool
+
+	// drainer counts calls in flight and turns new ones away during shutdown
+	drainer *Drainer
+
+	// identityHeaders carry the authenticated caller's identity upstream
+	identityHeaders IdentityHeaders
+
+	// trustedProxies may set the X-Forwarded headers of the requests they pass on
+	trustedProxies TrustedProxies
+}
+
+// RequestRecorder receives the outcome of every upstream call
+type RequestRecorder interface {
+	RecordRequest(serviceName string, statusCode int, latency time.Duration)
+}
+
+// BaseURLResolver looks up the upstream base URL configured for a service, returning
+// "" when the service has none
+type BaseURLResolver interface {
+	BaseURL(serviceName string) string
 }
 
 // Response represents a proxy response
@@ -28,16 +90,44 @@ type Response struct {
 	StatusCode int
 	Headers    http.Header
 	Body       []byte
+
+	// stream is the still open upstream body of a streamed response, in place of Body
+	stream io.ReadCloser
+}
+
+// requestHeadersKey is the context key for per-call upstream headers
+type requestHeadersKey struct{}
+
+// WithRequestHeaders returns a context whose upstream requests carry headers in addition
+// to the engine defaults, e.g. credentials supplied for a single call
+func WithRequestHeaders(ctx context.Context, headers map[string]string) context.Context {
+	return context.WithValue(ctx, requestHeadersKey{}, headers)
 }
 
 // New creates a new proxy engine
 func New(logger *zap.Logger, timeout time.Duration) *Engine {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	routeTransport := transport.Clone()
+	transport.ResponseHeaderTimeout = timeout
+	streamTransport := transport.Clone()
 	return &Engine{
 		client: &http.Client{
-			Timeout: timeout,
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		streamClient: &http.Client{
+			Transport: streamTransport,
 		},
-		logger:  logger,
-		headers: make(map[string]string),
+		routeClient: &http.Client{
+			Transport: routeTransport,
+		},
+		transport:         transport,
+		routeTransport:    routeTransport,
+		streamTransport:   streamTransport,
+		streamIdleTimeout: DefaultStreamIdleTimeout,
+		logger:            logger,
+		headers:           make(map[string]string),
+		maxDecodedBytes:   DefaultMaxDecodedBytes,
 	}
 }
 
@@ -46,15 +136,62 @@ func (e *Engine) SetBaseURL(baseURL string) {
 	e.baseURL = strings.TrimSuffix(baseURL, "/")
 }
 
+// SetBaseURLResolver resolves the engine's service base URL through resolver on every
+// call, so it can change without rebuilding the engine. The base URL given to
+// SetBaseURL is used while the resolver has none. A nil resolver disables lookups.
+func (e *Engine) SetBaseURLResolver(resolver BaseURLResolver) {
+	e.baseURLs = resolver
+}
+
+// upstreamBaseURL returns the base URL for the engine's service
+func (e *Engine) upstreamBaseURL() string {
+	if e.baseURLs != nil {
+		if baseURL := e.baseURLs.BaseURL(e.serviceName); baseURL != "" {
+			return strings.TrimSuffix(baseURL, "/")
+		}
+	}
+	return e.baseURL
+}
+
 // SetHeaders sets default headers for upstream requests
 func (e *Engine) SetHeaders(headers map[string]string) {
 	e.headers = headers
 }
 
-// ExecuteRoute executes a route with the given parameters
+// SetRequestRecorder reports every upstream call to recorder. A nil recorder disables reporting.
+func (e *Engine) SetRequestRecorder(recorder RequestRecorder) {
+	e.recorder = recorder
+}
+
+// SetServiceName names the service the engine calls, for hook contexts and circuit breakers
+func (e *Engine) SetServiceName(name string) {
+	e.serviceName = name
+}
+
+// SetExpectContinue configures Expect: 100-continue negotiation for large request bodies.
+// Bodies of at least threshold bytes are sent with the header; a threshold of zero disables it.
+// The timeout bounds how long to wait for the upstream's 100 Continue before sending the body anyway.
+func (e *Engine) SetExpectContinue(timeout time.Duration, threshold int64) {
+	for _, transport := range e.transports() {
+		transport.ExpectContinueTimeout = timeout
+	}
+	e.expectContinueThreshold = threshold
+}
+
+// ExecuteRoute executes a route with the given parameters. A route with a Timeout of
+// its own is bounded by it rather than by the client timeout. While the engine's
+// drainer is draining, the call is answered with 503 without reaching the upstream.
 func (e *Engine) ExecuteRoute(ctx context.Context, route *parser.RouteConfig, params map[string]interface{}) (*Response, error) {
+	if !e.drainer.begin() {
+		return drainingResponse(), nil
+	}
+	defer e.drainer.end()
+
+	ctx, cancel := withRouteTimeout(ctx, route)
+	defer cancel()
+
 	// Build the URL with path parameters
-	reqURL, err := e.buildURL(route.Path, params)
+	reqURL, err := e.buildURL(route, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build URL: %w", err)
 	}
@@ -65,69 +202,218 @@ func (e *Engine) ExecuteRoute(ctx context.Context, route *parser.RouteConfig, pa
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	var hookCtx *hooks.HookContext
+	if e.hooks != nil {
+		hookCtx, err = e.runPreRequestHooks(ctx, req, route, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Execute request
 	e.logger.Debug("Executing proxy request",
 		zap.String("method", req.Method),
 		zap.String("url", req.URL.String()),
 		zap.String("operationID", route.OperationID))
 
-	resp, err := e.client.Do(req)
+	response, cached := cachedResponse(hookCtx)
+	if !cached {
+		response, err = e.roundTrip(ctx, route, req, false)
+	}
+	if err != nil {
+		if hookCtx != nil {
+			e.runErrorHooks(ctx, hookCtx, req, err)
+		}
+		return nil, err
+	}
+
+	if hookCtx != nil {
+		if err := e.runPostResponseHooks(ctx, hookCtx, req, response); err != nil {
+			return nil, err
+		}
+	}
+
+	e.logger.Debug("Proxy request completed",
+		zap.String("operationID", route.OperationID),
+		zap.Int("statusCode", response.StatusCode),
+		zap.Int("bodySize", len(response.Body)))
+
+	return response, nil
+}
+
+// roundTrip sends req upstream, through the service's circuit breaker when one is set,
+// or synthesizes the response to route in mock mode
+func (e *Engine) roundTrip(ctx context.Context, route *parser.RouteConfig, req *http.Request, stream bool) (*Response, error) {
+	if e.mock {
+		return mockResponse(ctx, route)
+	}
+
+	client := e.client
+	switch {
+	case route.Timeout > 0:
+		client = e.routeClient
+	case stream:
+		client = e.streamClient
+	}
+	if e.breakers != nil {
+		return e.sendThroughBreaker(ctx, client, req, stream)
+	}
+	return e.send(client, req, stream)
+}
+
+// withRouteTimeout derives a cancellable context for a call of route, bounded by the
+// route's own timeout when it has one
+func withRouteTimeout(ctx context.Context, route *parser.RouteConfig) (context.Context, context.CancelFunc) {
+	if route.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, route.Timeout)
+}
+
+// send performs the upstream call, with retries, and reads the whole response, decoding
+// a gzip or deflate body unless the context asks for it WithCompressedBody. With
+// stream set, a response that isStreaming is left open on the response instead, for
+// the caller to copy and close.
+func (e *Engine) send(client *http.Client, req *http.Request, stream bool) (*Response, error) {
+	req, span := startUpstreamSpan(req)
+	start := time.Now()
+	resp, err := e.doWithRetry(client, req)
+	latency := time.Since(start)
+	statusCode := 0
+	if err == nil {
+		statusCode = resp.StatusCode
+	}
+	endUpstreamSpan(span, statusCode, err)
+	observeRequest(e.serviceName, statusCode, latency)
+	if e.recorder != nil {
+		e.recorder.RecordRequest(e.serviceName, statusCode, latency)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+
+	if stream && isStreaming(resp, !e.hasPostResponseHooks()) {
+		return &Response{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header,
+			stream:     resp.Body,
+		}, nil
+	}
 	defer resp.Body.Close()
 
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(req.Context(), resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if !wantsCompressedBody(req.Context()) {
+		if body, err = decodeBody(body, resp.Header, e.maxDecodedBytes); err != nil {
+			return nil, err
+		}
+	}
 
-	response := &Response{
+	return &Response{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
 		Body:       body,
+	}, nil
+}
+
+// do sends the request upstream. An upstream that refuses Expect: 100-continue with
+// 417 Expectation Failed never received the body, so the request is replayed once
+// without the header from the buffered body.
+func (e *Engine) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusExpectationFailed || req.Header.Get("Expect") == "" || req.GetBody == nil {
+		return resp, err
 	}
 
-	e.logger.Debug("Proxy request completed",
-		zap.String("operationID", route.OperationID),
-		zap.Int("statusCode", resp.StatusCode),
-		zap.Int("bodySize", len(body)))
+	body, err := req.GetBody()
+	if err != nil {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
 
-	return response, nil
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	retry.Header.Del("Expect")
+
+	e.logger.Debug("Upstream rejected Expect: 100-continue, retrying without it",
+		zap.String("url", req.URL.String()))
+
+	return client.Do(retry)
 }
 
-// buildURL constructs the full URL with path parameters
-func (e *Engine) buildURL(path string, params map[string]interface{}) (string, error) {
-	fullPath := path
+// ApplyParameterDefaults fills in optional parameters that the caller omitted with
+// their OpenAPI default value. Required parameters are left for validation to report.
+func ApplyParameterDefaults(route *parser.RouteConfig, params map[string]interface{}) {
+	for _, param := range route.Parameters {
+		if param.Required || param.Default == nil {
+			continue
+		}
+		if _, exists := params[param.Name]; !exists {
+			params[param.Name] = param.Default
+		}
+	}
+}
 
-	// Replace path parameters
+// ResourcePath returns the route path with its path parameters filled in
+func ResourcePath(route *parser.RouteConfig, params map[string]interface{}) string {
+	return expandPath(route, params)
+}
+
+// expandPath replaces path parameter placeholders with their values serialized in the
+// parameter's style, percent-encoded so a value containing "/", "?" or "#" stays within
+// its path segment
+func expandPath(route *parser.RouteConfig, params map[string]interface{}) string {
+	declared := make(map[string]*parser.ParameterConfig)
+	for i, param := range route.Parameters {
+		if param.In == "path" {
+			declared[param.Name] = &route.Parameters[i]
+		}
+	}
+
+	fullPath := route.Path
 	for paramName, paramValue := range params {
 		placeholder := "{" + paramName + "}"
 		if strings.Contains(fullPath, placeholder) {
-			fullPath = strings.ReplaceAll(fullPath, placeholder, fmt.Sprintf("%v", paramValue))
+			fullPath = strings.ReplaceAll(fullPath, placeholder, pathValue(paramName, declared[paramName], paramValue))
 		}
 	}
+	return fullPath
+}
 
+// buildURL constructs the full URL with path and query parameters. Arguments bound to
+// the path, headers or request body are identified from the route rather than by name,
+// so a query parameter may share its name with the body argument.
+func (e *Engine) buildURL(route *parser.RouteConfig, params map[string]interface{}) (string, error) {
 	// Build full URL
-	fullURL := e.baseURL + fullPath
+	fullURL := e.upstreamBaseURL() + expandPath(route, params)
+
+	skip := map[string]bool{parser.IfMatchParam: true}
+	if route.RequestBody != nil {
+		skip[route.BodyArgument()] = true
+	}
+	declared := make(map[string]*parser.ParameterConfig)
+	for i, param := range route.Parameters {
+		if param.In != "query" {
+			skip[param.Name] = true
+		} else {
+			declared[param.Name] = &route.Parameters[i]
+		}
+	}
 
 	// Add query parameters
-	queryParams := make(map[string][]string)
+	query := make(url.Values)
 	for paramName, paramValue := range params {
-		// Skip path parameters and body
-		if strings.Contains(path, "{"+paramName+"}") || paramName == "body" {
+		if skip[paramName] || strings.Contains(route.Path, "{"+paramName+"}") {
 			continue
 		}
-		if queryParams[paramName] == nil {
-			queryParams[paramName] = make([]string, 0)
-		}
-		queryParams[paramName] = append(queryParams[paramName], fmt.Sprintf("%v", paramValue))
+		addQueryValues(query, paramName, declared[paramName], paramValue)
 	}
 
-	if len(queryParams) > 0 {
-		values := url.Values(queryParams)
-		fullURL += "?" + values.Encode()
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
 	}
 
 	return fullURL, nil
@@ -139,7 +425,7 @@ func (e *Engine) createRequest(ctx context.Context, route *parser.RouteConfig, r
 	var contentType string
 
 	if route.RequestBody != nil {
-		if bodyData, ok := params["body"]; ok {
+		if bodyData, ok := params[route.BodyArgument()]; ok {
 			b, ct, err := buildRequestBody(route, bodyData)
 			if err != nil {
 				return nil, err
@@ -157,9 +443,26 @@ func (e *Engine) createRequest(ctx context.Context, route *parser.RouteConfig, r
 		req.Header.Set("Content-Type", contentType)
 	}
 
+	if e.expectContinueThreshold > 0 && req.ContentLength >= e.expectContinueThreshold {
+		req.Header.Set("Expect", "100-continue")
+	}
+
 	addDefaultHeaders(req, e.headers)
+	if headers, ok := ctx.Value(requestHeadersKey{}).(map[string]string); ok {
+		addDefaultHeaders(req, headers)
+	}
+	if incoming, ok := ctx.Value(forwardedRequestKey{}).(*http.Request); ok {
+		addForwardedHeaders(req, incoming, e.trustedProxies.Contains(incoming.RemoteAddr))
+	}
 	addParameterHeaders(req, route.Parameters, params)
 
+	if ifMatch, ok := params[parser.IfMatchParam].(string); ok && ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	if len(e.identityHeaders) > 0 {
+		addIdentityHeaders(ctx, req, e.identityHeaders)
+	}
+
 	return req, nil
 }
 
@@ -184,6 +487,8 @@ func buildRequestBody(route *parser.RouteConfig, bodyData interface{}) (io.Reade
 		}
 		urlValues := url.Values(values)
 		return strings.NewReader(urlValues.Encode()), "application/x-www-form-urlencoded", nil
+	case "multipart/form-data":
+		return buildMultipartBody(route.RequestBody, bodyData)
 	case "text/plain":
 		return strings.NewReader(fmt.Sprintf("%v", bodyData)), "text/plain", nil
 	default:
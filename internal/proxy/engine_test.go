@@ -0,0 +1,1169 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/circuitbreaker"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"go.uber.org/zap"
+)
+
+func uploadRoute() *parser.RouteConfig {
+	return &parser.RouteConfig{
+		Path:        "/upload",
+		Method:      "POST",
+		OperationID: "upload",
+		RequestBody: &parser.RequestBodyConfig{
+			ContentType: "text/plain",
+		},
+	}
+}
+
+func TestEngine_ExpectContinue(t *testing.T) {
+	var sawExpect atomic.Bool
+	var bodyRead atomic.Bool
+
+	// The upstream rejects every upload that announces itself with Expect: 100-continue
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Expect") == "100-continue" {
+			sawExpect.Store(true)
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		io.Copy(io.Discard, r.Body)
+		bodyRead.Store(true)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetExpectContinue(time.Second, 64)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectSent     bool
+	}{
+		{"large body negotiates and is rejected", strings.Repeat("x", 1024), http.StatusRequestEntityTooLarge, true},
+		{"small body is sent directly", "small", http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sawExpect.Store(false)
+			bodyRead.Store(false)
+
+			resp, err := engine.ExecuteRoute(context.Background(), uploadRoute(), map[string]interface{}{"body": tt.body})
+			if err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if sawExpect.Load() != tt.expectSent {
+				t.Errorf("Expected Expect header sent = %v, got %v", tt.expectSent, sawExpect.Load())
+			}
+			if tt.expectSent && bodyRead.Load() {
+				t.Errorf("Upstream should not have read the body after rejecting at the 100-continue stage")
+			}
+		})
+	}
+}
+
+func TestEngine_ExpectContinueFallback(t *testing.T) {
+	var attempts atomic.Int32
+
+	// The upstream does not understand Expect and refuses it outright
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if r.Header.Get("Expect") != "" {
+			w.WriteHeader(http.StatusExpectationFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetExpectContinue(time.Second, 64)
+
+	payload := strings.Repeat("y", 512)
+	resp, err := engine.ExecuteRoute(context.Background(), uploadRoute(), map[string]interface{}{"body": payload})
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 after fallback, got %d", resp.StatusCode)
+	}
+	if string(resp.Body) != payload {
+		t.Errorf("Expected the buffered body to be replayed intact")
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Expected 2 upstream attempts, got %d", attempts.Load())
+	}
+}
+
+func TestEngine_IfMatch(t *testing.T) {
+	var ifMatch, rawQuery string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifMatch = r.Header.Get("If-Match")
+		rawQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+
+	route := &parser.RouteConfig{Path: "/pets/{id}", Method: "PUT", OperationID: "updatePet"}
+	params := map[string]interface{}{"id": "1", parser.IfMatchParam: `"v1"`}
+
+	if _, err := engine.ExecuteRoute(context.Background(), route, params); err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if ifMatch != `"v1"` {
+		t.Errorf("Expected If-Match %q, got %q", `"v1"`, ifMatch)
+	}
+	if rawQuery != "" {
+		t.Errorf("Expected reserved argument to stay out of the query, got %q", rawQuery)
+	}
+	if path := ResourcePath(route, params); path != "/pets/1" {
+		t.Errorf("Expected resource path /pets/1, got %s", path)
+	}
+}
+
+func TestEngine_QueryParameterNamedBody(t *testing.T) {
+	var rawQuery, body, trace string
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		rawQuery, body, trace = r.URL.RawQuery, string(data), r.Header.Get("X-Trace")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+
+	route := &parser.RouteConfig{
+		Path:        "/notes",
+		Method:      "POST",
+		OperationID: "createNote",
+		Parameters: []parser.ParameterConfig{
+			{Name: "body", In: "query", Type: "string"},
+			{Name: "X-Trace", In: "header", Type: "string"},
+		},
+		RequestBody: &parser.RequestBodyConfig{ContentType: "application/json"},
+	}
+	if arg := route.BodyArgument(); arg != "_body" {
+		t.Fatalf("Expected request body argument _body, got %s", arg)
+	}
+
+	params := map[string]interface{}{
+		"body":    "markdown",
+		"_body":   map[string]interface{}{"text": "hello"},
+		"X-Trace": "abc",
+	}
+	if _, err := engine.ExecuteRoute(context.Background(), route, params); err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if rawQuery != "body=markdown" {
+		t.Errorf("Expected query body=markdown, got %q", rawQuery)
+	}
+	if body != `{"text":"hello"}` {
+		t.Errorf("Expected JSON request body, got %q", body)
+	}
+	if trace != "abc" {
+		t.Errorf("Expected header parameter to be sent as a header, got %q", trace)
+	}
+
+	// Without a colliding parameter the request body keeps its usual argument
+	if arg := uploadRoute().BodyArgument(); arg != parser.BodyParam {
+		t.Errorf("Expected request body argument %s, got %s", parser.BodyParam, arg)
+	}
+}
+
+func TestEngine_BuildURLQueryArrays(t *testing.T) {
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL("https://api.example.com")
+
+	tests := []struct {
+		name     string
+		param    parser.ParameterConfig
+		value    interface{}
+		expected string
+	}{
+		{"explode repeats the key", parser.ParameterConfig{Name: "tag", In: "query", Style: "form", Explode: true},
+			[]interface{}{"a", "b c", "d&e"}, "tag=a&tag=b+c&tag=d%26e"},
+		{"explode false joins with commas", parser.ParameterConfig{Name: "tag", In: "query", Style: "form", Explode: false},
+			[]interface{}{"a", "b", "c"}, "tag=a%2Cb%2Cc"},
+		{"pipe delimited", parser.ParameterConfig{Name: "tag", In: "query", Style: "pipeDelimited", Explode: false},
+			[]string{"a", "b"}, "tag=a%7Cb"},
+		{"typed slice without style", parser.ParameterConfig{Name: "id", In: "query"},
+			[]int{1, 2}, "id=1&id=2"},
+		{"scalar", parser.ParameterConfig{Name: "tag", In: "query", Style: "form", Explode: false},
+			"a b", "tag=a+b"},
+		{"space delimited", parser.ParameterConfig{Name: "tag", In: "query", Style: "spaceDelimited", Explode: false},
+			[]interface{}{"a", "b", "c"}, "tag=a+b+c"},
+		{"space delimited with explode", parser.ParameterConfig{Name: "tag", In: "query", Style: "spaceDelimited", Explode: true},
+			[]interface{}{"a", "b"}, "tag=a&tag=b"},
+		{"deep object", parser.ParameterConfig{Name: "filter", In: "query", Style: "deepObject", Explode: true},
+			map[string]interface{}{"status": "sold", "price": map[string]interface{}{"max": 20}},
+			"filter%5Bprice%5D%5Bmax%5D=20&filter%5Bstatus%5D=sold"},
+		{"object with explode", parser.ParameterConfig{Name: "filter", In: "query", Style: "form", Explode: true},
+			map[string]interface{}{"status": "sold", "limit": 5}, "limit=5&status=sold"},
+		{"object without explode", parser.ParameterConfig{Name: "filter", In: "query", Style: "form", Explode: false},
+			map[string]interface{}{"status": "sold", "limit": 5}, "filter=limit%2C5%2Cstatus%2Csold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &parser.RouteConfig{Path: "/pets", Method: "GET", Parameters: []parser.ParameterConfig{tt.param}}
+			reqURL, err := engine.buildURL(route, map[string]interface{}{tt.param.Name: tt.value})
+			if err != nil {
+				t.Fatalf("buildURL() error = %v", err)
+			}
+			if expected := "https://api.example.com/pets?" + tt.expected; reqURL != expected {
+				t.Errorf("Expected %s, got %s", expected, reqURL)
+			}
+		})
+	}
+}
+
+func TestEngine_PathParameterStyles(t *testing.T) {
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL("https://api.example.com")
+
+	ids := []interface{}{3, 4, 5}
+	object := map[string]interface{}{"role": "admin", "name": "Al/ex"}
+	tests := []struct {
+		name     string
+		style    string
+		explode  bool
+		value    interface{}
+		expected string
+	}{
+		{"simple array", "simple", false, ids, "/items/3,4,5"},
+		{"simple object", "simple", false, object, "/items/name,Al%2Fex,role,admin"},
+		{"simple object with explode", "simple", true, object, "/items/name=Al%2Fex,role=admin"},
+		{"label scalar", "label", false, 5, "/items/.5"},
+		{"label array with explode", "label", true, ids, "/items/.3.4.5"},
+		{"matrix array", "matrix", false, ids, "/items/;id=3,4,5"},
+		{"matrix array with explode", "matrix", true, ids, "/items/;id=3;id=4;id=5"},
+		{"matrix object with explode", "matrix", true, object, "/items/;name=Al%2Fex;role=admin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route := &parser.RouteConfig{
+				Path:       "/items/{id}",
+				Method:     "GET",
+				Parameters: []parser.ParameterConfig{{Name: "id", In: "path", Required: true, Style: tt.style, Explode: tt.explode}},
+			}
+			reqURL, err := engine.buildURL(route, map[string]interface{}{"id": tt.value})
+			if err != nil {
+				t.Fatalf("buildURL() error = %v", err)
+			}
+			if expected := "https://api.example.com" + tt.expected; reqURL != expected {
+				t.Errorf("Expected %s, got %s", expected, reqURL)
+			}
+		})
+	}
+}
+
+func TestEngine_PathParameterEncoding(t *testing.T) {
+	var requestURI string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	route := &parser.RouteConfig{
+		Path:       "/items/{id}",
+		Method:     "GET",
+		Parameters: []parser.ParameterConfig{{Name: "id", In: "path", Required: true}},
+	}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{"slash", "a/b", "/items/a%2Fb"},
+		{"space", "hello world", "/items/hello%20world"},
+		{"fragment and query", "a#b?c", "/items/a%23b%3Fc"},
+		{"integer", 42, "/items/42"},
+		{"float", 1.5, "/items/1.5"},
+		{"boolean", true, "/items/true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{"id": tt.value}); err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+			if requestURI != tt.expected {
+				t.Errorf("Expected upstream request URI %s, got %s", tt.expected, requestURI)
+			}
+		})
+	}
+}
+
+// rewriteHook replaces the request body before the call and the status after it
+type rewriteHook struct{ hookType hooks.HookType }
+
+func (h *rewriteHook) Execute(ctx context.Context, hookCtx *hooks.HookContext) error {
+	if hookCtx.Response != nil {
+		hookCtx.Response.StatusCode = http.StatusAccepted
+		hookCtx.Response.Body = []byte("rewritten:" + string(hookCtx.Response.Body))
+		return nil
+	}
+	hookCtx.Request.Body = []byte(strings.ToUpper(string(hookCtx.Request.Body)))
+	hookCtx.Request.QueryParams["source"] = []string{"hook"}
+	return nil
+}
+func (h *rewriteHook) Type() hooks.HookType     { return h.hookType }
+func (h *rewriteHook) Priority() hooks.Priority { return hooks.PriorityMedium }
+func (h *rewriteHook) Name() string             { return "rewrite-" + string(h.hookType) }
+
+func TestEngine_Hooks(t *testing.T) {
+	var received, rawQuery string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		received, rawQuery = string(data), r.URL.RawQuery
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	manager := hooks.NewManager(zap.NewNop())
+	manager.RegisterHook(&rewriteHook{hookType: hooks.HookTypePreRequest})
+	manager.RegisterHook(&rewriteHook{hookType: hooks.HookTypePostResponse})
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetServiceName("uploads")
+	engine.SetHooks(manager)
+
+	resp, err := engine.ExecuteRoute(context.Background(), uploadRoute(), map[string]interface{}{"body": "hello"})
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if received != "HELLO" || rawQuery != "source=hook" {
+		t.Errorf("Expected upstream to see the rewritten request, got body %q query %q", received, rawQuery)
+	}
+	if resp.StatusCode != http.StatusAccepted || string(resp.Body) != "rewritten:ok" {
+		t.Errorf("Expected rewritten response, got %d %q", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestEngine_ResponseCache(t *testing.T) {
+	calls := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	}))
+	defer upstream.Close()
+
+	manager := hooks.NewManager(zap.NewNop())
+	for _, hook := range hooks.NewResponseCache(zap.NewNop(), time.Minute).Hooks() {
+		manager.RegisterHook(hook)
+	}
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetServiceName("petstore")
+	engine.SetHooks(manager)
+
+	list := &parser.RouteConfig{Path: "/pets", Method: "GET", OperationID: "listPets", Parameters: []parser.ParameterConfig{
+		{Name: "limit", In: "query"},
+		{Name: "tag", In: "query"},
+	}}
+	for i := 0; i < 2; i++ {
+		resp, err := engine.ExecuteRoute(context.Background(), list, map[string]interface{}{"limit": 10, "tag": "dog"})
+		if err != nil {
+			t.Fatalf("ExecuteRoute() error = %v", err)
+		}
+		if string(resp.Body) != `{"call":1}` || resp.Headers.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected the first response from cache, got %q (%s)", resp.Body, resp.Headers.Get("Content-Type"))
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected the second identical GET to be served from cache, got %d upstream calls", calls)
+	}
+
+	create := &parser.RouteConfig{Path: "/pets", Method: "POST", OperationID: "createPet"}
+	for i := 0; i < 2; i++ {
+		if _, err := engine.ExecuteRoute(context.Background(), create, map[string]interface{}{}); err != nil {
+			t.Fatalf("ExecuteRoute() error = %v", err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("Expected POSTs to bypass the cache, got %d upstream calls", calls)
+	}
+}
+
+func TestEngine_BodyDefaults(t *testing.T) {
+	var received map[string]interface{}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	defaults := hooks.NewBodyDefaultsHook(hooks.PriorityHigh)
+	defaults.SetDefaults("billing", "", map[string]interface{}{"apiVersion": "2024-01", "source": "mcp"})
+	manager := hooks.NewManager(zap.NewNop())
+	manager.RegisterHook(defaults)
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetServiceName("billing")
+	engine.SetHooks(manager)
+
+	route := &parser.RouteConfig{
+		Path:        "/invoices",
+		Method:      "POST",
+		OperationID: "createInvoice",
+		RequestBody: &parser.RequestBodyConfig{ContentType: "application/json"},
+	}
+	params := map[string]interface{}{"body": map[string]interface{}{"amount": 10, "source": "client"}}
+	if _, err := engine.ExecuteRoute(context.Background(), route, params); err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+
+	if received["apiVersion"] != "2024-01" || received["source"] != "client" || received["amount"] != float64(10) {
+		t.Errorf("Expected the missing default to be added and the client's values kept, got %v", received)
+	}
+}
+
+func TestEngine_RouteTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 100*time.Millisecond)
+	engine.SetBaseURL(upstream.URL)
+
+	report := &parser.RouteConfig{Path: "/reports", Method: "POST", OperationID: "buildReport", Timeout: 2 * time.Second}
+	if response, err := engine.ExecuteRoute(context.Background(), report, map[string]interface{}{}); err != nil {
+		t.Errorf("Expected the route timeout to allow the slow call, got %v", err)
+	} else if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", response.StatusCode)
+	}
+
+	lookup := &parser.RouteConfig{Path: "/reports/1", Method: "GET", OperationID: "getReport"}
+	if _, err := engine.ExecuteRoute(context.Background(), lookup, map[string]interface{}{}); err == nil {
+		t.Errorf("Expected the client timeout to fail the slow call")
+	}
+
+	// A route timeout shorter than the client's applies too
+	quick := &parser.RouteConfig{Path: "/reports/1", Method: "GET", OperationID: "getReport", Timeout: 50 * time.Millisecond}
+	engine = New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	start := time.Now()
+	if _, err := engine.ExecuteRoute(context.Background(), quick, map[string]interface{}{}); err == nil {
+		t.Errorf("Expected the route timeout to fail the slow call")
+	}
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Errorf("Expected the call to stop at the route timeout, took %v", elapsed)
+	}
+}
+
+func TestEngine_Retry(t *testing.T) {
+	tests := []struct {
+		name             string
+		route            *parser.RouteConfig
+		params           map[string]interface{}
+		failures         int32
+		failStatus       int
+		maxAttempts      int
+		expectedStatus   int
+		expectedRequests int32
+	}{
+		{"GET succeeds after two failures", &parser.RouteConfig{Path: "/pets", Method: "GET"}, nil,
+			2, http.StatusServiceUnavailable, 3, http.StatusOK, 3},
+		{"PUT with body is replayed", &parser.RouteConfig{Path: "/upload", Method: "PUT", RequestBody: &parser.RequestBodyConfig{ContentType: "text/plain"}},
+			map[string]interface{}{"body": "payload"}, 2, http.StatusBadGateway, 3, http.StatusOK, 3},
+		{"attempts are capped", &parser.RouteConfig{Path: "/pets", Method: "GET"}, nil,
+			2, http.StatusGatewayTimeout, 2, http.StatusGatewayTimeout, 2},
+		{"POST is not retried", uploadRoute(), map[string]interface{}{"body": "payload"},
+			2, http.StatusServiceUnavailable, 3, http.StatusServiceUnavailable, 1},
+		{"non-retryable status", &parser.RouteConfig{Path: "/pets", Method: "GET"}, nil,
+			2, http.StatusInternalServerError, 3, http.StatusInternalServerError, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			var lastBody atomic.Value
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				lastBody.Store(string(data))
+				if requests.Add(1) <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			engine := New(zap.NewNop(), 5*time.Second)
+			engine.SetBaseURL(upstream.URL)
+			engine.SetRetry(tt.maxAttempts, time.Millisecond, nil)
+
+			params := tt.params
+			if params == nil {
+				params = map[string]interface{}{}
+			}
+			resp, err := engine.ExecuteRoute(context.Background(), tt.route, params)
+			if err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if got := requests.Load(); got != tt.expectedRequests {
+				t.Errorf("Expected %d upstream requests, got %d", tt.expectedRequests, got)
+			}
+			if tt.params != nil && lastBody.Load() != "payload" {
+				t.Errorf("Expected every attempt to carry the body, got %q", lastBody.Load())
+			}
+		})
+	}
+}
+
+func TestEngine_RetryStopsAtDeadline(t *testing.T) {
+	var requests atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetRetry(5, time.Second, []int{http.StatusServiceUnavailable})
+
+	if engine.MaxAttempts() != 5 || len(engine.RetryableStatusCodes()) != 1 {
+		t.Errorf("Expected retry settings to be exposed, got %d %v", engine.MaxAttempts(), engine.RetryableStatusCodes())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := engine.ExecuteRoute(ctx, &parser.RouteConfig{Path: "/pets", Method: "GET"}, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable || requests.Load() != 1 {
+		t.Errorf("Expected a single attempt when the backoff outlasts the deadline, got %d after %d requests", resp.StatusCode, requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected no wait past the deadline, took %v", elapsed)
+	}
+}
+
+func TestEngine_CircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name           string
+		fallback       bool
+		expectedStatus int
+		expectedHeader string
+	}{
+		{"open breaker short-circuits", false, http.StatusServiceUnavailable, "open"},
+		{"fallback serves last good response", true, http.StatusOK, "fallback"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			var failing atomic.Bool
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if failing.Load() {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.Write([]byte("pets"))
+			}))
+			defer upstream.Close()
+
+			engine := New(zap.NewNop(), 5*time.Second)
+			engine.SetBaseURL(upstream.URL)
+			engine.SetServiceName("petstore")
+			engine.SetCircuitBreaker(circuitbreaker.NewManager(zap.NewNop(), true), circuitbreaker.Config{
+				MaxFailures:  3,
+				ResetTimeout: time.Minute,
+			}, tt.fallback)
+
+			route := &parser.RouteConfig{Path: "/pets", Method: "GET"}
+			execute := func() *Response {
+				resp, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{})
+				if err != nil {
+					t.Fatalf("ExecuteRoute() error = %v", err)
+				}
+				return resp
+			}
+
+			if resp := execute(); resp.StatusCode != http.StatusOK {
+				t.Fatalf("Expected 200 while upstream is healthy, got %d", resp.StatusCode)
+			}
+
+			failing.Store(true)
+			for i := 0; i < 3; i++ {
+				if resp := execute(); resp.StatusCode != http.StatusInternalServerError {
+					t.Errorf("Expected upstream 500 to pass through, got %d", resp.StatusCode)
+				}
+			}
+
+			before := requests.Load()
+			resp := execute()
+			if requests.Load() != before {
+				t.Errorf("Expected open breaker to skip the upstream, got %d extra requests", requests.Load()-before)
+			}
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if header := resp.Headers.Get(CircuitBreakerHeader); header != tt.expectedHeader {
+				t.Errorf("Expected %s header %q, got %q", CircuitBreakerHeader, tt.expectedHeader, header)
+			}
+			if tt.fallback {
+				if string(resp.Body) != "pets" {
+					t.Errorf("Expected cached body, got %q", resp.Body)
+				}
+			} else if retryAfter := resp.Headers.Get("Retry-After"); retryAfter == "" || retryAfter == "0" {
+				t.Errorf("Expected a positive Retry-After, got %q", retryAfter)
+			}
+		})
+	}
+}
+
+func TestEngine_CircuitBreakerIgnoresClientErrors(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusNotFound)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer upstream.Close()
+
+	manager := circuitbreaker.NewManager(zap.NewNop(), true)
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetServiceName("petstore")
+	engine.SetCircuitBreaker(manager, circuitbreaker.Config{
+		MaxFailures:  3,
+		ResetTimeout: time.Minute,
+	}, false)
+
+	route := &parser.RouteConfig{Path: "/pets", Method: "GET"}
+	for i := 0; i < 10; i++ {
+		resp, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("ExecuteRoute() error = %v", err)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected upstream 404 to pass through, got %d", resp.StatusCode)
+		}
+	}
+	breaker, _ := manager.GetBreaker("petstore")
+	if state := breaker.GetState(); state != circuitbreaker.StateClosed {
+		t.Fatalf("Expected 404s to keep the breaker closed, got %s", state)
+	}
+
+	status.Store(http.StatusServiceUnavailable)
+	for i := 0; i < 3; i++ {
+		engine.ExecuteRoute(context.Background(), route, map[string]interface{}{})
+	}
+	if state := breaker.GetState(); state != circuitbreaker.StateOpen {
+		t.Errorf("Expected 503s to open the breaker, got %s", state)
+	}
+}
+
+func TestEngine_CircuitBreakerHealthURL(t *testing.T) {
+	var requests, probes atomic.Int32
+	var healthy atomic.Bool
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" {
+			probes.Add(1)
+			if !healthy.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			return
+		}
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("pets"))
+	}))
+	defer upstream.Close()
+
+	manager := circuitbreaker.NewManager(zap.NewNop(), true)
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetServiceName("petstore")
+	engine.SetCircuitBreaker(manager, circuitbreaker.Config{
+		MaxFailures:  2,
+		ResetTimeout: 10 * time.Millisecond,
+	}, false)
+	engine.SetHealthURL(upstream.URL + "/health")
+
+	route := &parser.RouteConfig{Path: "/pets", Method: "GET"}
+	execute := func() *Response {
+		resp, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("ExecuteRoute() error = %v", err)
+		}
+		return resp
+	}
+
+	execute()
+	execute()
+	time.Sleep(20 * time.Millisecond)
+	if resp := execute(); resp.StatusCode != http.StatusServiceUnavailable || resp.Headers.Get(CircuitBreakerHeader) != "open" {
+		t.Errorf("Expected a failed probe to keep the circuit open, got %d", resp.StatusCode)
+	}
+	if requests.Load() != 2 || probes.Load() != 1 {
+		t.Errorf("Expected the probe instead of a real call, got %d calls and %d probes", requests.Load(), probes.Load())
+	}
+
+	healthy.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	if resp := execute(); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected a passing probe to close the circuit, got %d", resp.StatusCode)
+	}
+	breaker, _ := manager.GetBreaker("petstore")
+	if state := breaker.GetState(); state != circuitbreaker.StateClosed || probes.Load() != 2 {
+		t.Errorf("Expected the breaker closed after a second probe, got %s with %d probes", state, probes.Load())
+	}
+}
+
+// flushRecorder reports the body written so far each time it is flushed
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes chan string
+}
+
+func (r *flushRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.flushes <- r.Body.String()
+}
+
+func TestEngine_StreamRoute(t *testing.T) {
+	next := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 1; i <= 3; i++ {
+			fmt.Fprintf(w, "id: %d\ndata: event %d\n\n", i, i)
+			w.(http.Flusher).Flush()
+			select {
+			case <-next:
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	route := &parser.RouteConfig{Path: "/events", Method: "GET"}
+
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.StreamRoute(context.Background(), route, map[string]interface{}{}, recorder)
+	}()
+
+	// Each event must reach the client before the upstream sends the next one
+	for i := 1; i <= 3; i++ {
+		want := fmt.Sprintf("data: event %d\n\n", i)
+		deadline := time.After(2 * time.Second)
+		for received := ""; !strings.HasSuffix(received, want); {
+			select {
+			case received = <-recorder.flushes:
+			case <-deadline:
+				t.Fatalf("Expected event %d to be flushed, got %q", i, recorder.Body.String())
+			}
+		}
+		next <- struct{}{}
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("StreamRoute() error = %v", err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected 200 text/event-stream, got %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	if strings.Count(recorder.Body.String(), "data: ") != 3 {
+		t.Errorf("Expected three events, got %q", recorder.Body.String())
+	}
+}
+
+func TestEngine_StreamRouteCancel(t *testing.T) {
+	closed := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(closed)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	route := &parser.RouteConfig{Path: "/events", Method: "GET"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.StreamRoute(ctx, route, map[string]interface{}{}, recorder)
+	}()
+
+	for received := ""; !strings.Contains(received, "first"); {
+		received = <-recorder.flushes
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected cancellation to end the stream cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected StreamRoute to return after cancellation")
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the upstream connection to be closed")
+	}
+}
+
+func TestEngine_StreamRouteIdleTimeout(t *testing.T) {
+	closed := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(closed)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetStreamIdleTimeout(100 * time.Millisecond)
+	route := &parser.RouteConfig{Path: "/events", Method: "GET"}
+
+	recorder := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), flushes: make(chan string, 16)}
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.StreamRoute(context.Background(), route, map[string]interface{}{}, recorder)
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "idle") {
+			t.Errorf("Expected an idle stream error, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected StreamRoute to give up on a silent stream")
+	}
+	if !strings.Contains(recorder.Body.String(), "first") {
+		t.Errorf("Expected the event sent before the silence, got %q", recorder.Body.String())
+	}
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the upstream connection to be closed")
+	}
+}
+
+func TestEngine_StreamRouteTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for {
+			fmt.Fprint(w, "data: tick\n\n")
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	route := &parser.RouteConfig{Path: "/events", Method: "GET", Timeout: 200 * time.Millisecond}
+
+	recorder := httptest.NewRecorder()
+	err := engine.StreamRoute(context.Background(), route, map[string]interface{}{}, recorder)
+	if err == nil || !strings.Contains(err.Error(), "route timeout") {
+		t.Errorf("Expected a route timeout error, got %v", err)
+	}
+	if !strings.Contains(recorder.Body.String(), "tick") {
+		t.Errorf("Expected the events sent before the timeout, got %q", recorder.Body.String())
+	}
+
+	// A caller that goes away ends the stream cleanly
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	if err := engine.StreamRoute(ctx, route, map[string]interface{}{}, httptest.NewRecorder()); err != nil {
+		t.Errorf("Expected no error when the caller cancels, got %v", err)
+	}
+}
+
+func TestEngine_StreamRouteBuffered(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Connection", "keep-alive")
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+
+	recorder := httptest.NewRecorder()
+	err := engine.StreamRoute(context.Background(), &parser.RouteConfig{Path: "/pets/1", Method: "GET"}, map[string]interface{}{}, recorder)
+	if err != nil {
+		t.Fatalf("StreamRoute() error = %v", err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"id":1}` {
+		t.Errorf("Expected buffered body, got %d %q", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get("Content-Length") != "" || recorder.Header().Get("Connection") != "" {
+		t.Errorf("Expected hop-by-hop headers to be dropped, got %v", recorder.Header())
+	}
+}
+
+func TestEngine_Drain(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/reports" {
+			close(started)
+			<-release
+		}
+		w.Write([]byte(`{"done":true}`))
+	}))
+	defer upstream.Close()
+
+	drainer := NewDrainer()
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetDrainer(drainer)
+
+	type result struct {
+		response *Response
+		err      error
+	}
+	slow := make(chan result, 1)
+	go func() {
+		response, err := engine.ExecuteRoute(context.Background(), &parser.RouteConfig{Path: "/reports", Method: "GET"}, map[string]interface{}{})
+		slow <- result{response, err}
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		drained <- drainer.Drain(ctx)
+	}()
+	for !drainer.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New calls are turned away while the slow one is still running
+	response, err := engine.ExecuteRoute(context.Background(), &parser.RouteConfig{Path: "/pets/1", Method: "GET"}, map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("ExecuteRoute() error = %v", err)
+	}
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while draining, got %d", response.StatusCode)
+	}
+	recorder := httptest.NewRecorder()
+	if err := engine.StreamRoute(context.Background(), &parser.RouteConfig{Path: "/pets/1", Method: "GET"}, map[string]interface{}{}, recorder); err != nil {
+		t.Fatalf("StreamRoute() error = %v", err)
+	}
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected streamed call to get 503 while draining, got %d", recorder.Code)
+	}
+	select {
+	case err := <-drained:
+		t.Fatalf("Expected Drain to wait for the in-flight call, returned %v", err)
+	default:
+	}
+	if n := drainer.InFlight(); n != 1 {
+		t.Errorf("Expected 1 call in flight, got %d", n)
+	}
+
+	close(release)
+	got := <-slow
+	if got.err != nil || got.response.StatusCode != http.StatusOK || string(got.response.Body) != `{"done":true}` {
+		t.Errorf("Expected the in-flight call to complete, got %+v", got)
+	}
+	if err := <-drained; err != nil {
+		t.Errorf("Expected Drain to finish once the call completed, got %v", err)
+	}
+}
+
+func TestDrainer_Deadline(t *testing.T) {
+	drainer := NewDrainer()
+	if !drainer.begin() {
+		t.Fatal("Expected calls to be accepted before draining")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := drainer.Drain(ctx)
+	if err == nil || !strings.Contains(err.Error(), "1 requests still in flight") {
+		t.Errorf("Expected a deadline error naming the call in flight, got %v", err)
+	}
+
+	drainer.end()
+	if err := drainer.Drain(context.Background()); err != nil {
+		t.Errorf("Expected Drain to succeed once idle, got %v", err)
+	}
+}
+
+func TestEngine_IdentityHeaders(t *testing.T) {
+	var received http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	identityHeaders, err := NewIdentityHeaders(map[string]string{"userId": "x-auth-user", "scopes": "X-Auth-Scopes"})
+	if err != nil {
+		t.Fatalf("NewIdentityHeaders() error = %v", err)
+	}
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetIdentityHeaders(identityHeaders)
+
+	// The client tries to claim another identity through a header parameter and per-call headers
+	route := &parser.RouteConfig{
+		Path:       "/pets",
+		Method:     "GET",
+		Parameters: []parser.ParameterConfig{{Name: "X-Auth-User", In: "header"}},
+	}
+	params := map[string]interface{}{"X-Auth-User": "admin"}
+	spoofed := WithRequestHeaders(context.Background(), map[string]string{"X-Auth-Scopes": "admin:all"})
+
+	tests := []struct {
+		name           string
+		authCtx        *auth.AuthContext
+		expectedUser   string
+		expectedScopes string
+	}{
+		{"authenticated", &auth.AuthContext{UserID: "alice", Scopes: []string{"read:pets", "write:pets"}, Valid: true}, "alice", "read:pets,write:pets"},
+		{"authenticated without scopes", &auth.AuthContext{UserID: "bob", Valid: true}, "bob", ""},
+		{"anonymous", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := spoofed
+			if tt.authCtx != nil {
+				ctx = auth.WithAuthContext(ctx, tt.authCtx)
+			}
+			if _, err := engine.ExecuteRoute(ctx, route, params); err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+			if got := received.Values("X-Auth-User"); len(got) > 1 || received.Get("X-Auth-User") != tt.expectedUser {
+				t.Errorf("Expected X-Auth-User %q, got %v", tt.expectedUser, got)
+			}
+			if got := received.Get("X-Auth-Scopes"); got != tt.expectedScopes {
+				t.Errorf("Expected X-Auth-Scopes %q, got %q", tt.expectedScopes, got)
+			}
+		})
+	}
+
+	if _, err := NewIdentityHeaders(map[string]string{"email": "X-Auth-Email"}); err == nil {
+		t.Errorf("Expected an unknown identity field to be rejected")
+	}
+	if _, err := NewIdentityHeaders(map[string]string{"userId": "X Auth User"}); err == nil {
+		t.Errorf("Expected an invalid header name to be rejected")
+	}
+	// The configuration loader lowercases map keys
+	if headers, err := NewIdentityHeaders(map[string]string{"userid": "X-Auth-User"}); err != nil || headers["userId"] != "X-Auth-User" {
+		t.Errorf("Expected fields to match regardless of case, got %v, %v", headers, err)
+	}
+}
+
+func TestApplyParameterDefaults(t *testing.T) {
+	route := &parser.RouteConfig{
+		Path:   "/pets",
+		Method: "GET",
+		Parameters: []parser.ParameterConfig{
+			{Name: "limit", In: "query", Type: "integer", Default: float64(20)},
+			{Name: "sort", In: "query", Type: "string", Default: "name"},
+			{Name: "owner", In: "query", Type: "string"},
+			{Name: "region", In: "query", Type: "string", Required: true, Default: "eu"},
+		},
+	}
+
+	params := map[string]interface{}{"sort": "age"}
+	ApplyParameterDefaults(route, params)
+
+	tests := []struct {
+		name     string
+		expected interface{}
+		present  bool
+	}{
+		{"limit", float64(20), true},
+		{"sort", "age", true},
+		{"owner", nil, false},
+		{"region", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, exists := params[tt.name]
+			if exists != tt.present {
+				t.Fatalf("Expected %s present = %v, got %v", tt.name, tt.present, exists)
+			}
+			if tt.present && value != tt.expected {
+				t.Errorf("Expected %s = %v, got %v", tt.name, tt.expected, value)
+			}
+		})
+	}
+}
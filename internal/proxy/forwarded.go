@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// forwardedRequestKey is the context key for the client request a call is made for
+type forwardedRequestKey struct{}
+
+// WithForwardedRequest returns a context whose upstream requests carry X-Forwarded-For,
+// X-Forwarded-Proto and X-Forwarded-Host describing incoming, the client's request
+func WithForwardedRequest(ctx context.Context, incoming *http.Request) context.Context {
+	return context.WithValue(ctx, forwardedRequestKey{}, incoming)
+}
+
+// TrustedProxies lists the networks of the proxies in front of the server, whose
+// X-Forwarded headers describe the original client
+type TrustedProxies []*net.IPNet
+
+// NewTrustedProxies parses IP addresses and CIDR ranges such as 10.0.0.0/8
+func NewTrustedProxies(entries []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: not an IP address or CIDR range", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// Contains reports whether remoteAddr, a host or host:port, belongs to a trusted proxy
+func (t TrustedProxies) Contains(remoteAddr string) bool {
+	if len(t) == 0 {
+		return false
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetTrustedProxies keeps the X-Forwarded headers of requests arriving from the given
+// proxies. Headers sent by any other client are replaced by what the connection shows.
+func (e *Engine) SetTrustedProxies(proxies TrustedProxies) {
+	e.trustedProxies = proxies
+}
+
+// addForwardedHeaders sets the X-Forwarded headers for incoming on req. When incoming
+// comes from a trusted proxy, the client's address is appended to the X-Forwarded-For
+// chain it arrived with and the protocol and host it forwarded are kept as the original
+// ones. Otherwise all three are derived from the connection.
+func addForwardedHeaders(req *http.Request, incoming *http.Request, trusted bool) {
+	clientIP, _, err := net.SplitHostPort(incoming.RemoteAddr)
+	if err != nil {
+		clientIP = incoming.RemoteAddr
+	}
+	var chain []string
+	if trusted {
+		chain = incoming.Header.Values("X-Forwarded-For")
+	}
+	if clientIP != "" {
+		chain = append(chain, clientIP)
+	}
+	if len(chain) > 0 {
+		req.Header.Set("X-Forwarded-For", strings.Join(chain, ", "))
+	}
+
+	var proto, host string
+	if trusted {
+		proto = incoming.Header.Get("X-Forwarded-Proto")
+		host = incoming.Header.Get("X-Forwarded-Host")
+	}
+	if proto == "" {
+		proto = "http"
+		if incoming.TLS != nil {
+			proto = "https"
+		}
+	}
+	req.Header.Set("X-Forwarded-Proto", proto)
+
+	if host == "" {
+		host = incoming.Host
+	}
+	if host != "" {
+		req.Header.Set("X-Forwarded-Host", host)
+	}
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddForwardedHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		headers       map[string]string
+		trusted       bool
+		tls           bool
+		expectedFor   string
+		expectedProto string
+		expectedHost  string
+	}{
+		{
+			name:          "direct client",
+			remoteAddr:    "203.0.113.7:52100",
+			expectedFor:   "203.0.113.7",
+			expectedProto: "http",
+			expectedHost:  "api.example.com",
+		},
+		{
+			name:          "appends to the chain of a trusted proxy",
+			remoteAddr:    "10.0.0.2:52100",
+			headers:       map[string]string{"X-Forwarded-For": "198.51.100.1, 10.0.0.1"},
+			trusted:       true,
+			expectedFor:   "198.51.100.1, 10.0.0.1, 10.0.0.2",
+			expectedProto: "http",
+			expectedHost:  "api.example.com",
+		},
+		{
+			name:          "tls",
+			remoteAddr:    "[2001:db8::1]:443",
+			tls:           true,
+			expectedFor:   "2001:db8::1",
+			expectedProto: "https",
+			expectedHost:  "api.example.com",
+		},
+		{
+			name:          "keeps the protocol and host seen by a trusted proxy",
+			remoteAddr:    "10.0.0.2:52100",
+			headers:       map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "public.example.com"},
+			trusted:       true,
+			expectedFor:   "10.0.0.2",
+			expectedProto: "https",
+			expectedHost:  "public.example.com",
+		},
+		{
+			name:       "ignores headers sent by an untrusted client",
+			remoteAddr: "203.0.113.7:52100",
+			headers: map[string]string{
+				"X-Forwarded-For":   "127.0.0.1",
+				"X-Forwarded-Proto": "https",
+				"X-Forwarded-Host":  "internal.example.com",
+			},
+			expectedFor:   "203.0.113.7",
+			expectedProto: "http",
+			expectedHost:  "api.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming := httptest.NewRequest(http.MethodGet, "http://api.example.com/apis/petstore/pets", nil)
+			incoming.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				incoming.Header.Set(name, value)
+			}
+			if tt.tls {
+				incoming.TLS = &tls.ConnectionState{}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "http://upstream/pets", nil)
+			addForwardedHeaders(req, incoming, tt.trusted)
+
+			if got := req.Header.Get("X-Forwarded-For"); got != tt.expectedFor {
+				t.Errorf("Expected X-Forwarded-For %q, got %q", tt.expectedFor, got)
+			}
+			if got := req.Header.Get("X-Forwarded-Proto"); got != tt.expectedProto {
+				t.Errorf("Expected X-Forwarded-Proto %q, got %q", tt.expectedProto, got)
+			}
+			if got := req.Header.Get("X-Forwarded-Host"); got != tt.expectedHost {
+				t.Errorf("Expected X-Forwarded-Host %q, got %q", tt.expectedHost, got)
+			}
+		})
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	proxies, err := NewTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10", "2001:db8::/32"})
+	if err != nil {
+		t.Fatalf("NewTrustedProxies() error = %v", err)
+	}
+
+	tests := []struct {
+		remoteAddr string
+		expected   bool
+	}{
+		{remoteAddr: "10.1.2.3:52100", expected: true},
+		{remoteAddr: "192.0.2.10:443", expected: true},
+		{remoteAddr: "192.0.2.11:443", expected: false},
+		{remoteAddr: "[2001:db8::1]:443", expected: true},
+		{remoteAddr: "203.0.113.7", expected: false},
+		{remoteAddr: "not-an-address", expected: false},
+	}
+	for _, tt := range tests {
+		if got := proxies.Contains(tt.remoteAddr); got != tt.expected {
+			t.Errorf("Contains(%q) = %v, expected %v", tt.remoteAddr, got, tt.expected)
+		}
+	}
+
+	if _, err := NewTrustedProxies([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("Expected an error for an invalid CIDR range")
+	}
+	if _, err := NewTrustedProxies([]string{"gateway"}); err == nil {
+		t.Error("Expected an error for a host name")
+	}
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"go.uber.org/zap"
+)
+
+// SetHooks runs manager's hooks around every upstream call. A nil manager disables hooks.
+func (e *Engine) SetHooks(manager *hooks.Manager) {
+	e.hooks = manager
+}
+
+// runPreRequestHooks builds the hook context for an outgoing request, runs the
+// pre-request hooks and applies their changes back onto the request
+func (e *Engine) runPreRequestHooks(ctx context.Context, req *http.Request, route *parser.RouteConfig, params map[string]interface{}) (*hooks.HookContext, error) {
+	var helper hooks.ContextHelper
+	hookCtx := helper.NewHookContext(req, e.serviceName, route.OperationID, params)
+	hookCtx.Request.Route = route.Route
+	hookCtx.Metadata[hooks.CallerHeadersKey] = e.callerHeaders(ctx)
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		hookCtx.Request.Body = body
+	}
+
+	if err := e.hooks.ExecutePreRequestHooks(ctx, hookCtx); err != nil {
+		var violations *hooks.ValidationError
+		if errors.As(err, &violations) {
+			return nil, validationError(violations)
+		}
+		return nil, fmt.Errorf("pre-request hook: %w", err)
+	}
+
+	applyRequestContext(req, hookCtx.Request)
+	return hookCtx, nil
+}
+
+// callerHeaders lists the headers set on the request from per-call credentials or
+// from the caller's identity, which responses must not be shared across
+func (e *Engine) callerHeaders(ctx context.Context) []string {
+	var names []string
+	if headers, ok := ctx.Value(requestHeadersKey{}).(map[string]string); ok {
+		for name := range headers {
+			names = append(names, name)
+		}
+	}
+	for _, header := range e.identityHeaders {
+		names = append(names, header)
+	}
+	return names
+}
+
+// cachedResponse returns the response a pre-request hook placed in the hook metadata
+// to answer the call without reaching the upstream
+func cachedResponse(hookCtx *hooks.HookContext) (*Response, bool) {
+	if hookCtx == nil {
+		return nil, false
+	}
+	cached, ok := hookCtx.Metadata[hooks.CachedResponseKey].(*hooks.ResponseContext)
+	if !ok {
+		return nil, false
+	}
+
+	headers := make(http.Header, len(cached.Headers))
+	for name, value := range cached.Headers {
+		headers.Set(name, value)
+	}
+	return &Response{StatusCode: cached.StatusCode, Headers: headers, Body: cached.Body}, true
+}
+
+// validationError reports a validation hook's violations the way ValidateRequest reports
+// invalid tool arguments, so callers handle both alike
+func validationError(err *hooks.ValidationError) *ValidationError {
+	return &ValidationError{Errors: err.Violations}
+}
+
+// runPostResponseHooks runs the post-response hooks and applies any status, header
+// or body changes to the response
+func (e *Engine) runPostResponseHooks(ctx context.Context, hookCtx *hooks.HookContext, req *http.Request, response *Response) error {
+	var helper hooks.ContextHelper
+	helper.AddResponseContext(hookCtx, response.StatusCode, response.Headers, response.Body, nil, req.URL.String())
+
+	if err := e.hooks.ExecutePostResponseHooks(ctx, hookCtx); err != nil {
+		return fmt.Errorf("post-response hook: %w", err)
+	}
+
+	response.StatusCode = hookCtx.Response.StatusCode
+	response.Body = hookCtx.Response.Body
+	if response.Headers == nil {
+		response.Headers = make(http.Header)
+	}
+	applyHeaders(response.Headers, hookCtx.Response.Headers)
+	return nil
+}
+
+// runErrorHooks reports a failed upstream call to the error hooks
+func (e *Engine) runErrorHooks(ctx context.Context, hookCtx *hooks.HookContext, req *http.Request, callErr error) {
+	var helper hooks.ContextHelper
+	helper.AddResponseContext(hookCtx, 0, nil, nil, callErr, req.URL.String())
+
+	if err := e.hooks.ExecuteErrorHooks(ctx, hookCtx); err != nil {
+		e.logger.Warn("Error hook failed",
+			zap.String("operationID", hookCtx.Request.OperationID),
+			zap.Error(err))
+	}
+}
+
+// applyRequestContext copies the method, path, query, headers and body from a hook
+// context back onto the outgoing request
+func applyRequestContext(req *http.Request, reqCtx *hooks.RequestContext) {
+	req.Method = reqCtx.Method
+	if reqCtx.Path != req.URL.Path {
+		req.URL.Path = reqCtx.Path
+		req.URL.RawPath = ""
+	}
+	req.URL.RawQuery = url.Values(reqCtx.QueryParams).Encode()
+	applyHeaders(req.Header, reqCtx.Headers)
+
+	req.Body, req.GetBody, req.ContentLength = nil, nil, 0
+	if len(reqCtx.Body) > 0 {
+		body := reqCtx.Body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+	}
+}
+
+// applyHeaders reconciles header with the single-valued view hooks work on: headers a
+// hook removed are deleted and changed values replaced, leaving untouched
+// multi-valued headers intact
+func applyHeaders(header http.Header, values map[string]string) {
+	for name := range header {
+		if _, ok := values[name]; !ok {
+			header.Del(name)
+		}
+	}
+	for name, value := range values {
+		if header.Get(name) != value {
+			header.Set(name, value)
+		}
+	}
+}
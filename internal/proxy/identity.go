@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+)
+
+// identityFields reads each auth context field that can be sent upstream
+var identityFields = map[string]func(*auth.AuthContext) string{
+	"userId":   func(authCtx *auth.AuthContext) string { return authCtx.UserID },
+	"username": func(authCtx *auth.AuthContext) string { return authCtx.Username },
+	"scopes":   func(authCtx *auth.AuthContext) string { return strings.Join(authCtx.Scopes, ",") },
+}
+
+// IdentityHeaders maps auth context fields to the upstream headers that carry them
+type IdentityHeaders map[string]string
+
+// NewIdentityHeaders checks that config maps auth context fields (userId, username or
+// scopes) to header names, e.g. userId: X-Auth-User. Fields match regardless of case,
+// as the configuration loader lowercases map keys.
+func NewIdentityHeaders(config map[string]string) (IdentityHeaders, error) {
+	headers := make(IdentityHeaders, len(config))
+	for _, key := range slices.Sorted(maps.Keys(config)) {
+		field, ok := identityField(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown identity field %q: must be userId, username or scopes", key)
+		}
+		header := strings.TrimSpace(config[key])
+		if header == "" || strings.ContainsAny(header, " \t\r\n:") {
+			return nil, fmt.Errorf("invalid header name %q for identity field %s", config[key], field)
+		}
+		headers[field] = http.CanonicalHeaderKey(header)
+	}
+	return headers, nil
+}
+
+// identityField returns the name of the identity field matching key regardless of case
+func identityField(key string) (string, bool) {
+	for field := range identityFields {
+		if strings.EqualFold(field, key) {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// SetIdentityHeaders tells the upstream who the caller is: every call made with an
+// authenticated auth context in its context carries the mapped fields in headers.
+// Values the client supplied for these headers are always dropped, so they cannot
+// be spoofed.
+func (e *Engine) SetIdentityHeaders(headers IdentityHeaders) {
+	e.identityHeaders = headers
+}
+
+// addIdentityHeaders replaces the identity headers on req with the caller's identity
+func addIdentityHeaders(ctx context.Context, req *http.Request, headers IdentityHeaders) {
+	for _, header := range headers {
+		req.Header.Del(header)
+	}
+
+	authCtx, ok := auth.GetAuthContext(ctx)
+	if !ok || authCtx == nil || !authCtx.Valid {
+		return
+	}
+	for field, header := range headers {
+		if value := identityFields[field](authCtx); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+}
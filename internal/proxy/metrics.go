@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestsTotal counts upstream calls, labelled by service and status code
+var requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "swagger_mcp_proxy_requests_total",
+	Help: "Number of requests proxied to upstream services.",
+}, []string{"service", "status"})
+
+// requestDuration observes how long upstream calls take, retries included
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "swagger_mcp_proxy_request_duration_seconds",
+	Help:    "Duration of requests proxied to upstream services, retries included.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"service", "status"})
+
+func init() {
+	prometheus.MustRegister(requestsTotal, requestDuration)
+}
+
+// observeRequest records an upstream call; a status code of 0 means it failed without a response
+func observeRequest(serviceName string, statusCode int, latency time.Duration) {
+	status := "error"
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	requestsTotal.WithLabelValues(serviceName, status).Inc()
+	requestDuration.WithLabelValues(serviceName, status).Observe(latency.Seconds())
+}
@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+// MockHeader marks responses synthesized from the spec rather than returned by the upstream
+const MockHeader = "X-Mock-Response"
+
+// maxMockDepth bounds how deep nested schemas are filled in, so recursive schemas end
+const maxMockDepth = 8
+
+// mockStatusKey is the context key for the status a mocked call answers with
+type mockStatusKey struct{}
+
+// WithMockStatus returns a context whose mocked calls answer with status instead of the
+// operation's success response, to exercise a client's error paths
+func WithMockStatus(ctx context.Context, status int) context.Context {
+	return context.WithValue(ctx, mockStatusKey{}, status)
+}
+
+// SetMock makes the engine answer every call with a response synthesized from the
+// operation's declared responses instead of calling the upstream. The body is the
+// declared example, or is built from the response schema with its required fields
+// filled in.
+func (e *Engine) SetMock(enabled bool) {
+	e.mock = enabled
+}
+
+// mockResponse synthesizes the response to a call of route
+func mockResponse(ctx context.Context, route *parser.RouteConfig) (*Response, error) {
+	status, responseRef := mockStatus(ctx, route.Responses)
+
+	headers := make(http.Header)
+	headers.Set(MockHeader, "true")
+	response := &Response{StatusCode: status, Headers: headers}
+
+	if responseRef == nil || responseRef.Value == nil || len(responseRef.Value.Content) == 0 {
+		return response, nil
+	}
+
+	body, err := json.Marshal(mockBody(responseRef.Value.Content))
+	if err != nil {
+		return nil, err
+	}
+	headers.Set("Content-Type", "application/json")
+	response.Body = body
+	return response, nil
+}
+
+// mockStatus picks the status to answer with and its declared response: the status
+// requested through the context, or else the first declared success response, falling
+// back to the default response
+func mockStatus(ctx context.Context, responses *openapi3.Responses) (int, *openapi3.ResponseRef) {
+	if status, ok := ctx.Value(mockStatusKey{}).(int); ok {
+		if responses == nil {
+			return status, nil
+		}
+		if responseRef := responses.Status(status); responseRef != nil {
+			return status, responseRef
+		}
+		return status, responses.Default()
+	}
+
+	if responses == nil {
+		return http.StatusOK, nil
+	}
+	codes := slices.Sorted(maps.Keys(responses.Map()))
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			// A range such as 2XX
+			status = http.StatusOK
+		}
+		return status, responses.Value(code)
+	}
+	return http.StatusOK, responses.Default()
+}
+
+// mockBody returns the example of the response's JSON content, or of its first content
+// type when none is JSON, or a value built from its schema
+func mockBody(content openapi3.Content) interface{} {
+	mediaType := content.Get("application/json")
+	if mediaType == nil {
+		types := slices.Sorted(maps.Keys(content))
+		for _, contentType := range types {
+			if strings.Contains(contentType, "json") {
+				mediaType = content[contentType]
+				break
+			}
+		}
+		if mediaType == nil {
+			mediaType = content[types[0]]
+		}
+	}
+	if mediaType == nil {
+		return nil
+	}
+
+	if mediaType.Example != nil {
+		return mediaType.Example
+	}
+	for _, name := range slices.Sorted(maps.Keys(mediaType.Examples)) {
+		if example := mediaType.Examples[name]; example != nil && example.Value != nil && example.Value.Value != nil {
+			return example.Value.Value
+		}
+	}
+	return mockValue(mediaType.Schema, 0)
+}
+
+// mockValue builds a value for a schema from its example, default or first enum value,
+// or else a type-appropriate placeholder. Objects get their required properties and
+// arrays their minimum number of items.
+func mockValue(schemaRef *openapi3.SchemaRef, depth int) interface{} {
+	if schemaRef == nil || schemaRef.Value == nil || depth > maxMockDepth {
+		return nil
+	}
+	schema := schemaRef.Value
+
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	case len(schema.AllOf) > 0:
+		merged := make(map[string]interface{})
+		for _, part := range schema.AllOf {
+			if object, ok := mockValue(part, depth+1).(map[string]interface{}); ok {
+				maps.Copy(merged, object)
+			}
+		}
+		return merged
+	case len(schema.OneOf) > 0:
+		return mockValue(schema.OneOf[0], depth+1)
+	case len(schema.AnyOf) > 0:
+		return mockValue(schema.AnyOf[0], depth+1)
+	}
+
+	schemaType := ""
+	for _, t := range schema.Type.Slice() {
+		if t != openapi3.TypeNull {
+			schemaType = t
+			break
+		}
+	}
+	if schemaType == "" && len(schema.Properties) > 0 {
+		schemaType = openapi3.TypeObject
+	}
+
+	switch schemaType {
+	case openapi3.TypeObject:
+		object := make(map[string]interface{}, len(schema.Required))
+		for _, name := range schema.Required {
+			object[name] = mockValue(schema.Properties[name], depth+1)
+		}
+		return object
+	case openapi3.TypeArray:
+		items := make([]interface{}, schema.MinItems)
+		for i := range items {
+			items[i] = mockValue(schema.Items, depth+1)
+		}
+		return items
+	case openapi3.TypeString:
+		return mockString(schema.Format)
+	case openapi3.TypeInteger:
+		if schema.Min != nil {
+			return int64(*schema.Min)
+		}
+		return 0
+	case openapi3.TypeNumber:
+		if schema.Min != nil {
+			return *schema.Min
+		}
+		return 0.0
+	case openapi3.TypeBoolean:
+		return false
+	}
+	return nil
+}
+
+// mockString returns a placeholder that is valid for a string format
+func mockString(format string) string {
+	switch format {
+	case "date-time":
+		return "1970-01-01T00:00:00Z"
+	case "date":
+		return "1970-01-01"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	}
+	return "string"
+}
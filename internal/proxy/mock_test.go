@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+const mockSpec = `{
+  "openapi": "3.0.0",
+  "info": {"title": "Pet Store", "version": "1.0.0"},
+  "paths": {
+    "/pets": {
+      "get": {
+        "operationId": "listPets",
+        "responses": {
+          "200": {
+            "description": "ok",
+            "content": {"application/json": {"example": [{"id": 1, "name": "Rex"}]}}
+          },
+          "404": {
+            "description": "not found",
+            "content": {"application/json": {"example": {"error": "no pets"}}}
+          },
+          "default": {
+            "description": "error",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Error"}}}
+          }
+        }
+      }
+    },
+    "/pets/{id}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {
+          "201": {
+            "description": "ok",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+          }
+        }
+      },
+      "delete": {
+        "operationId": "deletePet",
+        "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}],
+        "responses": {"204": {"description": "deleted"}}
+      }
+    }
+  },
+  "components": {
+    "schemas": {
+      "Pet": {
+        "type": "object",
+        "required": ["id", "name", "status", "tags", "owner", "bornAt"],
+        "properties": {
+          "id": {"type": "integer", "minimum": 1},
+          "name": {"type": "string"},
+          "status": {"type": "string", "enum": ["available", "sold"]},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "owner": {"$ref": "#/components/schemas/Owner"},
+          "bornAt": {"type": "string", "format": "date-time"},
+          "nickname": {"type": "string"}
+        }
+      },
+      "Owner": {
+        "type": "object",
+        "required": ["email", "verified"],
+        "properties": {
+          "email": {"type": "string", "format": "email"},
+          "verified": {"type": "boolean"},
+          "friend": {"$ref": "#/components/schemas/Owner"}
+        }
+      },
+      "Error": {
+        "type": "object",
+        "required": ["code"],
+        "properties": {"code": {"type": "integer", "example": 500}}
+      }
+    }
+  }
+}`
+
+// mockRoutes parses mockSpec into routes keyed by operation ID
+func mockRoutes(t *testing.T) map[string]*parser.RouteConfig {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(mockSpec))
+	if err != nil {
+		t.Fatalf("Failed to load spec: %v", err)
+	}
+	p := parser.New(zap.NewNop(), "")
+	if err := p.ParseSpec(spec); err != nil {
+		t.Fatalf("Failed to parse spec: %v", err)
+	}
+
+	routes := make(map[string]*parser.RouteConfig)
+	for _, route := range p.GetRoutes() {
+		routes[route.OperationID] = &route
+	}
+	return routes
+}
+
+func TestEngine_Mock(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Expected no upstream call in mock mode, got %s %s", r.Method, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	engine.SetMock(true)
+	routes := mockRoutes(t)
+
+	tests := []struct {
+		name           string
+		operationID    string
+		status         int
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"explicit example", "listPets", 0, 200, `[{"id": 1, "name": "Rex"}]`},
+		{"schema only", "getPet", 0, 201, `{
+			"id": 1, "name": "string", "status": "available", "tags": [],
+			"owner": {"email": "user@example.com", "verified": false},
+			"bornAt": "1970-01-01T00:00:00Z"
+		}`},
+		{"requested status with declared response", "listPets", 404, 404, `{"error": "no pets"}`},
+		{"requested status falls back to default response", "listPets", 503, 503, `{"code": 500}`},
+		{"no content", "deletePet", 0, 204, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.status != 0 {
+				ctx = WithMockStatus(ctx, tt.status)
+			}
+
+			response, err := engine.ExecuteRoute(ctx, routes[tt.operationID], map[string]interface{}{"id": "7"})
+			if err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+			if response.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, response.StatusCode)
+			}
+			if response.Headers.Get(MockHeader) != "true" {
+				t.Errorf("Expected %s header", MockHeader)
+			}
+
+			if tt.expectedBody == "" {
+				if len(response.Body) != 0 {
+					t.Errorf("Expected no body, got %s", response.Body)
+				}
+				return
+			}
+			if got := response.Headers.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Expected application/json, got %q", got)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(response.Body, &got); err != nil {
+				t.Fatalf("Failed to decode body %s: %v", response.Body, err)
+			}
+			json.Unmarshal([]byte(tt.expectedBody), &want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected body %v, got %v", want, got)
+			}
+		})
+	}
+}
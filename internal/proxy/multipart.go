@@ -0,0 +1,210 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+// DefaultMaxMultipartBytes bounds the combined size of the parts decoded from one response
+const DefaultMaxMultipartBytes = 10 * 1024 * 1024
+
+// Part is one body part of a multipart/mixed response
+type Part struct {
+	Headers  map[string]string `json:"headers"`
+	Body     interface{}       `json:"body"`
+	Encoding string            `json:"encoding,omitempty"` // "base64" when the body is not text
+}
+
+// maxMultipartBytesKey is the context key for the size limit of multipart/mixed bodies
+type maxMultipartBytesKey struct{}
+
+// WithMaxMultipartBytes returns a context whose calls fail once a multipart/mixed
+// response body grows past maxBytes while it is read, rather than after it has been
+// buffered. Pass the same limit to ParseMultipartMixed.
+func WithMaxMultipartBytes(ctx context.Context, maxBytes int64) context.Context {
+	return context.WithValue(ctx, maxMultipartBytesKey{}, maxBytes)
+}
+
+// multipartLimit returns the multipart/mixed body limit set on ctx, or zero for none
+func multipartLimit(ctx context.Context) int64 {
+	maxBytes, _ := ctx.Value(maxMultipartBytesKey{}).(int64)
+	return maxBytes
+}
+
+// IsMultipartMixed reports whether a response carries a multipart/mixed body
+func (r *Response) IsMultipartMixed() bool {
+	return isMultipartMixed(r.Headers)
+}
+
+// isMultipartMixed reports whether header describes a multipart/mixed body
+func isMultipartMixed(header http.Header) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/mixed"
+}
+
+// readBody reads an upstream response body. A multipart/mixed body is read up to the
+// limit set WithMaxMultipartBytes and fails once it grows past it.
+func readBody(ctx context.Context, resp *http.Response) ([]byte, error) {
+	maxBytes := multipartLimit(ctx)
+	if maxBytes <= 0 || !isMultipartMixed(resp.Header) {
+		return io.ReadAll(resp.Body)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, fmt.Errorf("multipart response exceeds %d bytes", maxBytes)
+	}
+	return body, nil
+}
+
+// ParseMultipartMixed splits a multipart/mixed response into its parts. JSON parts are
+// decoded, other text is returned as a string and binary content as base64. Parsing
+// fails once the part bodies exceed maxBytes in total.
+func ParseMultipartMixed(resp *Response, maxBytes int64) ([]Part, error) {
+	_, params, err := mime.ParseMediaType(resp.Headers.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type: %w", err)
+	}
+	boundary := params["boundary"]
+	if boundary == "" {
+		return nil, errors.New("multipart response has no boundary")
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(resp.Body), boundary)
+	parts := make([]Part, 0)
+	remaining := maxBytes
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read part %d: %w", len(parts)+1, err)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(part, remaining+1))
+		part.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read part %d: %w", len(parts)+1, err)
+		}
+		remaining -= int64(len(data))
+		if remaining < 0 {
+			return nil, fmt.Errorf("multipart response exceeds %d bytes", maxBytes)
+		}
+
+		headers := make(map[string]string, len(part.Header))
+		for name, values := range part.Header {
+			headers[name] = strings.Join(values, ", ")
+		}
+		parts = append(parts, decodePart(headers, data))
+	}
+
+	return parts, nil
+}
+
+// decodePart picks the most useful representation of a part body
+func decodePart(headers map[string]string, data []byte) Part {
+	mediaType, _, _ := mime.ParseMediaType(headers["Content-Type"])
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		var body interface{}
+		if json.Unmarshal(data, &body) == nil {
+			return Part{Headers: headers, Body: body}
+		}
+	}
+
+	if utf8.Valid(data) {
+		return Part{Headers: headers, Body: string(data)}
+	}
+	return Part{Headers: headers, Body: base64.StdEncoding.EncodeToString(data), Encoding: "base64"}
+}
+
+// buildMultipartBody encodes a body object as multipart/form-data. File fields are
+// expected as base64 strings and sent as file parts named after the field; arrays
+// become repeated parts and nested objects are sent as JSON.
+func buildMultipartBody(requestBody *parser.RequestBodyConfig, bodyData interface{}) (io.Reader, string, error) {
+	fields, ok := bodyData.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("multipart body must be an object, got %T", bodyData)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, name := range names {
+		values, isArray := fields[name].([]interface{})
+		if !isArray {
+			values = []interface{}{fields[name]}
+		}
+
+		for _, value := range values {
+			var err error
+			if requestBody.IsFileField(name) {
+				err = writeFilePart(writer, name, value)
+			} else {
+				err = writeFieldPart(writer, name, value)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finish multipart body: %w", err)
+	}
+
+	return &buf, writer.FormDataContentType(), nil
+}
+
+// writeFilePart decodes a base64 file input and writes it as a file part
+func writeFilePart(writer *multipart.Writer, name string, value interface{}) error {
+	encoded, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("file field %s must be a base64 string, got %T", name, value)
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("file field %s is not valid base64: %w", name, err)
+	}
+
+	part, err := writer.CreateFormFile(name, name)
+	if err != nil {
+		return fmt.Errorf("failed to create part %s: %w", name, err)
+	}
+	_, err = part.Write(data)
+	return err
+}
+
+// writeFieldPart writes a plain form field, encoding objects as JSON
+func writeFieldPart(writer *multipart.Writer, name string, value interface{}) error {
+	text := fmt.Sprintf("%v", value)
+	if _, isObject := value.(map[string]interface{}); isObject {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode field %s: %w", name, err)
+		}
+		text = string(data)
+	}
+	return writer.WriteField(name, text)
+}
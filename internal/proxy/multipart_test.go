@@ -0,0 +1,237 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"go.uber.org/zap"
+)
+
+const twoPartBody = "--batch\r\n" +
+	"Content-Type: application/json\r\n" +
+	"Content-ID: <1>\r\n" +
+	"\r\n" +
+	`{"id": 1, "name": "Rex"}` + "\r\n" +
+	"--batch\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"Content-ID: <2>\r\n" +
+	"\r\n" +
+	"not found\r\n" +
+	"--batch--\r\n"
+
+func multipartResponse(contentType, body string) *Response {
+	headers := make(http.Header)
+	headers.Set("Content-Type", contentType)
+	return &Response{StatusCode: http.StatusOK, Headers: headers, Body: []byte(body)}
+}
+
+func TestParseMultipartMixed(t *testing.T) {
+	resp := multipartResponse(`multipart/mixed; boundary="batch"`, twoPartBody)
+	if !resp.IsMultipartMixed() {
+		t.Fatal("Expected response to be detected as multipart/mixed")
+	}
+
+	parts, err := ParseMultipartMixed(resp, DefaultMaxMultipartBytes)
+	if err != nil {
+		t.Fatalf("ParseMultipartMixed() error = %v", err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %d", len(parts))
+	}
+
+	first, ok := parts[0].Body.(map[string]interface{})
+	if !ok || first["name"] != "Rex" {
+		t.Errorf("Expected decoded JSON part, got %v", parts[0].Body)
+	}
+	if parts[0].Headers["Content-Id"] != "<1>" {
+		t.Errorf("Expected part headers to be kept, got %v", parts[0].Headers)
+	}
+	if parts[1].Body != "not found" || parts[1].Encoding != "" {
+		t.Errorf("Expected plain text part, got %v", parts[1])
+	}
+}
+
+func TestParseMultipartMixed_Limits(t *testing.T) {
+	resp := multipartResponse("multipart/mixed; boundary=batch", twoPartBody)
+	if _, err := ParseMultipartMixed(resp, 30); err == nil || !strings.Contains(err.Error(), "exceeds 30 bytes") {
+		t.Errorf("Expected size limit error, got %v", err)
+	}
+
+	binary := "--b\r\nContent-Type: application/octet-stream\r\n\r\n\xff\xfe\r\n--b--\r\n"
+	parts, err := ParseMultipartMixed(multipartResponse("multipart/mixed; boundary=b", binary), DefaultMaxMultipartBytes)
+	if err != nil {
+		t.Fatalf("ParseMultipartMixed() error = %v", err)
+	}
+	if parts[0].Encoding != "base64" || parts[0].Body != "//4=" {
+		t.Errorf("Expected base64 encoded binary part, got %v", parts[0])
+	}
+
+	if _, err := ParseMultipartMixed(multipartResponse("multipart/mixed", twoPartBody), DefaultMaxMultipartBytes); err == nil {
+		t.Error("Expected error for missing boundary")
+	}
+	if multipartResponse("application/json", "{}").IsMultipartMixed() {
+		t.Error("Expected JSON response not to be detected as multipart/mixed")
+	}
+}
+
+func TestEngine_MultipartResponseLimit(t *testing.T) {
+	large := "--b\r\nContent-Type: text/plain\r\n\r\n" + strings.Repeat("x", 4096) + "\r\n--b--\r\n"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`"` + strings.Repeat("x", 4096) + `"`))
+			return
+		}
+		w.Header().Set("Content-Type", "multipart/mixed; boundary=b")
+		w.Write([]byte(large))
+	}))
+	defer upstream.Close()
+
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	ctx := WithMaxMultipartBytes(context.Background(), 1024)
+
+	route := &parser.RouteConfig{Path: "/batch", Method: "GET"}
+	if _, err := engine.ExecuteRoute(ctx, route, map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "exceeds 1024 bytes") {
+		t.Errorf("Expected the multipart body to be rejected while read, got %v", err)
+	}
+	if resp, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{}); err != nil || len(resp.Body) != len(large) {
+		t.Errorf("Expected the whole body without a limit, got error %v", err)
+	}
+
+	// Other bodies are not bound by the multipart limit
+	route = &parser.RouteConfig{Path: "/json", Method: "GET"}
+	if _, err := engine.ExecuteRoute(ctx, route, map[string]interface{}{}); err != nil {
+		t.Errorf("Expected a JSON body to be read in full, got %v", err)
+	}
+}
+
+// multipartRoute builds an upload route whose body has the given schema properties
+func multipartRoute(t *testing.T, properties string) *parser.RouteConfig {
+	t.Helper()
+
+	schema := openapi3.NewSchemaRef("", &openapi3.Schema{})
+	if err := json.Unmarshal([]byte(`{"type": "object", "properties": `+properties+`}`), schema.Value); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	return &parser.RouteConfig{
+		Path:        "/upload",
+		Method:      "POST",
+		RequestBody: &parser.RequestBodyConfig{ContentType: "multipart/form-data", Schema: schema},
+	}
+}
+
+func TestEngine_MultipartBody(t *testing.T) {
+	type file struct {
+		name, filename, content string
+	}
+
+	tests := []struct {
+		name           string
+		properties     string
+		body           map[string]interface{}
+		expectedFields map[string][]string
+		expectedFiles  []file
+	}{
+		{
+			name:          "single file",
+			properties:    `{"file": {"type": "string", "format": "binary"}}`,
+			body:          map[string]interface{}{"file": base64.StdEncoding.EncodeToString([]byte("\x00binary\xff"))},
+			expectedFiles: []file{{"file", "file", "\x00binary\xff"}},
+		},
+		{
+			name: "files and fields",
+			properties: `{
+				"description": {"type": "string"},
+				"size": {"type": "integer"},
+				"labels": {"type": "array", "items": {"type": "string"}},
+				"metadata": {"type": "object"},
+				"attachments": {"type": "array", "items": {"type": "string", "format": "binary"}}
+			}`,
+			body: map[string]interface{}{
+				"description": "holiday photos",
+				"size":        float64(2),
+				"labels":      []interface{}{"beach", "sun"},
+				"metadata":    map[string]interface{}{"album": "2024"},
+				"attachments": []interface{}{
+					base64.StdEncoding.EncodeToString([]byte("one")),
+					base64.StdEncoding.EncodeToString([]byte("two")),
+				},
+			},
+			expectedFields: map[string][]string{
+				"description": {"holiday photos"},
+				"size":        {"2"},
+				"labels":      {"beach", "sun"},
+				"metadata":    {`{"album":"2024"}`},
+			},
+			expectedFiles: []file{{"attachments", "attachments", "one"}, {"attachments", "attachments", "two"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var form *multipart.Form
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Errorf("Failed to parse multipart body: %v", err)
+				}
+				form = r.MultipartForm
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer upstream.Close()
+
+			engine := New(zap.NewNop(), 5*time.Second)
+			engine.SetBaseURL(upstream.URL)
+			if _, err := engine.ExecuteRoute(context.Background(), multipartRoute(t, tt.properties), map[string]interface{}{"body": tt.body}); err != nil {
+				t.Fatalf("ExecuteRoute() error = %v", err)
+			}
+			if form == nil {
+				t.Fatal("Expected a multipart body upstream")
+			}
+
+			for name, expected := range tt.expectedFields {
+				if got := strings.Join(form.Value[name], "|"); got != strings.Join(expected, "|") {
+					t.Errorf("Expected field %s = %v, got %v", name, expected, form.Value[name])
+				}
+			}
+			if len(form.Value) != len(tt.expectedFields) {
+				t.Errorf("Expected %d fields, got %v", len(tt.expectedFields), form.Value)
+			}
+
+			var files []file
+			for name, headers := range form.File {
+				for _, header := range headers {
+					f, _ := header.Open()
+					content, _ := io.ReadAll(f)
+					f.Close()
+					files = append(files, file{name, header.Filename, string(content)})
+				}
+			}
+			if fmt.Sprint(files) != fmt.Sprint(tt.expectedFiles) {
+				t.Errorf("Expected files %v, got %v", tt.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestEngine_MultipartBodyInvalidBase64(t *testing.T) {
+	engine := New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL("http://127.0.0.1:1")
+
+	route := multipartRoute(t, `{"file": {"type": "string", "format": "binary"}}`)
+	_, err := engine.ExecuteRoute(context.Background(), route, map[string]interface{}{"body": map[string]interface{}{"file": "not base64!"}})
+	if err == nil || !strings.Contains(err.Error(), "not valid base64") {
+		t.Errorf("Expected a base64 error, got %v", err)
+	}
+}
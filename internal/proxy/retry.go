@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/backoff"
+	"go.uber.org/zap"
+)
+
+// DefaultRetryableStatusCodes are the upstream statuses retried when none are configured
+var DefaultRetryableStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+// SetRetry retries idempotent requests up to maxAttempts times in total on transport
+// errors and the given statuses, waiting about delay before the first retry and twice
+// as long before each further one. A maxAttempts of one or less disables retries and
+// empty statusCodes select DefaultRetryableStatusCodes.
+func (e *Engine) SetRetry(maxAttempts int, delay time.Duration, statusCodes []int) {
+	if len(statusCodes) == 0 {
+		statusCodes = DefaultRetryableStatusCodes
+	}
+
+	e.maxAttempts = maxAttempts
+	e.retryDelay = delay
+	e.retryableStatus = make(map[int]bool, len(statusCodes))
+	for _, code := range statusCodes {
+		e.retryableStatus[code] = true
+	}
+}
+
+// MaxAttempts returns how many times a retryable request is sent at most
+func (e *Engine) MaxAttempts() int {
+	if e.maxAttempts < 1 {
+		return 1
+	}
+	return e.maxAttempts
+}
+
+// RetryableStatusCodes returns the upstream statuses that trigger a retry, in ascending order
+func (e *Engine) RetryableStatusCodes() []int {
+	codes := make([]int, 0, len(e.retryableStatus))
+	for code := range e.retryableStatus {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// doWithRetry sends req, retrying failed attempts with exponential backoff while the
+// request is idempotent, its body can be replayed and its context leaves time to wait
+func (e *Engine) doWithRetry(client *http.Client, req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req.Method) && isReplayable(req) {
+		attempts = e.MaxAttempts()
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := e.do(client, req)
+		if attempt >= attempts || !e.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := backoff.Delay(e.retryDelay, attempt)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		e.logger.Debug("Retrying upstream request",
+			zap.String("method", req.Method),
+			zap.String("url", req.URL.String()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying
+func (e *Engine) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return e.retryableStatus[resp.StatusCode]
+}
+
+// isIdempotent reports whether a method may safely be sent more than once
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// isReplayable reports whether a request's body can be sent again
+func isReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
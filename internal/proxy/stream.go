@@ -0,0 +1,179 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"go.uber.org/zap"
+)
+
+// DefaultStreamIdleTimeout is how long a streamed body may stay silent before the
+// upstream connection is closed
+const DefaultStreamIdleTimeout = 5 * time.Minute
+
+// streamChunkSize is the largest piece of a streamed body written before flushing
+const streamChunkSize = 32 * 1024
+
+// hopHeaders are connection-specific headers that must not be copied from the upstream
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Trailer":           true,
+	"Content-Length":    true,
+}
+
+// StreamRoute executes a route and writes the upstream response to w. Server-Sent
+// Events are copied as they arrive and flushed after every chunk, as are other bodies
+// of unknown length unless post-response hooks need to see them; hooks never see an
+// event stream. Any other response is buffered and handled as by ExecuteRoute.
+// Cancelling ctx, the route's own Timeout expiring, or the stream staying silent for
+// longer than the engine's stream idle timeout closes the upstream connection.
+// An error is returned when nothing could be written, or when the stream broke off,
+// went silent or outlived the route's Timeout after its headers were sent; a caller
+// that goes away ends it without one. A stream counts as in flight for the engine's
+// drainer until it ends.
+func (e *Engine) StreamRoute(ctx context.Context, route *parser.RouteConfig, params map[string]interface{}, w http.ResponseWriter) error {
+	if !e.drainer.begin() {
+		response := drainingResponse()
+		writeHeaders(w, response)
+		_, err := w.Write(response.Body)
+		return err
+	}
+	defer e.drainer.end()
+
+	ctx, cancel := withRouteTimeout(ctx, route)
+	defer cancel()
+
+	reqURL, err := e.buildURL(route, params)
+	if err != nil {
+		return fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	req, err := e.createRequest(ctx, route, reqURL, params)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var hookCtx *hooks.HookContext
+	if e.hooks != nil {
+		hookCtx, err = e.runPreRequestHooks(ctx, req, route, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	e.logger.Debug("Executing streaming proxy request",
+		zap.String("method", req.Method),
+		zap.String("url", req.URL.String()),
+		zap.String("operationID", route.OperationID))
+
+	response, err := e.roundTrip(ctx, route, req, true)
+	if err != nil {
+		if hookCtx != nil {
+			e.runErrorHooks(ctx, hookCtx, req, err)
+		}
+		return err
+	}
+
+	if response.stream == nil {
+		if hookCtx != nil {
+			if err := e.runPostResponseHooks(ctx, hookCtx, req, response); err != nil {
+				return err
+			}
+		}
+		writeHeaders(w, response)
+		_, err := w.Write(response.Body)
+		return err
+	}
+	defer response.stream.Close()
+
+	var idleTimer *time.Timer
+	var idled atomic.Bool
+	if e.streamIdleTimeout > 0 {
+		idleTimer = time.AfterFunc(e.streamIdleTimeout, func() {
+			idled.Store(true)
+			cancel()
+		})
+		defer idleTimer.Stop()
+	}
+
+	writeHeaders(w, response)
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, readErr := response.stream.Read(buf)
+		if n > 0 {
+			if idleTimer != nil {
+				idleTimer.Reset(e.streamIdleTimeout)
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to write stream: %w", err)
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if readErr != nil {
+			if idled.Load() {
+				return fmt.Errorf("stream idle for longer than %s", e.streamIdleTimeout)
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return fmt.Errorf("stream cut off by the route timeout of %s", route.Timeout)
+			}
+			if readErr == io.EOF || ctx.Err() != nil {
+				e.logger.Debug("Streaming proxy request completed",
+					zap.String("operationID", route.OperationID),
+					zap.Int("statusCode", response.StatusCode))
+				return nil
+			}
+			return fmt.Errorf("failed to read stream: %w", readErr)
+		}
+	}
+}
+
+// SetStreamIdleTimeout closes streamed responses that send nothing for longer than
+// timeout. Zero or less lets them stay silent until the caller goes away.
+func (e *Engine) SetStreamIdleTimeout(timeout time.Duration) {
+	e.streamIdleTimeout = timeout
+}
+
+// writeHeaders copies the response headers, except hop-by-hop ones, and writes the status
+func writeHeaders(w http.ResponseWriter, response *Response) {
+	for key, values := range response.Headers {
+		if hopHeaders[http.CanonicalHeaderKey(key)] {
+			continue
+		}
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(response.StatusCode)
+}
+
+// isStreaming reports whether an upstream response should be copied as it arrives:
+// an event stream, or, when unknownLength is set, a body whose length is not known up front
+func isStreaming(resp *http.Response, unknownLength bool) bool {
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil && mediaType == "text/event-stream" {
+		return true
+	}
+	return unknownLength && resp.ContentLength < 0 && resp.Request.Method != http.MethodHead
+}
+
+// hasPostResponseHooks reports whether hooks are registered to rewrite responses
+func (e *Engine) hasPostResponseHooks() bool {
+	return e.hooks != nil && len(e.hooks.GetRegisteredHooks()[hooks.HookTypePostResponse]) > 0
+}
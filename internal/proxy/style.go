@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+// addQueryValues serializes a query argument into query following its parameter's
+// style and explode settings. Arrays are sent as repeated values, or joined with the
+// style's delimiter when explode is false. Objects are sent as one value per property
+// with explode, as name=key,value,... without, or as name[key]=value with deepObject.
+// Undeclared arguments use the form style with explode.
+func addQueryValues(query url.Values, name string, param *parser.ParameterConfig, value interface{}) {
+	style, explode := "form", true
+	if param != nil && param.Style != "" {
+		style, explode = param.Style, param.Explode
+	}
+
+	if properties, ok := objectProperties(value); ok {
+		switch {
+		case style == "deepObject":
+			addDeepObject(query, name, value)
+		case explode:
+			for _, property := range properties {
+				query.Add(property[0], property[1])
+			}
+		default:
+			query.Add(name, strings.Join(flatten(properties), ","))
+		}
+		return
+	}
+
+	values := arrayValues(value)
+	if values == nil {
+		query.Add(name, formatValue(value))
+		return
+	}
+	if explode {
+		for _, v := range values {
+			query.Add(name, v)
+		}
+		return
+	}
+
+	delimiter := ","
+	switch style {
+	case "spaceDelimited":
+		delimiter = " "
+	case "pipeDelimited":
+		delimiter = "|"
+	}
+	query.Add(name, strings.Join(values, delimiter))
+}
+
+// addDeepObject adds the properties of an object as name[key]=value, nesting brackets
+// for nested objects
+func addDeepObject(query url.Values, name string, value interface{}) {
+	v := reflect.ValueOf(value)
+	for _, key := range sortedKeys(v) {
+		property := v.MapIndex(key).Interface()
+		propertyName := name + "[" + fmt.Sprintf("%v", key.Interface()) + "]"
+		if _, ok := objectProperties(property); ok {
+			addDeepObject(query, propertyName, property)
+			continue
+		}
+		if values := arrayValues(property); values != nil {
+			for _, item := range values {
+				query.Add(propertyName, item)
+			}
+			continue
+		}
+		query.Add(propertyName, formatValue(property))
+	}
+}
+
+// pathValue serializes a path argument following its parameter's style and explode
+// settings: simple (the default) as 3,4,5, label as .3.4.5 and matrix as ;id=3,4,5.
+// Each value is percent-encoded so it stays within its path segment.
+func pathValue(name string, param *parser.ParameterConfig, value interface{}) string {
+	style, explode := "simple", false
+	if param != nil && param.Style != "" {
+		style, explode = param.Style, param.Explode
+	}
+
+	// Properties with explode are key=value pairs, without they alternate key,value
+	var parts []string
+	properties, isObject := objectProperties(value)
+	items := arrayValues(value)
+	switch {
+	case isObject:
+		for _, property := range properties {
+			key, val := url.PathEscape(property[0]), url.PathEscape(property[1])
+			if explode {
+				parts = append(parts, key+"="+val)
+			} else {
+				parts = append(parts, key, val)
+			}
+		}
+	case items != nil:
+		for _, v := range items {
+			parts = append(parts, url.PathEscape(v))
+		}
+	default:
+		parts = []string{url.PathEscape(formatValue(value))}
+	}
+
+	switch style {
+	case "label":
+		if explode {
+			return "." + strings.Join(parts, ".")
+		}
+		return "." + strings.Join(parts, ",")
+	case "matrix":
+		switch {
+		case isObject && explode:
+			return ";" + strings.Join(parts, ";")
+		case explode:
+			prefix := ";" + url.PathEscape(name) + "="
+			return prefix + strings.Join(parts, prefix)
+		default:
+			return ";" + url.PathEscape(name) + "=" + strings.Join(parts, ",")
+		}
+	default:
+		return strings.Join(parts, ",")
+	}
+}
+
+// objectProperties returns the key and formatted value of each property of a map,
+// sorted by key, and whether value is a map
+func objectProperties(value interface{}) ([][2]string, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map {
+		return nil, false
+	}
+	properties := make([][2]string, 0, v.Len())
+	for _, key := range sortedKeys(v) {
+		properties = append(properties, [2]string{fmt.Sprintf("%v", key.Interface()), formatValue(v.MapIndex(key).Interface())})
+	}
+	return properties, true
+}
+
+// arrayValues returns the formatted elements of a slice or array, or nil for other values
+func arrayValues(value interface{}) []string {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil
+	}
+	values := make([]string, v.Len())
+	for i := range values {
+		values[i] = formatValue(v.Index(i).Interface())
+	}
+	return values
+}
+
+// sortedKeys returns a map's keys in a stable order
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprintf("%v", keys[i].Interface()) < fmt.Sprintf("%v", keys[j].Interface())
+	})
+	return keys
+}
+
+// flatten lists properties as alternating keys and values
+func flatten(properties [][2]string) []string {
+	flat := make([]string, 0, 2*len(properties))
+	for _, property := range properties {
+		flat = append(flat, property[0], property[1])
+	}
+	return flat
+}
+
+// formatValue formats a scalar argument
+func formatValue(value interface{}) string {
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans started around upstream calls
+const tracerName = "github.com/zeroLR/swagger-mcp-go/internal/proxy"
+
+// startUpstreamSpan starts a client span for an upstream call as a child of the span in
+// the request's context, and injects the trace context into the request headers. The
+// URL is recorded without its query, which may carry credentials.
+func startUpstreamSpan(req *http.Request) (*http.Request, trace.Span) {
+	spanURL := *req.URL
+	spanURL.RawQuery = ""
+	spanURL.Fragment = ""
+	spanURL.User = nil
+
+	ctx, span := otel.Tracer(tracerName).Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(spanURL.String()),
+		))
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req, span
+}
+
+// endUpstreamSpan records the outcome of an upstream call and ends its span; a status
+// code of 0 means the call failed without a response
+func endUpstreamSpan(span trace.Span, statusCode int, err error) {
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	default:
+		span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+	}
+	span.End()
+}
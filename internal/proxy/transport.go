@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// NewTLSConfig builds the TLS settings for upstream connections: certificate checks
+// turned off with insecureSkipVerify, or the certificates in the PEM bundle caFile
+// trusted alongside the system roots. Without either it returns nil, which keeps the
+// default verification.
+func NewTLSConfig(insecureSkipVerify bool, caFile string) (*tls.Config, error) {
+	if !insecureSkipVerify && caFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// SetTransport tunes the connections to the upstream: how many idle connections are
+// kept per host and for how long, and the TLS settings from NewTLSConfig. Zero values
+// and a nil tlsConfig keep the defaults.
+func (e *Engine) SetTransport(maxIdleConnsPerHost int, idleConnTimeout time.Duration, tlsConfig *tls.Config) {
+	for _, transport := range e.transports() {
+		if maxIdleConnsPerHost > 0 {
+			transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		}
+		if idleConnTimeout > 0 {
+			transport.IdleConnTimeout = idleConnTimeout
+		}
+		if tlsConfig != nil {
+			transport.TLSClientConfig = tlsConfig.Clone()
+		}
+	}
+}
+
+// transports returns the transports of the engine's clients
+func (e *Engine) transports() []*http.Transport {
+	return []*http.Transport{e.transport, e.routeTransport, e.streamTransport}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+func TestEngine_TransportTLS(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer upstream.Close()
+
+	// The test server's self-signed certificate, as a CA bundle
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw})
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write CA file: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		insecureSkipVerify bool
+		caFile             string
+		wantErr            bool
+	}{
+		{"default verification rejects self-signed", false, "", true},
+		{"insecure skip verify", true, "", false},
+		{"custom CA", false, caFile, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := NewTLSConfig(tt.insecureSkipVerify, tt.caFile)
+			if err != nil {
+				t.Fatalf("NewTLSConfig() error = %v", err)
+			}
+
+			engine := New(zap.NewNop(), 5*time.Second)
+			engine.SetBaseURL(upstream.URL)
+			engine.SetTransport(0, 0, tlsConfig)
+
+			route := &parser.RouteConfig{Path: "/pets", Method: "GET", OperationID: "listPets"}
+			_, err = engine.ExecuteRoute(context.Background(), route, map[string]interface{}{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteRoute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEngine_SetTransport(t *testing.T) {
+	engine := New(zap.NewNop(), 5*time.Second)
+	defaultIdleTimeout := engine.transport.IdleConnTimeout
+
+	engine.SetTransport(32, 0, nil)
+	for _, transport := range []*http.Transport{engine.transport, engine.routeTransport} {
+		if transport.MaxIdleConnsPerHost != 32 {
+			t.Errorf("Expected 32 idle connections per host, got %d", transport.MaxIdleConnsPerHost)
+		}
+		if transport.IdleConnTimeout != defaultIdleTimeout {
+			t.Errorf("Expected a zero timeout to keep %v, got %v", defaultIdleTimeout, transport.IdleConnTimeout)
+		}
+		if tlsConfig := transport.TLSClientConfig; tlsConfig != nil && (tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs != nil) {
+			t.Errorf("Expected the default TLS verification to be kept")
+		}
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	if tlsConfig, err := NewTLSConfig(false, ""); err != nil || tlsConfig != nil {
+		t.Errorf("Expected no TLS settings by default, got %v, %v", tlsConfig, err)
+	}
+	if _, err := NewTLSConfig(false, filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Errorf("Expected an error for a missing CA file")
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0o600)
+	if _, err := NewTLSConfig(false, notPEM); err == nil {
+		t.Errorf("Expected an error for a CA file without certificates")
+	}
+}
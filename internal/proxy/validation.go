@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/hooks"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+// FieldError locates a single validation failure in a tool call
+type FieldError = hooks.Violation
+
+// ValidationError reports every parameter and body field that failed validation
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return (&hooks.ValidationError{Violations: e.Errors}).Error()
+}
+
+// ValidateRequest checks tool arguments against a route's parameters and request body
+// schema, returning a *ValidationError that locates each problem
+func ValidateRequest(route *parser.RouteConfig, params map[string]interface{}) error {
+	var fieldErrors []FieldError
+
+	for _, param := range route.Parameters {
+		value, exists := params[param.Name]
+		if !exists || value == nil {
+			if param.Required {
+				fieldErrors = append(fieldErrors, FieldError{
+					In:      param.In,
+					Name:    param.Name,
+					Message: "required parameter is missing",
+				})
+			}
+			continue
+		}
+
+		if message := checkParameterValue(param, value); message != "" {
+			fieldErrors = append(fieldErrors, FieldError{
+				In:      param.In,
+				Name:    param.Name,
+				Message: message,
+			})
+		}
+	}
+
+	if route.RequestBody != nil {
+		body, exists := params[route.BodyArgument()]
+		if !exists || body == nil {
+			if route.RequestBody.Required {
+				fieldErrors = append(fieldErrors, FieldError{
+					In:      "body",
+					Pointer: "/",
+					Message: "required request body is missing",
+				})
+			}
+		} else if route.RequestBody.Schema != nil && route.RequestBody.Schema.Value != nil {
+			fieldErrors = append(fieldErrors, bodyErrors(route.RequestBody.Schema.Value, body)...)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// checkParameterValue returns a message when value does not fit the parameter's type or enum
+func checkParameterValue(param parser.ParameterConfig, value interface{}) string {
+	switch param.Type {
+	case "integer":
+		switch v := value.(type) {
+		case float64:
+			if v != float64(int64(v)) {
+				return "expected integer"
+			}
+		case int, int64:
+		case string:
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				return "expected integer"
+			}
+		default:
+			return "expected integer"
+		}
+	case "number":
+		switch v := value.(type) {
+		case float64, int, int64:
+		case string:
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return "expected number"
+			}
+		default:
+			return "expected number"
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return "expected boolean"
+			}
+		default:
+			return "expected boolean"
+		}
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			return "expected array"
+		}
+	}
+
+	if len(param.Enum) > 0 {
+		actual := fmt.Sprintf("%v", value)
+		for _, allowed := range param.Enum {
+			if fmt.Sprintf("%v", allowed) == actual {
+				return ""
+			}
+		}
+		return fmt.Sprintf("value must be one of %v", param.Enum)
+	}
+
+	return ""
+}
+
+// bodyErrors validates the body against its schema and converts failures to JSON pointers
+func bodyErrors(schema *openapi3.Schema, body interface{}) []FieldError {
+	err := schema.VisitJSON(body, openapi3.MultiErrors(), openapi3.VisitAsRequest())
+	if err == nil {
+		return nil
+	}
+	if fieldErrors := hooks.BodyViolations(err); len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return []FieldError{{In: "body", Pointer: "/", Message: err.Error()}}
+}
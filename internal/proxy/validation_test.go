@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+)
+
+func validationRoute(t *testing.T) *parser.RouteConfig {
+	t.Helper()
+
+	schema := &openapi3.SchemaRef{}
+	err := schema.UnmarshalJSON([]byte(`{
+  "type": "object",
+  "required": ["pet"],
+  "properties": {
+    "pet": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": {"type": "string"},
+        "tags": {"type": "array", "items": {"type": "string"}}
+      }
+    }
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+
+	return &parser.RouteConfig{
+		Path:   "/stores/{storeId}/pets",
+		Method: "POST",
+		Parameters: []parser.ParameterConfig{
+			{Name: "storeId", In: "path", Required: true, Type: "string"},
+			{Name: "limit", In: "query", Type: "integer"},
+			{Name: "mode", In: "query", Type: "string", Enum: []interface{}{"fast", "safe"}},
+			{Name: "X-Request-Id", In: "header", Required: true, Type: "string"},
+		},
+		RequestBody: &parser.RequestBodyConfig{
+			Required:    true,
+			ContentType: "application/json",
+			Schema:      schema,
+		},
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	validBody := map[string]interface{}{
+		"pet": map[string]interface{}{"name": "Rex"},
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected []string
+	}{
+		{
+			name: "valid request",
+			params: map[string]interface{}{
+				"storeId": "1", "limit": float64(10), "X-Request-Id": "abc", "body": validBody,
+			},
+		},
+		{
+			name: "integer query parameter given a word",
+			params: map[string]interface{}{
+				"storeId": "1", "limit": "ten", "X-Request-Id": "abc", "body": validBody,
+			},
+			expected: []string{"in: query, name: limit: expected integer"},
+		},
+		{
+			name: "enum query parameter",
+			params: map[string]interface{}{
+				"storeId": "1", "mode": "slow", "X-Request-Id": "abc", "body": validBody,
+			},
+			expected: []string{"in: query, name: mode: value must be one of [fast safe]"},
+		},
+		{
+			name:   "missing path and header parameters",
+			params: map[string]interface{}{"body": validBody},
+			expected: []string{
+				"in: path, name: storeId: required parameter is missing",
+				"in: header, name: X-Request-Id: required parameter is missing",
+			},
+		},
+		{
+			name: "missing nested body field",
+			params: map[string]interface{}{
+				"storeId": "1", "X-Request-Id": "abc",
+				"body": map[string]interface{}{"pet": map[string]interface{}{}},
+			},
+			expected: []string{"in: body, pointer: /pet/name:"},
+		},
+		{
+			name: "wrong type in body array",
+			params: map[string]interface{}{
+				"storeId": "1", "X-Request-Id": "abc",
+				"body": map[string]interface{}{
+					"pet": map[string]interface{}{"name": "Rex", "tags": []interface{}{"a", float64(2)}},
+				},
+			},
+			expected: []string{"in: body, pointer: /pet/tags/1:"},
+		},
+		{
+			name:     "missing body",
+			params:   map[string]interface{}{"storeId": "1", "X-Request-Id": "abc"},
+			expected: []string{"in: body, pointer: /: required request body is missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRequest(validationRoute(t), tt.params)
+			if len(tt.expected) == 0 {
+				if err != nil {
+					t.Errorf("ValidateRequest() unexpected error = %v", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected *ValidationError, got %v", err)
+			}
+			if len(validationErr.Errors) != len(tt.expected) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.expected), validationErr.Errors)
+			}
+			for i, expected := range tt.expected {
+				if got := validationErr.Errors[i].String(); !strings.HasPrefix(got, expected) {
+					t.Errorf("Expected error starting with %q, got %q", expected, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"sync"
+)
+
+// ConcurrencyLimiter bounds how many requests per key may be in flight at once. Unlike
+// the rate limiters it counts requests that have not finished yet, so it protects
+// against a slow upstream piling up open connections even at a modest request rate.
+type ConcurrencyLimiter struct {
+	maxInFlight int
+	semaphores  map[string]chan struct{}
+	mutex       sync.Mutex
+}
+
+// NewConcurrencyLimiter creates a limiter allowing maxInFlight concurrent requests per
+// key; values below one allow a single request
+func NewConcurrencyLimiter(maxInFlight int) *ConcurrencyLimiter {
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	return &ConcurrencyLimiter{
+		maxInFlight: maxInFlight,
+		semaphores:  make(map[string]chan struct{}),
+	}
+}
+
+// Acquire takes a slot for key without waiting. When ok is true the caller must call
+// release once the request has finished; calling it more than once is harmless.
+func (l *ConcurrencyLimiter) Acquire(key string) (release func(), ok bool) {
+	semaphore := l.semaphore(key)
+
+	select {
+	case semaphore <- struct{}{}:
+		var once sync.Once
+		return func() {
+			once.Do(func() { <-semaphore })
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// InFlight returns how many slots of a key are taken
+func (l *ConcurrencyLimiter) InFlight(key string) int {
+	return len(l.semaphore(key))
+}
+
+// MaxInFlight returns the number of concurrent requests allowed per key
+func (l *ConcurrencyLimiter) MaxInFlight() int {
+	return l.maxInFlight
+}
+
+// semaphore returns the buffered channel holding a key's slots
+func (l *ConcurrencyLimiter) semaphore(key string) chan struct{} {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	semaphore, exists := l.semaphores[key]
+	if !exists {
+		semaphore = make(chan struct{}, l.maxInFlight)
+		l.semaphores[key] = semaphore
+	}
+	return semaphore
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+	key := "test-key"
+
+	// Hold both slots
+	releases := make([]func(), 0, 2)
+	for i := 0; i < 2; i++ {
+		release, ok := limiter.Acquire(key)
+		if !ok {
+			t.Fatalf("Acquire %d should succeed", i+1)
+		}
+		releases = append(releases, release)
+	}
+	if inFlight := limiter.InFlight(key); inFlight != 2 {
+		t.Errorf("Expected 2 in flight, got %d", inFlight)
+	}
+
+	// Third request should be rejected at the limit
+	if _, ok := limiter.Acquire(key); ok {
+		t.Errorf("Acquire should fail when all slots are held")
+	}
+
+	// Other keys have their own slots
+	if release, ok := limiter.Acquire("other-key"); !ok {
+		t.Errorf("Acquire for another key should succeed")
+	} else {
+		release()
+	}
+
+	// Releasing twice frees only one slot
+	releases[0]()
+	releases[0]()
+	if inFlight := limiter.InFlight(key); inFlight != 1 {
+		t.Errorf("Expected 1 in flight after release, got %d", inFlight)
+	}
+	if _, ok := limiter.Acquire(key); !ok {
+		t.Errorf("Acquire should succeed after a slot is released")
+	}
+}
+
+func TestMiddlewareConcurrencyLimit(t *testing.T) {
+	logger := zap.NewNop()
+	manager := NewManager(logger, true)
+	defer manager.Stop()
+
+	manager.SetConcurrencyLimiter("*", NewConcurrencyLimiter(5))
+	manager.SetConcurrencyLimiter("test-service", NewConcurrencyLimiter(1))
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	handler := manager.Middleware("test-service")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-unblock
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Hold the service's only slot with a slow request
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	}()
+	<-started
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/test", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 at the concurrency limit, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("Retry-After header should be set")
+	}
+
+	// Services without their own limiter use the default cap
+	other := httptest.NewRecorder()
+	manager.Middleware("other-service")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(other, httptest.NewRequest("GET", "/test", nil))
+	if other.Code != http.StatusOK {
+		t.Errorf("Another service should not share the slot")
+	}
+
+	close(unblock)
+	<-done
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/test", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("Expected status 200 once the slot is released, got %d", recorder.Code)
+	}
+}
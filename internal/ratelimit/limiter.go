@@ -3,11 +3,15 @@ package ratelimit
 import (
 	"context"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
 	"go.uber.org/zap"
 )
 
@@ -17,6 +21,8 @@ type Limiter interface {
 	Allow(key string) (allowed bool, retryAfter time.Duration)
 	// Reset resets the rate limit for a key
 	Reset(key string)
+	// Remaining returns how many requests a key may still make right now
+	Remaining(key string) int
 	// Config returns the current configuration
 	Config() Config
 }
@@ -117,6 +123,23 @@ func (l *TokenBucketLimiter) Allow(key string) (bool, time.Duration) {
 	return false, retryAfter
 }
 
+// Remaining returns the whole tokens left in a key's bucket
+func (l *TokenBucketLimiter) Remaining(key string) int {
+	l.mutex.RLock()
+	b, exists := l.buckets[key]
+	l.mutex.RUnlock()
+	if !exists {
+		return l.config.BurstSize
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	elapsed := time.Since(b.lastRefill)
+	tokensToAdd := elapsed.Seconds() * (float64(l.config.RequestsPerMinute) / l.config.WindowSize.Seconds())
+	return int(min(float64(l.config.BurstSize), b.tokens+tokensToAdd))
+}
+
 // Reset resets the rate limit for a key
 func (l *TokenBucketLimiter) Reset(key string) {
 	l.mutex.Lock()
@@ -252,6 +275,31 @@ func (l *SlidingWindowLimiter) Allow(key string) (bool, time.Duration) {
 	return false, l.config.WindowSize
 }
 
+// Remaining returns how many more requests fit in a key's current window
+func (l *SlidingWindowLimiter) Remaining(key string) int {
+	l.mutex.RLock()
+	w, exists := l.windows[key]
+	l.mutex.RUnlock()
+	if !exists {
+		return l.config.RequestsPerMinute
+	}
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	windowStart := time.Now().Add(-l.config.WindowSize)
+	remaining := l.config.RequestsPerMinute
+	for _, reqTime := range w.requests {
+		if reqTime.After(windowStart) {
+			remaining--
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining
+}
+
 // Reset resets the rate limit for a key
 func (l *SlidingWindowLimiter) Reset(key string) {
 	l.mutex.Lock()
@@ -299,20 +347,341 @@ func (l *SlidingWindowLimiter) cleanup() {
 	}
 }
 
+// FixedWindowLimiter allows RequestsPerMinute requests per key in each window, with
+// windows aligned to multiples of WindowSize. It is the cheapest algorithm and its counts
+// line up with per-period quotas, but a client can make up to twice the limit in quick
+// succession by spending one window's allowance at its end and the next at its start.
+type FixedWindowLimiter struct {
+	config        Config
+	counters      map[string]*counter
+	mutex         sync.RWMutex
+	logger        *zap.Logger
+	cleanupTicker *time.Ticker
+	stopCleanup   chan struct{}
+}
+
+// counter holds the requests made by a key in its current fixed window
+type counter struct {
+	windowStart time.Time
+	count       int
+	mutex       sync.Mutex
+}
+
+// NewFixedWindowLimiter creates a new fixed window rate limiter
+func NewFixedWindowLimiter(config Config, logger *zap.Logger) *FixedWindowLimiter {
+	limiter := &FixedWindowLimiter{
+		config:      config,
+		counters:    make(map[string]*counter),
+		logger:      logger,
+		stopCleanup: make(chan struct{}),
+	}
+
+	// Set defaults
+	if limiter.config.RequestsPerMinute <= 0 {
+		limiter.config.RequestsPerMinute = 100
+	}
+	if limiter.config.WindowSize <= 0 {
+		limiter.config.WindowSize = time.Minute
+	}
+	if limiter.config.KeyGenerator == nil {
+		limiter.config.KeyGenerator = DefaultKeyGenerator
+	}
+
+	// Start cleanup goroutine
+	limiter.cleanupTicker = time.NewTicker(5 * time.Minute)
+	go limiter.cleanup()
+
+	return limiter
+}
+
+// Allow checks if a request is allowed
+func (l *FixedWindowLimiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	c, exists := l.counters[key]
+	if !exists {
+		c = &counter{}
+		l.counters[key] = c
+	}
+	l.mutex.Unlock()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	l.advance(c, now)
+
+	if c.count < l.config.RequestsPerMinute {
+		c.count++
+		return true, 0
+	}
+
+	// The counter starts over at the next window boundary
+	return false, c.windowStart.Add(l.config.WindowSize).Sub(now)
+}
+
+// advance starts a new window for a counter once its current one has ended
+func (l *FixedWindowLimiter) advance(c *counter, now time.Time) {
+	windowStart := now.Truncate(l.config.WindowSize)
+	if !c.windowStart.Equal(windowStart) {
+		c.windowStart = windowStart
+		c.count = 0
+	}
+}
+
+// Remaining returns how many more requests a key may make in the current window
+func (l *FixedWindowLimiter) Remaining(key string) int {
+	l.mutex.RLock()
+	c, exists := l.counters[key]
+	l.mutex.RUnlock()
+	if !exists {
+		return l.config.RequestsPerMinute
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	l.advance(c, time.Now())
+	return l.config.RequestsPerMinute - c.count
+}
+
+// Reset resets the rate limit for a key
+func (l *FixedWindowLimiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.counters, key)
+}
+
+// Config returns the current configuration
+func (l *FixedWindowLimiter) Config() Config {
+	return l.config
+}
+
+// Stop stops the cleanup goroutine
+func (l *FixedWindowLimiter) Stop() {
+	select {
+	case <-l.stopCleanup:
+		// Already stopped
+		return
+	default:
+		close(l.stopCleanup)
+	}
+	if l.cleanupTicker != nil {
+		l.cleanupTicker.Stop()
+	}
+}
+
+// cleanup removes counters whose window ended long ago
+func (l *FixedWindowLimiter) cleanup() {
+	for {
+		select {
+		case <-l.cleanupTicker.C:
+			l.mutex.Lock()
+			now := time.Now()
+			for key, c := range l.counters {
+				c.mutex.Lock()
+				if now.Sub(c.windowStart.Add(l.config.WindowSize)) > 10*time.Minute {
+					delete(l.counters, key)
+				}
+				c.mutex.Unlock()
+			}
+			l.mutex.Unlock()
+		case <-l.stopCleanup:
+			return
+		}
+	}
+}
+
+// LeakyBucketLimiter queues requests in a bucket of depth BurstSize that drains at a
+// constant RequestsPerMinute per WindowSize. Unlike the token bucket, which lets a full
+// bucket's worth through at once after a quiet spell, it is meant for smoothing: a
+// request is rejected whenever the queue is full, however long the key was idle before.
+// Keep BurstSize small to enforce a steady rate; larger values tolerate short bursts.
+type LeakyBucketLimiter struct {
+	config        Config
+	buckets       map[string]*leakyBucket
+	mutex         sync.RWMutex
+	logger        *zap.Logger
+	cleanupTicker *time.Ticker
+	stopCleanup   chan struct{}
+}
+
+// leakyBucket holds how many queued requests a key has left to drain
+type leakyBucket struct {
+	level    float64
+	lastLeak time.Time
+	mutex    sync.Mutex
+}
+
+// NewLeakyBucketLimiter creates a new leaky bucket rate limiter
+func NewLeakyBucketLimiter(config Config, logger *zap.Logger) *LeakyBucketLimiter {
+	limiter := &LeakyBucketLimiter{
+		config:      config,
+		buckets:     make(map[string]*leakyBucket),
+		logger:      logger,
+		stopCleanup: make(chan struct{}),
+	}
+
+	// Set defaults
+	if limiter.config.RequestsPerMinute <= 0 {
+		limiter.config.RequestsPerMinute = 100
+	}
+	if limiter.config.BurstSize <= 0 {
+		limiter.config.BurstSize = 1
+	}
+	if limiter.config.WindowSize <= 0 {
+		limiter.config.WindowSize = time.Minute
+	}
+	if limiter.config.KeyGenerator == nil {
+		limiter.config.KeyGenerator = DefaultKeyGenerator
+	}
+
+	// Start cleanup goroutine
+	limiter.cleanupTicker = time.NewTicker(5 * time.Minute)
+	go limiter.cleanup()
+
+	return limiter
+}
+
+// Allow checks if a request is allowed
+func (l *LeakyBucketLimiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &leakyBucket{lastLeak: time.Now()}
+		l.buckets[key] = b
+	}
+	l.mutex.Unlock()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	l.leak(b, time.Now())
+
+	if b.level+1.0 <= float64(l.config.BurstSize) {
+		b.level += 1.0
+		return true, 0
+	}
+
+	// Wait until enough has drained to make room for one more request
+	overflow := b.level + 1.0 - float64(l.config.BurstSize)
+	secondsToWait := overflow / l.drainRate()
+	return false, time.Duration(secondsToWait * float64(time.Second))
+}
+
+// leak drains a bucket for the time passed since it last drained
+func (l *LeakyBucketLimiter) leak(b *leakyBucket, now time.Time) {
+	drained := now.Sub(b.lastLeak).Seconds() * l.drainRate()
+	b.level = max(0, b.level-drained)
+	b.lastLeak = now
+}
+
+// drainRate returns how many queued requests drain per second
+func (l *LeakyBucketLimiter) drainRate() float64 {
+	return float64(l.config.RequestsPerMinute) / l.config.WindowSize.Seconds()
+}
+
+// Remaining returns how many more requests fit in a key's bucket right now
+func (l *LeakyBucketLimiter) Remaining(key string) int {
+	l.mutex.RLock()
+	b, exists := l.buckets[key]
+	l.mutex.RUnlock()
+	if !exists {
+		return l.config.BurstSize
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	l.leak(b, time.Now())
+	return int(float64(l.config.BurstSize) - b.level)
+}
+
+// Reset resets the rate limit for a key
+func (l *LeakyBucketLimiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.buckets, key)
+}
+
+// Config returns the current configuration
+func (l *LeakyBucketLimiter) Config() Config {
+	return l.config
+}
+
+// Stop stops the cleanup goroutine
+func (l *LeakyBucketLimiter) Stop() {
+	select {
+	case <-l.stopCleanup:
+		// Already stopped
+		return
+	default:
+		close(l.stopCleanup)
+	}
+	if l.cleanupTicker != nil {
+		l.cleanupTicker.Stop()
+	}
+}
+
+// cleanup removes old buckets
+func (l *LeakyBucketLimiter) cleanup() {
+	for {
+		select {
+		case <-l.cleanupTicker.C:
+			l.mutex.Lock()
+			now := time.Now()
+			for key, b := range l.buckets {
+				b.mutex.Lock()
+				if now.Sub(b.lastLeak) > 10*time.Minute {
+					delete(l.buckets, key)
+				}
+				b.mutex.Unlock()
+			}
+			l.mutex.Unlock()
+		case <-l.stopCleanup:
+			return
+		}
+	}
+}
+
 // Manager manages rate limiting across services
 type Manager struct {
-	limiters map[string]Limiter
-	logger   *zap.Logger
-	enabled  bool
-	mutex    sync.RWMutex
+	limiters          map[string]Limiter
+	operationLimiters map[operationKey]Limiter
+	concurrency       map[string]*ConcurrencyLimiter
+	logger            *zap.Logger
+	enabled           bool
+	mutex             sync.RWMutex
+}
+
+// operationKey identifies one operation of a service
+type operationKey struct {
+	serviceName string
+	operationID string
+}
+
+// operationIDKey is the context key for the operation a request was routed to
+type operationIDKey struct{}
+
+// WithOperationID returns a context recording the operation a request was routed to,
+// so operation limiters apply to it
+func WithOperationID(ctx context.Context, operationID string) context.Context {
+	return context.WithValue(ctx, operationIDKey{}, operationID)
+}
+
+// OperationIDFromContext returns the operation recorded by WithOperationID, if any
+func OperationIDFromContext(ctx context.Context) string {
+	operationID, _ := ctx.Value(operationIDKey{}).(string)
+	return operationID
 }
 
 // NewManager creates a new rate limiting manager
 func NewManager(logger *zap.Logger, enabled bool) *Manager {
 	return &Manager{
-		limiters: make(map[string]Limiter),
-		logger:   logger,
-		enabled:  enabled,
+		limiters:          make(map[string]Limiter),
+		operationLimiters: make(map[operationKey]Limiter),
+		concurrency:       make(map[string]*ConcurrencyLimiter),
+		logger:            logger,
+		enabled:           enabled,
 	}
 }
 
@@ -326,34 +695,95 @@ func (m *Manager) SetServiceLimiter(serviceName string, limiter Limiter) {
 		zap.Int("requestsPerMinute", limiter.Config().RequestsPerMinute))
 }
 
+// SetOperationLimiter sets a rate limiter for one operation of a service. It takes
+// precedence over the service and global limiters for requests routed to that operation.
+func (m *Manager) SetOperationLimiter(serviceName, operationID string, limiter Limiter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.operationLimiters[operationKey{serviceName, operationID}] = limiter
+	m.logger.Info("Set rate limiter for operation",
+		zap.String("service", serviceName),
+		zap.String("operationID", operationID),
+		zap.Int("requestsPerMinute", limiter.Config().RequestsPerMinute))
+}
+
 // SetGlobalLimiter sets a global rate limiter for all services
 func (m *Manager) SetGlobalLimiter(limiter Limiter) {
 	m.SetServiceLimiter("*", limiter)
 }
 
-// IsAllowed checks if a request is allowed for a service
-func (m *Manager) IsAllowed(serviceName string, req *http.Request) (bool, time.Duration) {
+// SetConcurrencyLimiter bounds the in-flight requests of a service; "*" sets the default
+// for services without their own. Slots are counted per service, so the default gives
+// each service its own cap rather than one shared by all.
+func (m *Manager) SetConcurrencyLimiter(serviceName string, limiter *ConcurrencyLimiter) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.concurrency[serviceName] = limiter
+	m.logger.Info("Set concurrency limiter for service",
+		zap.String("service", serviceName),
+		zap.Int("maxInFlight", limiter.MaxInFlight()))
+}
+
+// Acquire takes an in-flight slot for a service. It always succeeds, with a no-op release,
+// when no concurrency limiter applies; otherwise release must be called once the request is done.
+func (m *Manager) Acquire(serviceName string) (release func(), ok bool) {
+	limiter := m.concurrencyLimiterFor(serviceName)
+	if limiter == nil {
+		return func() {}, true
+	}
+	return limiter.Acquire(serviceName)
+}
+
+// concurrencyLimiterFor returns the concurrency limiter that applies to a service, or nil
+func (m *Manager) concurrencyLimiterFor(serviceName string) *ConcurrencyLimiter {
 	if !m.enabled {
-		return true, 0
+		return nil
 	}
 
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
-	// Try service-specific limiter first
-	limiter, exists := m.limiters[serviceName]
-	if !exists {
-		// Fall back to global limiter
-		limiter, exists = m.limiters["*"]
-		if !exists {
-			return true, 0
-		}
+	if limiter, exists := m.concurrency[serviceName]; exists {
+		return limiter
+	}
+	return m.concurrency["*"]
+}
+
+// IsAllowed checks if a request is allowed for a service, using the limiter of the
+// operation recorded in the request context when there is one
+func (m *Manager) IsAllowed(serviceName string, req *http.Request) (bool, time.Duration) {
+	limiter := m.limiterFor(serviceName, OperationIDFromContext(req.Context()))
+	if limiter == nil {
+		return true, 0
 	}
 
 	key := limiter.Config().KeyGenerator(req)
 	return limiter.Allow(key)
 }
 
+// limiterFor returns the most specific limiter that applies to an operation of a service,
+// or nil when requests are not limited
+func (m *Manager) limiterFor(serviceName, operationID string) Limiter {
+	if !m.enabled {
+		return nil
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if operationID != "" {
+		if limiter, exists := m.operationLimiters[operationKey{serviceName, operationID}]; exists {
+			return limiter
+		}
+	}
+	// Then the service-specific limiter
+	if limiter, exists := m.limiters[serviceName]; exists {
+		return limiter
+	}
+	// Fall back to global limiter
+	return m.limiters["*"]
+}
+
 // ResetKey resets rate limiting for a specific key across all services
 func (m *Manager) ResetKey(key string) {
 	m.mutex.RLock()
@@ -370,57 +800,124 @@ func (m *Manager) GetStats() map[string]interface{} {
 	defer m.mutex.RUnlock()
 
 	stats := map[string]interface{}{
-		"enabled":         m.enabled,
-		"serviceLimiters": len(m.limiters),
-		"limiters":        make(map[string]interface{}),
+		"enabled":           m.enabled,
+		"serviceLimiters":   len(m.limiters),
+		"operationLimiters": len(m.operationLimiters),
+		"limiters":          make(map[string]interface{}),
 	}
 
 	for serviceName, limiter := range m.limiters {
-		config := limiter.Config()
-		stats["limiters"].(map[string]interface{})[serviceName] = map[string]interface{}{
-			"requestsPerMinute": config.RequestsPerMinute,
-			"burstSize":         config.BurstSize,
-			"windowSize":        config.WindowSize.String(),
+		stats["limiters"].(map[string]interface{})[serviceName] = limiterStats(limiter)
+	}
+	for key, limiter := range m.operationLimiters {
+		stats["limiters"].(map[string]interface{})[key.serviceName+"/"+key.operationID] = limiterStats(limiter)
+	}
+
+	concurrency := make(map[string]interface{}, len(m.concurrency))
+	for serviceName, limiter := range m.concurrency {
+		concurrency[serviceName] = map[string]interface{}{
+			"maxInFlight": limiter.MaxInFlight(),
 		}
 	}
+	stats["concurrency"] = concurrency
 
 	return stats
 }
 
+// limiterStats describes a limiter's configuration
+func limiterStats(limiter Limiter) map[string]interface{} {
+	config := limiter.Config()
+	return map[string]interface{}{
+		"requestsPerMinute": config.RequestsPerMinute,
+		"burstSize":         config.BurstSize,
+		"windowSize":        config.WindowSize.String(),
+	}
+}
+
 // Stop stops all rate limiters
 func (m *Manager) Stop() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	for _, limiter := range m.limiters {
-		if tbl, ok := limiter.(*TokenBucketLimiter); ok {
-			tbl.Stop()
-		}
-		if swl, ok := limiter.(*SlidingWindowLimiter); ok {
-			swl.Stop()
-		}
+		stopLimiter(limiter)
+	}
+	for _, limiter := range m.operationLimiters {
+		stopLimiter(limiter)
+	}
+}
+
+// stopLimiter stops the cleanup goroutine of the built-in limiters
+func stopLimiter(limiter Limiter) {
+	if tbl, ok := limiter.(*TokenBucketLimiter); ok {
+		tbl.Stop()
+	}
+	if swl, ok := limiter.(*SlidingWindowLimiter); ok {
+		swl.Stop()
+	}
+	if fwl, ok := limiter.(*FixedWindowLimiter); ok {
+		fwl.Stop()
+	}
+	if lbl, ok := limiter.(*LeakyBucketLimiter); ok {
+		lbl.Stop()
 	}
 }
 
-// Middleware creates an HTTP middleware for rate limiting
+// Middleware creates an HTTP middleware for rate limiting. Requests within the rate limit
+// must then get an in-flight slot; when the service's concurrency cap is reached they are
+// rejected with 503.
 func (m *Manager) Middleware(serviceName string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			allowed, retryAfter := m.IsAllowed(serviceName, r)
-			if !allowed {
-				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
-				w.Header().Set("X-RateLimit-Limit", "")
-				w.Header().Set("X-RateLimit-Remaining", "0")
-				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(retryAfter).Unix(), 10))
-				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			if !m.checkRate(w, r, serviceName) {
 				return
 			}
 
+			release, ok := m.Acquire(serviceName)
+			if !ok {
+				rejectedTotal.WithLabelValues(serviceName, "concurrency").Inc()
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Too many concurrent requests", http.StatusServiceUnavailable)
+				return
+			}
+			defer release()
+
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// checkRate applies the rate limiter for a request and sets the X-RateLimit headers,
+// writing a 429 response and returning false when the request is rejected
+func (m *Manager) checkRate(w http.ResponseWriter, r *http.Request, serviceName string) bool {
+	limiter := m.limiterFor(serviceName, OperationIDFromContext(r.Context()))
+	if limiter == nil {
+		return true
+	}
+
+	config := limiter.Config()
+	key := config.KeyGenerator(r)
+	allowed, retryAfter := limiter.Allow(key)
+
+	now := time.Now()
+	reset := now.Truncate(config.WindowSize).Add(config.WindowSize)
+	if !allowed {
+		reset = now.Add(retryAfter)
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(config.RequestsPerMinute))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(limiter.Remaining(key)))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+	if !allowed {
+		rejectedTotal.WithLabelValues(serviceName, "rate").Inc()
+		// Round up so clients never retry before the limiter allows them
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
 // Default key generators
 
 // DefaultKeyGenerator generates keys based on client IP
@@ -428,7 +925,8 @@ func DefaultKeyGenerator(req *http.Request) string {
 	return getClientIP(req)
 }
 
-// UserBasedKeyGenerator generates keys based on authenticated user
+// UserBasedKeyGenerator generates keys based on the user authenticated by the auth
+// middleware, which must run first, and on the client IP for anonymous requests
 func UserBasedKeyGenerator(req *http.Request) string {
 	// Try to get user from context first (set by auth middleware)
 	if userID := getUserFromContext(req.Context()); userID != "" {
@@ -445,35 +943,51 @@ func ServiceBasedKeyGenerator(serviceName string) KeyGenerator {
 	}
 }
 
-// getClientIP extracts the client IP from the request
+// getClientIP extracts the client IP from the request: the first address in
+// X-Forwarded-For, which each proxy appends to, then X-Real-IP, then the connection's
+// remote address without its port. Values that are not an IP address are skipped.
 func getClientIP(req *http.Request) string {
-	// Check X-Forwarded-For header first
-	xff := req.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if comma := req.Header.Get("X-Forwarded-For"); comma != "" {
-			return comma
+	// X-Forwarded-For lists the client followed by the proxies in between
+	if xff := req.Header.Get("X-Forwarded-For"); xff != "" {
+		client, _, _ := strings.Cut(xff, ",")
+		if ip := parseIP(client); ip != "" {
+			return ip
 		}
 	}
 
 	// Check X-Real-IP header
-	if xri := req.Header.Get("X-Real-IP"); xri != "" {
-		return xri
+	if ip := parseIP(req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
 	}
 
 	// Fall back to RemoteAddr
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if ip := parseIP(host); ip != "" {
+		return ip
+	}
 	return req.RemoteAddr
 }
 
-// getUserFromContext extracts user ID from request context
+// parseIP returns value in canonical form when it is an IP address, or ""
+func parseIP(value string) string {
+	ip := net.ParseIP(strings.Trim(strings.TrimSpace(value), "[]"))
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// getUserFromContext extracts the authenticated user ID from the request context, or ""
+// when the request was not authenticated
 func getUserFromContext(ctx context.Context) string {
-	// This would typically extract from auth context
-	// For now, return empty string
-	if authCtx := ctx.Value("authContext"); authCtx != nil {
-		// This would extract user ID from auth context
-		// Implementation depends on auth system
+	authCtx, ok := auth.GetAuthContext(ctx)
+	if !ok || authCtx == nil || !authCtx.Valid {
+		return ""
 	}
-	return ""
+	return authCtx.UserID
 }
 
 // Helper function for min
@@ -3,9 +3,12 @@ package ratelimit
 import (
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -48,6 +51,93 @@ func TestTokenBucketLimiter(t *testing.T) {
 	}
 }
 
+func TestFixedWindowLimiter(t *testing.T) {
+	config := Config{
+		RequestsPerMinute: 3,
+		WindowSize:        time.Second, // 3 requests per second for testing
+		KeyGenerator:      DefaultKeyGenerator,
+	}
+
+	logger := zap.NewNop()
+	limiter := NewFixedWindowLimiter(config, logger)
+	defer limiter.Stop()
+
+	key := "test-key"
+
+	// Start at a window boundary so all requests land in the same window
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+
+	// Should allow 3 requests
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow(key)
+		if !allowed {
+			t.Errorf("Request %d should be allowed", i+1)
+		}
+	}
+
+	// 4th request should be rejected until the window ends
+	allowed, retryAfter := limiter.Allow(key)
+	if allowed {
+		t.Errorf("4th request should be rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("Retry after should be within the window, got %v", retryAfter)
+	}
+	if remaining := limiter.Remaining(key); remaining != 0 {
+		t.Errorf("Expected 0 remaining, got %d", remaining)
+	}
+
+	// Test counter reset at the next window
+	time.Sleep(retryAfter + 10*time.Millisecond)
+	allowed, _ = limiter.Allow(key)
+	if !allowed {
+		t.Errorf("Request should be allowed in the next window")
+	}
+}
+
+func TestLeakyBucketLimiter(t *testing.T) {
+	config := Config{
+		RequestsPerMinute: 60, // drains 1 request per second
+		BurstSize:         3,
+		WindowSize:        time.Minute,
+		KeyGenerator:      DefaultKeyGenerator,
+	}
+
+	logger := zap.NewNop()
+	limiter := NewLeakyBucketLimiter(config, logger)
+	defer limiter.Stop()
+
+	key := "test-key"
+
+	// Should allow requests until the queue is full
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow(key)
+		if !allowed {
+			t.Errorf("Request %d should be allowed (queue depth)", i+1)
+		}
+	}
+
+	// Next request should be rejected
+	allowed, retryAfter := limiter.Allow(key)
+	if allowed {
+		t.Errorf("Request should be rejected when the queue is full")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("Retry after should be at most one drain interval, got %v", retryAfter)
+	}
+
+	// Test draining at the constant rate
+	time.Sleep(retryAfter + 10*time.Millisecond)
+	allowed, _ = limiter.Allow(key)
+	if !allowed {
+		t.Errorf("Request should be allowed after the bucket drained")
+	}
+	allowed, _ = limiter.Allow(key)
+	if allowed {
+		t.Errorf("Only one request should fit after one drain interval")
+	}
+}
+
 func TestSlidingWindowLimiter(t *testing.T) {
 	config := Config{
 		RequestsPerMinute: 3,
@@ -201,14 +291,132 @@ func TestManagerMiddleware(t *testing.T) {
 	}
 }
 
+func TestManagerOperationLimiter(t *testing.T) {
+	logger := zap.NewNop()
+	manager := NewManager(logger, true)
+	defer manager.Stop()
+
+	manager.SetServiceLimiter("test-service", NewTokenBucketLimiter(Config{
+		RequestsPerMinute: 100,
+		BurstSize:         100,
+		WindowSize:        time.Minute,
+		KeyGenerator:      DefaultKeyGenerator,
+	}, logger))
+	manager.SetOperationLimiter("test-service", "search", NewTokenBucketLimiter(Config{
+		RequestsPerMinute: 1,
+		BurstSize:         1,
+		WindowSize:        time.Minute,
+		KeyGenerator:      DefaultKeyGenerator,
+	}, logger))
+
+	newRequest := func(operationID string) *http.Request {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.1:8080"
+		if operationID != "" {
+			req = req.WithContext(WithOperationID(req.Context(), operationID))
+		}
+		return req
+	}
+
+	// The operation limiter overrides the more generous service limiter
+	if allowed, _ := manager.IsAllowed("test-service", newRequest("search")); !allowed {
+		t.Errorf("First search request should be allowed")
+	}
+	if allowed, _ := manager.IsAllowed("test-service", newRequest("search")); allowed {
+		t.Errorf("Second search request should be rejected by the operation limiter")
+	}
+
+	// Other operations, and requests without one, fall back to the service limiter
+	for _, operationID := range []string{"listItems", ""} {
+		if allowed, _ := manager.IsAllowed("test-service", newRequest(operationID)); !allowed {
+			t.Errorf("Request for operation %q should use the service limiter", operationID)
+		}
+	}
+
+	// Operation limiters are scoped to their service
+	if allowed, _ := manager.IsAllowed("other-service", newRequest("search")); !allowed {
+		t.Errorf("Request to another service should not be limited")
+	}
+}
+
+func TestMiddlewareRateLimitHeaders(t *testing.T) {
+	logger := zap.NewNop()
+	manager := NewManager(logger, true)
+	defer manager.Stop()
+
+	config := Config{
+		RequestsPerMinute: 3,
+		WindowSize:        time.Minute,
+		KeyGenerator:      DefaultKeyGenerator,
+	}
+	limiter := NewSlidingWindowLimiter(config, logger)
+	defer limiter.Stop()
+
+	manager.SetServiceLimiter("test-service", limiter)
+
+	handler := manager.Middleware("test-service")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	expected := []struct {
+		status    int
+		remaining string
+	}{
+		{http.StatusOK, "2"},
+		{http.StatusOK, "1"},
+		{http.StatusOK, "0"},
+		{http.StatusTooManyRequests, "0"},
+	}
+
+	for i, want := range expected {
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.RemoteAddr = "192.168.1.1:8080"
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != want.status {
+			t.Errorf("Request %d: expected status %d, got %d", i+1, want.status, recorder.Code)
+		}
+		if got := recorder.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("Request %d: expected X-RateLimit-Limit 3, got %q", i+1, got)
+		}
+		if got := recorder.Header().Get("X-RateLimit-Remaining"); got != want.remaining {
+			t.Errorf("Request %d: expected X-RateLimit-Remaining %s, got %q", i+1, want.remaining, got)
+		}
+
+		reset, err := strconv.ParseInt(recorder.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			t.Fatalf("Request %d: X-RateLimit-Reset should be a Unix time: %v", i+1, err)
+		}
+		if now := time.Now().Unix(); reset < now || reset > now+60 {
+			t.Errorf("Request %d: expected reset within the window, got %d (now %d)", i+1, reset, now)
+		}
+	}
+}
+
+func TestLimiterRemaining(t *testing.T) {
+	logger := zap.NewNop()
+	limiter := NewTokenBucketLimiter(Config{RequestsPerMinute: 60, BurstSize: 5, WindowSize: time.Minute}, logger)
+	defer limiter.Stop()
+
+	if got := limiter.Remaining("test-key"); got != 5 {
+		t.Errorf("Expected 5 remaining for an unseen key, got %d", got)
+	}
+	limiter.Allow("test-key")
+	limiter.Allow("test-key")
+	if got := limiter.Remaining("test-key"); got != 3 {
+		t.Errorf("Expected 3 remaining after two requests, got %d", got)
+	}
+}
+
 func TestKeyGenerators(t *testing.T) {
 	// Test DefaultKeyGenerator
 	req := httptest.NewRequest("GET", "/test", nil)
 	req.RemoteAddr = "192.168.1.1:8080"
 
 	key := DefaultKeyGenerator(req)
-	if key != "192.168.1.1:8080" {
-		t.Errorf("Expected key to be RemoteAddr, got %s", key)
+	if key != "192.168.1.1" {
+		t.Errorf("Expected key to be the RemoteAddr IP, got %s", key)
 	}
 
 	// Test with X-Forwarded-For header
@@ -227,6 +435,71 @@ func TestKeyGenerators(t *testing.T) {
 	}
 }
 
+func TestGetClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		forwarded  string
+		realIP     string
+		remoteAddr string
+		expected   string
+	}{
+		{"multi-hop X-Forwarded-For", " 203.0.113.7 , 10.0.0.1, 10.0.0.2", "198.51.100.1", "10.0.0.3:443", "203.0.113.7"},
+		{"single X-Forwarded-For", "203.0.113.7", "", "10.0.0.3:443", "203.0.113.7"},
+		{"IPv6 X-Forwarded-For", "2001:db8::1, 10.0.0.1", "", "10.0.0.3:443", "2001:db8::1"},
+		{"X-Real-IP", "", "198.51.100.1", "10.0.0.3:443", "198.51.100.1"},
+		{"invalid X-Forwarded-For falls back to X-Real-IP", "unknown, 10.0.0.1", "198.51.100.1", "10.0.0.3:443", "198.51.100.1"},
+		{"bare RemoteAddr", "", "", "192.168.1.1:8080", "192.168.1.1"},
+		{"IPv6 RemoteAddr", "", "", "[2001:db8::2]:8080", "2001:db8::2"},
+		{"RemoteAddr without port", "", "", "192.168.1.1", "192.168.1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if ip := getClientIP(req); ip != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestUserBasedKeyGenerator(t *testing.T) {
+	logger := zap.NewNop()
+	basic := auth.NewBasicAuthProvider(logger)
+	basic.Configure(map[string]interface{}{"users": map[string]interface{}{"alice": "secret"}})
+	authManager := auth.NewManager(logger)
+	authManager.RegisterProvider(models.AuthTypeBasic, basic)
+
+	// The auth middleware runs first and puts the user in the context the key is built from
+	var key string
+	handler := authManager.Middleware(&models.AuthPolicy{Type: models.AuthTypeBasic, Required: true})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key = UserBasedKeyGenerator(r)
+		}))
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.1:8080"
+	req.SetBasicAuth("alice", "secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if key != "user:alice" {
+		t.Errorf("Expected key user:alice, got %s", key)
+	}
+
+	// Requests nobody authenticated fall back to the client IP
+	req = httptest.NewRequest("GET", "/test", nil)
+	req.RemoteAddr = "192.168.1.1:8080"
+	if key := UserBasedKeyGenerator(req); key != "ip:192.168.1.1" {
+		t.Errorf("Expected key ip:192.168.1.1, got %s", key)
+	}
+}
+
 func TestManagerStats(t *testing.T) {
 	logger := zap.NewNop()
 	manager := NewManager(logger, true)
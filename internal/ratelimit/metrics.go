@@ -0,0 +1,14 @@
+package ratelimit
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// rejectedTotal counts requests turned away by the middleware, labelled by service and
+// by reason: "rate" for the rate limit, "concurrency" for the in-flight cap
+var rejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "swagger_mcp_ratelimit_rejected_total",
+	Help: "Number of requests rejected by rate or concurrency limits.",
+}, []string{"service", "reason"})
+
+func init() {
+	prometheus.MustRegister(rejectedTotal)
+}
@@ -0,0 +1,138 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Mask replaces every redacted value
+const Mask = "***"
+
+// DefaultHeaders are always redacted, in addition to the configured headers
+var DefaultHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+
+// Redactor masks sensitive headers and JSON body fields before requests are logged.
+// Rules apply to every service, and services can add rules of their own.
+type Redactor struct {
+	global   rules
+	services map[string]rules // lowercased service name -> global plus service rules
+}
+
+// rules lists the canonical header names and body field paths to mask
+type rules struct {
+	headers    map[string]bool
+	bodyFields [][]string
+}
+
+// New creates a redactor masking DefaultHeaders and headers, and the JSON body fields
+// at the given dot-separated paths, e.g. password or card.number. A path crosses
+// arrays, so items.token masks the token of every item.
+func New(headers, bodyFields []string) *Redactor {
+	r := &Redactor{
+		global:   rules{headers: make(map[string]bool)},
+		services: make(map[string]rules),
+	}
+	r.global.add(DefaultHeaders, nil)
+	r.global.add(headers, bodyFields)
+	return r
+}
+
+// SetServiceRules masks headers and bodyFields for serviceName on top of the rules
+// shared by every service. Service names match regardless of case, as the
+// configuration loader lowercases map keys.
+func (r *Redactor) SetServiceRules(serviceName string, headers, bodyFields []string) {
+	key := strings.ToLower(serviceName)
+	service, exists := r.services[key]
+	if !exists {
+		service = rules{headers: make(map[string]bool)}
+		for header := range r.global.headers {
+			service.headers[header] = true
+		}
+		service.bodyFields = append(service.bodyFields, r.global.bodyFields...)
+	}
+	service.add(headers, bodyFields)
+	r.services[key] = service
+}
+
+// Headers returns a copy of headers with the values of redacted headers masked
+func (r *Redactor) Headers(serviceName string, headers map[string]string) map[string]string {
+	rules := r.rulesFor(serviceName)
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if rules.headers[http.CanonicalHeaderKey(name)] {
+			value = Mask
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// HTTPHeaders returns header as single values, joining repeated ones, with the values
+// of redacted headers masked
+func (r *Redactor) HTTPHeaders(serviceName string, header http.Header) map[string]string {
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return r.Headers(serviceName, flat)
+}
+
+// Body decodes a JSON body with the redacted fields masked. It reports false for
+// bodies that are not JSON, which should not be logged.
+func (r *Redactor) Body(serviceName string, body []byte) (interface{}, bool) {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, false
+	}
+	for _, path := range r.rulesFor(serviceName).bodyFields {
+		maskPath(document, path)
+	}
+	return document, true
+}
+
+// rulesFor returns the rules applying to serviceName
+func (r *Redactor) rulesFor(serviceName string) rules {
+	if service, exists := r.services[strings.ToLower(serviceName)]; exists {
+		return service
+	}
+	return r.global
+}
+
+// add masks headers and the fields at bodyFields
+func (rs *rules) add(headers, bodyFields []string) {
+	for _, header := range headers {
+		if header = strings.TrimSpace(header); header != "" {
+			rs.headers[http.CanonicalHeaderKey(header)] = true
+		}
+	}
+	for _, field := range bodyFields {
+		if field = strings.TrimSpace(field); field != "" {
+			rs.bodyFields = append(rs.bodyFields, strings.Split(field, "."))
+		}
+	}
+}
+
+// maskPath masks the value at path within value, descending into every element of
+// the arrays met on the way
+func maskPath(value interface{}, path []string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		child, exists := v[path[0]]
+		if !exists {
+			return
+		}
+		if len(path) == 1 {
+			v[path[0]] = Mask
+			return
+		}
+		maskPath(child, path[1:])
+	case []interface{}:
+		for _, item := range v {
+			maskPath(item, path)
+		}
+	}
+}
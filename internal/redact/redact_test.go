@@ -0,0 +1,99 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestRedactor_Headers(t *testing.T) {
+	redactor := New([]string{"x-api-key"}, nil)
+	redactor.SetServiceRules("Billing", []string{"X-Card-Token"}, nil)
+
+	headers := map[string]string{
+		"authorization": "Bearer secret",
+		"Cookie":        "session=abc",
+		"X-Api-Key":     "key",
+		"X-Card-Token":  "tok",
+		"Accept":        "application/json",
+	}
+
+	tests := []struct {
+		name        string
+		serviceName string
+		expected    map[string]string
+	}{
+		{
+			name: "default and configured headers",
+			expected: map[string]string{
+				"authorization": Mask, "Cookie": Mask, "X-Api-Key": Mask, "X-Card-Token": "tok", "Accept": "application/json",
+			},
+		},
+		{
+			name:        "service rules add to the shared ones",
+			serviceName: "billing",
+			expected: map[string]string{
+				"authorization": Mask, "Cookie": Mask, "X-Api-Key": Mask, "X-Card-Token": Mask, "Accept": "application/json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := redactor.Headers(tt.serviceName, headers)
+			for name, expected := range tt.expected {
+				if redacted[name] != expected {
+					t.Errorf("Expected %s to be %q, got %q", name, expected, redacted[name])
+				}
+			}
+		})
+	}
+	if headers["authorization"] != "Bearer secret" {
+		t.Errorf("Expected the original headers to be left unchanged")
+	}
+
+	response := redactor.HTTPHeaders("", http.Header{"Set-Cookie": {"a=1", "b=2"}, "Vary": {"Accept", "Origin"}})
+	if response["Set-Cookie"] != Mask || response["Vary"] != "Accept, Origin" {
+		t.Errorf("Expected Set-Cookie to be masked and other values joined, got %v", response)
+	}
+}
+
+func TestRedactor_Body(t *testing.T) {
+	redactor := New(nil, []string{"password", "items.token", "card.number"})
+	redactor.SetServiceRules("billing", nil, []string{"card.cvc"})
+
+	body := `{"user": "ann", "password": "hunter2", "items": [{"token": "t1", "id": 1}, {"id": 2}], "card": {"number": "4111", "cvc": "123"}}`
+
+	tests := []struct {
+		name        string
+		serviceName string
+		expected    string
+	}{
+		{
+			name:     "shared paths",
+			expected: `{"card":{"cvc":"123","number":"***"},"items":[{"id":1,"token":"***"},{"id":2}],"password":"***","user":"ann"}`,
+		},
+		{
+			name:        "service paths",
+			serviceName: "billing",
+			expected:    `{"card":{"cvc":"***","number":"***"},"items":[{"id":1,"token":"***"},{"id":2}],"password":"***","user":"ann"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, ok := redactor.Body(tt.serviceName, []byte(body))
+			if !ok {
+				t.Fatalf("Expected a JSON body to be decoded")
+			}
+			data, _ := json.Marshal(document)
+			if string(data) != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+
+	if _, ok := redactor.Body("", []byte("password=hunter2")); ok {
+		t.Errorf("Expected a non-JSON body not to be decoded")
+	}
+}
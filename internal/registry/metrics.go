@@ -0,0 +1,104 @@
+package registry
+
+import (
+	"sort"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+)
+
+// serviceMetrics accumulates the upstream calls made for one service
+type serviceMetrics struct {
+	requests       int64
+	errors         int64
+	averageLatency time.Duration
+	lastRequest    time.Time
+}
+
+// RecordRequest records one upstream call for a service. Status codes of 400 and
+// above count as errors, as does 0, which marks a call that got no response.
+func (r *Registry) RecordRequest(serviceName string, statusCode int, latency time.Duration) {
+	r.metricsMutex.Lock()
+	defer r.metricsMutex.Unlock()
+
+	metrics, exists := r.metrics[serviceName]
+	if !exists {
+		metrics = &serviceMetrics{}
+		r.metrics[serviceName] = metrics
+	}
+
+	metrics.requests++
+	if statusCode == 0 || statusCode >= 400 {
+		metrics.errors++
+	}
+	// Running mean, so no total has to be kept that could overflow
+	metrics.averageLatency += (latency - metrics.averageLatency) / time.Duration(metrics.requests)
+	metrics.lastRequest = time.Now()
+}
+
+// ServiceStats returns the request metrics and spec details of a registered service
+func (r *Registry) ServiceStats(serviceName string) (models.ServiceStats, bool) {
+	r.mutex.RLock()
+	spec, exists := r.specs[serviceName]
+	r.mutex.RUnlock()
+	if !exists {
+		return models.ServiceStats{}, false
+	}
+	return r.serviceStats(spec), true
+}
+
+// AllServiceStats returns the stats of every registered service, ordered by name
+func (r *Registry) AllServiceStats() []models.ServiceStats {
+	specs := r.List()
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].ServiceName < specs[j].ServiceName
+	})
+
+	stats := make([]models.ServiceStats, 0, len(specs))
+	for _, spec := range specs {
+		stats = append(stats, r.serviceStats(spec))
+	}
+	return stats
+}
+
+// serviceStats combines a spec's details with the metrics recorded for its service
+func (r *Registry) serviceStats(spec *models.SpecInfo) models.ServiceStats {
+	stats := models.ServiceStats{
+		ServiceName:   spec.ServiceName,
+		SpecFetchedAt: spec.FetchedAt,
+		SpecURL:       spec.URL,
+		RouteCount:    countOperations(spec),
+	}
+
+	r.metricsMutex.Lock()
+	defer r.metricsMutex.Unlock()
+
+	if metrics, exists := r.metrics[spec.ServiceName]; exists {
+		stats.RequestCount = metrics.requests
+		stats.ErrorCount = metrics.errors
+		stats.AverageLatency = metrics.averageLatency
+		stats.LastRequest = metrics.lastRequest
+	}
+	return stats
+}
+
+// resetMetrics forgets the metrics of a service that is no longer registered
+func (r *Registry) resetMetrics(serviceName string) {
+	r.metricsMutex.Lock()
+	defer r.metricsMutex.Unlock()
+
+	delete(r.metrics, serviceName)
+}
+
+// countOperations counts the operations a spec defines
+func countOperations(spec *models.SpecInfo) int {
+	if spec.Spec == nil || spec.Spec.Paths == nil {
+		return 0
+	}
+
+	count := 0
+	for _, pathItem := range spec.Spec.Paths.Map() {
+		count += len(pathItem.Operations())
+	}
+	return count
+}
@@ -2,6 +2,8 @@ package registry
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +17,9 @@ type Registry struct {
 	mutex  sync.RWMutex
 	logger *zap.Logger
 	events chan SpecEvent
+
+	metrics      map[string]*serviceMetrics
+	metricsMutex sync.Mutex
 }
 
 // SpecEvent represents a specification change event
@@ -39,9 +44,10 @@ const (
 // New creates a new registry instance
 func New(logger *zap.Logger) *Registry {
 	return &Registry{
-		specs:  make(map[string]*models.SpecInfo),
-		logger: logger,
-		events: make(chan SpecEvent, 100),
+		specs:   make(map[string]*models.SpecInfo),
+		logger:  logger,
+		events:  make(chan SpecEvent, 100),
+		metrics: make(map[string]*serviceMetrics),
 	}
 }
 
@@ -51,6 +57,17 @@ func (r *Registry) Add(specInfo *models.SpecInfo) error {
 	defer r.mutex.Unlock()
 
 	existing, exists := r.specs[specInfo.ServiceName]
+	if exists && specInfo.DisabledOperations == nil {
+		// Operations stay disabled across spec refreshes
+		specInfo.DisabledOperations = existing.DisabledOperations
+	}
+	if exists && specInfo.BaseURL == "" {
+		// So does an upstream set by an operator
+		specInfo.BaseURL = existing.BaseURL
+	}
+	if exists && specInfo.ServerVariables == nil {
+		specInfo.ServerVariables = existing.ServerVariables
+	}
 	r.specs[specInfo.ServiceName] = specInfo
 
 	eventType := SpecEventAdded
@@ -99,6 +116,28 @@ func (r *Registry) Get(serviceName string) (*models.SpecInfo, bool) {
 	return spec, true
 }
 
+// Touch records that serviceName's spec was fetched again at fetchedAt and found
+// unchanged, renewing its TTL and storing the validators for the next conditional
+// fetch. Unlike Add, the entry is updated in place and no event is emitted, so
+// holders of it, such as bound routes, keep using it without being rebuilt.
+func (r *Registry) Touch(serviceName string, fetchedAt time.Time, etag, lastModified string) (*models.SpecInfo, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return nil, false
+	}
+	spec.FetchedAt = fetchedAt
+	spec.ETag = etag
+	spec.LastModified = lastModified
+
+	r.logger.Debug("Renewed unchanged spec for service",
+		zap.String("serviceName", serviceName),
+		zap.Time("fetchedAt", fetchedAt))
+	return spec, true
+}
+
 // Remove removes a specification from the registry
 func (r *Registry) Remove(serviceName string) bool {
 	r.mutex.Lock()
@@ -109,6 +148,7 @@ func (r *Registry) Remove(serviceName string) bool {
 	}
 
 	delete(r.specs, serviceName)
+	r.resetMetrics(serviceName)
 
 	r.logger.Info("Removed spec for service", zap.String("serviceName", serviceName))
 
@@ -122,6 +162,203 @@ func (r *Registry) Remove(serviceName string) bool {
 	return true
 }
 
+// SetMetadata merges operator annotations into a service's metadata, removing keys
+// whose value is empty. Metadata is informational only and never sent upstream.
+// It returns a copy of the resulting metadata and whether the service exists.
+func (r *Registry) SetMetadata(serviceName string, metadata map[string]string) (map[string]string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return nil, false
+	}
+
+	// Copy on write so readers holding the previous entry or map are unaffected
+	merged := make(map[string]string, len(spec.Metadata)+len(metadata))
+	for key, value := range spec.Metadata {
+		merged[key] = value
+	}
+	for key, value := range metadata {
+		if value == "" {
+			delete(merged, key)
+		} else {
+			merged[key] = value
+		}
+	}
+	updated := *spec
+	updated.Metadata = merged
+	r.specs[serviceName] = &updated
+
+	r.logger.Info("Updated metadata for service",
+		zap.String("serviceName", serviceName),
+		zap.Int("keyCount", len(merged)))
+
+	r.emitEvent(SpecEvent{
+		Type:        SpecEventUpdated,
+		ServiceName: serviceName,
+		SpecInfo:    &updated,
+		Timestamp:   time.Now(),
+	})
+
+	result := make(map[string]string, len(merged))
+	for key, value := range merged {
+		result[key] = value
+	}
+	return result, true
+}
+
+// SetHeaders changes the headers stored with a service, which are sent when the spec is
+// refetched and on proxied calls. Headers are merged, matching names regardless of
+// case and removing those given an empty value, or replace the stored set entirely.
+// The entry is replaced rather than modified so holders of the previous one, such as
+// bound routes, can tell it changed. It returns a copy of the resulting headers and
+// whether the service exists.
+func (r *Registry) SetHeaders(serviceName string, headers map[string]string, replace bool) (map[string]string, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return nil, false
+	}
+
+	merged := make(map[string]string, len(spec.Headers)+len(headers))
+	if !replace {
+		for name, value := range spec.Headers {
+			merged[name] = value
+		}
+	}
+	for name, value := range headers {
+		for existing := range merged {
+			if strings.EqualFold(existing, name) {
+				delete(merged, existing)
+			}
+		}
+		if value != "" {
+			merged[name] = value
+		}
+	}
+
+	updated := *spec
+	updated.Headers = merged
+	r.specs[serviceName] = &updated
+
+	r.logger.Info("Updated headers for service",
+		zap.String("serviceName", serviceName),
+		zap.Int("headerCount", len(merged)),
+		zap.Bool("replace", replace))
+
+	r.emitEvent(SpecEvent{
+		Type:        SpecEventUpdated,
+		ServiceName: serviceName,
+		SpecInfo:    &updated,
+		Timestamp:   time.Now(),
+	})
+
+	result := make(map[string]string, len(merged))
+	for name, value := range merged {
+		result[name] = value
+	}
+	return result, true
+}
+
+// SetBaseURL sets the upstream base URL for a service's proxied calls. An empty
+// baseURL reverts to the spec's first server. It returns whether the service exists.
+func (r *Registry) SetBaseURL(serviceName, baseURL string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return false
+	}
+
+	updated := *spec
+	updated.BaseURL = baseURL
+	r.specs[serviceName] = &updated
+
+	r.logger.Info("Updated base URL for service",
+		zap.String("serviceName", serviceName),
+		zap.String("baseURL", baseURL))
+
+	r.emitEvent(SpecEvent{
+		Type:        SpecEventUpdated,
+		ServiceName: serviceName,
+		SpecInfo:    &updated,
+		Timestamp:   time.Now(),
+	})
+	return true
+}
+
+// BaseURL returns the upstream base URL set for a service, or "" when none is set
+func (r *Registry) BaseURL(serviceName string) string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if spec, exists := r.specs[serviceName]; exists {
+		return spec.BaseURL
+	}
+	return ""
+}
+
+// SetOperationEnabled enables or disables a single operation of a service. Disabled
+// operations are recorded on the registry entry and survive spec refreshes.
+// It returns whether the service exists.
+func (r *Registry) SetOperationEnabled(serviceName, operationID string, enabled bool) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return false
+	}
+
+	// Copy on write so readers holding the previous slice are unaffected
+	disabled := make([]string, 0, len(spec.DisabledOperations)+1)
+	for _, id := range spec.DisabledOperations {
+		if id != operationID {
+			disabled = append(disabled, id)
+		}
+	}
+	if !enabled {
+		disabled = append(disabled, operationID)
+	}
+	sort.Strings(disabled)
+	spec.DisabledOperations = disabled
+
+	r.logger.Info("Updated operation state for service",
+		zap.String("serviceName", serviceName),
+		zap.String("operationId", operationID),
+		zap.Bool("enabled", enabled))
+
+	r.emitEvent(SpecEvent{
+		Type:        SpecEventUpdated,
+		ServiceName: serviceName,
+		SpecInfo:    spec,
+		Timestamp:   time.Now(),
+	})
+
+	return true
+}
+
+// IsOperationEnabled reports whether an operation of a service may be called
+func (r *Registry) IsOperationEnabled(serviceName, operationID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	spec, exists := r.specs[serviceName]
+	if !exists {
+		return true
+	}
+	for _, id := range spec.DisabledOperations {
+		if id == operationID {
+			return false
+		}
+	}
+	return true
+}
+
 // List returns all registered specifications
 func (r *Registry) List() []*models.SpecInfo {
 	r.mutex.RLock()
@@ -135,6 +372,43 @@ func (r *Registry) List() []*models.SpecInfo {
 	return specs
 }
 
+// ListPage returns the specifications whose service name or title contains filter,
+// ignoring case, ordered by service name so pages are stable. It skips the first
+// offset matches and returns at most limit of the rest, or all of them when limit is
+// zero, along with the number of matches.
+func (r *Registry) ListPage(filter string, offset, limit int) ([]*models.SpecInfo, int) {
+	filter = strings.ToLower(filter)
+	specs := r.List()
+	matches := make([]*models.SpecInfo, 0, len(specs))
+	for _, spec := range specs {
+		if filter == "" || strings.Contains(strings.ToLower(spec.ServiceName), filter) ||
+			strings.Contains(strings.ToLower(specTitle(spec)), filter) {
+			matches = append(matches, spec)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].ServiceName < matches[j].ServiceName
+	})
+
+	total := len(matches)
+	if offset > total {
+		offset = total
+	}
+	matches = matches[offset:]
+	if limit > 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches, total
+}
+
+// specTitle is the title from the spec's info, if it has one
+func specTitle(spec *models.SpecInfo) string {
+	if spec.Spec == nil || spec.Spec.Info == nil {
+		return ""
+	}
+	return spec.Spec.Info.Title
+}
+
 // GetExpired returns all expired specifications
 func (r *Registry) GetExpired() []*models.SpecInfo {
 	r.mutex.RLock()
@@ -157,39 +431,40 @@ func (r *Registry) Events() <-chan SpecEvent {
 
 // StartCleanup starts a background goroutine to clean up expired specs
 func (r *Registry) StartCleanup(ctx context.Context, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
 	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				r.cleanupExpired()
+				r.PruneExpired(time.Now())
 			}
 		}
 	}()
 }
 
-// Stats returns statistics about the registry
+// Stats returns statistics about the registry, including per-service request metrics
 func (r *Registry) Stats() map[string]interface{} {
 	r.mutex.RLock()
-	defer r.mutex.RUnlock()
-
-	stats := map[string]interface{}{
-		"totalSpecs":   len(r.specs),
-		"expiredSpecs": len(r.GetExpired()),
-		"services":     make([]string, 0, len(r.specs)),
-	}
-
 	services := make([]string, 0, len(r.specs))
-	for serviceName := range r.specs {
+	expired := 0
+	for serviceName, spec := range r.specs {
 		services = append(services, serviceName)
+		if r.isExpired(spec) {
+			expired++
+		}
 	}
-	stats["services"] = services
+	r.mutex.RUnlock()
 
-	return stats
+	return map[string]interface{}{
+		"totalSpecs":   len(services),
+		"expiredSpecs": expired,
+		"services":     services,
+		"serviceStats": r.AllServiceStats(),
+	}
 }
 
 // isExpired checks if a specification has exceeded its TTL
@@ -211,18 +486,21 @@ func (r *Registry) emitEvent(event SpecEvent) {
 	}
 }
 
-// cleanupExpired removes expired specifications that have been expired for too long
-func (r *Registry) cleanupExpired() {
+// PruneExpired removes specifications that have been expired for longer than
+// their TTL and returns how many were removed
+func (r *Registry) PruneExpired(now time.Time) int {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	now := time.Now()
+	removed := 0
 	for serviceName, spec := range r.specs {
 		if r.isExpired(spec) {
 			// Only remove specs that have been expired for more than their TTL duration
 			expiredFor := now.Sub(spec.FetchedAt.Add(spec.TTL))
 			if expiredFor > spec.TTL {
 				delete(r.specs, serviceName)
+				r.resetMetrics(serviceName)
+				removed++
 				r.logger.Info("Cleaned up expired spec",
 					zap.String("serviceName", serviceName),
 					zap.Duration("expiredFor", expiredFor))
@@ -235,4 +513,5 @@ func (r *Registry) cleanupExpired() {
 			}
 		}
 	}
+	return removed
 }
@@ -1,6 +1,7 @@
 package registry_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -137,6 +138,115 @@ func TestRegistry_List(t *testing.T) {
 	}
 }
 
+func TestRegistry_SetHeaders(t *testing.T) {
+	reg := registry.New(zap.NewNop())
+	reg.Add(&models.SpecInfo{
+		ServiceName: "petstore",
+		Headers:     map[string]string{"Authorization": "Bearer old", "X-Tenant-Id": "acme"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+	previous, _ := reg.Get("petstore")
+
+	headers, ok := reg.SetHeaders("petstore", map[string]string{"x-tenant-id": "", "X-Region": "eu"}, false)
+	if !ok {
+		t.Fatal("Expected the service to exist")
+	}
+	if len(headers) != 2 || headers["Authorization"] != "Bearer old" || headers["X-Region"] != "eu" {
+		t.Errorf("Expected the empty value to remove X-Tenant-Id, got %v", headers)
+	}
+
+	current, _ := reg.Get("petstore")
+	if current == previous {
+		t.Error("Expected the entry to be replaced so holders of the old one see a change")
+	}
+	if previous.Headers["X-Tenant-Id"] != "acme" {
+		t.Errorf("Expected the previous entry to be left unchanged, got %v", previous.Headers)
+	}
+
+	if _, ok := reg.SetHeaders("missing", map[string]string{"X-Region": "eu"}, true); ok {
+		t.Error("Expected an unknown service to be reported")
+	}
+}
+
+func TestRegistry_SetBaseURL(t *testing.T) {
+	reg := registry.New(zap.NewNop())
+	reg.Add(&models.SpecInfo{ServiceName: "petstore", FetchedAt: time.Now(), TTL: time.Hour})
+	reg.Add(&models.SpecInfo{ServiceName: "zoo", FetchedAt: time.Now(), TTL: time.Hour})
+
+	if !reg.SetBaseURL("petstore", "https://staging.petstore.example.com") {
+		t.Fatal("Expected the service to exist")
+	}
+	if baseURL := reg.BaseURL("petstore"); baseURL != "https://staging.petstore.example.com" {
+		t.Errorf("Expected the new base URL, got %q", baseURL)
+	}
+	if baseURL := reg.BaseURL("zoo"); baseURL != "" {
+		t.Errorf("Expected other services to keep no base URL, got %q", baseURL)
+	}
+
+	// A refreshed spec keeps the operator's base URL
+	reg.Add(&models.SpecInfo{ServiceName: "petstore", FetchedAt: time.Now(), TTL: time.Hour})
+	if baseURL := reg.BaseURL("petstore"); baseURL != "https://staging.petstore.example.com" {
+		t.Errorf("Expected the base URL to survive a refresh, got %q", baseURL)
+	}
+
+	if reg.SetBaseURL("missing", "https://example.com") {
+		t.Error("Expected an unknown service to be reported")
+	}
+}
+
+func TestRegistry_ListPage(t *testing.T) {
+	reg := registry.New(zap.NewNop())
+	titles := map[string]string{
+		"orders":    "Order API",
+		"billing":   "Invoices",
+		"inventory": "Stock levels",
+		"payments":  "Billing gateway",
+		"users":     "Accounts",
+	}
+	for serviceName, title := range titles {
+		reg.Add(&models.SpecInfo{
+			ServiceName: serviceName,
+			Spec:        &openapi3.T{OpenAPI: "3.0.0", Info: &openapi3.Info{Title: title}},
+			FetchedAt:   time.Now(),
+			TTL:         time.Hour,
+		})
+	}
+
+	tests := []struct {
+		name          string
+		filter        string
+		offset, limit int
+		expected      []string
+		expectedTotal int
+	}{
+		{"all", "", 0, 0, []string{"billing", "inventory", "orders", "payments", "users"}, 5},
+		{"first page", "", 0, 2, []string{"billing", "inventory"}, 5},
+		{"second page", "", 2, 2, []string{"orders", "payments"}, 5},
+		{"last partial page", "", 4, 2, []string{"users"}, 5},
+		{"offset past the end", "", 10, 2, []string{}, 5},
+		{"filter matches name or title", "BILL", 0, 0, []string{"billing", "payments"}, 2},
+		{"filtered page", "in", 1, 2, []string{"inventory", "payments"}, 3},
+		{"no match", "shipping", 0, 0, []string{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, total := reg.ListPage(tt.filter, tt.offset, tt.limit)
+			names := make([]string, len(specs))
+			for i, spec := range specs {
+				names[i] = spec.ServiceName
+			}
+			if total != tt.expectedTotal {
+				t.Errorf("Expected total %d, got %d", tt.expectedTotal, total)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
 func TestRegistry_Stats(t *testing.T) {
 	logger := zap.NewNop()
 	reg := registry.New(logger)
@@ -173,3 +283,224 @@ func TestRegistry_Stats(t *testing.T) {
 		t.Errorf("Expected services ['test-service'], got %v", services)
 	}
 }
+
+func TestRegistry_RecordRequest(t *testing.T) {
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		URL:         "http://example.com/api.json",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+
+	reg.RecordRequest("test-service", 200, 10*time.Millisecond)
+	reg.RecordRequest("test-service", 500, 20*time.Millisecond)
+	reg.RecordRequest("test-service", 0, 30*time.Millisecond)
+
+	stats, exists := reg.ServiceStats("test-service")
+	if !exists {
+		t.Fatal("Expected stats for registered service")
+	}
+	if stats.RequestCount != 3 {
+		t.Errorf("Expected 3 requests, got %d", stats.RequestCount)
+	}
+	if stats.ErrorCount != 2 {
+		t.Errorf("Expected 2 errors, got %d", stats.ErrorCount)
+	}
+	if stats.AverageLatency != 20*time.Millisecond {
+		t.Errorf("Expected average latency 20ms, got %v", stats.AverageLatency)
+	}
+	if stats.LastRequest.IsZero() {
+		t.Errorf("Expected last request time to be set")
+	}
+	if stats.SpecURL != "http://example.com/api.json" {
+		t.Errorf("Expected spec URL, got %s", stats.SpecURL)
+	}
+
+	if _, exists := reg.ServiceStats("unknown"); exists {
+		t.Errorf("Expected no stats for unknown service")
+	}
+
+	// Metrics are dropped with the service
+	reg.Remove("test-service")
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+	if stats, _ := reg.ServiceStats("test-service"); stats.RequestCount != 0 {
+		t.Errorf("Expected metrics to reset after removal, got %d requests", stats.RequestCount)
+	}
+}
+
+func TestRegistry_SetMetadata(t *testing.T) {
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+
+	// Unknown service
+	if _, ok := reg.SetMetadata("missing", map[string]string{"team": "payments"}); ok {
+		t.Fatal("Should not set metadata on an unknown service")
+	}
+
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		URL:         "http://example.com/api.json",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+		Metadata:    map[string]string{"team": "payments"},
+	})
+
+	metadata, ok := reg.SetMetadata("test-service", map[string]string{
+		"runbook": "https://runbooks.example.com/test-service",
+		"slack":   "#payments-oncall",
+	})
+	if !ok {
+		t.Fatal("Should set metadata on a registered service")
+	}
+	if len(metadata) != 3 || metadata["team"] != "payments" {
+		t.Errorf("Expected metadata to be merged, got %v", metadata)
+	}
+
+	// Empty values remove keys
+	previous, _ := reg.Get("test-service")
+	metadata, _ = reg.SetMetadata("test-service", map[string]string{"slack": ""})
+	if _, exists := metadata["slack"]; exists {
+		t.Errorf("Expected slack key to be removed, got %v", metadata)
+	}
+
+	retrieved, _ := reg.Get("test-service")
+	if retrieved.Metadata["runbook"] != "https://runbooks.example.com/test-service" {
+		t.Errorf("Expected stored runbook metadata, got %v", retrieved.Metadata)
+	}
+	if len(retrieved.Headers) != 0 {
+		t.Errorf("Metadata should not leak into request headers, got %v", retrieved.Headers)
+	}
+	if retrieved == previous || previous.Metadata["slack"] != "#payments-oncall" {
+		t.Errorf("Expected the entry to be replaced and the previous one left unchanged, got %v", previous.Metadata)
+	}
+}
+
+func TestRegistry_Touch(t *testing.T) {
+	reg := registry.New(zap.NewNop())
+
+	if _, ok := reg.Touch("missing", time.Now(), "", ""); ok {
+		t.Fatal("Should not touch an unknown service")
+	}
+
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		URL:         "http://example.com/api.json",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now().Add(-2 * time.Hour),
+		TTL:         time.Hour,
+		ETag:        `"v1"`,
+	})
+	<-reg.Events()
+	previous, fresh := reg.Get("test-service")
+	if fresh {
+		t.Fatal("Expected the spec to have expired")
+	}
+
+	fetchedAt := time.Now()
+	touched, ok := reg.Touch("test-service", fetchedAt, `"v2"`, "Wed, 01 Jul 2026 10:00:00 GMT")
+	if !ok || touched != previous {
+		t.Fatalf("Expected the registered entry to be renewed in place, got %p and %p", touched, previous)
+	}
+	if current, fresh := reg.Get("test-service"); !fresh || !current.FetchedAt.Equal(fetchedAt) || current.ETag != `"v2"` || current.LastModified == "" {
+		t.Errorf("Expected a fresh entry with the new validators, got %+v", current)
+	}
+	select {
+	case event := <-reg.Events():
+		t.Errorf("Expected no event for an unchanged spec, got %s", event.Type)
+	default:
+	}
+}
+
+func TestRegistry_SetOperationEnabled(t *testing.T) {
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+
+	if reg.SetOperationEnabled("missing", "getPet", false) {
+		t.Fatal("Should not disable an operation on an unknown service")
+	}
+
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		URL:         "http://example.com/api.json",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+
+	reg.SetOperationEnabled("test-service", "deletePet", false)
+	reg.SetOperationEnabled("test-service", "deletePet", false)
+	if reg.IsOperationEnabled("test-service", "deletePet") {
+		t.Error("Expected deletePet to be disabled")
+	}
+	if !reg.IsOperationEnabled("test-service", "getPet") {
+		t.Error("Expected sibling operation to stay enabled")
+	}
+
+	retrieved, _ := reg.Get("test-service")
+	if len(retrieved.DisabledOperations) != 1 {
+		t.Errorf("Expected 1 disabled operation, got %v", retrieved.DisabledOperations)
+	}
+
+	// Refreshing the spec keeps operations disabled
+	reg.Add(&models.SpecInfo{
+		ServiceName: "test-service",
+		URL:         "http://example.com/api.json",
+		Spec:        &openapi3.T{OpenAPI: "3.0.0"},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+	})
+	if reg.IsOperationEnabled("test-service", "deletePet") {
+		t.Error("Expected deletePet to stay disabled after refresh")
+	}
+
+	reg.SetOperationEnabled("test-service", "deletePet", true)
+	if !reg.IsOperationEnabled("test-service", "deletePet") {
+		t.Error("Expected deletePet to be re-enabled")
+	}
+}
+
+func TestRegistry_PruneExpired(t *testing.T) {
+	logger := zap.NewNop()
+	reg := registry.New(logger)
+
+	spec := &openapi3.T{
+		OpenAPI: "3.0.0",
+		Info:    &openapi3.Info{Title: "Test API", Version: "1.0.0"},
+	}
+
+	specs := []*models.SpecInfo{
+		// Expired for longer than its TTL
+		{ServiceName: "stale", Spec: spec, FetchedAt: time.Now().Add(-3 * time.Hour), TTL: time.Hour},
+		// Expired, but only recently
+		{ServiceName: "expired", Spec: spec, FetchedAt: time.Now().Add(-90 * time.Minute), TTL: time.Hour},
+		{ServiceName: "fresh", Spec: spec, FetchedAt: time.Now(), TTL: time.Hour},
+		{ServiceName: "permanent", Spec: spec, FetchedAt: time.Now().Add(-48 * time.Hour)},
+	}
+	for _, specInfo := range specs {
+		if err := reg.Add(specInfo); err != nil {
+			t.Fatalf("Failed to add spec: %v", err)
+		}
+	}
+
+	if removed := reg.PruneExpired(time.Now()); removed != 1 {
+		t.Errorf("Expected 1 spec pruned, got %d", removed)
+	}
+	if specInfo, _ := reg.Get("stale"); specInfo != nil {
+		t.Error("Expected stale spec to be pruned")
+	}
+	for _, serviceName := range []string{"expired", "fresh", "permanent"} {
+		if specInfo, _ := reg.Get(serviceName); specInfo == nil {
+			t.Errorf("Expected %s spec to be kept", serviceName)
+		}
+	}
+}
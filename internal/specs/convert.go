@@ -0,0 +1,65 @@
+package specs
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of a file
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// versionMarker holds the top-level fields that tell Swagger 2.0 from OpenAPI 3
+type versionMarker struct {
+	Swagger string `json:"swagger"`
+	OpenAPI string `json:"openapi"`
+}
+
+// LoadSpec decodes an OpenAPI 3 document, or a Swagger 2.0 document converted to
+// OpenAPI 3, using loader for OpenAPI 3 input. JSON and YAML are both accepted; a
+// leading byte order mark and blank lines are ignored.
+func LoadSpec(loader *openapi3.Loader, data []byte) (*openapi3.T, error) {
+	data = trimLeadingBlankLines(bytes.TrimPrefix(data, utf8BOM))
+
+	var marker versionMarker
+	if err := yaml.Unmarshal(data, &marker); err == nil && marker.OpenAPI == "" && strings.HasPrefix(marker.Swagger, "2.") {
+		return convertSwagger2(loader, data)
+	}
+	return loader.LoadFromData(data)
+}
+
+// trimLeadingBlankLines drops blank lines before the document but keeps the
+// indentation of its first line, which YAML depends on
+func trimLeadingBlankLines(data []byte) []byte {
+	for {
+		line, rest, found := bytes.Cut(data, []byte("\n"))
+		if !found || len(bytes.TrimSpace(line)) > 0 {
+			return data
+		}
+		data = rest
+	}
+}
+
+// convertSwagger2 converts a Swagger 2.0 document to OpenAPI 3. The host, basePath and
+// schemes become servers; a basePath without a host is kept as a relative server URL.
+func convertSwagger2(loader *openapi3.Loader, data []byte) (*openapi3.T, error) {
+	var doc2 openapi2.T
+	if err := yaml.Unmarshal(data, &doc2); err != nil {
+		return nil, fmt.Errorf("failed to parse Swagger 2.0 spec: %w", err)
+	}
+
+	spec, err := openapi2conv.ToV3WithLoader(&doc2, loader, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert Swagger 2.0 spec to OpenAPI 3: %w", err)
+	}
+
+	if doc2.Host == "" && doc2.BasePath != "" && doc2.BasePath != "/" {
+		spec.AddServer(&openapi3.Server{URL: doc2.BasePath})
+	}
+	return spec, nil
+}
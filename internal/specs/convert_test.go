@@ -0,0 +1,110 @@
+package specs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+)
+
+const swagger2Spec = `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy Petstore", "version": "1.0.0"},
+  "host": "legacy.example.com",
+  "basePath": "/v1",
+  "schemes": ["https", "http"],
+  "paths": {
+    "/pets/{petId}": {
+      "get": {
+        "operationId": "getPet",
+        "parameters": [{"name": "petId", "in": "path", "required": true, "type": "string"}],
+        "responses": {"200": {"description": "A pet", "schema": {"$ref": "#/definitions/Pet"}}}
+      }
+    },
+    "/pets": {
+      "post": {
+        "operationId": "addPet",
+        "consumes": ["application/json"],
+        "parameters": [{"name": "pet", "in": "body", "required": true, "schema": {"$ref": "#/definitions/Pet"}}],
+        "responses": {"201": {"description": "Created"}}
+      }
+    }
+  },
+  "definitions": {
+    "Pet": {"type": "object", "properties": {"name": {"type": "string"}}}
+  }
+}`
+
+func TestLoadSpec_Swagger2(t *testing.T) {
+	spec, err := LoadSpec(openapi3.NewLoader(), []byte(swagger2Spec))
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		t.Fatalf("Converted spec is invalid: %v", err)
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got %s", spec.OpenAPI)
+	}
+	if spec.Paths.Len() != 2 {
+		t.Errorf("Expected 2 paths, got %d", spec.Paths.Len())
+	}
+	getPet := spec.Paths.Value("/pets/{petId}")
+	if getPet == nil || getPet.Get == nil || getPet.Get.OperationID != "getPet" {
+		t.Fatalf("Expected getPet to survive conversion, got %+v", getPet)
+	}
+	addPet := spec.Paths.Value("/pets").Post
+	if addPet.RequestBody == nil || addPet.RequestBody.Value.Content.Get("application/json") == nil {
+		t.Errorf("Expected body parameter to become a JSON request body, got %+v", addPet.RequestBody)
+	}
+
+	servers := make([]string, 0, len(spec.Servers))
+	for _, server := range spec.Servers {
+		servers = append(servers, server.URL)
+	}
+	if strings.Join(servers, " ") != "https://legacy.example.com/v1 http://legacy.example.com/v1" {
+		t.Errorf("Expected servers from host, basePath and schemes, got %v", servers)
+	}
+}
+
+func TestLoadSpec_Swagger2WithoutHost(t *testing.T) {
+	data := strings.Replace(swagger2Spec, `"host": "legacy.example.com",`, "", 1)
+	spec, err := LoadSpec(openapi3.NewLoader(), []byte(data))
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if len(spec.Servers) != 1 || spec.Servers[0].URL != "/v1" {
+		t.Errorf("Expected basePath as a relative server, got %v", spec.Servers)
+	}
+}
+
+func TestLoadSpec_Swagger2ConversionError(t *testing.T) {
+	data := strings.Replace(swagger2Spec, `"legacy.example.com"`, `"https://legacy.example.com"`, 1)
+	_, err := LoadSpec(openapi3.NewLoader(), []byte(data))
+	if err == nil || !strings.Contains(err.Error(), "failed to convert Swagger 2.0") {
+		t.Errorf("Expected a conversion error, got %v", err)
+	}
+}
+
+func TestFetcher_FetchesSwagger2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(swagger2Spec))
+	}))
+	defer server.Close()
+
+	fetcher := New(zap.NewNop(), 5*time.Second, 0)
+	specInfo, err := fetcher.FetchSpec(context.Background(), server.URL, "legacy", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("FetchSpec() error = %v", err)
+	}
+	if specInfo.Spec.Paths.Value("/pets/{petId}") == nil {
+		t.Error("Expected fetched Swagger 2.0 paths to be kept")
+	}
+}
@@ -18,6 +18,13 @@ type Fetcher struct {
 	client  *http.Client
 	logger  *zap.Logger
 	maxSize int64
+
+	// allowInvalid returns specs that fail validation with their errors recorded
+	// instead of rejecting them
+	allowInvalid bool
+
+	maxAttempts int
+	retryDelay  time.Duration
 }
 
 // New creates a new spec fetcher
@@ -31,11 +38,39 @@ func New(logger *zap.Logger, timeout time.Duration, maxSize int64) *Fetcher {
 	}
 }
 
+// SetAllowInvalid makes FetchSpec return specs that fail validation, with the problems
+// in their ValidationErrors, rather than an error. Specs that cannot be parsed, e.g.
+// because of a dangling $ref, are still rejected.
+func (f *Fetcher) SetAllowInvalid(allow bool) {
+	f.allowInvalid = allow
+}
+
+// AllowInvalid reports whether specs that fail validation are returned
+func (f *Fetcher) AllowInvalid() bool {
+	return f.allowInvalid
+}
+
 // FetchSpec fetches and validates an OpenAPI specification from a URL
 func (f *Fetcher) FetchSpec(ctx context.Context, specURL, serviceName string, headers map[string]string, ttl time.Duration) (*models.SpecInfo, error) {
+	specInfo, _, err := f.fetch(ctx, specURL, serviceName, headers, ttl, nil)
+	return specInfo, err
+}
+
+// RefreshSpec fetches existing's document again, asking the server to answer 304 Not
+// Modified when it has not changed since existing was fetched. An unchanged spec is
+// not downloaded or parsed again: the result is a copy of existing with FetchedAt
+// renewed, and changed is false. Servers that do not support conditional requests
+// get a full fetch.
+func (f *Fetcher) RefreshSpec(ctx context.Context, existing *models.SpecInfo) (specInfo *models.SpecInfo, changed bool, err error) {
+	return f.fetch(ctx, existing.URL, existing.ServiceName, existing.Headers, existing.TTL, existing)
+}
+
+// fetch downloads and validates a spec, conditionally on the validators of previous
+// when it is not nil
+func (f *Fetcher) fetch(ctx context.Context, specURL, serviceName string, headers map[string]string, ttl time.Duration, previous *models.SpecInfo) (*models.SpecInfo, bool, error) {
 	// Validate URL
 	if _, err := url.Parse(specURL); err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+		return nil, false, fmt.Errorf("invalid URL: %w", err)
 	}
 
 	f.logger.Info("Fetching OpenAPI spec",
@@ -45,7 +80,7 @@ func (f *Fetcher) FetchSpec(ctx context.Context, specURL, serviceName string, he
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "GET", specURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add custom headers
@@ -56,35 +91,63 @@ func (f *Fetcher) FetchSpec(ctx context.Context, specURL, serviceName string, he
 	// Set Accept header for content negotiation
 	req.Header.Set("Accept", "application/json, application/yaml, text/yaml")
 
-	// Make request
-	resp, err := f.client.Do(req)
+	if previous != nil {
+		if previous.ETag != "" {
+			req.Header.Set("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	// Make request, retrying while the server is unavailable
+	resp, err := f.doWithRetry(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch spec: %w", err)
+		return nil, false, fmt.Errorf("failed to fetch spec: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && previous != nil {
+		f.logger.Info("OpenAPI spec not modified",
+			zap.String("url", specURL),
+			zap.String("serviceName", serviceName))
+
+		unchanged := *previous
+		unchanged.FetchedAt = time.Now()
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			unchanged.ETag = etag
+		}
+		if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+			unchanged.LastModified = lastModified
+		}
+		return &unchanged, false, nil
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return nil, false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	// Read response with size limit
 	body, err := f.readLimitedBody(resp.Body, f.maxSize)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse OpenAPI spec
-	loader := openapi3.NewLoader()
-	loader.IsExternalRefsAllowed = false // Security: disable external refs
-
-	spec, err := loader.LoadFromData(body)
+	// Parse OpenAPI spec, JSON or YAML
+	spec, err := LoadSpec(NewLoader(), body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+		return nil, false, parseError(DetectFormat(resp.Header.Get("Content-Type"), req.URL.Path), err)
 	}
 
 	// Validate spec
-	if err := spec.Validate(ctx); err != nil {
-		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	validationErrors, err := CheckSpec(ctx, spec, f.allowInvalid)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(validationErrors) > 0 {
+		f.logger.Warn("OpenAPI spec failed validation, registering it anyway",
+			zap.String("url", specURL),
+			zap.String("serviceName", serviceName),
+			zap.Strings("errors", validationErrors))
 	}
 
 	var pathCount int
@@ -92,7 +155,7 @@ func (f *Fetcher) FetchSpec(ctx context.Context, specURL, serviceName string, he
 		pathCount = len(spec.Paths.Map())
 	}
 
-	f.logger.Info("Successfully fetched and validated OpenAPI spec",
+	f.logger.Info("Successfully fetched OpenAPI spec",
 		zap.String("url", specURL),
 		zap.String("serviceName", serviceName),
 		zap.String("title", spec.Info.Title),
@@ -107,12 +170,16 @@ func (f *Fetcher) FetchSpec(ctx context.Context, specURL, serviceName string, he
 		FetchedAt:   time.Now(),
 		TTL:         ttl,
 		Headers:     headers,
-	}, nil
+
+		ValidationErrors: validationErrors,
+		ETag:             resp.Header.Get("ETag"),
+		LastModified:     resp.Header.Get("Last-Modified"),
+	}, true, nil
 }
 
 // ValidateSpec validates an OpenAPI specification without fetching
 func (f *Fetcher) ValidateSpec(ctx context.Context, spec *openapi3.T) error {
-	return spec.Validate(ctx)
+	return Validate(ctx, spec)
 }
 
 // readLimitedBody reads response body with size limit
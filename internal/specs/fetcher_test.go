@@ -0,0 +1,226 @@
+package specs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const fetcherTestSpec = `{
+	"openapi": "3.0.0",
+	"info": {"title": "Test", "version": "1.0.0"},
+	"paths": {
+		"/pets": {
+			"get": {
+				"responses": {
+					"200": {
+						"description": "OK",
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}
+					}
+				}
+			}
+		}
+	},
+	"components": {"schemas": {"Pet": {"type": "object"}}}
+}`
+
+func TestFetcher_FetchSpecValidation(t *testing.T) {
+	danglingRef := strings.Replace(fetcherTestSpec, `"#/components/schemas/Pet"`, `"#/components/schemas/Missing"`, 1)
+	badType := strings.Replace(fetcherTestSpec, `"type": "object"`, `"type": "strin"`, 1)
+
+	tests := []struct {
+		name         string
+		spec         string
+		allowInvalid bool
+		expectErr    bool
+		expectValid  bool
+	}{
+		{name: "valid spec", spec: fetcherTestSpec, expectValid: true},
+		{name: "dangling ref", spec: danglingRef, expectErr: true},
+		{name: "dangling ref is never registered", spec: danglingRef, allowInvalid: true, expectErr: true},
+		{name: "invalid schema rejected", spec: badType, expectErr: true},
+		{name: "invalid schema allowed", spec: badType, allowInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.spec))
+			}))
+			defer server.Close()
+
+			fetcher := New(zap.NewNop(), 5*time.Second, 1024*1024)
+			fetcher.SetAllowInvalid(tt.allowInvalid)
+
+			specInfo, err := fetcher.FetchSpec(context.Background(), server.URL+"/openapi.json", "petstore", nil, time.Hour)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected the spec to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchSpec() error = %v", err)
+			}
+			if specInfo.Valid() != tt.expectValid {
+				t.Errorf("Expected Valid() %v, got %v with errors %v", tt.expectValid, specInfo.Valid(), specInfo.ValidationErrors)
+			}
+		})
+	}
+}
+
+func TestFetcher_Retry(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxAttempts      int
+		failures         int32
+		failStatus       int
+		timeout          time.Duration
+		delay            time.Duration
+		expectErr        string
+		expectedRequests int32
+	}{
+		{name: "fails twice then serves the spec", maxAttempts: 4, failures: 2,
+			failStatus: http.StatusServiceUnavailable, expectedRequests: 3},
+		{name: "returns the last error", maxAttempts: 3, failures: 5,
+			failStatus: http.StatusBadGateway, expectErr: "HTTP 502", expectedRequests: 3},
+		{name: "client errors are not retried", maxAttempts: 3, failures: 5,
+			failStatus: http.StatusNotFound, expectErr: "HTTP 404", expectedRequests: 1},
+		{name: "retries disabled", maxAttempts: 1, failures: 1,
+			failStatus: http.StatusServiceUnavailable, expectErr: "HTTP 503", expectedRequests: 1},
+		{name: "deadline leaves no time to wait", maxAttempts: 3, failures: 5,
+			failStatus: http.StatusServiceUnavailable, timeout: 100 * time.Millisecond, delay: time.Second,
+			expectErr: "HTTP 503", expectedRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.failures {
+					w.WriteHeader(tt.failStatus)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(fetcherTestSpec))
+			}))
+			defer server.Close()
+
+			delay := tt.delay
+			if delay == 0 {
+				delay = time.Millisecond
+			}
+			fetcher := New(zap.NewNop(), 5*time.Second, 1024*1024)
+			fetcher.SetRetry(tt.maxAttempts, delay)
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			specInfo, err := fetcher.FetchSpec(ctx, server.URL+"/openapi.json", "petstore", nil, time.Hour)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Errorf("Expected error containing %q, got %v", tt.expectErr, err)
+				}
+			} else if err != nil || specInfo.Spec == nil {
+				t.Errorf("Expected the spec to be fetched, got %v", err)
+			}
+			if requests.Load() != tt.expectedRequests {
+				t.Errorf("Expected %d requests, got %d", tt.expectedRequests, requests.Load())
+			}
+		})
+	}
+}
+
+func TestFetcher_RetryTransportError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	specURL := server.URL + "/openapi.json"
+	server.Close()
+
+	fetcher := New(zap.NewNop(), time.Second, 1024*1024)
+	fetcher.SetRetry(3, time.Millisecond)
+
+	if _, err := fetcher.FetchSpec(context.Background(), specURL, "petstore", nil, time.Hour); err == nil || !strings.Contains(err.Error(), "failed to fetch spec") {
+		t.Errorf("Expected the last transport error, got %v", err)
+	}
+}
+
+func TestFetcher_RefreshSpec(t *testing.T) {
+	const lastModified = "Wed, 01 Jul 2026 10:00:00 GMT"
+
+	tests := []struct {
+		name          string
+		etag          string
+		lastModified  string
+		expectChanged bool
+	}{
+		{name: "unchanged by etag", etag: `"v1"`},
+		{name: "unchanged by last modified", lastModified: lastModified},
+		{name: "no validators", expectChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conditional []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.lastModified != "" {
+					w.Header().Set("Last-Modified", tt.lastModified)
+				}
+				if match, since := r.Header.Get("If-None-Match"), r.Header.Get("If-Modified-Since"); match != "" || since != "" {
+					conditional = append(conditional, match+since)
+					if (tt.etag != "" && match == tt.etag) || (tt.lastModified != "" && since == tt.lastModified) {
+						w.WriteHeader(http.StatusNotModified)
+						return
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(fetcherTestSpec))
+			}))
+			defer server.Close()
+
+			fetcher := New(zap.NewNop(), 5*time.Second, 1024*1024)
+			existing, err := fetcher.FetchSpec(context.Background(), server.URL+"/openapi.json", "petstore", map[string]string{"X-Token": "secret"}, time.Hour)
+			if err != nil {
+				t.Fatalf("FetchSpec() error = %v", err)
+			}
+			if existing.ETag != tt.etag || existing.LastModified != tt.lastModified {
+				t.Errorf("Expected validators %q and %q to be stored, got %q and %q", tt.etag, tt.lastModified, existing.ETag, existing.LastModified)
+			}
+			existing.FetchedAt = existing.FetchedAt.Add(-time.Hour)
+
+			refreshed, changed, err := fetcher.RefreshSpec(context.Background(), existing)
+			if err != nil {
+				t.Fatalf("RefreshSpec() error = %v", err)
+			}
+			if changed != tt.expectChanged {
+				t.Errorf("Expected changed %v, got %v", tt.expectChanged, changed)
+			}
+			// An unchanged spec keeps the document parsed by the first fetch
+			if (refreshed.Spec == existing.Spec) == tt.expectChanged {
+				t.Errorf("Expected the spec to be re-parsed only when changed")
+			}
+			if !refreshed.FetchedAt.After(existing.FetchedAt) {
+				t.Errorf("Expected FetchedAt to be renewed, got %v", refreshed.FetchedAt)
+			}
+			if refreshed == existing || refreshed.Headers["X-Token"] != "secret" || refreshed.ETag != tt.etag {
+				t.Errorf("Expected a copy of the existing entry, got %+v", refreshed)
+			}
+			if tt.expectChanged != (len(conditional) == 0) {
+				t.Errorf("Expected conditional headers only when validators were stored, got %v", conditional)
+			}
+		})
+	}
+}
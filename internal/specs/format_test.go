@@ -0,0 +1,146 @@
+package specs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const yamlSpec = `openapi: 3.0.0
+info:
+  title: YAML Petstore
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: A list of pets
+`
+
+const swagger2YAMLSpec = `swagger: "2.0"
+info:
+  title: Legacy YAML
+  version: 1.0.0
+basePath: /v2
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: A list of pets
+`
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		name        string
+		expected    string
+	}{
+		{"application/yaml", "/openapi", FormatYAML},
+		{"text/yaml; charset=utf-8", "", FormatYAML},
+		{"application/x-yaml", "", FormatYAML},
+		{"application/json", "/openapi.yaml", FormatJSON},
+		{"application/vnd.oai.openapi+json", "", FormatJSON},
+		{"text/plain", "/specs/openapi.yml", FormatYAML},
+		{"", "petstore.JSON", FormatJSON},
+		{"", "/openapi", ""},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat(tt.contentType, tt.name); got != tt.expected {
+			t.Errorf("DetectFormat(%q, %q) = %q, expected %q", tt.contentType, tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestFetcher_FetchesYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{"OpenAPI 3", yamlSpec, "YAML Petstore"},
+		{"byte order mark and blank lines", "\ufeff\n  \n" + yamlSpec, "YAML Petstore"},
+		{"Swagger 2.0", swagger2YAMLSpec, "Legacy YAML"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/yaml")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fetcher := New(zap.NewNop(), 5*time.Second, 0)
+			specInfo, err := fetcher.FetchSpec(context.Background(), server.URL+"/openapi", "pets", nil, time.Minute)
+			if err != nil {
+				t.Fatalf("FetchSpec() error = %v", err)
+			}
+			if specInfo.Spec.Info.Title != tt.expected {
+				t.Errorf("Expected title %q, got %q", tt.expected, specInfo.Spec.Info.Title)
+			}
+			if specInfo.Spec.Paths.Value("/pets") == nil {
+				t.Error("Expected /pets to be parsed")
+			}
+		})
+	}
+}
+
+func TestFetcher_NamesFormatOnParseError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/yaml")
+		w.Write([]byte("openapi: [3.0.0\n"))
+	}))
+	defer server.Close()
+
+	fetcher := New(zap.NewNop(), 5*time.Second, 0)
+	_, err := fetcher.FetchSpec(context.Background(), server.URL, "broken", nil, time.Minute)
+	if err == nil || !strings.Contains(err.Error(), "as YAML") {
+		t.Errorf("Expected a YAML parse error, got %v", err)
+	}
+}
+
+func TestLoadSpecFromFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		file     string
+		content  string
+		expected string
+	}{
+		{"petstore.yml", yamlSpec, "YAML Petstore"},
+		{"legacy.yaml", swagger2YAMLSpec, "Legacy YAML"},
+		{"petstore.json", swagger2Spec, "Legacy Petstore"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write spec: %v", err)
+			}
+
+			spec, err := LoadSpecFromFile(path)
+			if err != nil {
+				t.Fatalf("LoadSpecFromFile() error = %v", err)
+			}
+			if spec.Info.Title != tt.expected {
+				t.Errorf("Expected title %q, got %q", tt.expected, spec.Info.Title)
+			}
+		})
+	}
+
+	if _, err := LoadSpecFromFile(filepath.Join(dir, "missing.yml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,70 @@
+package specs
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/backoff"
+	"go.uber.org/zap"
+)
+
+// SetRetry fetches specs up to maxAttempts times in total when the server cannot be
+// reached or answers with a 5xx status, waiting about delay before the first retry
+// and twice as long before each further one. A maxAttempts of one or less disables
+// retries.
+func (f *Fetcher) SetRetry(maxAttempts int, delay time.Duration) {
+	f.maxAttempts = maxAttempts
+	f.retryDelay = delay
+}
+
+// MaxAttempts returns how many times a spec is requested at most
+func (f *Fetcher) MaxAttempts() int {
+	if f.maxAttempts < 1 {
+		return 1
+	}
+	return f.maxAttempts
+}
+
+// doWithRetry sends req, retrying failed attempts with exponential backoff while its
+// context leaves time to wait. The last response or error is returned once attempts
+// run out.
+func (f *Fetcher) doWithRetry(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := f.client.Do(req)
+		if attempt >= f.MaxAttempts() || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := backoff.Delay(f.retryDelay, attempt)
+		if deadline, ok := req.Context().Deadline(); ok && time.Until(deadline) < delay {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		f.logger.Warn("Retrying OpenAPI spec fetch",
+			zap.String("url", req.URL.String()),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether a fetch attempt failed in a way worth retrying
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
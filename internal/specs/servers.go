@@ -0,0 +1,99 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+)
+
+// serverVariablePattern matches a {variable} placeholder in a server URL
+var serverVariablePattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// ServerURL fills in the variables of a server URL template such as
+// https://{region}.api.example.com/{basePath}. A value in overrides takes precedence
+// over the variable's default; variables left without either are listed in the error.
+func ServerURL(server *openapi3.Server, overrides map[string]string) (string, error) {
+	unresolved := make(map[string]bool)
+	resolved := serverVariablePattern.ReplaceAllStringFunc(server.URL, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if value := overrides[name]; value != "" {
+			return value
+		}
+		if variable := server.Variables[name]; variable != nil && variable.Default != "" {
+			return variable.Default
+		}
+		unresolved[name] = true
+		return placeholder
+	})
+
+	if len(unresolved) > 0 {
+		names := make([]string, 0, len(unresolved))
+		for name := range unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("server %s has variables without a default or configured value: %s",
+			server.URL, strings.Join(names, ", "))
+	}
+	return resolved, nil
+}
+
+// BaseURL resolves the spec's first server with ServerURL, filling in its variables
+// from the service's configured values. A relative server URL such as /v1 is resolved
+// against the URL the spec was fetched from. It returns "" for specs without servers.
+func BaseURL(specInfo *models.SpecInfo) (string, error) {
+	if specInfo.Spec == nil || len(specInfo.Spec.Servers) == 0 {
+		return "", nil
+	}
+
+	server, err := ServerURL(specInfo.Spec.Servers[0], specInfo.ServerVariables)
+	if err != nil {
+		return "", err
+	}
+	serverURL, err := url.Parse(server)
+	if err != nil || serverURL.IsAbs() {
+		return server, nil
+	}
+
+	specURL, err := url.Parse(specInfo.URL)
+	if err != nil || !specURL.IsAbs() {
+		return server, nil
+	}
+	return specURL.ResolveReference(serverURL).String(), nil
+}
+
+// Validate checks spec against the OpenAPI specification, except for its servers:
+// their variables may lack a default when the service configures a value, so
+// ServerURL checks them when the spec is registered instead
+func Validate(ctx context.Context, spec *openapi3.T) error {
+	doc := *spec
+	doc.Servers = nil
+	return doc.Validate(ctx)
+}
+
+// CheckSpec validates spec. An invalid spec is an error unless allowInvalid is set, in
+// which case its problems are returned instead, to be recorded on the registered spec.
+func CheckSpec(ctx context.Context, spec *openapi3.T, allowInvalid bool) ([]string, error) {
+	err := Validate(ctx, spec)
+	if err == nil {
+		return nil, nil
+	}
+	if !allowInvalid {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	if multi, ok := err.(openapi3.MultiError); ok {
+		messages := make([]string, len(multi))
+		for i, e := range multi {
+			messages[i] = e.Error()
+		}
+		return messages, nil
+	}
+	return []string{err.Error()}, nil
+}
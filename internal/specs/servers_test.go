@@ -0,0 +1,159 @@
+package specs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+)
+
+func TestServerURL(t *testing.T) {
+	server := &openapi3.Server{
+		URL: "https://{region}.api.example.com/{basePath}",
+		Variables: map[string]*openapi3.ServerVariable{
+			"region":   {Default: "eu", Enum: []string{"eu", "us"}},
+			"basePath": {},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		overrides   map[string]string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name:        "variable without a default",
+			expectedErr: "basePath",
+		},
+		{
+			name:      "default and configured value",
+			overrides: map[string]string{"basePath": "v2"},
+			expected:  "https://eu.api.example.com/v2",
+		},
+		{
+			name:      "configured value overrides the default",
+			overrides: map[string]string{"region": "us", "basePath": "v2"},
+			expected:  "https://us.api.example.com/v2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := ServerURL(server, tt.overrides)
+			if tt.expectedErr != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), ": "+tt.expectedErr) {
+					t.Errorf("Expected an error listing only %s, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ServerURL() error = %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		specURL  string
+		servers  openapi3.Servers
+		expected string
+	}{
+		{
+			name:     "absolute server",
+			specURL:  "https://docs.example.com/openapi.json",
+			servers:  openapi3.Servers{{URL: "https://api.example.com/v1"}},
+			expected: "https://api.example.com/v1",
+		},
+		{
+			name:     "relative server resolved against the spec URL",
+			specURL:  "https://api.example.com/docs/openapi.json",
+			servers:  openapi3.Servers{{URL: "/v1"}},
+			expected: "https://api.example.com/v1",
+		},
+		{
+			name:     "relative server of a spec loaded from a file",
+			specURL:  "specs/petstore.json",
+			servers:  openapi3.Servers{{URL: "/v1"}},
+			expected: "/v1",
+		},
+		{
+			name:    "no servers",
+			specURL: "https://api.example.com/openapi.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specInfo := &models.SpecInfo{URL: tt.specURL, Spec: &openapi3.T{Servers: tt.servers}}
+			resolved, err := BaseURL(specInfo)
+			if err != nil {
+				t.Fatalf("BaseURL() error = %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestCheckSpec(t *testing.T) {
+	const invalidSpec = `{
+		"openapi": "3.0.0",
+		"info": {"title": "Test", "version": "1.0.0"},
+		"paths": {
+			"/pets": {
+				"get": {
+					"responses": {
+						"200": {
+							"description": "OK",
+							"content": {"application/json": {"schema": {"type": "strin"}}}
+						}
+					}
+				}
+			}
+		}
+	}`
+	validSpec := strings.Replace(invalidSpec, `"strin"`, `"string"`, 1)
+
+	tests := []struct {
+		name         string
+		data         string
+		allowInvalid bool
+		expectErr    bool
+		expectErrors bool
+	}{
+		{name: "valid spec", data: validSpec},
+		{name: "invalid spec rejected", data: invalidSpec, expectErr: true},
+		{name: "invalid spec allowed", data: invalidSpec, allowInvalid: true, expectErrors: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := openapi3.NewLoader().LoadFromData([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Failed to load spec: %v", err)
+			}
+
+			validationErrors, err := CheckSpec(context.Background(), spec, tt.allowInvalid)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if (len(validationErrors) > 0) != tt.expectErrors {
+				t.Errorf("Expected validation errors %v, got %v", tt.expectErrors, validationErrors)
+			}
+			for _, message := range validationErrors {
+				if !strings.Contains(message, "strin") {
+					t.Errorf("Expected the error to name the bad schema type, got %q", message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/config"
+	"github.com/zeroLR/swagger-mcp-go/internal/parser"
+	"github.com/zeroLR/swagger-mcp-go/internal/proxy"
+)
+
+// useRecorder installs a tracer provider that keeps finished spans in memory
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestMiddleware_UpstreamSpanIsChild(t *testing.T) {
+	recorder := useRecorder(t)
+
+	var traceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upstream.Close()
+
+	engine := proxy.New(zap.NewNop(), 5*time.Second)
+	engine.SetBaseURL(upstream.URL)
+	route := &parser.RouteConfig{Path: "/pets", Method: http.MethodGet, OperationID: "listPets"}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/apis/pets", func(c *gin.Context) {
+		response, err := engine.ExecuteRoute(c.Request.Context(), route, map[string]interface{}{})
+		if err != nil {
+			c.Status(http.StatusBadGateway)
+			return
+		}
+		c.Status(response.StatusCode)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/apis/pets", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rec.Code)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	client, server := spans[0], spans[1]
+
+	if server.Name() != "GET /apis/pets" || server.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Expected server span 'GET /apis/pets', got %q (%v)", server.Name(), server.SpanKind())
+	}
+	if client.SpanKind() != trace.SpanKindClient {
+		t.Errorf("Expected client span, got %v", client.SpanKind())
+	}
+	if client.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("Expected upstream span to be a child of the server span")
+	}
+	if client.SpanContext().TraceID() != server.SpanContext().TraceID() {
+		t.Errorf("Expected spans to share a trace")
+	}
+
+	attributes := make(map[string]string)
+	for _, kv := range client.Attributes() {
+		attributes[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attributes["http.request.method"] != "GET" {
+		t.Errorf("Expected method attribute GET, got %q", attributes["http.request.method"])
+	}
+	if attributes["url.full"] != upstream.URL+"/pets" {
+		t.Errorf("Expected URL attribute %s/pets, got %q", upstream.URL, attributes["url.full"])
+	}
+	if attributes["http.response.status_code"] != "200" {
+		t.Errorf("Expected status attribute 200, got %q", attributes["http.response.status_code"])
+	}
+
+	want := "00-" + client.SpanContext().TraceID().String() + "-" + client.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("Expected upstream traceparent %s, got %q", want, traceparent)
+	}
+}
+
+func TestMiddleware_ContinuesIncomingTrace(t *testing.T) {
+	recorder := useRecorder(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Middleware())
+	router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	if got := spans[0].SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected incoming trace ID, got %s", got)
+	}
+	if got := spans[0].Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("Expected incoming parent span, got %s", got)
+	}
+}
+
+func TestSetup_Disabled(t *testing.T) {
+	shutdown, err := Setup(t.Context(), &config.Config{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := shutdown(t.Context()); err != nil {
+		t.Errorf("Expected no error from shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package websocket
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
+)
+
+// TopicSpecs is the topic clients subscribe to for spec added, updated and removed events
+const TopicSpecs = "specs"
+
+// specEventTypes maps registry events to the event types sent to clients
+var specEventTypes = map[registry.SpecEventType]string{
+	registry.SpecEventAdded:   EventTypeSpecAdded,
+	registry.SpecEventUpdated: EventTypeSpecUpdated,
+	registry.SpecEventRemoved: EventTypeSpecRemoved,
+}
+
+// BridgeSpecEvents starts a goroutine broadcasting spec events from the registry to the
+// clients subscribed to TopicSpecs, until ctx is done or events is closed. The registry
+// has a single event channel, so nothing else should consume it.
+func (s *Server) BridgeSpecEvents(ctx context.Context, events <-chan registry.SpecEvent) {
+	go func() {
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				eventType, forwarded := specEventTypes[event.Type]
+				if !forwarded {
+					continue
+				}
+				s.logger.Debug("Broadcasting spec event",
+					zap.String("eventType", eventType),
+					zap.String("serviceName", event.ServiceName))
+				s.Broadcast(TopicSpecs, MCPEventMessage(eventType, specEventPayload(event)))
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// specEventPayload describes the spec an event is about, leaving out the parsed
+// document and the headers, which may hold credentials
+func specEventPayload(event registry.SpecEvent) map[string]interface{} {
+	payload := map[string]interface{}{
+		"serviceName": event.ServiceName,
+		"timestamp":   event.Timestamp,
+	}
+	if info := event.SpecInfo; info != nil {
+		payload["id"] = info.ID
+		payload["url"] = info.URL
+		payload["fetchedAt"] = info.FetchedAt
+		if info.Spec != nil && info.Spec.Info != nil {
+			payload["title"] = info.Spec.Info.Title
+			payload["version"] = info.Spec.Info.Version
+		}
+	}
+	return payload
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
 )
 
 // Config represents WebSocket server configuration
@@ -20,6 +25,15 @@ type Config struct {
 	PongWait        time.Duration `yaml:"pongWait" json:"pongWait"`
 	WriteWait       time.Duration `yaml:"writeWait" json:"writeWait"`
 	MaxMessageSize  int64         `yaml:"maxMessageSize" json:"maxMessageSize"`
+
+	// MaxClients caps the number of connected clients; further connections are closed
+	// with a "server full" reason. Zero means no limit.
+	MaxClients int `yaml:"maxClients" json:"maxClients"`
+
+	// AllowedOrigins lists the origins allowed to connect: exact origins such as
+	// "https://app.example.com", wildcard subdomains such as "https://*.example.com",
+	// or "*" for any origin
+	AllowedOrigins []string `yaml:"allowedOrigins" json:"allowedOrigins"`
 }
 
 // Message represents a WebSocket message
@@ -50,6 +64,7 @@ type Client struct {
 	send          chan Message
 	hub           *Hub
 	subscriptions map[string]bool
+	authCtx       *auth.AuthContext
 	mutex         sync.RWMutex
 	logger        *zap.Logger
 }
@@ -66,6 +81,12 @@ func NewClient(id string, conn *websocket.Conn, hub *Hub, logger *zap.Logger) *C
 	}
 }
 
+// AuthContext returns the credentials the client connected with, or nil when the
+// server does not authenticate connections
+func (c *Client) AuthContext() *auth.AuthContext {
+	return c.authCtx
+}
+
 // Subscribe adds a subscription for the client
 func (c *Client) Subscribe(topic string) {
 	c.mutex.Lock()
@@ -159,16 +180,20 @@ func (c *Client) WritePump() {
 	}
 }
 
+// broadcastBuffer is how many broadcasts may wait for the hub before new ones are dropped
+const broadcastBuffer = 256
+
 // Hub maintains the set of active clients and broadcasts messages to the clients
 type Hub struct {
-	clients    map[*Client]bool
-	broadcast  chan BroadcastMessage
-	register   chan *Client
-	unregister chan *Client
-	config     Config
-	logger     *zap.Logger
-	handlers   map[string]MessageHandler
-	mutex      sync.RWMutex
+	clients      map[*Client]bool
+	clientsMutex sync.RWMutex
+	broadcast    chan BroadcastMessage
+	register     chan *Client
+	unregister   chan *Client
+	config       Config
+	logger       *zap.Logger
+	handlers     map[string]MessageHandler
+	mutex        sync.RWMutex
 }
 
 // BroadcastMessage represents a message to be broadcast
@@ -204,7 +229,7 @@ func NewHub(config Config, logger *zap.Logger) *Hub {
 
 	hub := &Hub{
 		clients:    make(map[*Client]bool),
-		broadcast:  make(chan BroadcastMessage),
+		broadcast:  make(chan BroadcastMessage, broadcastBuffer),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		config:     config,
@@ -323,17 +348,30 @@ func (h *Hub) Run(ctx context.Context) {
 	for {
 		select {
 		case client := <-h.register:
-			h.clients[client] = true
+			h.clientsMutex.Lock()
+			full := h.config.MaxClients > 0 && len(h.clients) >= h.config.MaxClients
+			if !full {
+				h.clients[client] = true
+			}
+			h.clientsMutex.Unlock()
+
+			if full {
+				h.reject(client)
+				continue
+			}
 			h.logger.Info("Client registered", zap.String("clientId", client.ID))
 
 		case client := <-h.unregister:
+			h.clientsMutex.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
 				close(client.send)
 				h.logger.Info("Client unregistered", zap.String("clientId", client.ID))
 			}
+			h.clientsMutex.Unlock()
 
 		case broadcastMsg := <-h.broadcast:
+			h.clientsMutex.Lock()
 			for client := range h.clients {
 				if broadcastMsg.Topic == "" || client.IsSubscribed(broadcastMsg.Topic) {
 					select {
@@ -344,6 +382,7 @@ func (h *Hub) Run(ctx context.Context) {
 					}
 				}
 			}
+			h.clientsMutex.Unlock()
 
 		case <-ctx.Done():
 			h.logger.Info("Stopping WebSocket hub")
@@ -352,6 +391,22 @@ func (h *Hub) Run(ctx context.Context) {
 	}
 }
 
+// reject turns away a client over MaxClients with a close frame. Closing its send
+// channel stops the client's pumps, and since it was never registered its unregister
+// is ignored.
+func (h *Hub) reject(client *Client) {
+	h.logger.Warn("Rejecting client, server full",
+		zap.String("clientId", client.ID),
+		zap.Int("maxClients", h.config.MaxClients))
+
+	if client.conn != nil {
+		message := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "server full")
+		client.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(h.config.WriteWait))
+		client.conn.Close()
+	}
+	close(client.send)
+}
+
 // Broadcast sends a message to all subscribed clients
 func (h *Hub) Broadcast(topic string, message Message) {
 	broadcastMsg := BroadcastMessage{
@@ -370,6 +425,8 @@ func (h *Hub) Broadcast(topic string, message Message) {
 
 // GetClientCount returns the number of connected clients
 func (h *Hub) GetClientCount() int {
+	h.clientsMutex.RLock()
+	defer h.clientsMutex.RUnlock()
 	return len(h.clients)
 }
 
@@ -378,25 +435,94 @@ type Server struct {
 	hub      *Hub
 	upgrader websocket.Upgrader
 	logger   *zap.Logger
+
+	authManager *auth.Manager
+	authPolicy  *models.AuthPolicy
 }
 
 // NewServer creates a new WebSocket server
 func NewServer(config Config, logger *zap.Logger) *Server {
 	hub := NewHub(config, logger)
 
+	serverLogger := logger.Named("websocket-server")
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  config.ReadBufferSize,
 		WriteBufferSize: config.WriteBufferSize,
-		CheckOrigin: func(r *http.Request) bool {
-			return config.CheckOrigin || true // Allow all origins for now
-		},
+		CheckOrigin:     originChecker(config, serverLogger),
 	}
 
 	return &Server{
 		hub:      hub,
 		upgrader: upgrader,
-		logger:   logger.Named("websocket-server"),
+		logger:   serverLogger,
+	}
+}
+
+// originChecker decides which origins may open a connection. With AllowedOrigins set
+// only those origins are accepted; otherwise CheckOrigin limits connections to the
+// server's own origin, and without it every origin is accepted. Requests without an
+// Origin header do not come from a browser and are always accepted.
+func originChecker(config Config, logger *zap.Logger) func(r *http.Request) bool {
+	if len(config.AllowedOrigins) == 0 {
+		if !config.CheckOrigin {
+			logger.Warn("WebSocket connections are accepted from any origin; set allowedOrigins or checkOrigin to restrict them")
+			return func(r *http.Request) bool { return true }
+		}
+		return func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+	}
+
+	patterns := make([]string, len(config.AllowedOrigins))
+	for i, pattern := range config.AllowedOrigins {
+		patterns[i] = strings.ToLower(strings.TrimSuffix(pattern, "/"))
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, pattern := range patterns {
+			if originMatches(pattern, strings.ToLower(origin)) {
+				return true
+			}
+		}
+		logger.Warn("Rejected WebSocket connection from disallowed origin", zap.String("origin", origin))
+		return false
+	}
+}
+
+// originMatches reports whether a lower-cased origin matches an allowlist pattern. A
+// pattern such as "https://*.example.com" matches any subdomain of example.com over
+// https, but not example.com itself; without a scheme it matches any scheme.
+func originMatches(pattern, origin string) bool {
+	if pattern == "*" || pattern == origin {
+		return true
 	}
+
+	scheme, host, found := strings.Cut(origin, "://")
+	if !found {
+		return false
+	}
+	patternScheme, patternHost, found := strings.Cut(pattern, "://")
+	if !found {
+		patternScheme, patternHost = "", pattern
+	}
+	if patternScheme != "" && patternScheme != scheme {
+		return false
+	}
+	if patternHost == host {
+		return true
+	}
+
+	suffix, wildcard := strings.CutPrefix(patternHost, "*.")
+	return wildcard && strings.HasSuffix(host, "."+suffix)
 }
 
 // RegisterHandler registers a message handler
@@ -409,13 +535,45 @@ func (s *Server) Broadcast(topic string, message Message) {
 	s.hub.Broadcast(topic, message)
 }
 
+// SetAuth authenticates upgrade requests against policy using manager's providers.
+// Bearer tokens may also be passed in the access_token query parameter, since browsers
+// cannot set headers on WebSocket requests. Without a policy, or with one that is not
+// required, every connection is accepted.
+func (s *Server) SetAuth(manager *auth.Manager, policy *models.AuthPolicy) {
+	s.authManager = manager
+	s.authPolicy = policy
+}
+
+// authenticate checks the credentials of an upgrade request
+func (s *Server) authenticate(r *http.Request) (*auth.AuthContext, error) {
+	if s.authManager == nil || s.authPolicy == nil || !s.authPolicy.Required {
+		return nil, nil
+	}
+
+	if s.authPolicy.Type == models.AuthTypeBearer && r.Header.Get("Authorization") == "" {
+		if token := r.URL.Query().Get("access_token"); token != "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+	return s.authManager.Authenticate(r.Context(), r, s.authPolicy)
+}
+
 // Start starts the WebSocket hub
 func (s *Server) Start(ctx context.Context) {
 	go s.hub.Run(ctx)
 }
 
-// HandleWebSocket handles WebSocket upgrade requests
+// HandleWebSocket handles WebSocket upgrade requests, answering 401 without upgrading
+// when the server authenticates connections and the credentials are missing or invalid
 func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	authCtx, err := s.authenticate(r)
+	if err != nil {
+		s.logger.Debug("WebSocket authentication failed", zap.Error(err))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		s.logger.Error("WebSocket upgrade failed", zap.Error(err))
@@ -426,6 +584,7 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	clientID := fmt.Sprintf("client_%d", time.Now().UnixNano())
 
 	client := NewClient(clientID, conn, s.hub, s.logger)
+	client.authCtx = authCtx
 	s.hub.register <- client
 
 	// Start client goroutines
@@ -437,6 +596,7 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 func (s *Server) GetStats() map[string]interface{} {
 	return map[string]interface{}{
 		"connectedClients": s.hub.GetClientCount(),
+		"maxClients":       s.hub.config.MaxClients,
 		"config":           s.hub.config,
 	}
 }
@@ -2,10 +2,20 @@ package websocket
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
+
+	"github.com/zeroLR/swagger-mcp-go/internal/auth"
+	"github.com/zeroLR/swagger-mcp-go/internal/models"
+	"github.com/zeroLR/swagger-mcp-go/internal/registry"
 )
 
 func TestHub_BasicFunctionality(t *testing.T) {
@@ -345,3 +355,292 @@ func TestConfig(t *testing.T) {
 		t.Errorf("Expected custom PingInterval 30s, got %v", customHub.config.PingInterval)
 	}
 }
+
+func TestServer_CheckOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		origin  string
+		allowed bool
+	}{
+		{"exact origin", Config{AllowedOrigins: []string{"https://app.example.com"}}, "https://app.example.com", true},
+		{"exact origin ignores case and trailing slash", Config{AllowedOrigins: []string{"https://App.example.com/"}}, "https://app.example.com", true},
+		{"disallowed origin", Config{AllowedOrigins: []string{"https://app.example.com"}}, "https://evil.example.org", false},
+		{"different scheme", Config{AllowedOrigins: []string{"https://app.example.com"}}, "http://app.example.com", false},
+		{"wildcard subdomain", Config{AllowedOrigins: []string{"https://*.example.com"}}, "https://api.eu.example.com", true},
+		{"wildcard excludes apex", Config{AllowedOrigins: []string{"https://*.example.com"}}, "https://example.com", false},
+		{"wildcard excludes lookalike", Config{AllowedOrigins: []string{"https://*.example.com"}}, "https://evilexample.com", false},
+		{"wildcard without scheme", Config{AllowedOrigins: []string{"*.example.com"}}, "http://app.example.com", true},
+		{"any origin", Config{AllowedOrigins: []string{"*"}}, "https://anything.test", true},
+		{"no origin header", Config{AllowedOrigins: []string{"https://app.example.com"}}, "", true},
+		{"same origin only", Config{CheckOrigin: true}, "https://evil.example.org", false},
+		{"permissive without allowlist", Config{}, "https://evil.example.org", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(tt.config, zap.NewNop())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			server.Start(ctx)
+
+			httpServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+			defer httpServer.Close()
+
+			header := http.Header{}
+			if tt.origin != "" {
+				header.Set("Origin", tt.origin)
+			}
+			conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), header)
+			if conn != nil {
+				conn.Close()
+			}
+
+			if tt.allowed && err != nil {
+				t.Errorf("Expected origin %q to be allowed, got %v", tt.origin, err)
+			}
+			if !tt.allowed && (err == nil || resp == nil || resp.StatusCode != http.StatusForbidden) {
+				t.Errorf("Expected origin %q to be rejected with 403", tt.origin)
+			}
+		})
+	}
+}
+
+func TestServer_CheckOriginSameHost(t *testing.T) {
+	server := NewServer(Config{CheckOrigin: true}, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.Start(ctx)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer httpServer.Close()
+
+	header := http.Header{"Origin": []string{httpServer.URL}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), header)
+	if err != nil {
+		t.Fatalf("Expected same-origin connection to be allowed, got %v", err)
+	}
+	conn.Close()
+}
+
+// newAuthServer starts a server requiring HMAC-signed bearer tokens, with a "whoami"
+// handler replying with the connection's user ID
+func newAuthServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	provider := auth.NewBearerTokenProvider(zap.NewNop())
+	if err := provider.Configure(map[string]interface{}{"hmacSecret": "shared-secret"}); err != nil {
+		t.Fatalf("Failed to configure provider: %v", err)
+	}
+	manager := auth.NewManager(zap.NewNop())
+	manager.RegisterProvider(models.AuthTypeBearer, provider)
+
+	server := NewServer(Config{}, zap.NewNop())
+	server.SetAuth(manager, &models.AuthPolicy{Type: models.AuthTypeBearer, Required: true})
+	server.RegisterHandler("whoami", func(client *Client, message Message) error {
+		client.Send(Message{
+			Type: MessageTypeResponse,
+			ID:   message.ID,
+			Data: map[string]interface{}{"userId": client.AuthContext().UserID},
+		})
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	server.Start(ctx)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	t.Cleanup(httpServer.Close)
+	return httpServer
+}
+
+func signToken(t *testing.T, secret string) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestServer_Auth(t *testing.T) {
+	httpServer := newAuthServer(t)
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	tests := []struct {
+		name    string
+		url     string
+		header  http.Header
+		allowed bool
+	}{
+		{"valid token in header", wsURL, http.Header{"Authorization": []string{"Bearer " + signToken(t, "shared-secret")}}, true},
+		{"valid token in query", wsURL + "?access_token=" + signToken(t, "shared-secret"), nil, true},
+		{"invalid token", wsURL, http.Header{"Authorization": []string{"Bearer " + signToken(t, "other-secret")}}, false},
+		{"no token", wsURL, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, resp, err := websocket.DefaultDialer.Dial(tt.url, tt.header)
+			if !tt.allowed {
+				if err == nil {
+					conn.Close()
+				}
+				if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+					t.Fatalf("Expected 401, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected connection to be accepted, got %v", err)
+			}
+			defer conn.Close()
+
+			if err := conn.WriteJSON(Message{Type: "whoami", ID: "1"}); err != nil {
+				t.Fatalf("Failed to send message: %v", err)
+			}
+			var reply Message
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.ReadJSON(&reply); err != nil {
+				t.Fatalf("Failed to read reply: %v", err)
+			}
+			if reply.Data["userId"] != "user-1" {
+				t.Errorf("Expected client auth context for user-1, got %v", reply.Data["userId"])
+			}
+		})
+	}
+}
+
+func TestServer_BridgeSpecEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := registry.New(zap.NewNop())
+	server := NewServer(Config{}, zap.NewNop())
+	server.Start(ctx)
+	server.BridgeSpecEvents(ctx, reg.Events())
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	subscribe := Message{Type: MessageTypeSubscribe, ID: "1", Data: map[string]interface{}{"topic": TopicSpecs}}
+	if err := conn.WriteJSON(subscribe); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	var reply Message
+	if err := conn.ReadJSON(&reply); err != nil || reply.Type != MessageTypeResponse {
+		t.Fatalf("Expected subscription response, got %+v (%v)", reply, err)
+	}
+
+	err = reg.Add(&models.SpecInfo{
+		ID:          "petstore-1",
+		ServiceName: "petstore",
+		URL:         "https://example.com/openapi.json",
+		Spec:        &openapi3.T{Info: &openapi3.Info{Title: "Pet Store", Version: "1.0.0"}},
+		FetchedAt:   time.Now(),
+		TTL:         time.Hour,
+		Headers:     map[string]string{"Authorization": "secret"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add spec: %v", err)
+	}
+
+	var event Message
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("Failed to read event: %v", err)
+	}
+	if event.Type != MessageTypeEvent || event.Data["eventType"] != EventTypeSpecAdded {
+		t.Fatalf("Expected %s event, got %+v", EventTypeSpecAdded, event)
+	}
+	payload, _ := event.Data["payload"].(map[string]interface{})
+	if payload["serviceName"] != "petstore" || payload["title"] != "Pet Store" {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+	if _, leaked := payload["headers"]; leaked {
+		t.Errorf("Expected spec headers to be left out of the payload")
+	}
+}
+
+func TestHub_MaxClients(t *testing.T) {
+	hub := NewHub(Config{MaxClients: 2}, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+
+	newClient := func(id string) *Client {
+		return &Client{
+			ID:            id,
+			send:          make(chan Message, 1),
+			hub:           hub,
+			subscriptions: make(map[string]bool),
+			logger:        zap.NewNop(),
+		}
+	}
+
+	for _, id := range []string{"client-1", "client-2"} {
+		hub.register <- newClient(id)
+	}
+	rejected := newClient("client-3")
+	hub.register <- rejected
+
+	select {
+	case _, ok := <-rejected.send:
+		if ok {
+			t.Fatalf("Expected the rejected client's send channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the client over the cap to be rejected")
+	}
+	if count := hub.GetClientCount(); count != 2 {
+		t.Errorf("Expected 2 clients, got %d", count)
+	}
+}
+
+func TestServer_MaxClientsClosesWithReason(t *testing.T) {
+	server := NewServer(Config{MaxClients: 1}, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server.Start(ctx)
+
+	httpServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer httpServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	first, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer first.Close()
+
+	second, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer second.Close()
+
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = second.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Fatalf("Expected close with code %d, got %v", websocket.CloseTryAgainLater, err)
+	}
+	if closeErr := err.(*websocket.CloseError); closeErr.Text != "server full" {
+		t.Errorf("Expected reason 'server full', got %q", closeErr.Text)
+	}
+
+	stats := server.GetStats()
+	if stats["connectedClients"] != 1 || stats["maxClients"] != 1 {
+		t.Errorf("Expected 1 of 1 clients in stats, got %v of %v", stats["connectedClients"], stats["maxClients"])
+	}
+}